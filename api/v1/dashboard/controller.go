@@ -97,6 +97,45 @@ func (c *DashboardController) GetRepMaxHandler(ctx *fiber.Ctx) error {
 	return ctx.JSON(repMax)
 }
 
+// @Summary     Get average RPE
+// @Description Get the average RPE per day for a specific exercise
+// @Tags        dashboard
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId path string true "Exercise ID"
+// @Param       startDate query string true "Start date"
+// @Param       endDate query string true "End date"
+// @Success     200 {object} AverageRPEResponse
+// @Failure     400 {object} Error
+// @Router      /dashboard/rpe/{exerciseId} [get]
+func (c *DashboardController) GetAverageRPEHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	exerciseId := ctx.Params("exerciseId")
+
+	startDate, err := time.Parse("2006-01-02 15:04:05", ctx.Query("startDate"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid start date format. Expected format: YYYY-MM-DD HH:mm:ss",
+		})
+	}
+
+	endDate, err := time.Parse("2006-01-02 15:04:05", ctx.Query("endDate"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid end date format. Expected format: YYYY-MM-DD HH:mm:ss",
+		})
+	}
+
+	averageRPE, err := c.Service.GetAverageRPE(userId, exerciseId, startDate, endDate)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(averageRPE)
+}
+
 // @Summary     Get nutrition summary
 // @Description Get nutrition summary
 // @Tags        dashboard
@@ -200,4 +239,5 @@ func (c *DashboardController) Handle() {
 	g.Get("/body-composition", c.GetBodyCompositionAnalysis)
 	g.Get("/strength-standards", c.GetUserStrengthStandardsHandler)
 	g.Get("/rep-max/:exerciseId", c.GetRepMaxHandler)
+	g.Get("/rpe/:exerciseId", c.GetAverageRPEHandler)
 }
@@ -28,7 +28,7 @@ type WorkoutAnalysis struct {
 	TotalWorkouts          int     `json:"total_workouts"`
 	TotalExercises         int     `json:"total_exercises"`
 	TotalVolume            float64 `json:"total_volume"`
-	AverageWorkoutDuration float64 `json:"average_workout_duration"`
+	AverageWorkoutDuration float64 `json:"average_workout_duration"` // Seconds
 }
 
 type UserStrengthStandards struct {
@@ -59,6 +59,14 @@ type RepMaxResponse struct {
 	LastUpdated  time.Time `json:"lastUpdated"`
 }
 
+// AverageRPEResponse provides data points for plotting the effort of an exercise over time
+type AverageRPEResponse struct {
+	ExerciseID string    `json:"exerciseId"`
+	Labels     []string  `json:"labels"`     // Date labels in "2024-01-01" format, only days with rated sets
+	Values     []float64 `json:"values"`     // Average RPE of the rated sets logged that day
+	AverageRPE float64   `json:"averageRpe"` // Average RPE over the whole date range
+}
+
 type ExerciseProgress struct {
 	ExerciseID     string            `json:"exerciseId"`
 	Exercise       exercise.Exercise `json:"exercise"`
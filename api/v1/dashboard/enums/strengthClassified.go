@@ -40,19 +40,30 @@ type ConsiderExercise struct {
 	Exercise  string
 	Equipment exerciseEnums.Equipment
 	ID        string
+	Standard  string // Exercise name in strengthStandard.json
 }
 
 var ConsiderExercises = []ConsiderExercise{
-	{Exercise: "Bench Press", Equipment: exerciseEnums.Barbell},
-	{Exercise: "Bench Press", Equipment: exerciseEnums.Dumbbell},
-	{Exercise: "Squat", Equipment: exerciseEnums.Barbell},
-	{Exercise: "Deadlift", Equipment: exerciseEnums.Barbell},
-	{Exercise: "Incline Bench Press", Equipment: exerciseEnums.Barbell},
-	{Exercise: "Pull-up", Equipment: exerciseEnums.BodyWeight},
-	{Exercise: "Shoulder Press", Equipment: exerciseEnums.Barbell},
-	{Exercise: "Pulldown", Equipment: exerciseEnums.Cable},
-	{Exercise: "Chest Dip", Equipment: exerciseEnums.BodyWeight},
-	{Exercise: "Chin-up", Equipment: exerciseEnums.BodyWeight},
+	{Exercise: "Bench Press", Equipment: exerciseEnums.Barbell, Standard: "Bench Press"},
+	{Exercise: "Bench Press", Equipment: exerciseEnums.Dumbbell, Standard: "Dumbbell Bench Press"},
+	{Exercise: "Squat", Equipment: exerciseEnums.Barbell, Standard: "Squat"},
+	{Exercise: "Deadlift", Equipment: exerciseEnums.Barbell, Standard: "Deadlift"},
+	{Exercise: "Incline Bench Press", Equipment: exerciseEnums.Barbell, Standard: "Incline Bench Press"},
+	{Exercise: "Pull-up", Equipment: exerciseEnums.BodyWeight, Standard: "Pull Ups"},
+	{Exercise: "Shoulder Press", Equipment: exerciseEnums.Barbell, Standard: "Shoulder Press"},
+	{Exercise: "Pulldown", Equipment: exerciseEnums.Cable, Standard: "Lat Pulldown"},
+	{Exercise: "Chest Dip", Equipment: exerciseEnums.BodyWeight, Standard: "Dips"},
+	{Exercise: "Chin-up", Equipment: exerciseEnums.BodyWeight, Standard: "Chin ups"},
+}
+
+// GetStandardName returns the strength standard name for an exercise and equipment pair
+func GetStandardName(exercise string, equipment exerciseEnums.Equipment) (string, bool) {
+	for _, ce := range ConsiderExercises {
+		if ce.Exercise == exercise && ce.Equipment == equipment {
+			return ce.Standard, true
+		}
+	}
+	return "", false
 }
 
 // ClassifyStrength returns the StrengthType based on the given strength score
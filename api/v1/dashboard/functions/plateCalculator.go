@@ -0,0 +1,128 @@
+package dashboardFunctions
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// PlateCount is a plate weight with how many plates of it there are
+type PlateCount struct {
+	Weight float64 `json:"weight" bson:"weight" validate:"gt=0"`
+	Count  int     `json:"count" bson:"count" validate:"min=0,max=100"`
+}
+
+// PlateBreakdown is how to load a bar: the plates to put on each side and the weight they make
+type PlateBreakdown struct {
+	Bar       float64      `json:"bar"`
+	PerSide   []PlateCount `json:"per_side"`
+	Total     float64      `json:"total"`     // The bar with its plates
+	Remainder float64      `json:"remainder"` // What the plates could not make up to the target
+}
+
+// CalculatePlates works out the plates per side that load a bar as close to the target as possible
+// without going over, using as few plates as it can. Weights are in any one unit, counts are plates
+// in total so a pair is needed for every plate per side.
+func CalculatePlates(target, bar float64, plates []PlateCount) (*PlateBreakdown, error) {
+	if bar < 0 {
+		return nil, errors.New("bar weight cannot be negative")
+	}
+	if target < bar {
+		return nil, errors.New("target weight is below the bar weight")
+	}
+
+	// Work in hundredths to keep float rounding out of the sums
+	type slot struct {
+		weight int
+		max    int
+	}
+	pairs := make(map[int]int)
+	for _, plate := range plates {
+		if weight := hundredths(plate.Weight); weight > 0 && plate.Count >= 2 {
+			pairs[weight] += plate.Count / 2
+		}
+	}
+	slots := make([]slot, 0, len(pairs))
+	for weight, max := range pairs {
+		slots = append(slots, slot{weight: weight, max: max})
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].weight < slots[j].weight
+	})
+
+	// Loads only come in steps of the plates' greatest common divisor
+	step := 1
+	if len(slots) > 0 {
+		step = slots[0].weight
+		for _, s := range slots[1:] {
+			step = gcd(step, s.weight)
+		}
+	}
+	perSide := (hundredths(target) - hundredths(bar)) / 2 / step
+
+	// fewest[i][load] is the fewest plates of the i lightest slots loading a side with exactly
+	// load steps, or noLoad when they cannot
+	const noLoad = math.MaxInt32
+	fewest := make([][]int, len(slots)+1)
+	fewest[0] = make([]int, perSide+1)
+	for load := 1; load <= perSide; load++ {
+		fewest[0][load] = noLoad
+	}
+	for i, s := range slots {
+		weight := s.weight / step
+		prev, next := fewest[i], make([]int, perSide+1)
+		for load := range next {
+			next[load] = prev[load]
+			for count := 1; count <= s.max && count*weight <= load; count++ {
+				if used := prev[load-count*weight]; used != noLoad && used+count < next[load] {
+					next[load] = used + count
+				}
+			}
+		}
+		fewest[i+1] = next
+	}
+
+	bestLoad := perSide
+	for fewest[len(slots)][bestLoad] == noLoad {
+		bestLoad--
+	}
+
+	// Walk back from the heaviest plates, taking as many of each as still leaves the fewest plates
+	best := make([]int, len(slots))
+	load := bestLoad
+	for i := len(slots) - 1; i >= 0; i-- {
+		weight := slots[i].weight / step
+		for count := slots[i].max; count >= 0; count-- {
+			if count*weight > load {
+				continue
+			}
+			if used := fewest[i][load-count*weight]; used != noLoad && used+count == fewest[i+1][load] {
+				best[i] = count
+				load -= count * weight
+				break
+			}
+		}
+	}
+
+	breakdown := &PlateBreakdown{Bar: bar, PerSide: make([]PlateCount, 0)}
+	for i := len(best) - 1; i >= 0; i-- {
+		if count := best[i]; count > 0 {
+			breakdown.PerSide = append(breakdown.PerSide, PlateCount{Weight: float64(slots[i].weight) / 100, Count: count})
+		}
+	}
+	breakdown.Total = float64(hundredths(bar)+2*bestLoad*step) / 100
+	breakdown.Remainder = math.Round((target-breakdown.Total)*100) / 100
+
+	return breakdown, nil
+}
+
+func hundredths(weight float64) int {
+	return int(math.Round(weight * 100))
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,98 @@
+package dashboardFunctions
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// WarmUpStep is a set of a warm-up scheme: a percentage of the working weight for some reps.
+// A percentage of 0 is the empty bar.
+type WarmUpStep struct {
+	Percent float64 `json:"percent" bson:"percent" validate:"min=0,max=100"`
+	Reps    int     `json:"reps" bson:"reps" validate:"required,min=1,max=30"`
+}
+
+type WarmUpScheme string
+
+const (
+	StandardWarmUp     WarmUpScheme = "standard"
+	MinimalWarmUp      WarmUpScheme = "minimal"
+	PowerliftingWarmUp WarmUpScheme = "powerlifting" // More, smaller jumps for heavy singles
+	CustomWarmUp       WarmUpScheme = "custom"       // Steps of the user's own
+)
+
+// WarmUpSchemes are the steps of the built-in schemes
+var WarmUpSchemes = map[WarmUpScheme][]WarmUpStep{
+	StandardWarmUp:     {{Percent: 0, Reps: 10}, {Percent: 40, Reps: 5}, {Percent: 60, Reps: 3}, {Percent: 80, Reps: 2}},
+	MinimalWarmUp:      {{Percent: 50, Reps: 5}, {Percent: 75, Reps: 3}},
+	PowerliftingWarmUp: {{Percent: 0, Reps: 10}, {Percent: 30, Reps: 5}, {Percent: 50, Reps: 5}, {Percent: 70, Reps: 3}, {Percent: 80, Reps: 2}, {Percent: 90, Reps: 1}},
+}
+
+// Loading is how weights are made up: a bar with plates, or fixed increments when there are no
+// plates, like dumbbells and machines
+type Loading struct {
+	Bar       float64
+	Plates    []PlateCount
+	Increment float64
+}
+
+// WarmUpSet is a set of a warm-up ramp, with the plates per side when loaded on a bar
+type WarmUpSet struct {
+	SetNumber int             `json:"set_number"`
+	Weight    float64         `json:"weight"`
+	Reps      int             `json:"reps"`
+	Percent   float64         `json:"percent"`
+	Plates    *PlateBreakdown `json:"plates,omitempty"`
+}
+
+// GenerateWarmUp ramps up to a working weight following the steps of a scheme. Each weight is
+// rounded down to one that can be loaded; steps that end up no heavier than the one before or
+// not lighter than the working weight are left out.
+func GenerateWarmUp(working float64, steps []WarmUpStep, loading Loading) ([]WarmUpSet, error) {
+	if working <= 0 {
+		return nil, errors.New("working weight must be greater than 0")
+	}
+
+	ordered := append([]WarmUpStep(nil), steps...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Percent < ordered[j].Percent
+	})
+
+	sets := make([]WarmUpSet, 0, len(ordered))
+	previous := 0.0
+	for _, step := range ordered {
+		set := WarmUpSet{Reps: step.Reps, Percent: step.Percent}
+		weight := working * step.Percent / 100
+
+		if len(loading.Plates) > 0 || step.Percent == 0 {
+			if weight < loading.Bar {
+				weight = loading.Bar
+			}
+			breakdown, err := CalculatePlates(weight, loading.Bar, loading.Plates)
+			if err != nil {
+				return nil, err
+			}
+			set.Weight = breakdown.Total
+			set.Plates = breakdown
+		} else {
+			set.Weight = roundDown(weight, loading.Increment)
+		}
+
+		if set.Weight <= 0 || set.Weight <= previous || set.Weight >= working {
+			continue
+		}
+		previous = set.Weight
+		set.SetNumber = len(sets) + 1
+		sets = append(sets, set)
+	}
+
+	return sets, nil
+}
+
+func roundDown(weight, increment float64) float64 {
+	if increment <= 0 {
+		return math.Round(weight*100) / 100
+	}
+	return math.Round(math.Floor(weight/increment+1e-9)*increment*100) / 100
+}
@@ -0,0 +1,154 @@
+package loading
+
+import (
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Error error
+
+type LoadingController struct {
+	Instance fiber.Router
+	Service  ILoadingService
+}
+
+// @Summary     Get loading settings
+// @Description Get the user's bar, plates and warm-up scheme
+// @Tags        loading
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} LoadingSettings
+// @Failure     500 {object} Error
+// @Router      /loading/settings [get]
+func (c *LoadingController) GetSettingsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	settings, err := c.Service.GetSettings(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(settings)
+}
+
+// @Summary     Update loading settings
+// @Description Set the user's bar, plates and warm-up scheme, and whether planned sessions start with warm-up sets
+// @Tags        loading
+// @Accept      json
+// @Produce     json
+// @Param       settings body UpdateLoadingSettingsDto true "Loading Settings"
+// @Success     200 {object} LoadingSettings
+// @Failure     400 {object} Error
+// @Router      /loading/settings [put]
+func (c *LoadingController) UpdateSettingsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(UpdateLoadingSettingsDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	settings, err := c.Service.UpdateSettings(dto, userId)
+	if err != nil {
+		return loadingError(ctx, err)
+	}
+
+	return ctx.JSON(settings)
+}
+
+// @Summary     Get warm-up sets
+// @Description Ramp up to a working weight with the user's warm-up scheme, or the one asked for, rounded to loadable weights
+// @Tags        loading
+// @Accept      json
+// @Produce     json
+// @Param       weight query number true "Working weight"
+// @Param       unit query string false "Unit of the weight" Enums(kg, lbs)
+// @Param       scheme query string false "Warm-up scheme" Enums(standard, minimal, powerlifting, custom)
+// @Param       noBar query bool false "Round to fixed increments instead of loading the bar"
+// @Success     200 {object} WarmUpResponse
+// @Failure     400 {object} Error
+// @Router      /loading/warm-up [get]
+func (c *LoadingController) GetWarmUpHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	query := new(WarmUpQuery)
+	if err := ctx.QueryParser(query); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(query); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	warmUp, err := c.Service.GetWarmUp(query, userId)
+	if err != nil {
+		return loadingError(ctx, err)
+	}
+
+	return ctx.JSON(warmUp)
+}
+
+// @Summary     Get plates per side
+// @Description Work out the plates per side to load a weight on the user's bar with their plates
+// @Tags        loading
+// @Accept      json
+// @Produce     json
+// @Param       weight query number true "Weight to load"
+// @Param       unit query string false "Unit of the weight" Enums(kg, lbs)
+// @Success     200 {object} PlatesResponse
+// @Failure     400 {object} Error
+// @Router      /loading/plates [get]
+func (c *LoadingController) GetPlatesHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	query := new(PlatesQuery)
+	if err := ctx.QueryParser(query); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(query); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	plates, err := c.Service.GetPlates(query, userId)
+	if err != nil {
+		return loadingError(ctx, err)
+	}
+
+	return ctx.JSON(plates)
+}
+
+func loadingError(ctx *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	if err == ErrNoCustomSteps || err == ErrBelowBar {
+		status = fiber.StatusBadRequest
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
+func (c *LoadingController) Handle() {
+	g := c.Instance.Group("/loading")
+
+	g.Get("/settings", c.GetSettingsHandler)
+	g.Put("/settings", c.UpdateSettingsHandler)
+	g.Get("/warm-up", c.GetWarmUpHandler)
+	g.Get("/plates", c.GetPlatesHandler)
+}
@@ -0,0 +1,44 @@
+package loading
+
+import (
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+)
+
+// UpdateLoadingSettingsDto changes the fields it sets. Changing the unit without a bar or plates
+// switches them to the defaults of the new unit.
+type UpdateLoadingSettingsDto struct {
+	Unit         unitEnums.ExerciseWeightUnit    `json:"unit" validate:"omitempty,oneof=kg lbs"`
+	BarWeight    *float64                        `json:"barWeight,omitempty" validate:"omitempty,min=0,max=100"`
+	Plates       []dashboardFunctions.PlateCount `json:"plates,omitempty" validate:"omitempty,max=20,dive"`
+	WarmUpScheme dashboardFunctions.WarmUpScheme `json:"warmUpScheme" validate:"omitempty,oneof=standard minimal powerlifting custom"`
+	CustomSteps  []dashboardFunctions.WarmUpStep `json:"customSteps,omitempty" validate:"omitempty,max=10,dive"`
+	AutoWarmUp   *bool                           `json:"autoWarmUp,omitempty"`
+}
+
+type WarmUpQuery struct {
+	Weight float64                         `query:"weight" validate:"required,gt=0,max=1000"`
+	Unit   unitEnums.ExerciseWeightUnit    `query:"unit" validate:"omitempty,oneof=kg lbs"` // Of the weight, the settings' unit by default
+	Scheme dashboardFunctions.WarmUpScheme `query:"scheme" validate:"omitempty,oneof=standard minimal powerlifting custom"`
+	NoBar  bool                            `query:"noBar"` // For dumbbells and machines, weights go up in fixed increments
+}
+
+type PlatesQuery struct {
+	Weight float64                      `query:"weight" validate:"required,gt=0,max=1000"`
+	Unit   unitEnums.ExerciseWeightUnit `query:"unit" validate:"omitempty,oneof=kg lbs"` // Of the weight, the settings' unit by default
+}
+
+// WarmUpResponse is a warm-up ramp with weights in the unit of the user's plates
+type WarmUpResponse struct {
+	Unit          unitEnums.ExerciseWeightUnit    `json:"unit"`
+	WorkingWeight float64                         `json:"working_weight"`
+	Scheme        dashboardFunctions.WarmUpScheme `json:"scheme"`
+	Sets          []dashboardFunctions.WarmUpSet  `json:"sets"`
+}
+
+// PlatesResponse is how to load a weight with the user's bar and plates, in their unit
+type PlatesResponse struct {
+	Unit   unitEnums.ExerciseWeightUnit `json:"unit"`
+	Target float64                      `json:"target"`
+	dashboardFunctions.PlateBreakdown
+}
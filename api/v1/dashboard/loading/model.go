@@ -0,0 +1,70 @@
+package loading
+
+import (
+	"time"
+
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoadingSettings are the bar and plates a user loads and how they like to warm up
+type LoadingSettings struct {
+	ID           primitive.ObjectID              `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID       string                          `json:"userid" bson:"userid"`
+	Unit         unitEnums.ExerciseWeightUnit    `json:"unit" bson:"unit"` // Of the bar and plates
+	BarWeight    float64                         `json:"bar_weight" bson:"bar_weight"`
+	Plates       []dashboardFunctions.PlateCount `json:"plates" bson:"plates"` // Plates in total, not per side
+	WarmUpScheme dashboardFunctions.WarmUpScheme `json:"warm_up_scheme" bson:"warm_up_scheme"`
+	CustomSteps  []dashboardFunctions.WarmUpStep `json:"custom_steps" bson:"custom_steps"`
+	AutoWarmUp   bool                            `json:"auto_warm_up" bson:"auto_warm_up"` // Add warm-up sets to planned sessions when they start
+	UpdatedAt    time.Time                       `json:"updated_at" bson:"updated_at"`
+}
+
+// DefaultSettings are the settings of a user who set none: a standard bar and a gym's plates
+func DefaultSettings(userId string, unit unitEnums.ExerciseWeightUnit) *LoadingSettings {
+	settings := &LoadingSettings{
+		UserID:       userId,
+		Unit:         unit,
+		WarmUpScheme: dashboardFunctions.StandardWarmUp,
+		CustomSteps:  make([]dashboardFunctions.WarmUpStep, 0),
+	}
+	settings.BarWeight, settings.Plates = defaultEquipment(unit)
+	return settings
+}
+
+func defaultEquipment(unit unitEnums.ExerciseWeightUnit) (float64, []dashboardFunctions.PlateCount) {
+	if unit == unitEnums.ExerciseWeightUnitPound {
+		return 45, []dashboardFunctions.PlateCount{
+			{Weight: 45, Count: 8}, {Weight: 35, Count: 2}, {Weight: 25, Count: 2},
+			{Weight: 10, Count: 2}, {Weight: 5, Count: 2}, {Weight: 2.5, Count: 2},
+		}
+	}
+	return 20, []dashboardFunctions.PlateCount{
+		{Weight: 25, Count: 8}, {Weight: 20, Count: 2}, {Weight: 15, Count: 2}, {Weight: 10, Count: 2},
+		{Weight: 5, Count: 2}, {Weight: 2.5, Count: 2}, {Weight: 1.25, Count: 2},
+	}
+}
+
+// Steps are the warm-up steps of the user's scheme
+func (s *LoadingSettings) Steps(scheme dashboardFunctions.WarmUpScheme) []dashboardFunctions.WarmUpStep {
+	if scheme == "" {
+		scheme = s.WarmUpScheme
+	}
+	if scheme == dashboardFunctions.CustomWarmUp {
+		return s.CustomSteps
+	}
+	return dashboardFunctions.WarmUpSchemes[scheme]
+}
+
+// Loading is how the user makes up weights, on the bar or in the increments of fixed weights
+func (s *LoadingSettings) Loading(barbell bool) dashboardFunctions.Loading {
+	if !barbell {
+		increment := 2.5
+		if s.Unit == unitEnums.ExerciseWeightUnitPound {
+			increment = 5
+		}
+		return dashboardFunctions.Loading{Increment: increment}
+	}
+	return dashboardFunctions.Loading{Bar: s.BarWeight, Plates: s.Plates}
+}
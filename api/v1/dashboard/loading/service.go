@@ -0,0 +1,259 @@
+package loading
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/user"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrNoCustomSteps = errors.New("the custom warm-up scheme has no steps")
+	ErrBelowBar      = errors.New("the weight is lighter than the bar")
+)
+
+type LoadingService struct {
+	DB          *mongo.Database
+	UnitService unit.IUnitService
+}
+
+type ILoadingService interface {
+	GetSettings(userId string) (*LoadingSettings, error)
+	UpdateSettings(dto *UpdateLoadingSettingsDto, userId string) (*LoadingSettings, error)
+	GetWarmUp(query *WarmUpQuery, userId string) (*WarmUpResponse, error)
+	GetPlates(query *PlatesQuery, userId string) (*PlatesResponse, error)
+	WarmUpTargets(userId string, weights map[string]float64) (map[string][]workout.TargetSet, error)
+}
+
+// GetSettings returns the user's loading settings, the defaults for their weight unit until they
+// set any
+func (s *LoadingService) GetSettings(userId string) (*LoadingSettings, error) {
+	settings := &LoadingSettings{}
+	err := s.DB.Collection("loadingSettings").FindOne(context.Background(), bson.D{{Key: "userid", Value: userId}}).Decode(settings)
+	if err == mongo.ErrNoDocuments {
+		return DefaultSettings(userId, s.weightUnit(userId)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *LoadingService) UpdateSettings(dto *UpdateLoadingSettingsDto, userId string) (*LoadingSettings, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if dto.Unit != "" && dto.Unit != settings.Unit {
+		settings.Unit = dto.Unit
+		settings.BarWeight, settings.Plates = defaultEquipment(dto.Unit)
+	}
+	if dto.BarWeight != nil {
+		settings.BarWeight = *dto.BarWeight
+	}
+	if dto.Plates != nil {
+		settings.Plates = dto.Plates
+	}
+	if dto.WarmUpScheme != "" {
+		settings.WarmUpScheme = dto.WarmUpScheme
+	}
+	if dto.CustomSteps != nil {
+		settings.CustomSteps = dto.CustomSteps
+	}
+	if dto.AutoWarmUp != nil {
+		settings.AutoWarmUp = *dto.AutoWarmUp
+	}
+	if settings.WarmUpScheme == dashboardFunctions.CustomWarmUp && len(settings.CustomSteps) == 0 {
+		return nil, ErrNoCustomSteps
+	}
+
+	filter := bson.D{{Key: "userid", Value: userId}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "unit", Value: settings.Unit},
+		{Key: "bar_weight", Value: settings.BarWeight},
+		{Key: "plates", Value: settings.Plates},
+		{Key: "warm_up_scheme", Value: settings.WarmUpScheme},
+		{Key: "custom_steps", Value: settings.CustomSteps},
+		{Key: "auto_warm_up", Value: settings.AutoWarmUp},
+		{Key: "updated_at", Value: time.Now()},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	result := &LoadingSettings{}
+	if err := s.DB.Collection("loadingSettings").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetWarmUp ramps up to a working weight with the user's scheme, or the one asked for
+func (s *LoadingService) GetWarmUp(query *WarmUpQuery, userId string) (*WarmUpResponse, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := query.Scheme
+	if scheme == "" {
+		scheme = settings.WarmUpScheme
+	}
+	steps := settings.Steps(scheme)
+	if len(steps) == 0 {
+		return nil, ErrNoCustomSteps
+	}
+
+	working, err := s.convert(query.Weight, query.Unit, settings.Unit)
+	if err != nil {
+		return nil, err
+	}
+	sets, err := dashboardFunctions.GenerateWarmUp(working, steps, settings.Loading(!query.NoBar))
+	if err != nil {
+		return nil, err
+	}
+
+	return &WarmUpResponse{
+		Unit:          settings.Unit,
+		WorkingWeight: working,
+		Scheme:        scheme,
+		Sets:          sets,
+	}, nil
+}
+
+// GetPlates works out the plates per side to load a weight on the user's bar
+func (s *LoadingService) GetPlates(query *PlatesQuery, userId string) (*PlatesResponse, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.convert(query.Weight, query.Unit, settings.Unit)
+	if err != nil {
+		return nil, err
+	}
+	if target < settings.BarWeight {
+		return nil, ErrBelowBar
+	}
+	breakdown, err := dashboardFunctions.CalculatePlates(target, settings.BarWeight, settings.Plates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlatesResponse{Unit: settings.Unit, Target: target, PlateBreakdown: *breakdown}, nil
+}
+
+// WarmUpTargets gives the warm-up sets leading to the working weights of a session's exercises,
+// keyed by exercise ID. Weights are in kg; barbell exercises are loaded with the user's bar and
+// plates. Users who do not auto warm up get none.
+func (s *LoadingService) WarmUpTargets(userId string, weights map[string]float64) (map[string][]workout.TargetSet, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return nil, err
+	}
+	steps := settings.Steps("")
+	if !settings.AutoWarmUp || len(steps) == 0 {
+		return nil, nil
+	}
+
+	equipment, err := s.equipment(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string][]workout.TargetSet)
+	for exerciseId, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		working, err := s.convert(weight, unitEnums.ExerciseWeightUnitKg, settings.Unit)
+		if err != nil {
+			return nil, err
+		}
+		sets, err := dashboardFunctions.GenerateWarmUp(working, steps, settings.Loading(equipment[exerciseId] == exerciseEnums.Barbell))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, set := range sets {
+			weightInKg, err := s.convert(set.Weight, settings.Unit, unitEnums.ExerciseWeightUnitKg)
+			if err != nil {
+				return nil, err
+			}
+			targets[exerciseId] = append(targets[exerciseId], workout.TargetSet{
+				SetNumber: set.SetNumber,
+				MinReps:   set.Reps,
+				MaxReps:   set.Reps,
+				Weight:    weightInKg,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// equipment returns the equipment of the exercises keyed by their IDs
+func (s *LoadingService) equipment(weights map[string]float64) (map[string]exerciseEnums.Equipment, error) {
+	ids := make([]primitive.ObjectID, 0, len(weights))
+	for exerciseId := range weights {
+		if oid, err := primitive.ObjectIDFromHex(exerciseId); err == nil {
+			ids = append(ids, oid)
+		}
+	}
+
+	equipment := make(map[string]exerciseEnums.Equipment)
+	if len(ids) == 0 {
+		return equipment, nil
+	}
+	cursor, err := s.DB.Collection("exercises").Find(context.Background(), bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}})
+	if err != nil {
+		return nil, err
+	}
+	var exercises []exercise.Exercise
+	if err := cursor.All(context.Background(), &exercises); err != nil {
+		return nil, err
+	}
+	for _, ex := range exercises {
+		equipment[ex.ID.Hex()] = ex.Equipment
+	}
+	return equipment, nil
+}
+
+// convert converts a weight between units, keeping it when no unit is given
+func (s *LoadingService) convert(weight float64, from, to unitEnums.ExerciseWeightUnit) (float64, error) {
+	if from == "" || from == to {
+		return weight, nil
+	}
+	converted, err := s.UnitService.ConvertUnits(weight, string(from), string(to), "scale")
+	if err != nil {
+		return 0, err
+	}
+	return math.Round(converted*100) / 100, nil
+}
+
+// weightUnit returns the user's weight unit, defaulting to kg
+func (s *LoadingService) weightUnit(userId string) unitEnums.ExerciseWeightUnit {
+	userOid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+
+	userObj := &user.User{}
+	if err := s.DB.Collection("users").FindOne(context.Background(), bson.D{{Key: "_id", Value: userOid}}).Decode(userObj); err != nil {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+	if userObj.WeightUnit == "" {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+	return userObj.WeightUnit
+}
@@ -39,6 +39,7 @@ type IDashboardService interface {
 	GetDashboard(userId string, startDate, endDate time.Time) (*DashboardResponse, error)
 	GetUserStrengthStandards(userId string) (*UserStrengthStandards, error)
 	GetRepMax(userId string, exerciseId string, useLatest bool) (*RepMaxResponse, error)
+	GetAverageRPE(userId string, exerciseId string, startDate, endDate time.Time) (*AverageRPEResponse, error)
 	GetNutritionSummary(userid string, startDate, endDate time.Time) (*NutritionSummaryResponse, error)
 	GetBodyCompositionAnalysis(userId string, startDate, endDate time.Time) (*BodyCompositionAnalysisResponse, error)
 }
@@ -61,7 +62,7 @@ func calculateMovingAverageInt(values []int, window int) []float64 {
 func calculateMaxSetVolume(sets []exerciseLog.SetLog) float64 {
 	maxSetVolume := 0.0
 	for _, set := range sets {
-		setVolume := set.Weight * float64(set.Reps)
+		setVolume := set.Load() * float64(set.Reps)
 		if setVolume > maxSetVolume {
 			maxSetVolume = setVolume
 		}
@@ -69,14 +70,23 @@ func calculateMaxSetVolume(sets []exerciseLog.SetLog) float64 {
 	return maxSetVolume
 }
 
+// Helper function to calculate 1RM of a single set, accounting for machine or band assistance
+// and for the reps left in reserve when the set was not taken to failure
+func calculateSetOneRM(set exerciseLog.SetLog, equipment exerciseEnums.Equipment, bodyWeight float64) (float64, error) {
+	if exerciseEnums.IsAssistedEquipment(equipment) && bodyWeight > 0 {
+		return dashboardFunctions.CalculateAssistedOneRepMax(bodyWeight, set.Weight, set.RepsToFailure())
+	}
+	return dashboardFunctions.CalculateOneRepMax(set.Load(), set.RepsToFailure())
+}
+
 // Helper function to calculate best 1RM from sets
-func calculateBestOneRM(sets []exerciseLog.SetLog) (float64, error) {
+func calculateBestOneRM(log exerciseLog.ExerciseLog, equipment exerciseEnums.Equipment) (float64, error) {
 	bestOneRM := 0.0
-	for _, set := range sets {
-		if set.Weight <= 0 || set.Reps <= 0 {
+	for _, set := range log.Sets {
+		if set.Load() <= 0 || set.Reps <= 0 {
 			continue
 		}
-		oneRM, err := dashboardFunctions.CalculateOneRepMax(set.Weight, float64(set.Reps))
+		oneRM, err := calculateSetOneRM(set, equipment, log.BodyWeight)
 		if err != nil {
 			continue
 		}
@@ -330,7 +340,12 @@ func (ds *DashboardService) GetUserStrengthStandards(userId string) (*UserStreng
 			standards = strengthStandards.Female
 		}
 
-		exerciseStandards, exists := standards[ex.Name]
+		standardName, exists := dashboardEnums.GetStandardName(ex.Name, ex.Equipment)
+		if !exists {
+			continue
+		}
+
+		exerciseStandards, exists := standards[standardName]
 		if !exists {
 			continue
 		}
@@ -345,22 +360,33 @@ func (ds *DashboardService) GetUserStrengthStandards(userId string) (*UserStreng
 			}
 		}
 
-		// Calculate max weight and reps
-		var maxWeight float64
-		var maxReps int
+		// Body weight exercise standards are given as added weight, so compare against the total load
+		logBodyWeight := latestLog.BodyWeight
+		if logBodyWeight <= 0 {
+			logBodyWeight = userBodyWeight
+		}
+		if exerciseEnums.IsBodyWeightLoaded(ex.Equipment) {
+			closestStandard.Standards.Beginner += logBodyWeight
+			closestStandard.Standards.Novice += logBodyWeight
+			closestStandard.Standards.Intermediate += logBodyWeight
+			closestStandard.Standards.Advanced += logBodyWeight
+			closestStandard.Standards.Elite += logBodyWeight
+		}
+
+		// Calculate 1RM from the set with the highest volume
+		var bestSet exerciseLog.SetLog
 		for _, set := range latestLog.Sets {
-			if set.Weight*float64(set.Reps) > maxWeight*float64(maxReps) {
-				maxWeight = set.Weight
-				maxReps = set.Reps
+			if set.Load()*float64(set.Reps) > bestSet.Load()*float64(bestSet.Reps) {
+				bestSet = set
 			}
 		}
+		if bestSet.Reps < 1 {
+			continue
+		}
 
-		// Calculate 1RM if more than 1 rep
-		if maxReps > 1 {
-			maxWeight, err = dashboardFunctions.CalculateOneRepMax(maxWeight, float64(maxReps))
-			if err != nil {
-				continue
-			}
+		maxWeight, err := calculateSetOneRM(bestSet, ex.Equipment, logBodyWeight)
+		if err != nil {
+			continue
 		}
 
 		// Calculate relative strength
@@ -450,17 +476,44 @@ func (ds *DashboardService) GetRepMax(userId string, exerciseId string, useLates
 	// Add remaining stages
 	pipeline = append(pipeline,
 		bson.D{{Key: "$unwind", Value: "$sets"}},
+		// Use the effective load so body weight and assisted sets count, falling back to older logs' weight
+		bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "load", Value: bson.D{
+				{Key: "$cond", Value: bson.A{
+					bson.D{{Key: "$gt", Value: bson.A{"$sets.effectiveweight", 0}}},
+					"$sets.effectiveweight",
+					"$sets.weight",
+				}},
+			}},
+		}}},
+		// Count reps in reserve, from RIR or else 10 - RPE, as performed reps
+		bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "repsToFailure", Value: bson.D{
+				{Key: "$add", Value: bson.A{
+					"$sets.reps",
+					bson.D{{Key: "$ifNull", Value: bson.A{
+						"$sets.rir",
+						bson.D{{Key: "$cond", Value: bson.A{
+							bson.D{{Key: "$gt", Value: bson.A{"$sets.rpe", 0}}},
+							bson.D{{Key: "$max", Value: bson.A{bson.D{{Key: "$subtract", Value: bson.A{10, "$sets.rpe"}}}, 0}}},
+							0,
+						}}},
+					}}},
+				}},
+			}},
+		}}},
 		bson.D{{Key: "$match", Value: bson.D{
-			{Key: "sets.weight", Value: bson.D{{Key: "$gt", Value: 0}}},
+			{Key: "load", Value: bson.D{{Key: "$gt", Value: 0}}},
 			{Key: "sets.reps", Value: bson.D{{Key: "$gt", Value: 0}}},
+			{Key: "repsToFailure", Value: bson.D{{Key: "$lte", Value: 36}}},
 		}}},
 		bson.D{{Key: "$addFields", Value: bson.D{
 			{Key: "oneRM", Value: bson.D{
 				{Key: "$divide", Value: bson.A{
-					"$sets.weight",
+					"$load",
 					bson.D{{Key: "$subtract", Value: bson.A{
 						1.0278,
-						bson.D{{Key: "$multiply", Value: bson.A{0.0278, "$sets.reps"}}},
+						bson.D{{Key: "$multiply", Value: bson.A{0.0278, "$repsToFailure"}}},
 					}}},
 				}},
 			}},
@@ -513,6 +566,79 @@ func (ds *DashboardService) GetRepMax(userId string, exerciseId string, useLates
 	}, nil
 }
 
+func (ds *DashboardService) GetAverageRPE(userId string, exerciseId string, startDate, endDate time.Time) (*AverageRPEResponse, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "exerciseid", Value: exerciseId},
+		{Key: "datetime", Value: bson.D{
+			{Key: "$gte", Value: startDate},
+			{Key: "$lte", Value: endDate},
+		}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "datetime", Value: 1}})
+
+	cursor, err := ds.DB.Collection("exerciseLogs").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	logs := make([]exerciseLog.ExerciseLog, 0)
+	if err := cursor.All(context.Background(), &logs); err != nil {
+		return nil, err
+	}
+
+	response := &AverageRPEResponse{
+		ExerciseID: exerciseId,
+		Labels:     []string{},
+		Values:     []float64{},
+	}
+
+	// Average the rated working sets per day, logs are already sorted by date
+	dailySum := make(map[string]float64)
+	dailyCount := make(map[string]int)
+	var totalRPE float64
+	var totalCount int
+	for _, log := range logs {
+		dateStr := log.DateTime.Format("2006-01-02")
+		for _, set := range log.Sets {
+			rpe, ok := setRPE(set)
+			if !ok || set.Type == exerciseLog.WarmUpSet {
+				continue
+			}
+
+			if dailyCount[dateStr] == 0 {
+				response.Labels = append(response.Labels, dateStr)
+			}
+			dailySum[dateStr] += rpe
+			dailyCount[dateStr]++
+			totalRPE += rpe
+			totalCount++
+		}
+	}
+
+	for _, label := range response.Labels {
+		response.Values = append(response.Values, math.Round(dailySum[label]/float64(dailyCount[label])*100)/100)
+	}
+
+	if totalCount > 0 {
+		response.AverageRPE = math.Round(totalRPE/float64(totalCount)*100) / 100
+	}
+
+	return response, nil
+}
+
+// Helper function to get the RPE of a set, derived from RIR when only that was logged
+func setRPE(set exerciseLog.SetLog) (float64, bool) {
+	if set.RPE != nil {
+		return *set.RPE, true
+	}
+	if set.RIR != nil {
+		return math.Max(10-float64(*set.RIR), 1), true
+	}
+	return 0, false
+}
+
 func (ds *DashboardService) GetTopProgressExercises(exerciseData []ExerciseData) ([]ExerciseProgress, error) {
 	// Calculate progress for each exercise
 	var progressList []ExerciseProgress
@@ -542,11 +668,11 @@ func (ds *DashboardService) GetTopProgressExercises(exerciseData []ExerciseData)
 		}
 
 		// Calculate 1RM Progress
-		startOneRM, err := calculateBestOneRM(firstLog.Sets)
+		startOneRM, err := calculateBestOneRM(firstLog, exercise.RootExercise.Equipment)
 		if err != nil {
 			continue
 		}
-		endOneRM, err := calculateBestOneRM(lastLog.Sets)
+		endOneRM, err := calculateBestOneRM(lastLog, exercise.RootExercise.Equipment)
 		if err != nil {
 			continue
 		}
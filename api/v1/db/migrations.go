@@ -0,0 +1,55 @@
+package dbmongo
+
+import (
+	"log"
+	"os"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/programLibrary"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunMigrations brings existing documents up to date with the current models and seeds the
+// program library.
+// Each migration skips documents it has already migrated, so it is safe to run on every start.
+// A failing migration is reported and does not stop the server, it is tried again on the next start.
+func RunMigrations(db *mongo.Database) {
+	// Logs recorded before weight units existed were entered in kg unless LEGACY_WEIGHT_UNIT says otherwise
+	legacyUnit := unitEnums.ExerciseWeightUnit(os.Getenv("LEGACY_WEIGHT_UNIT"))
+	if legacyUnit == "" {
+		legacyUnit = unitEnums.ExerciseWeightUnitKg
+	}
+	if legacyUnit != unitEnums.ExerciseWeightUnitKg && legacyUnit != unitEnums.ExerciseWeightUnitPound {
+		log.Printf("Error migrating exercise log weight units: unknown LEGACY_WEIGHT_UNIT %q", legacyUnit)
+	} else {
+		exerciseLogService := &exerciseLog.ExerciseLogService{DB: db, UnitService: &unit.UnitService{}}
+		migrated, err := exerciseLogService.MigrateWeightUnits(legacyUnit)
+		if err != nil {
+			log.Printf("Error migrating exercise log weight units: %v", err)
+		}
+		if migrated > 0 {
+			log.Printf("Tagged %d exercise logs with a weight unit", migrated)
+		}
+	}
+
+	sessionService := &workoutSession.WorkoutSessionService{DB: db}
+	migrated, err := sessionService.MigrateLoggedDurations()
+	if err != nil {
+		log.Printf("Error migrating logged session durations: %v", err)
+	}
+	if migrated > 0 {
+		log.Printf("Moved %d logged session durations to seconds", migrated)
+	}
+
+	libraryService := &programLibrary.ProgramLibraryService{DB: db}
+	seeded, err := libraryService.SeedLibrary(programLibrary.LibraryFile)
+	if err != nil {
+		log.Printf("Error seeding the program library: %v", err)
+	}
+	if seeded > 0 {
+		log.Printf("Seeded %d library programs", seeded)
+	}
+}
@@ -36,5 +36,251 @@ func CreateIndexes(db *mongo.Database) error {
 		return err
 	}
 
+	// Keep a single personal record document per user and exercise
+	personalRecordIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "exerciseid", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("personalRecords").Indexes().CreateOne(context.Background(), personalRecordIndex)
+	if err != nil {
+		return err
+	}
+
+	// A single history entry per record a log improved
+	recordHistoryIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "exerciseid", Value: 1},
+			{Key: "type", Value: 1},
+			{Key: "reps", Value: 1},
+			{Key: "exerciselogid", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("personalRecordHistory").Indexes().CreateOne(context.Background(), recordHistoryIndex)
+	if err != nil {
+		return err
+	}
+
+	// Keep a single progression target per user, workout and exercise
+	progressionTargetIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "workoutid", Value: 1},
+			{Key: "exerciseid", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("progressionTargets").Indexes().CreateOne(context.Background(), progressionTargetIndex)
+	if err != nil {
+		return err
+	}
+
+	// Library programs are seeded and looked up by slug
+	programLibraryIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("programLibrary").Indexes().CreateOne(context.Background(), programLibraryIndex)
+	if err != nil {
+		return err
+	}
+
+	// One calendar feed per user, looked up by its token
+	calendarFeedIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	_, err = db.Collection("calendarFeeds").Indexes().CreateMany(context.Background(), calendarFeedIndexes)
+	if err != nil {
+		return err
+	}
+
+	// Drop plan previews once they can no longer be committed
+	planPreviewIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err = db.Collection("planPreviews").Indexes().CreateOne(context.Background(), planPreviewIndex)
+	if err != nil {
+		return err
+	}
+
+	// An event is notified once per user; delivery runs look up due notifications
+	notificationIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "userid", Value: 1},
+				{Key: "event_key", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "deliver_at", Value: 1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "userid", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+		},
+	}
+	_, err = db.Collection("notifications").Indexes().CreateMany(context.Background(), notificationIndexes)
+	if err != nil {
+		return err
+	}
+
+	notificationSettingsIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "userid", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("notificationSettings").Indexes().CreateOne(context.Background(), notificationSettingsIndex)
+	if err != nil {
+		return err
+	}
+
+	// The idle session sweeper looks up ongoing sessions by their last change
+	workoutSessionIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "updated_at", Value: 1},
+		},
+	}
+	_, err = db.Collection("workoutSessions").Indexes().CreateOne(context.Background(), workoutSessionIndex)
+	if err != nil {
+		return err
+	}
+
+	// A user has a single ongoing session
+	ongoingSessionIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "userid", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{
+			{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{"in_progress", "paused"}}}},
+		}),
+	}
+	_, err = db.Collection("workoutSessions").Indexes().CreateOne(context.Background(), ongoingSessionIndex)
+	if err != nil {
+		return err
+	}
+
+	// Session summaries compare with the previous session of the same workout
+	workoutHistoryIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "workoutid", Value: 1},
+			{Key: "start_time", Value: -1},
+		},
+	}
+	_, err = db.Collection("workoutSessions").Indexes().CreateOne(context.Background(), workoutHistoryIndex)
+	if err != nil {
+		return err
+	}
+
+	// A single rest default per user and exercise
+	restDefaultIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "exerciseid", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("restDefaults").Indexes().CreateOne(context.Background(), restDefaultIndex)
+	if err != nil {
+		return err
+	}
+
+	// A single set of loading settings per user
+	loadingSettingsIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "userid", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = db.Collection("loadingSettings").Indexes().CreateOne(context.Background(), loadingSettingsIndex)
+	if err != nil {
+		return err
+	}
+
+	// Pages of a user's logs, of all exercises or a single one, and the stats over them
+	exerciseLogIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "userid", Value: 1},
+				{Key: "datetime", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "userid", Value: 1},
+				{Key: "exerciseid", Value: 1},
+				{Key: "datetime", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+		},
+	}
+	_, err = db.Collection("exerciseLogs").Indexes().CreateMany(context.Background(), exerciseLogIndexes)
+	if err != nil {
+		return err
+	}
+
+	// A workout of another app is imported once per user, its logs are found to clean up an import
+	// that stopped half way
+	importKey := bson.D{{Key: "import_key", Value: bson.D{{Key: "$exists", Value: true}}}}
+	sessionImportIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "import_key", Value: 1},
+		},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(importKey),
+	}
+	_, err = db.Collection("workoutSessions").Indexes().CreateOne(context.Background(), sessionImportIndex)
+	if err != nil {
+		return err
+	}
+	logImportIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "import_key", Value: 1},
+		},
+		Options: options.Index().SetPartialFilterExpression(importKey),
+	}
+	_, err = db.Collection("exerciseLogs").Indexes().CreateOne(context.Background(), logImportIndex)
+	if err != nil {
+		return err
+	}
+
+	// Earlier imports of a user give the mappings they chose
+	historyImportIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userid", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	}
+	_, err = db.Collection("historyImports").Indexes().CreateOne(context.Background(), historyImportIndex)
+	if err != nil {
+		return err
+	}
+
+	// Muscle and equipment filters on logs look up the exercises that have them
+	exerciseFilterIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_muscle", Value: 1}}},
+		{Keys: bson.D{{Key: "equipment", Value: 1}}},
+	}
+	_, err = db.Collection("exercises").Indexes().CreateMany(context.Background(), exerciseFilterIndexes)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
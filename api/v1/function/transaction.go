@@ -0,0 +1,57 @@
+package function
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// standalone remembers per client whether the deployment is a standalone server
+var standalone sync.Map
+
+// WithTransaction runs fn in a multi-document transaction, which the driver retries on transient
+// errors. The writes fn makes with ctx are committed together or not at all.
+// Standalone servers have no transactions, there fn runs once without one.
+func WithTransaction(db *mongo.Database, fn func(ctx context.Context) error) error {
+	single, err := isStandalone(db)
+	if err != nil {
+		return err
+	}
+	if single {
+		return fn(context.Background())
+	}
+
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(context.Background(), func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// isStandalone asks the server for its topology. Replica set members answer with their set name
+// and mongos routers with the isdbgrid message; any other server is a standalone one.
+func isStandalone(db *mongo.Database) (bool, error) {
+	client := db.Client()
+	if single, ok := standalone.Load(client); ok {
+		return single.(bool), nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+	single := hello.SetName == "" && hello.Msg != "isdbgrid"
+	standalone.Store(client, single)
+	return single, nil
+}
@@ -0,0 +1,242 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrChannelNotConfigured = errors.New("notification channel is not configured")
+	// ErrSubscriptionGone is returned when the receiving end no longer exists, e.g. an expired push
+	// subscription, so sending again is pointless
+	ErrSubscriptionGone = errors.New("notification subscription is gone")
+)
+
+// Channel sends a notification to a user through one medium
+type Channel interface {
+	Send(ctx context.Context, settings *NotificationSettings, n *Notification) error
+}
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	EventKey  string            `json:"event_key"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func newWebhookPayload(n *Notification) WebhookPayload {
+	return WebhookPayload{
+		ID:        n.ID.Hex(),
+		Type:      string(n.Type),
+		EventKey:  n.EventKey,
+		Title:     n.Title,
+		Body:      n.Body,
+		Data:      n.Data,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the body, keyed by the user's webhook secret
+const WebhookSignatureHeader = "X-Gymsbro-Signature"
+
+// SignWebhook signs a webhook body with a secret
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSender posts notifications as JSON to the user's webhook URL
+type WebhookSender struct {
+	Client *http.Client // outboundClient unless replaced
+}
+
+func (w *WebhookSender) Send(ctx context.Context, settings *NotificationSettings, n *Notification) error {
+	if settings.WebhookURL == "" {
+		return fmt.Errorf("%w: no webhook url", ErrChannelNotConfigured)
+	}
+	if _, err := CheckURLScheme(settings.WebhookURL); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(newWebhookPayload(n))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if settings.WebhookSecret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(settings.WebhookSecret, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = outboundClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone {
+		return ErrSubscriptionGone
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailSender sends notifications as plain text emails through an SMTP server
+type EmailSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	SendMail func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (e *EmailSender) Send(ctx context.Context, settings *NotificationSettings, n *Notification) error {
+	if e.Host == "" || e.From == "" {
+		return ErrChannelNotConfigured
+	}
+	if settings.Email == "" {
+		return fmt.Errorf("%w: no email address", ErrChannelNotConfigured)
+	}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	send := e.SendMail // sendMail unless replaced
+	if send == nil {
+		send = sendMail
+	}
+	return send(ctx, e.Host+":"+e.Port, auth, e.From, []string{settings.Email}, EmailMessage(e.From, settings.Email, n))
+}
+
+// sendMail sends a message the way smtp.SendMail does, within the deadline of ctx. The connection
+// is closed when ctx is done, which fails the exchange in progress.
+func sendMail(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(a); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// EmailMessage builds the RFC 5322 message of a notification
+func EmailMessage(from string, to string, n *Notification) []byte {
+	// Header values cannot hold line breaks
+	clean := strings.NewReplacer("\r", " ", "\n", " ")
+
+	var b strings.Builder
+	b.WriteString("From: " + clean.Replace(from) + "\r\n")
+	b.WriteString("To: " + clean.Replace(to) + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", clean.Replace(n.Title)) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Body, "\n", "\r\n") + "\r\n")
+	return []byte(b.String())
+}
+
+// MemorySender keeps sent notifications in memory, for tests
+type MemorySender struct {
+	mu   sync.Mutex
+	sent []Notification
+	Err  error // Returned by Send when set
+}
+
+func (m *MemorySender) Send(ctx context.Context, settings *NotificationSettings, n *Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+	m.sent = append(m.sent, *n)
+	return nil
+}
+
+// Sent returns the notifications sent so far
+func (m *MemorySender) Sent() []Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Notification(nil), m.sent...)
+}
@@ -0,0 +1,237 @@
+package notification
+
+import (
+	"errors"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Error error
+
+type NotificationController struct {
+	Instance fiber.Router
+	Service  INotificationService
+}
+
+// @Summary     Get notifications
+// @Description Get the user's notification inbox, newest first, with the number of unread notifications
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Param       unread query bool false "Only unread notifications"
+// @Param       limit query int false "Page size, 50 by default and 100 at most"
+// @Param       skip query int false "Notifications to skip"
+// @Success     200 {object} Inbox
+// @Failure     400 {object} Error
+// @Router      /notifications [get]
+func (c *NotificationController) GetInboxHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	filters := new(InboxFilters)
+	if err := ctx.QueryParser(filters); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err := validator.New().Struct(filters); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	inbox, err := c.Service.GetInbox(filters, userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(inbox)
+}
+
+// @Summary     Mark notification read
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Notification ID"
+// @Success     200 {object} Notification
+// @Failure     404 {object} Error
+// @Router      /notifications/{id}/read [post]
+func (c *NotificationController) MarkReadHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	id := ctx.Params("id")
+
+	n, err := c.Service.MarkRead(id, userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "notification not found",
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(n)
+}
+
+// @Summary     Mark all notifications read
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} map[string]int64
+// @Failure     500 {object} Error
+// @Router      /notifications/read [post]
+func (c *NotificationController) MarkAllReadHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	count, err := c.Service.MarkAllRead(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(fiber.Map{"marked": count})
+}
+
+// @Summary     Delete notification
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Notification ID"
+// @Success     204
+// @Failure     404 {object} Error
+// @Router      /notifications/{id} [delete]
+func (c *NotificationController) DeleteNotificationHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	id := ctx.Params("id")
+
+	if err := c.Service.DeleteNotification(id, userId); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "notification not found",
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary     Get notification settings
+// @Description Get the user's channel, quiet hours and reminders
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} NotificationSettings
+// @Failure     500 {object} Error
+// @Router      /notifications/settings [get]
+func (c *NotificationController) GetSettingsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	settings, err := c.Service.GetSettings(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(settings)
+}
+
+// @Summary     Update notification settings
+// @Description Set the user's channel, quiet hours and reminders. Times are "HH:MM" in the given timezone.
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Param       settings body UpdateSettingsDto true "Notification settings"
+// @Success     200 {object} NotificationSettings
+// @Failure     400 {object} Error
+// @Router      /notifications/settings [put]
+func (c *NotificationController) UpdateSettingsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(UpdateSettingsDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	settings, err := c.Service.UpdateSettings(dto, userId)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSettings) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(settings)
+}
+
+// @Summary     Send test notification
+// @Description Send a notification through the user's channel right away to check it works
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Success     201 {object} Notification
+// @Failure     500 {object} Error
+// @Router      /notifications/test [post]
+func (c *NotificationController) SendTestHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	n, err := c.Service.SendTest(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(n)
+}
+
+// @Summary     Get Web Push key
+// @Description Get the VAPID public key browsers subscribe to push notifications with
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} map[string]string
+// @Failure     404 {object} Error
+// @Router      /notifications/push/key [get]
+func (c *NotificationController) GetVapidPublicKeyHandler(ctx *fiber.Ctx) error {
+	key := c.Service.GetVapidPublicKey()
+	if key == "" {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "push notifications are not configured",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{"public_key": key})
+}
+
+func (c *NotificationController) Handle() {
+	g := c.Instance.Group("/notifications")
+
+	g.Get("/", c.GetInboxHandler)
+	g.Get("/settings", c.GetSettingsHandler)
+	g.Put("/settings", c.UpdateSettingsHandler)
+	g.Get("/push/key", c.GetVapidPublicKeyHandler)
+	g.Post("/test", c.SendTestHandler)
+	g.Post("/read", c.MarkAllReadHandler)
+	g.Post("/:id/read", c.MarkReadHandler)
+	g.Delete("/:id", c.DeleteNotificationHandler)
+}
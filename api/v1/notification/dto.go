@@ -0,0 +1,28 @@
+package notification
+
+import (
+	"time"
+
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+)
+
+type UpdateSettingsDto struct {
+	Channel            notificationEnums.ChannelType `json:"channel" validate:"required,oneof=inbox webhook email push"`
+	WebhookURL         string                        `json:"webhook_url" validate:"omitempty,url,startswith=https://"`
+	WebhookSecret      string                        `json:"webhook_secret"`       // Replaces the stored secret, which is kept when empty
+	ClearWebhookSecret bool                          `json:"clear_webhook_secret"` // Removes the stored secret, webhooks go out unsigned
+	Email              string                        `json:"email" validate:"omitempty,email"`
+	PushSubscription   *PushSubscription             `json:"push_subscription"`
+	Timezone           string                        `json:"timezone"`
+	QuietHours         *QuietHours                   `json:"quiet_hours"`
+	WorkoutReminder    Reminder                      `json:"workout_reminder"`
+	FoodLogReminder    Reminder                      `json:"food_log_reminder"`
+	WeighInReminder    Reminder                      `json:"weigh_in_reminder"`
+	WeighInDays        []time.Weekday                `json:"weigh_in_days" validate:"dive,min=0,max=6"`
+}
+
+type InboxFilters struct {
+	Unread bool `json:"unread" query:"unread"`
+	Limit  int  `json:"limit" query:"limit" validate:"min=0,max=100"` // 50 when not set
+	Skip   int  `json:"skip" query:"skip" validate:"min=0"`
+}
@@ -0,0 +1,37 @@
+package notificationEnums
+
+type NotificationType string
+
+const (
+	WorkoutReminder  NotificationType = "workout_reminder"  // A planned workout is due today
+	FoodLogReminder  NotificationType = "food_log_reminder" // Nothing has been logged to eat today
+	WeighInReminder  NotificationType = "weigh_in_reminder" // No weight has been logged on a weigh-in day
+	TestNotification NotificationType = "test"              // Sent on demand to check a channel
+	RestOver         NotificationType = "rest_over"         // The rest timer of an ongoing session ran out
+)
+
+// IgnoresQuietHours tells whether notifications of the type are only useful right away
+func (t NotificationType) IgnoresQuietHours() bool {
+	return t == TestNotification || t == RestOver
+}
+
+type ChannelType string
+
+const (
+	InboxChannel   ChannelType = "inbox" // Only kept in the inbox
+	WebhookChannel ChannelType = "webhook"
+	EmailChannel   ChannelType = "email"
+	PushChannel    ChannelType = "push" // Web Push
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // Waiting for its delivery time, quiet hours or a retry
+	DeliverySending   DeliveryStatus = "sending"   // Claimed by a delivery run
+	DeliveryDelivered DeliveryStatus = "delivered" // Sent through the channel, or kept in the inbox only
+	DeliveryFailed    DeliveryStatus = "failed"    // Gave up after MaxDeliveryAttempts
+)
+
+// MaxDeliveryAttempts is how many times a notification is sent before it is marked failed
+const MaxDeliveryAttempts = 3
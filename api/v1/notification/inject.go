@@ -0,0 +1,51 @@
+package notification
+
+import (
+	"log"
+	"os"
+
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+)
+
+type Dependencies struct {
+	Channels       map[notificationEnums.ChannelType]Channel
+	VapidPublicKey string
+}
+
+// InjectDependencies sets up the channels the environment configures. Webhooks always work; email
+// needs SMTP_HOST and SMTP_FROM and Web Push needs the VAPID keys.
+func InjectDependencies() *Dependencies {
+	channels := map[notificationEnums.ChannelType]Channel{
+		notificationEnums.WebhookChannel: &WebhookSender{},
+	}
+
+	if host, from := os.Getenv("SMTP_HOST"), os.Getenv("SMTP_FROM"); host != "" && from != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		channels[notificationEnums.EmailChannel] = &EmailSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	} else {
+		log.Println("SMTP environment variables not set, email notifications are off")
+	}
+
+	publicKey, privateKey := os.Getenv("VAPID_PUBLIC_KEY"), os.Getenv("VAPID_PRIVATE_KEY")
+	if publicKey != "" && privateKey != "" {
+		channels[notificationEnums.PushChannel] = &PushSender{
+			PublicKey:  publicKey,
+			PrivateKey: privateKey,
+			Subject:    os.Getenv("VAPID_SUBJECT"),
+		}
+	} else {
+		log.Println("VAPID environment variables not set, push notifications are off")
+		publicKey = ""
+	}
+
+	return &Dependencies{Channels: channels, VapidPublicKey: publicKey}
+}
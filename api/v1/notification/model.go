@@ -0,0 +1,100 @@
+package notification
+
+import (
+	"time"
+
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification is a message to a user. It stays in the user's inbox whatever its channel.
+type Notification struct {
+	ID          primitive.ObjectID                 `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      string                             `json:"userid" bson:"userid"`
+	Type        notificationEnums.NotificationType `json:"type" bson:"type"`
+	EventKey    string                             `json:"event_key" bson:"event_key"` // Unique per user, so an event is only notified once
+	Title       string                             `json:"title" bson:"title"`
+	Body        string                             `json:"body" bson:"body"`
+	Data        map[string]string                  `json:"data,omitempty" bson:"data,omitempty"`
+	Channel     notificationEnums.ChannelType      `json:"channel" bson:"channel"`
+	Status      notificationEnums.DeliveryStatus   `json:"status" bson:"status"`
+	Attempts    int                                `json:"attempts" bson:"attempts"`
+	LastError   string                             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	DeliverAt   time.Time                          `json:"deliver_at" bson:"deliver_at"` // Pushed back to the end of quiet hours
+	ClaimedAt   *time.Time                         `json:"-" bson:"claimed_at,omitempty"`
+	DeliveredAt *time.Time                         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+	ReadAt      *time.Time                         `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt   time.Time                          `json:"created_at" bson:"created_at"`
+}
+
+// Event is something a user is notified about. Key identifies it, notifying the same key twice
+// only stores and sends it once.
+type Event struct {
+	Type  notificationEnums.NotificationType
+	Key   string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// NotificationSettings is how and when a user wants to be notified
+type NotificationSettings struct {
+	ID               primitive.ObjectID            `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID           string                        `json:"userid" bson:"userid"`
+	Channel          notificationEnums.ChannelType `json:"channel" bson:"channel"`
+	WebhookURL       string                        `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`
+	WebhookSecret    string                        `json:"-" bson:"webhook_secret,omitempty"` // Signs webhook bodies when set, never sent back
+	HasWebhookSecret bool                          `json:"has_webhook_secret" bson:"-"`
+	Email            string                        `json:"email,omitempty" bson:"email,omitempty"`
+	PushSubscription *PushSubscription             `json:"push_subscription,omitempty" bson:"push_subscription,omitempty"`
+	Timezone         string                        `json:"timezone" bson:"timezone"` // IANA name, reminder times and quiet hours are in it
+	QuietHours       *QuietHours                   `json:"quiet_hours,omitempty" bson:"quiet_hours,omitempty"`
+	WorkoutReminder  Reminder                      `json:"workout_reminder" bson:"workout_reminder"`
+	FoodLogReminder  Reminder                      `json:"food_log_reminder" bson:"food_log_reminder"`
+	WeighInReminder  Reminder                      `json:"weigh_in_reminder" bson:"weigh_in_reminder"`
+	WeighInDays      []time.Weekday                `json:"weigh_in_days" bson:"weigh_in_days"`
+	UpdatedAt        time.Time                     `json:"updated_at" bson:"updated_at"`
+}
+
+// Reminder is a daily reminder sent from Time on, if still needed
+type Reminder struct {
+	Enabled bool   `json:"enabled" bson:"enabled"`
+	Time    string `json:"time" bson:"time" validate:"omitempty,len=5"` // "15:04"
+}
+
+// QuietHours is a daily range, which may cross midnight, that notifications wait out
+type QuietHours struct {
+	Start string `json:"start" bson:"start" validate:"required,len=5"` // "22:00"
+	End   string `json:"end" bson:"end" validate:"required,len=5"`     // "07:00"
+}
+
+// PushSubscription is a browser Web Push subscription, as the browser's PushSubscription.toJSON() gives it
+type PushSubscription struct {
+	Endpoint string   `json:"endpoint" bson:"endpoint" validate:"required,url,startswith=https://"`
+	Keys     PushKeys `json:"keys" bson:"keys"`
+}
+
+// PushKeys are the subscription's P-256 public key and auth secret, unpadded base64url
+type PushKeys struct {
+	P256dh string `json:"p256dh" bson:"p256dh" validate:"required"`
+	Auth   string `json:"auth" bson:"auth" validate:"required"`
+}
+
+// Inbox is a page of a user's notifications
+type Inbox struct {
+	Notifications []*Notification `json:"notifications"`
+	Unread        int64           `json:"unread"`
+}
+
+// DefaultSettings keeps notifications in the inbox and has every reminder off
+func DefaultSettings(userId string) *NotificationSettings {
+	return &NotificationSettings{
+		UserID:          userId,
+		Channel:         notificationEnums.InboxChannel,
+		Timezone:        "UTC",
+		WorkoutReminder: Reminder{Time: "07:00"},
+		FoodLogReminder: Reminder{Time: "20:00"},
+		WeighInReminder: Reminder{Time: "08:00"},
+		WeighInDays:     []time.Weekday{time.Monday},
+	}
+}
@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrUnsafeURL is returned for webhook and push URLs that could reach the server's own network
+var ErrUnsafeURL = errors.New("url must be https and reach a public address")
+
+// CheckURLScheme checks that a user given URL is https with a host
+func CheckURLScheme(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsafeURL, raw)
+	}
+	return u, nil
+}
+
+// CheckOutboundURL checks that a user given URL is https and that its host only resolves to
+// public addresses
+func CheckOutboundURL(ctx context.Context, raw string) error {
+	u, err := CheckURLScheme(raw)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %s does not resolve", ErrUnsafeURL, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrUnsafeURL, u.Hostname(), addr.IP)
+		}
+	}
+	return nil
+}
+
+// outboundURLs returns the URLs of the settings that notifications are posted to
+func outboundURLs(settings *NotificationSettings) []string {
+	urls := make([]string, 0, 2)
+	if settings.WebhookURL != "" {
+		urls = append(urls, settings.WebhookURL)
+	}
+	if settings.PushSubscription != nil {
+		urls = append(urls, settings.PushSubscription.Endpoint)
+	}
+	return urls
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which ip.IsPrivate leaves out
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP tells whether an address is routable on the internet. Loopback, private, carrier-grade
+// NAT, link-local (cloud metadata at 169.254.169.254 among them), multicast and unspecified
+// addresses are not.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// refusePrivateAddress is a net.Dialer Control that refuses to connect to non-public addresses.
+// It runs on the resolved address, so a host resolving differently after CheckOutboundURL is
+// still refused.
+func refusePrivateAddress(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: refusing to connect to %s", ErrUnsafeURL, host)
+	}
+	return nil
+}
+
+// outboundClient is the HTTP client of channels posting to user given URLs. It only dials public
+// addresses, ignores proxy settings and only follows https redirects.
+func outboundClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: refusePrivateAddress,
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("stopped after 5 redirects")
+			}
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrUnsafeURL, req.URL)
+			}
+			return nil
+		},
+	}
+}
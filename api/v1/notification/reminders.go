@@ -0,0 +1,183 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+)
+
+// ReminderWindow is how long after its time a reminder is still sent, so reminders missed while
+// the server was down are not sent hours late
+const ReminderWindow = 2 * time.Hour
+
+var ErrInvalidSettings = errors.New("invalid notification settings")
+
+// ParseClock parses a "15:04" time of day into minutes after midnight
+func ParseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time %q is not HH:MM", ErrInvalidSettings, clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidateSettings checks the channel has where to send to and the times and timezone parse
+func ValidateSettings(settings *NotificationSettings) error {
+	switch settings.Channel {
+	case notificationEnums.WebhookChannel:
+		if settings.WebhookURL == "" {
+			return fmt.Errorf("%w: the webhook channel needs a webhook_url", ErrInvalidSettings)
+		}
+	case notificationEnums.EmailChannel:
+		if settings.Email == "" {
+			return fmt.Errorf("%w: the email channel needs an email", ErrInvalidSettings)
+		}
+	case notificationEnums.PushChannel:
+		if settings.PushSubscription == nil {
+			return fmt.Errorf("%w: the push channel needs a push_subscription", ErrInvalidSettings)
+		}
+	}
+
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSettings, settings.Timezone)
+	}
+
+	clocks := []string{settings.WorkoutReminder.Time, settings.FoodLogReminder.Time, settings.WeighInReminder.Time}
+	if settings.QuietHours != nil {
+		clocks = append(clocks, settings.QuietHours.Start, settings.QuietHours.End)
+	}
+	for _, clock := range clocks {
+		if _, err := ParseClock(clock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Location returns the timezone of the settings, UTC if it does not load
+func Location(settings *NotificationSettings) *time.Location {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// InQuietHours tells whether a local time falls in the quiet hours
+func InQuietHours(quiet *QuietHours, local time.Time) bool {
+	if quiet == nil {
+		return false
+	}
+	start, err := ParseClock(quiet.Start)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(quiet.End)
+	if err != nil || start == end {
+		return false
+	}
+
+	minute := minuteOfDay(local)
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// DeliverAt returns when a notification created at now may be delivered: now, or the end of the
+// quiet hours now falls in
+func DeliverAt(settings *NotificationSettings, now time.Time) time.Time {
+	local := now.In(Location(settings))
+	if !InQuietHours(settings.QuietHours, local) {
+		return now
+	}
+
+	end, _ := ParseClock(settings.QuietHours.End)
+	deliverAt := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, local.Location())
+	if !deliverAt.After(local) {
+		deliverAt = deliverAt.AddDate(0, 0, 1)
+	}
+	return deliverAt.UTC()
+}
+
+// ReminderDue tells whether a reminder is due at a local time: enabled, its time passed and still
+// within ReminderWindow
+func ReminderDue(reminder Reminder, local time.Time) bool {
+	if !reminder.Enabled {
+		return false
+	}
+	at, err := ParseClock(reminder.Time)
+	if err != nil {
+		return false
+	}
+
+	since := minuteOfDay(local) - at
+	return since >= 0 && time.Duration(since)*time.Minute < ReminderWindow
+}
+
+// WeighInDay tells whether a local time falls on one of the weigh-in days
+func WeighInDay(settings *NotificationSettings, local time.Time) bool {
+	for _, day := range settings.WeighInDays {
+		if day == local.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkoutReminderEvent reminds of a workout planned on a date ("2006-01-02")
+func WorkoutReminderEvent(workoutId string, workoutName string, date string) Event {
+	return Event{
+		Type:  notificationEnums.WorkoutReminder,
+		Key:   fmt.Sprintf("%s:%s:%s", notificationEnums.WorkoutReminder, workoutId, date),
+		Title: "Workout planned today",
+		Body:  fmt.Sprintf("%s is on your plan for today.", workoutName),
+		Data:  map[string]string{"workoutid": workoutId, "date": date},
+	}
+}
+
+// RestOverEvent signals the end of a rest of a session. Its key changes when the timer is
+// extended, so each version of the timer is signalled on its own.
+func RestOverEvent(sessionId string, timer workoutSession.RestTimer) Event {
+	return Event{
+		Type:  notificationEnums.RestOver,
+		Key:   fmt.Sprintf("%s:%s", notificationEnums.RestOver, timer.Key(sessionId)),
+		Title: "Rest over",
+		Body:  fmt.Sprintf("Your %d second rest is over, time for the next set.", timer.TargetSeconds),
+		Data: map[string]string{
+			"sessionid":  sessionId,
+			"exerciseid": timer.ExerciseID,
+			"set_number": strconv.Itoa(timer.SetNumber),
+		},
+	}
+}
+
+// FoodLogReminderEvent reminds to log food on a date ("2006-01-02")
+func FoodLogReminderEvent(date string) Event {
+	return Event{
+		Type:  notificationEnums.FoodLogReminder,
+		Key:   fmt.Sprintf("%s:%s", notificationEnums.FoodLogReminder, date),
+		Title: "Log your meals",
+		Body:  "You have not logged anything to eat today.",
+		Data:  map[string]string{"date": date},
+	}
+}
+
+// WeighInReminderEvent reminds to log weight on a date ("2006-01-02")
+func WeighInReminderEvent(date string) Event {
+	return Event{
+		Type:  notificationEnums.WeighInReminder,
+		Key:   fmt.Sprintf("%s:%s", notificationEnums.WeighInReminder, date),
+		Title: "Weigh-in day",
+		Body:  "Today is a weigh-in day, log your weight.",
+		Data:  map[string]string{"date": date},
+	}
+}
@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Job is work the scheduler runs every Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// Scheduler runs jobs in the background. A job never overlaps with itself; errors are printed
+// and the job runs again on its next tick.
+type Scheduler struct {
+	Jobs []Job
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start runs every job once now, then on its interval, until Stop
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	for _, job := range s.Jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop stops the jobs and waits for running ones to finish
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.wg.Wait()
+	s.stop = nil
+}
+
+func (s *Scheduler) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	s.run(job, time.Now())
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.run(job, now)
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Error running job %s: %v\n", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(now); err != nil {
+		fmt.Printf("Error running job %s: %v\n", job.Name, err)
+	}
+}
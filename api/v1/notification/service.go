@@ -0,0 +1,649 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutPlan"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ClaimTimeout is how long a claimed notification waits before another delivery run retries it
+	ClaimTimeout = 5 * time.Minute
+	// RetryDelay is multiplied by the attempts made to wait before sending again
+	RetryDelay = 5 * time.Minute
+	// SendTimeout bounds a single send through a channel
+	SendTimeout = 15 * time.Second
+)
+
+type NotificationService struct {
+	DB             *mongo.Database
+	Channels       map[notificationEnums.ChannelType]Channel
+	VapidPublicKey string
+}
+
+type INotificationService interface {
+	GetSettings(userId string) (*NotificationSettings, error)
+	UpdateSettings(dto *UpdateSettingsDto, userId string) (*NotificationSettings, error)
+	GetInbox(filters *InboxFilters, userId string) (*Inbox, error)
+	MarkRead(id string, userId string) (*Notification, error)
+	MarkAllRead(userId string) (int64, error)
+	DeleteNotification(id string, userId string) error
+	SendTest(userId string) (*Notification, error)
+	GetVapidPublicKey() string
+	Notify(userId string, event Event) (*Notification, bool, error)
+	ScheduleRestOver(userId string, sessionId string, timer workoutSession.RestTimer) error
+	CancelRestOver(userId string, sessionId string, timer workoutSession.RestTimer) error
+	RunReminders(now time.Time) error
+	DeliverDue(now time.Time) (int, error)
+}
+
+// GetSettings returns the user's settings, DefaultSettings until they save some
+func (s *NotificationService) GetSettings(userId string) (*NotificationSettings, error) {
+	settings := new(NotificationSettings)
+	err := s.DB.Collection("notificationSettings").FindOne(context.Background(), bson.M{"userid": userId}).Decode(settings)
+	if err == mongo.ErrNoDocuments {
+		return DefaultSettings(userId), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings.HasWebhookSecret = settings.WebhookSecret != ""
+	return settings, nil
+}
+
+func (s *NotificationService) UpdateSettings(dto *UpdateSettingsDto, userId string) (*NotificationSettings, error) {
+	defaults := DefaultSettings(userId)
+	settings := &NotificationSettings{
+		UserID:           userId,
+		Channel:          dto.Channel,
+		WebhookURL:       dto.WebhookURL,
+		WebhookSecret:    dto.WebhookSecret,
+		Email:            dto.Email,
+		PushSubscription: dto.PushSubscription,
+		Timezone:         dto.Timezone,
+		QuietHours:       dto.QuietHours,
+		WorkoutReminder:  dto.WorkoutReminder,
+		FoodLogReminder:  dto.FoodLogReminder,
+		WeighInReminder:  dto.WeighInReminder,
+		WeighInDays:      dto.WeighInDays,
+		UpdatedAt:        time.Now(),
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = defaults.Timezone
+	}
+	for _, reminder := range []struct{ set, fallback *Reminder }{
+		{&settings.WorkoutReminder, &defaults.WorkoutReminder},
+		{&settings.FoodLogReminder, &defaults.FoodLogReminder},
+		{&settings.WeighInReminder, &defaults.WeighInReminder},
+	} {
+		if reminder.set.Time == "" {
+			reminder.set.Time = reminder.fallback.Time
+		}
+	}
+	if settings.WeighInDays == nil {
+		settings.WeighInDays = defaults.WeighInDays
+	}
+
+	if err := ValidateSettings(settings); err != nil {
+		return nil, err
+	}
+	if dto.ClearWebhookSecret && dto.WebhookSecret != "" {
+		return nil, fmt.Errorf("%w: give a new webhook secret or clear it, not both", ErrInvalidSettings)
+	}
+	// The server posts to these URLs itself, so they may not point into its own network
+	for _, raw := range outboundURLs(settings) {
+		if err := CheckOutboundURL(context.Background(), raw); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+		}
+	}
+
+	if _, err := s.DB.Collection("notificationSettings").UpdateOne(context.Background(), bson.M{"userid": userId}, SettingsUpdate(settings, dto), options.Update().SetUpsert(true)); err != nil {
+		return nil, err
+	}
+
+	return s.GetSettings(userId)
+}
+
+// SettingsUpdate sets the settings, unsetting the optional ones left empty. The webhook secret is
+// never sent back, so it is only replaced when a new one is given and only removed when asked to.
+func SettingsUpdate(settings *NotificationSettings, dto *UpdateSettingsDto) bson.M {
+	set := bson.M{
+		"userid":            settings.UserID,
+		"channel":           settings.Channel,
+		"timezone":          settings.Timezone,
+		"workout_reminder":  settings.WorkoutReminder,
+		"food_log_reminder": settings.FoodLogReminder,
+		"weigh_in_reminder": settings.WeighInReminder,
+		"weigh_in_days":     settings.WeighInDays,
+		"updated_at":        settings.UpdatedAt,
+	}
+	unset := bson.M{}
+	for _, optional := range []struct {
+		field string
+		value interface{}
+		empty bool
+	}{
+		{"webhook_url", settings.WebhookURL, settings.WebhookURL == ""},
+		{"email", settings.Email, settings.Email == ""},
+		{"push_subscription", settings.PushSubscription, settings.PushSubscription == nil},
+		{"quiet_hours", settings.QuietHours, settings.QuietHours == nil},
+	} {
+		if optional.empty {
+			unset[optional.field] = ""
+		} else {
+			set[optional.field] = optional.value
+		}
+	}
+	if dto.ClearWebhookSecret {
+		unset["webhook_secret"] = ""
+	} else if dto.WebhookSecret != "" {
+		set["webhook_secret"] = dto.WebhookSecret
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// GetInbox returns the user's notifications, newest first, with the number of unread ones
+func (s *NotificationService) GetInbox(filters *InboxFilters, userId string) (*Inbox, error) {
+	filter := bson.M{"userid": userId}
+	if filters.Unread {
+		filter["read_at"] = nil
+	}
+
+	limit := int64(filters.Limit)
+	if limit == 0 {
+		limit = 50
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(filters.Skip)).
+		SetLimit(limit)
+
+	cursor, err := s.DB.Collection("notifications").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	notifications := make([]*Notification, 0)
+	if err := cursor.All(context.Background(), &notifications); err != nil {
+		return nil, err
+	}
+
+	unread, err := s.DB.Collection("notifications").CountDocuments(context.Background(), bson.M{"userid": userId, "read_at": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Inbox{Notifications: notifications, Unread: unread}, nil
+}
+
+func (s *NotificationService) MarkRead(id string, userId string) (*Notification, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": objectId, "userid": userId}
+	update := bson.M{"$set": bson.M{"read_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	n := new(Notification)
+	if err := s.DB.Collection("notifications").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// MarkAllRead marks every unread notification read and returns how many there were
+func (s *NotificationService) MarkAllRead(userId string) (int64, error) {
+	filter := bson.M{"userid": userId, "read_at": nil}
+	update := bson.M{"$set": bson.M{"read_at": time.Now()}}
+
+	result, err := s.DB.Collection("notifications").UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *NotificationService) DeleteNotification(id string, userId string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.DB.Collection("notifications").DeleteOne(context.Background(), bson.M{"_id": objectId, "userid": userId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SendTest notifies the user through their channel right away, quiet hours aside
+func (s *NotificationService) SendTest(userId string) (*Notification, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	n := newNotification(userId, Event{
+		Type:  notificationEnums.TestNotification,
+		Key:   fmt.Sprintf("%s:%s", notificationEnums.TestNotification, primitive.NewObjectID().Hex()),
+		Title: "Test notification",
+		Body:  "Notifications reach you here.",
+	}, settings.Channel, now, now)
+
+	if _, err := s.DB.Collection("notifications").InsertOne(context.Background(), n); err != nil {
+		return nil, err
+	}
+	return s.deliver(n, settings, now)
+}
+
+func (s *NotificationService) GetVapidPublicKey() string {
+	return s.VapidPublicKey
+}
+
+func newNotification(userId string, event Event, channel notificationEnums.ChannelType, deliverAt time.Time, now time.Time) *Notification {
+	return &Notification{
+		ID:        primitive.NewObjectID(),
+		UserID:    userId,
+		Type:      event.Type,
+		EventKey:  event.Key,
+		Title:     event.Title,
+		Body:      event.Body,
+		Data:      event.Data,
+		Channel:   channel,
+		Status:    notificationEnums.DeliveryPending,
+		DeliverAt: deliverAt,
+		CreatedAt: now,
+	}
+}
+
+// Notify stores a notification of the event in the user's inbox and delivers it, unless quiet
+// hours hold it back. An event already notified is not stored or sent again; the existing
+// notification is returned and the bool is false.
+func (s *NotificationService) Notify(userId string, event Event) (*Notification, bool, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	n := newNotification(userId, event, settings.Channel, DeliverAt(settings, now), now)
+	if _, err := s.DB.Collection("notifications").InsertOne(context.Background(), n); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, false, err
+		}
+
+		existing := new(Notification)
+		filter := bson.M{"userid": userId, "event_key": event.Key}
+		if err := s.DB.Collection("notifications").FindOne(context.Background(), filter).Decode(existing); err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+
+	if n.DeliverAt.After(now) {
+		return n, true, nil
+	}
+	n, err = s.deliver(n, settings, now)
+	return n, true, err
+}
+
+// ScheduleRestOver stores a rest over notification to deliver when the timer ends. It is sent on
+// time by this instance and by the delivery job should the instance stop before.
+func (s *NotificationService) ScheduleRestOver(userId string, sessionId string, timer workoutSession.RestTimer) error {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return err
+	}
+
+	n := newNotification(userId, RestOverEvent(sessionId, timer), settings.Channel, timer.EndsAt, time.Now())
+	if _, err := s.DB.Collection("notifications").InsertOne(context.Background(), n); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
+	}
+
+	time.AfterFunc(time.Until(timer.EndsAt), func() {
+		if err := s.deliverPending(n.ID, time.Now()); err != nil {
+			fmt.Printf("Error delivering notification %s: %v\n", n.ID.Hex(), err)
+		}
+	})
+	return nil
+}
+
+// CancelRestOver drops the rest over notification of a timer that was skipped, extended or
+// replaced, unless it was already sent
+func (s *NotificationService) CancelRestOver(userId string, sessionId string, timer workoutSession.RestTimer) error {
+	filter := bson.M{
+		"userid":    userId,
+		"event_key": RestOverEvent(sessionId, timer).Key,
+		"status":    notificationEnums.DeliveryPending,
+	}
+	_, err := s.DB.Collection("notifications").DeleteOne(context.Background(), filter)
+	return err
+}
+
+// deliverPending sends a notification if it is still pending, a delivery run or a cancel may
+// have come first
+func (s *NotificationService) deliverPending(id primitive.ObjectID, now time.Time) error {
+	filter := bson.M{"_id": id, "status": notificationEnums.DeliveryPending}
+	update := bson.M{"$set": bson.M{"status": notificationEnums.DeliverySending, "claimed_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	n := new(Notification)
+	err := s.DB.Collection("notifications").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(n)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.GetSettings(n.UserID)
+	if err != nil {
+		return err
+	}
+	_, err = s.deliver(n, settings, now)
+	return err
+}
+
+// DeliverDue sends the pending notifications whose delivery time has come, and returns how many
+// were delivered
+func (s *NotificationService) DeliverDue(now time.Time) (int, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": notificationEnums.DeliveryPending, "deliver_at": bson.M{"$lte": now}},
+		bson.M{"status": notificationEnums.DeliverySending, "claimed_at": bson.M{"$lt": now.Add(-ClaimTimeout)}},
+	}}
+	update := bson.M{"$set": bson.M{"status": notificationEnums.DeliverySending, "claimed_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetSort(bson.D{{Key: "deliver_at", Value: 1}})
+
+	delivered := 0
+	for {
+		// Claiming one at a time keeps concurrent runs from sending the same notification
+		n := new(Notification)
+		err := s.DB.Collection("notifications").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(n)
+		if err == mongo.ErrNoDocuments {
+			return delivered, nil
+		}
+		if err != nil {
+			return delivered, err
+		}
+
+		settings, err := s.GetSettings(n.UserID)
+		if err != nil {
+			return delivered, err
+		}
+		n, err = s.deliver(n, settings, now)
+		if err != nil {
+			fmt.Printf("Error delivering notification %s: %v\n", n.ID.Hex(), err)
+			continue
+		}
+		if n.Status == notificationEnums.DeliveryDelivered {
+			delivered++
+		}
+	}
+}
+
+// deliver sends a notification through the user's current channel and saves the outcome. A
+// failed send is retried later until MaxDeliveryAttempts; the returned error is the one saving.
+func (s *NotificationService) deliver(n *Notification, settings *NotificationSettings, now time.Time) (*Notification, error) {
+	set := bson.M{"channel": settings.Channel}
+	unset := bson.M{"claimed_at": ""}
+	n.Channel = settings.Channel
+
+	if deliverAt := DeliverAt(settings, now); deliverAt.After(now) && !n.Type.IgnoresQuietHours() {
+		// Quiet hours started since it was scheduled
+		n.Status, n.DeliverAt = notificationEnums.DeliveryPending, deliverAt
+		set["status"], set["deliver_at"] = n.Status, n.DeliverAt
+		return n, s.saveDelivery(n, set, unset)
+	}
+
+	err := s.send(n, settings)
+	switch {
+	case err == nil:
+		n.Status, n.DeliveredAt, n.LastError = notificationEnums.DeliveryDelivered, &now, ""
+		set["status"], set["delivered_at"] = n.Status, now
+		unset["last_error"] = ""
+	case errors.Is(err, ErrSubscriptionGone):
+		n.Status, n.LastError = notificationEnums.DeliveryFailed, err.Error()
+		set["status"], set["last_error"] = n.Status, n.LastError
+		s.dropSubscription(settings)
+	default:
+		n.Attempts++
+		n.LastError = err.Error()
+		n.Status = notificationEnums.DeliveryFailed
+		if n.Attempts < notificationEnums.MaxDeliveryAttempts {
+			n.Status = notificationEnums.DeliveryPending
+			n.DeliverAt = DeliverAt(settings, now.Add(time.Duration(n.Attempts)*RetryDelay))
+			set["deliver_at"] = n.DeliverAt
+		}
+		set["status"], set["attempts"], set["last_error"] = n.Status, n.Attempts, n.LastError
+	}
+
+	return n, s.saveDelivery(n, set, unset)
+}
+
+func (s *NotificationService) send(n *Notification, settings *NotificationSettings) error {
+	if settings.Channel == notificationEnums.InboxChannel {
+		return nil
+	}
+
+	channel, ok := s.Channels[settings.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChannelNotConfigured, settings.Channel)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), SendTimeout)
+	defer cancel()
+	return channel.Send(ctx, settings, n)
+}
+
+func (s *NotificationService) saveDelivery(n *Notification, set bson.M, unset bson.M) error {
+	update := bson.M{"$set": set, "$unset": unset}
+	_, err := s.DB.Collection("notifications").UpdateByID(context.Background(), n.ID, update)
+	return err
+}
+
+// dropSubscription forgets a push subscription or webhook the receiving end rejected as gone,
+// falling back to the inbox
+func (s *NotificationService) dropSubscription(settings *NotificationSettings) {
+	unset := bson.M{"webhook_url": "", "webhook_secret": ""}
+	if settings.Channel == notificationEnums.PushChannel {
+		unset = bson.M{"push_subscription": ""}
+	}
+	update := bson.M{
+		"$set":   bson.M{"channel": notificationEnums.InboxChannel, "updated_at": time.Now()},
+		"$unset": unset,
+	}
+
+	if _, err := s.DB.Collection("notificationSettings").UpdateOne(context.Background(), bson.M{"userid": settings.UserID}, update); err != nil {
+		fmt.Printf("Error dropping notification subscription: %v\n", err)
+	}
+}
+
+// RunReminders notifies every user with reminders on of the ones due at now. Notify keeps a
+// reminder from being sent twice, so running it often is safe.
+func (s *NotificationService) RunReminders(now time.Time) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"workout_reminder.enabled": true},
+		bson.M{"food_log_reminder.enabled": true},
+		bson.M{"weigh_in_reminder.enabled": true},
+	}}
+
+	cursor, err := s.DB.Collection("notificationSettings").Find(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		settings := new(NotificationSettings)
+		if err := cursor.Decode(settings); err != nil {
+			return err
+		}
+
+		events, err := s.dueReminders(settings, now)
+		if err != nil {
+			fmt.Printf("Error checking reminders for user %s: %v\n", settings.UserID, err)
+			continue
+		}
+		for _, event := range events {
+			if _, _, err := s.Notify(settings.UserID, event); err != nil {
+				fmt.Printf("Error notifying user %s: %v\n", settings.UserID, err)
+			}
+		}
+	}
+	return cursor.Err()
+}
+
+// dueReminders returns the reminders of a user due at now that are still needed
+func (s *NotificationService) dueReminders(settings *NotificationSettings, now time.Time) ([]Event, error) {
+	local := now.In(Location(settings))
+	date := local.Format("2006-01-02")
+	events := make([]Event, 0)
+
+	if ReminderDue(settings.WorkoutReminder, local) {
+		workoutEvents, err := s.workoutReminders(settings.UserID, local)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, workoutEvents...)
+	}
+
+	if ReminderDue(settings.FoodLogReminder, local) {
+		filter := bson.M{"userid": settings.UserID, "date": date, "meals.0": bson.M{"$exists": true}}
+		logged, err := s.DB.Collection("foodlog").CountDocuments(context.Background(), filter)
+		if err != nil {
+			return nil, err
+		}
+		if logged == 0 {
+			events = append(events, FoodLogReminderEvent(date))
+		}
+	}
+
+	if ReminderDue(settings.WeighInReminder, local) && WeighInDay(settings, local) {
+		start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		filter := bson.M{
+			"userid":     settings.UserID,
+			"created_at": bson.M{"$gte": start, "$lt": start.AddDate(0, 0, 1)},
+		}
+		logged, err := s.DB.Collection("bodyCompositionLog").CountDocuments(context.Background(), filter)
+		if err != nil {
+			return nil, err
+		}
+		if logged == 0 {
+			events = append(events, WeighInReminderEvent(date))
+		}
+	}
+
+	return events, nil
+}
+
+// workoutReminders returns a reminder for each workout planned on the local day and not done yet.
+// Plans keep their dates as UTC days, so the local date is looked up as a UTC day.
+func (s *NotificationService) workoutReminders(userId string, local time.Time) ([]Event, error) {
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	filter := bson.M{
+		"userid": userId,
+		"rest":   bson.M{"$ne": true},
+		"dates":  bson.M{"$elemMatch": bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)}},
+	}
+
+	cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	plans := make([]workoutPlan.WorkoutPlan, 0)
+	if err := cursor.All(context.Background(), &plans); err != nil {
+		return nil, err
+	}
+
+	workoutIds := make([]primitive.ObjectID, 0, len(plans))
+	for _, plan := range plans {
+		if id, err := primitive.ObjectIDFromHex(plan.WorkoutID); err == nil {
+			workoutIds = append(workoutIds, id)
+		}
+	}
+	names, err := s.workoutNames(workoutIds)
+	if err != nil {
+		return nil, err
+	}
+
+	date := local.Format("2006-01-02")
+	events := make([]Event, 0)
+	for _, plan := range plans {
+		if PlanDone(plan, day) {
+			continue
+		}
+		name, ok := names[plan.WorkoutID]
+		if !ok {
+			name = "A workout"
+		}
+		events = append(events, WorkoutReminderEvent(plan.WorkoutID, name, date))
+	}
+	return events, nil
+}
+
+// PlanDone tells whether the workout the plan has on a UTC day is completed
+func PlanDone(plan workoutPlan.WorkoutPlan, day time.Time) bool {
+	for _, completion := range plan.Completions {
+		if completion.Date.UTC().Truncate(24 * time.Hour).Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *NotificationService) workoutNames(ids []primitive.ObjectID) (map[string]string, error) {
+	names := make(map[string]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	cursor, err := s.DB.Collection("workout").Find(context.Background(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	workouts := make([]workout.Workout, 0)
+	if err := cursor.All(context.Background(), &workouts); err != nil {
+		return nil, err
+	}
+
+	for _, w := range workouts {
+		names[w.ID.Hex()] = w.Name
+	}
+	return names, nil
+}
+
+// Jobs are the scheduled jobs of notifications: checking reminders and sending due notifications
+func (s *NotificationService) Jobs() []Job {
+	return []Job{
+		{Name: "reminders", Interval: time.Minute, Run: s.RunReminders},
+		{Name: "notification delivery", Interval: time.Minute, Run: func(now time.Time) error {
+			_, err := s.DeliverDue(now)
+			return err
+		}},
+	}
+}
@@ -0,0 +1,259 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/hkdf"
+)
+
+// PushRecordSize is the record size advertised in the aes128gcm header. Payloads fit one record.
+const PushRecordSize = 4096
+
+// PushSender sends notifications with the Web Push protocol (RFC 8030), encrypting payloads as
+// RFC 8291 describes and identifying the server with VAPID (RFC 8292)
+type PushSender struct {
+	PublicKey  string // VAPID keys, unpadded base64url as web-push generate-vapid-keys prints them
+	PrivateKey string
+	Subject    string       // "mailto:" or "https:" contact of the server
+	Client     *http.Client // outboundClient unless replaced
+}
+
+func (p *PushSender) Send(ctx context.Context, settings *NotificationSettings, n *Notification) error {
+	if p.PublicKey == "" || p.PrivateKey == "" {
+		return ErrChannelNotConfigured
+	}
+	subscription := settings.PushSubscription
+	if subscription == nil {
+		return fmt.Errorf("%w: no push subscription", ErrChannelNotConfigured)
+	}
+	if _, err := CheckURLScheme(subscription.Endpoint); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(newWebhookPayload(n))
+	if err != nil {
+		return err
+	}
+	body, err := EncryptPushPayload(subscription, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := p.vapidAuthorization(subscription.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", "86400")
+	req.Header.Set("Urgency", "normal")
+
+	client := p.Client
+	if client == nil {
+		client = outboundClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return ErrSubscriptionGone
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// vapidAuthorization signs a VAPID token for the origin of the push endpoint
+func (p *PushSender) vapidAuthorization(endpoint string, now time.Time) (string, error) {
+	origin, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	key, err := VapidSigningKey(p.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": origin.Scheme + "://" + origin.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": p.Subject,
+	}).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", token, p.PublicKey), nil
+}
+
+// VapidSigningKey turns a base64url VAPID private key into an ECDSA key
+func VapidSigningKey(privateKey string) (*ecdsa.PrivateKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	// The uncompressed public key is 0x04 || X || Y
+	public := key.PublicKey().Bytes()
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}, nil
+}
+
+// EncryptPushPayload encrypts a payload for a subscription with the aes128gcm content coding
+func EncryptPushPayload(subscription *PushSubscription, payload []byte) ([]byte, error) {
+	if len(payload)+17+86 > PushRecordSize {
+		return nil, fmt.Errorf("push payload of %d bytes is too large", len(payload))
+	}
+
+	uaPublicBytes, err := base64.RawURLEncoding.DecodeString(subscription.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push subscription key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push subscription key: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(subscription.Keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push subscription auth: %w", err)
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	cek, nonce, err := pushContentKeys(shared, authSecret, salt, uaPublicBytes, asPublicBytes)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record, ended by the 0x02 delimiter of the last record
+	plaintext := append(append([]byte(nil), payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, PushRecordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// pushContentKeys derives the content encryption key and nonce of RFC 8291 section 3.4
+func pushContentKeys(shared []byte, authSecret []byte, salt []byte, uaPublic []byte, asPublic []byte) ([]byte, []byte, error) {
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, authSecret, keyInfo), ikm); err != nil {
+		return nil, nil, err
+	}
+
+	cek := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: aes128gcm\x00")), cek); err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, 12)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: nonce\x00")), nonce); err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
+
+// DecryptPushPayload reverses EncryptPushPayload with the subscription's private key, as a
+// browser would
+func DecryptPushPayload(uaPrivate *ecdh.PrivateKey, authSecret []byte, body []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, fmt.Errorf("push body is too short")
+	}
+	salt := body[:16]
+	idLength := int(body[20])
+	if len(body) < 21+idLength {
+		return nil, fmt.Errorf("push body is too short")
+	}
+	asPublicBytes := body[21 : 21+idLength]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, nonce, err := pushContentKeys(shared, authSecret, salt, uaPrivate.PublicKey().Bytes(), asPublicBytes)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, body[21+idLength:], nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 0x02 {
+		return nil, fmt.Errorf("push payload is not a single last record")
+	}
+	return plaintext[:len(plaintext)-1], nil
+}
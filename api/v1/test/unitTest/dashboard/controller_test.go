@@ -44,6 +44,14 @@ func (m *MockDashboardService) GetRepMax(userId string, exerciseId string, useLa
 	return args.Get(0).(*dashboard.RepMaxResponse), args.Error(1)
 }
 
+func (m *MockDashboardService) GetAverageRPE(userId string, exerciseId string, startDate, endDate time.Time) (*dashboard.AverageRPEResponse, error) {
+	args := m.Called(userId, exerciseId, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dashboard.AverageRPEResponse), args.Error(1)
+}
+
 func (m *MockDashboardService) GetNutritionSummary(userId string, startDate, endDate time.Time) (*dashboard.NutritionSummaryResponse, error) {
 	args := m.Called(userId, startDate, endDate)
 	if args.Get(0) == nil {
@@ -206,6 +214,53 @@ func TestGetRepMaxHandler(t *testing.T) {
 	})
 }
 
+func TestGetAverageRPEHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully get average RPE", func(t *testing.T) {
+		exerciseId := "test_exercise"
+		startDate := time.Now().AddDate(0, 0, -7).UTC().Truncate(time.Second)
+		endDate := time.Now().UTC().Truncate(time.Second)
+
+		expectedResponse := &dashboard.AverageRPEResponse{
+			ExerciseID: exerciseId,
+			Labels:     []string{"2024-01-01", "2024-01-03"},
+			Values:     []float64{7.5, 8},
+			AverageRPE: 7.75,
+		}
+
+		mockService.On("GetAverageRPE", "test_user", exerciseId,
+			mock.MatchedBy(func(t time.Time) bool { return t.UTC().Truncate(time.Second).Equal(startDate) }),
+			mock.MatchedBy(func(t time.Time) bool { return t.UTC().Truncate(time.Second).Equal(endDate) }),
+		).Return(expectedResponse, nil)
+
+		url := fmt.Sprintf("/api/v1/dashboard/rpe/%s?startDate=%s&endDate=%s", exerciseId,
+			url.QueryEscape(startDate.Format("2006-01-02 15:04:05")),
+			url.QueryEscape(endDate.Format("2006-01-02 15:04:05")))
+
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result dashboard.AverageRPEResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, expectedResponse.Values, result.Values)
+		assert.Equal(t, expectedResponse.AverageRPE, result.AverageRPE)
+	})
+
+	t.Run("Invalid date format", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/dashboard/rpe/test_exercise?startDate=invalid&endDate=invalid", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
 func TestGetNutritionSummaryHandler(t *testing.T) {
 	app, mockService := setupTest()
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Npwskp/GymsbroBackend/api/v1/dashboard"
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
 	userFitnessPreferenceEnums "github.com/Npwskp/GymsbroBackend/api/v1/user/enums"
 	bodyCompositionLog "github.com/Npwskp/GymsbroBackend/api/v1/userLog/userBodyComposition"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
@@ -168,6 +169,71 @@ func TestGetRepMax(t *testing.T) {
 		assert.NotZero(t, result.EightRepMax)
 		assert.NotZero(t, result.TwelveRepMax)
 	})
+
+	t.Run("Count reps in reserve from RPE", func(t *testing.T) {
+		userId := "rpe_user"
+		exerciseId := primitive.NewObjectID().Hex()
+		rpe := 8.0
+
+		_, err := db.Collection("exerciseLogs").InsertOne(context.Background(), &exerciseLog.ExerciseLog{
+			UserID:     userId,
+			ExerciseID: exerciseId,
+			DateTime:   time.Now(),
+			Sets: []exerciseLog.SetLog{
+				{Weight: 100, Reps: 8, RPE: &rpe, Type: exerciseLog.WorkingSet},
+			},
+		})
+		assert.NoError(t, err)
+
+		result, err := service.GetRepMax(userId, exerciseId, true)
+		assert.NoError(t, err)
+		// 8 reps at RPE 8 is estimated as a 10 rep max
+		expected, _ := dashboardFunctions.CalculateOneRepMax(100, 10)
+		assert.Equal(t, expected, result.OneRepMax)
+	})
+}
+
+func TestGetAverageRPE(t *testing.T) {
+	db := setupTestDB(t)
+	service := &dashboard.DashboardService{DB: db}
+
+	t.Run("Successfully get average RPE per day", func(t *testing.T) {
+		userId := "test_user"
+		exerciseId := primitive.NewObjectID().Hex()
+		day := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		rpe7, rpe9 := 7.0, 9.0
+		rir1 := 1
+
+		logs := []interface{}{
+			&exerciseLog.ExerciseLog{
+				UserID:     userId,
+				ExerciseID: exerciseId,
+				DateTime:   day,
+				Sets: []exerciseLog.SetLog{
+					{Weight: 60, Reps: 5, RPE: &rpe7, Type: exerciseLog.WarmUpSet},
+					{Weight: 100, Reps: 5, RPE: &rpe7, Type: exerciseLog.WorkingSet},
+					{Weight: 100, Reps: 5, RPE: &rpe9, Type: exerciseLog.WorkingSet},
+				},
+			},
+			&exerciseLog.ExerciseLog{
+				UserID:     userId,
+				ExerciseID: exerciseId,
+				DateTime:   day.AddDate(0, 0, 2),
+				Sets: []exerciseLog.SetLog{
+					{Weight: 100, Reps: 5, RIR: &rir1, Type: exerciseLog.WorkingSet},
+					{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet},
+				},
+			},
+		}
+		_, err := db.Collection("exerciseLogs").InsertMany(context.Background(), logs)
+		assert.NoError(t, err)
+
+		result, err := service.GetAverageRPE(userId, exerciseId, day.AddDate(0, 0, -1), day.AddDate(0, 0, 3))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2024-01-01", "2024-01-03"}, result.Labels)
+		assert.Equal(t, []float64{8, 9}, result.Values)
+		assert.Equal(t, 8.33, result.AverageRPE)
+	})
 }
 func TestGetBodyCompositionAnalysis(t *testing.T) {
 	db := setupTestDB(t)
@@ -268,3 +268,41 @@ func TestSearchAndFilterExercise(t *testing.T) {
 		assert.Equal(t, "Barbell Squat", results[0].Name)
 	})
 }
+
+func TestMatchExerciseName(t *testing.T) {
+	exercises := []*exercise.Exercise{
+		{ID: primitive.NewObjectID(), Name: "Bench Press", Equipment: exerciseEnums.Barbell},
+		{ID: primitive.NewObjectID(), Name: "Bench Press", Equipment: exerciseEnums.Dumbbell},
+		{ID: primitive.NewObjectID(), Name: "Front Squat", Equipment: exerciseEnums.Barbell},
+		{ID: primitive.NewObjectID(), Name: "Chest Press", Equipment: exerciseEnums.LeverSelectorized},
+		{ID: primitive.NewObjectID(), Name: "Pull-up", Equipment: exerciseEnums.BodyWeight},
+	}
+
+	t.Run("Equipment in brackets picks the exercise", func(t *testing.T) {
+		matches := exercise.MatchExerciseName("Bench Press (Dumbbell)", exercises, 2)
+		if assert.Len(t, matches, 2) {
+			assert.Equal(t, exercises[1].ID.Hex(), matches[0].ExerciseID)
+			assert.Equal(t, 1.0, matches[0].Score)
+			assert.Equal(t, exercises[0].ID.Hex(), matches[1].ExerciseID)
+		}
+	})
+
+	t.Run("Words in another order", func(t *testing.T) {
+		matches := exercise.MatchExerciseName("Squat Front", exercises, 1)
+		assert.Equal(t, "Front Squat", matches[0].Name)
+		assert.Equal(t, 1.0, matches[0].Score)
+	})
+
+	t.Run("Machines and body weight", func(t *testing.T) {
+		assert.Equal(t, "Chest Press", exercise.MatchExerciseName("Chest Press (Machine)", exercises, 1)[0].Name)
+		matches := exercise.MatchExerciseName("Pull Up (Bodyweight)", exercises, 1)
+		assert.Equal(t, "Pull-up", matches[0].Name)
+		assert.Equal(t, 1.0, matches[0].Score)
+	})
+
+	t.Run("Unknown names score low", func(t *testing.T) {
+		matches := exercise.MatchExerciseName("Farmer's Walk", exercises, 0)
+		assert.Len(t, matches, len(exercises))
+		assert.Less(t, matches[0].Score, 0.8)
+	})
+}
@@ -2,6 +2,7 @@ package exerciseLog_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
@@ -67,6 +69,70 @@ func (m *MockExerciseLogService) DeleteLog(id string, userId string) error {
 	return args.Error(0)
 }
 
+func (m *MockExerciseLogService) CreateLogContext(ctx context.Context, log *exerciseLog.CreateExerciseLogDto, userId string) (*exerciseLog.ExerciseLog, error) {
+	args := m.Called(ctx, log, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*exerciseLog.ExerciseLog), args.Error(1)
+}
+
+func (m *MockExerciseLogService) UpdateLogContext(ctx context.Context, id string, log *exerciseLog.UpdateExerciseLogDto, userId string) (*exerciseLog.ExerciseLog, error) {
+	args := m.Called(ctx, id, log, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*exerciseLog.ExerciseLog), args.Error(1)
+}
+
+func (m *MockExerciseLogService) DeleteLogContext(ctx context.Context, id string, userId string) (*exerciseLog.ExerciseLog, error) {
+	args := m.Called(ctx, id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*exerciseLog.ExerciseLog), args.Error(1)
+}
+
+func (m *MockExerciseLogService) RefreshRecords(userId string, exerciseId string, logId string) []personalRecordEnums.AchievedRecord {
+	args := m.Called(userId, exerciseId, logId)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]personalRecordEnums.AchievedRecord)
+}
+
+func (m *MockExerciseLogService) GetLogs(userId string, filters *exerciseLog.LogFilters, page *exerciseLog.LogPageQuery) (*exerciseLog.LogPage, error) {
+	args := m.Called(userId, filters, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*exerciseLog.LogPage), args.Error(1)
+}
+
+func (m *MockExerciseLogService) GetWeeklyVolume(userId string, filters *exerciseLog.LogFilters) ([]*exerciseLog.WeeklyVolume, error) {
+	args := m.Called(userId, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*exerciseLog.WeeklyVolume), args.Error(1)
+}
+
+func (m *MockExerciseLogService) GetWeeklyMuscleSets(userId string, filters *exerciseLog.LogFilters) ([]*exerciseLog.WeeklyMuscleSets, error) {
+	args := m.Called(userId, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*exerciseLog.WeeklyMuscleSets), args.Error(1)
+}
+
+func (m *MockExerciseLogService) GetBestSets(userId string, filters *exerciseLog.LogFilters) ([]*exerciseLog.BestSet, error) {
+	args := m.Called(userId, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*exerciseLog.BestSet), args.Error(1)
+}
+
 // TestMiddleware sets up the test context with a mock user
 func testMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -148,6 +214,31 @@ func TestCreateLogHandler(t *testing.T) {
 		json.NewDecoder(resp.Body).Decode(&result)
 		assert.Equal(t, expectedResponse.Notes, result.Notes)
 	})
+
+	t.Run("Reject invalid effort and tempo", func(t *testing.T) {
+		rpe := 11.0
+		invalidSets := []exerciseLog.SetLog{
+			{Weight: 100, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet, RPE: &rpe},
+			{Weight: 100, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet, Tempo: "3-1-0"},
+			{Weight: 100, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet, RestSeconds: -30},
+		}
+
+		for _, set := range invalidSets {
+			createDto := exerciseLog.CreateExerciseLogDto{
+				ExerciseID: primitive.NewObjectID().Hex(),
+				Sets:       []exerciseLog.SetLog{set},
+			}
+
+			body, _ := json.Marshal(createDto)
+			req := httptest.NewRequest("POST", "/api/v1/exercise-log", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("userid", "test_user")
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		}
+	})
 }
 
 func TestGetUserLogsHandler(t *testing.T) {
@@ -219,6 +310,160 @@ func TestGetExerciseLogsHandler(t *testing.T) {
 	})
 }
 
+func TestGetLogsPageHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get a page of logs successfully", func(t *testing.T) {
+		expectedLogs := []*exerciseLog.ExerciseLog{
+			{ID: primitive.NewObjectID(), UserID: "test_user", ExerciseID: primitive.NewObjectID().Hex()},
+		}
+		mockService.On("GetLogs", "test_user", &exerciseLog.LogFilters{}, &exerciseLog.LogPageQuery{}).
+			Return(&exerciseLog.LogPage{Logs: expectedLogs, NextCursor: "next"}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/page", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result exerciseLog.LogPage
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, len(expectedLogs), len(result.Logs))
+		assert.Equal(t, "next", result.NextCursor)
+	})
+
+	t.Run("Filters and cursor are passed on", func(t *testing.T) {
+		filters := &exerciseLog.LogFilters{
+			Muscle:    "Biceps Brachii",
+			Equipment: "Barbell",
+			SetType:   exerciseLog.WorkingSet,
+			StartDate: "2024-01-01 00:00:00",
+		}
+		page := &exerciseLog.LogPageQuery{Cursor: "abc", Limit: 10}
+		mockService.On("GetLogs", "test_user", filters, page).Return(&exerciseLog.LogPage{Logs: []*exerciseLog.ExerciseLog{}}, nil).Once()
+
+		query := url.Values{}
+		query.Set("muscle", "Biceps Brachii")
+		query.Set("equipment", "Barbell")
+		query.Set("setType", "working")
+		query.Set("startDate", "2024-01-01 00:00:00")
+		query.Set("cursor", "abc")
+		query.Set("limit", "10")
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/page?"+query.Encode(), nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/page?limit=500", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		page := &exerciseLog.LogPageQuery{Cursor: "bad"}
+		mockService.On("GetLogs", "test_user", &exerciseLog.LogFilters{}, page).Return(nil, exerciseLog.ErrInvalidCursor).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/page?cursor=bad", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestLogStatsHandlers(t *testing.T) {
+	app, mockService := setupTest()
+	week := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Weekly volume", func(t *testing.T) {
+		filters := &exerciseLog.LogFilters{Timezone: "Asia/Bangkok"}
+		mockService.On("GetWeeklyVolume", "test_user", filters).
+			Return([]*exerciseLog.WeeklyVolume{{Week: week, Volume: 12000, Sets: 30, Logs: 6}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/stats/weekly-volume?timezone=Asia/Bangkok", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []exerciseLog.WeeklyVolume
+		json.NewDecoder(resp.Body).Decode(&result)
+		if assert.Len(t, result, 1) {
+			assert.Equal(t, 12000.0, result[0].Volume)
+			assert.True(t, week.Equal(result[0].Week))
+		}
+	})
+
+	t.Run("Weekly volume with an unknown timezone", func(t *testing.T) {
+		filters := &exerciseLog.LogFilters{Timezone: "Nowhere"}
+		mockService.On("GetWeeklyVolume", "test_user", filters).Return(nil, exerciseLog.ErrInvalidTimezone).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/stats/weekly-volume?timezone=Nowhere", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Weekly sets per muscle", func(t *testing.T) {
+		filters := &exerciseLog.LogFilters{Muscle: "Triceps Brachii"}
+		mockService.On("GetWeeklyMuscleSets", "test_user", filters).
+			Return([]*exerciseLog.WeeklyMuscleSets{{Week: week, Muscle: "Triceps Brachii", Sets: 12}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/stats/weekly-muscle-sets?muscle="+url.QueryEscape("Triceps Brachii"), nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []exerciseLog.WeeklyMuscleSets
+		json.NewDecoder(resp.Body).Decode(&result)
+		if assert.Len(t, result, 1) {
+			assert.Equal(t, 12, result[0].Sets)
+		}
+	})
+
+	t.Run("Best sets", func(t *testing.T) {
+		filters := &exerciseLog.LogFilters{EndDate: "2024-06-30 23:59:59"}
+		mockService.On("GetBestSets", "test_user", filters).
+			Return([]*exerciseLog.BestSet{{ExerciseID: "bench", LogID: primitive.NewObjectID(), Load: 100, Reps: 5, EstimatedOneRM: 112.5}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/stats/best-sets?endDate="+url.QueryEscape("2024-06-30 23:59:59"), nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []exerciseLog.BestSet
+		json.NewDecoder(resp.Body).Decode(&result)
+		if assert.Len(t, result, 1) {
+			assert.Equal(t, 112.5, result[0].EstimatedOneRM)
+		}
+	})
+
+	t.Run("Invalid set type", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/exercise-log/stats/best-sets?setType=heavy", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
 func TestGetLogsByDateRangeHandler(t *testing.T) {
 	app, mockService := setupTest()
 
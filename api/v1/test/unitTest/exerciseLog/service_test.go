@@ -5,6 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
@@ -65,6 +69,149 @@ func TestCreateLog(t *testing.T) {
 		assert.Equal(t, createDto.Notes, result.Notes)
 		assert.Equal(t, createDto.Sets, result.Sets)
 	})
+
+	t.Run("Use body weight for body weight and assisted exercises", func(t *testing.T) {
+		userId := "bodyweight_user"
+		now := time.Now()
+
+		_, err := db.Collection("bodyCompositionLog").InsertOne(context.Background(), bson.M{
+			"userid":     userId,
+			"weight":     80.0,
+			"created_at": now.Add(-time.Hour),
+		})
+		assert.NoError(t, err)
+
+		pullUp := exercise.Exercise{ID: primitive.NewObjectID(), Name: "Pull-up", Equipment: exerciseEnums.BodyWeight}
+		assistedPullUp := exercise.Exercise{ID: primitive.NewObjectID(), Name: "Pull-up", Equipment: exerciseEnums.Assisted}
+		_, err = db.Collection("exercises").InsertMany(context.Background(), []interface{}{pullUp, assistedPullUp})
+		assert.NoError(t, err)
+
+		weighted, err := service.CreateLog(&exerciseLog.CreateExerciseLogDto{
+			ExerciseID: pullUp.ID.Hex(),
+			DateTime:   now,
+			Sets: []exerciseLog.SetLog{
+				{Weight: 10, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet},
+				{Weight: 0, Reps: 8, SetNumber: 2, Type: exerciseLog.WorkingSet},
+			},
+		}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(80), weighted.BodyWeight)
+		assert.Equal(t, float64(90), weighted.Sets[0].EffectiveWeight)
+		assert.Equal(t, float64(80), weighted.Sets[1].EffectiveWeight)
+		assert.Equal(t, float64(90*5+80*8), weighted.TotalVolume)
+
+		assisted, err := service.CreateLog(&exerciseLog.CreateExerciseLogDto{
+			ExerciseID: assistedPullUp.ID.Hex(),
+			DateTime:   now,
+			Sets: []exerciseLog.SetLog{
+				{Weight: 30, Reps: 10, SetNumber: 1, Type: exerciseLog.WorkingSet},
+			},
+		}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(50), assisted.Sets[0].EffectiveWeight)
+		assert.Equal(t, float64(500), assisted.TotalVolume)
+	})
+
+	t.Run("Store weights in kg and keep entered weights", func(t *testing.T) {
+		service := &exerciseLog.ExerciseLogService{DB: db, UnitService: &unit.UnitService{}}
+		createDto := &exerciseLog.CreateExerciseLogDto{
+			ExerciseID: primitive.NewObjectID().Hex(),
+			Unit:       unitEnums.ExerciseWeightUnitPound,
+			Sets: []exerciseLog.SetLog{
+				{Weight: 225, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet},
+			},
+		}
+
+		result, err := service.CreateLog(createDto, "test_user")
+		assert.NoError(t, err)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitPound, result.Unit)
+		assert.Equal(t, float64(225), result.Sets[0].EnteredWeight)
+		assert.Equal(t, 102.06, result.Sets[0].Weight)
+		assert.InDelta(t, 510.3, result.TotalVolume, 0.001)
+
+		// Sending the log back as it was read keeps its weights
+		updated, err := service.UpdateLog(result.ID.Hex(), &exerciseLog.UpdateExerciseLogDto{Sets: result.Sets}, "test_user")
+		assert.NoError(t, err)
+		assert.Equal(t, float64(225), updated.Sets[0].EnteredWeight)
+		assert.Equal(t, 102.06, updated.Sets[0].Weight)
+	})
+}
+
+func TestMigrateWeightUnits(t *testing.T) {
+	db := setupTestDB(t)
+	service := &exerciseLog.ExerciseLogService{DB: db, UnitService: &unit.UnitService{}}
+
+	t.Run("Tag existing logs with the unit they were entered in", func(t *testing.T) {
+		// The unit the user chose since does not tell how older logs were entered
+		userId := primitive.NewObjectID()
+		_, err := db.Collection("users").InsertOne(context.Background(), bson.M{
+			"_id":         userId,
+			"weight_unit": unitEnums.ExerciseWeightUnitKg,
+		})
+		assert.NoError(t, err)
+
+		// Logs recorded before weight units existed have no unit field
+		legacyLogId := primitive.NewObjectID()
+		_, err = db.Collection("exerciseLogs").InsertOne(context.Background(), bson.M{
+			"_id":          legacyLogId,
+			"userid":       userId.Hex(),
+			"exerciseid":   primitive.NewObjectID().Hex(),
+			"total_volume": 1000.0,
+			"sets": bson.A{
+				bson.M{"weight": 100.0, "reps": 10, "setnumber": 1, "type": "working"},
+			},
+		})
+		assert.NoError(t, err)
+
+		migrated, err := service.MigrateWeightUnits(unitEnums.ExerciseWeightUnitPound)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, migrated)
+
+		var result exerciseLog.ExerciseLog
+		err = db.Collection("exerciseLogs").FindOne(context.Background(), bson.M{"_id": legacyLogId}).Decode(&result)
+		assert.NoError(t, err)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitPound, result.Unit)
+		assert.Equal(t, float64(100), result.Sets[0].EnteredWeight)
+		assert.Equal(t, 45.36, result.Sets[0].Weight)
+		assert.InDelta(t, 453.6, result.TotalVolume, 0.001)
+
+		// Running again leaves migrated logs untouched
+		migrated, err = service.MigrateWeightUnits(unitEnums.ExerciseWeightUnitPound)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, migrated)
+	})
+
+	t.Run("Logs that fail are skipped and body weight is not guessed", func(t *testing.T) {
+		assisted := primitive.NewObjectID()
+		_, err := db.Collection("exercises").InsertOne(context.Background(), bson.M{"_id": assisted, "equipment": "Assisted"})
+		assert.NoError(t, err)
+
+		assistedLogId := primitive.NewObjectID()
+		_, err = db.Collection("exerciseLogs").InsertMany(context.Background(), []interface{}{
+			bson.M{
+				"_id":        assistedLogId,
+				"userid":     primitive.NewObjectID().Hex(),
+				"exerciseid": assisted.Hex(),
+				"sets":       bson.A{bson.M{"weight": 20.0, "reps": 8, "setnumber": 1, "type": "working"}},
+			},
+			bson.M{
+				"userid":     primitive.NewObjectID().Hex(),
+				"exerciseid": "not an id",
+				"sets":       bson.A{bson.M{"weight": 20.0, "reps": 8, "setnumber": 1, "type": "working"}},
+			},
+		})
+		assert.NoError(t, err)
+
+		migrated, err := service.MigrateWeightUnits(unitEnums.ExerciseWeightUnitKg)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, migrated)
+
+		var result exerciseLog.ExerciseLog
+		err = db.Collection("exerciseLogs").FindOne(context.Background(), bson.M{"_id": assistedLogId}).Decode(&result)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, result.Sets[0].EffectiveWeight)
+		assert.Equal(t, 160.0, result.TotalVolume)
+	})
 }
 
 func TestGetLogsByUser(t *testing.T) {
@@ -174,6 +321,152 @@ func TestGetLogsByDateRange(t *testing.T) {
 	})
 }
 
+func TestGetLogs(t *testing.T) {
+	db := setupTestDB(t)
+	service := &exerciseLog.ExerciseLogService{DB: db}
+	userId := "test_user"
+
+	squat := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Squat", Equipment: exerciseEnums.Barbell, TargetMuscle: []exerciseEnums.TargetMuscle{exerciseEnums.Quadriceps}}
+	curl := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Curl", Equipment: exerciseEnums.Dumbbell, TargetMuscle: []exerciseEnums.TargetMuscle{exerciseEnums.BicepsBrachii}}
+	_, err := db.Collection("exercises").InsertMany(context.Background(), []interface{}{squat, curl})
+	assert.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		ex, setType := squat, exerciseLog.WorkingSet
+		if i%2 == 1 {
+			ex, setType = curl, exerciseLog.DropSet
+		}
+		_, err := db.Collection("exerciseLogs").InsertOne(context.Background(), &exerciseLog.ExerciseLog{
+			UserID:     userId,
+			ExerciseID: ex.ID.Hex(),
+			Sets:       []exerciseLog.SetLog{{Weight: 50, Reps: 5, SetNumber: 1, Type: setType}},
+			DateTime:   start.AddDate(0, 0, i),
+		})
+		assert.NoError(t, err)
+	}
+
+	t.Run("Pages follow each other newest first", func(t *testing.T) {
+		first, err := service.GetLogs(userId, &exerciseLog.LogFilters{}, &exerciseLog.LogPageQuery{Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, first.Logs, 2)
+		assert.NotEmpty(t, first.NextCursor)
+		assert.True(t, first.Logs[0].DateTime.After(first.Logs[1].DateTime))
+
+		seen := len(first.Logs)
+		cursor := first.NextCursor
+		for cursor != "" {
+			page, err := service.GetLogs(userId, &exerciseLog.LogFilters{}, &exerciseLog.LogPageQuery{Cursor: cursor, Limit: 2})
+			assert.NoError(t, err)
+			seen += len(page.Logs)
+			cursor = page.NextCursor
+		}
+		assert.Equal(t, 5, seen)
+	})
+
+	t.Run("Muscle, equipment and set type filters", func(t *testing.T) {
+		page, err := service.GetLogs(userId, &exerciseLog.LogFilters{Muscle: exerciseEnums.Quadriceps}, &exerciseLog.LogPageQuery{})
+		assert.NoError(t, err)
+		assert.Len(t, page.Logs, 3)
+
+		page, err = service.GetLogs(userId, &exerciseLog.LogFilters{Equipment: exerciseEnums.Dumbbell, SetType: exerciseLog.DropSet}, &exerciseLog.LogPageQuery{})
+		assert.NoError(t, err)
+		assert.Len(t, page.Logs, 2)
+
+		page, err = service.GetLogs(userId, &exerciseLog.LogFilters{ExerciseID: squat.ID.Hex(), Equipment: exerciseEnums.Dumbbell}, &exerciseLog.LogPageQuery{})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Logs)
+	})
+
+	t.Run("Date range", func(t *testing.T) {
+		page, err := service.GetLogs(userId, &exerciseLog.LogFilters{StartDate: "2024-01-02 00:00:00", EndDate: "2024-01-03 23:59:59"}, &exerciseLog.LogPageQuery{})
+		assert.NoError(t, err)
+		assert.Len(t, page.Logs, 2)
+
+		_, err = service.GetLogs(userId, &exerciseLog.LogFilters{StartDate: "2 January"}, &exerciseLog.LogPageQuery{})
+		assert.Equal(t, exerciseLog.ErrInvalidDate, err)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := service.GetLogs(userId, &exerciseLog.LogFilters{}, &exerciseLog.LogPageQuery{Cursor: "not a cursor"})
+		assert.Equal(t, exerciseLog.ErrInvalidCursor, err)
+	})
+}
+
+func TestLogStats(t *testing.T) {
+	db := setupTestDB(t)
+	service := &exerciseLog.ExerciseLogService{DB: db}
+	userId := "test_user"
+
+	bench := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Bench Press", Equipment: exerciseEnums.Barbell,
+		TargetMuscle: []exerciseEnums.TargetMuscle{exerciseEnums.PectoralisMajorSternal, exerciseEnums.TricepsBrachii}}
+	_, err := db.Collection("exercises").InsertOne(context.Background(), bench)
+	assert.NoError(t, err)
+
+	rir := 2
+	logs := []interface{}{
+		// Monday and Wednesday of one week, Monday of the next
+		&exerciseLog.ExerciseLog{UserID: userId, ExerciseID: bench.ID.Hex(), DateTime: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Sets: []exerciseLog.SetLog{
+			{Weight: 40, Reps: 10, SetNumber: 1, Type: exerciseLog.WarmUpSet},
+			{Weight: 80, Reps: 5, SetNumber: 2, Type: exerciseLog.WorkingSet},
+		}},
+		&exerciseLog.ExerciseLog{UserID: userId, ExerciseID: bench.ID.Hex(), DateTime: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC), Sets: []exerciseLog.SetLog{
+			{Weight: 90, Reps: 3, SetNumber: 1, Type: exerciseLog.WorkingSet, RIR: &rir},
+		}},
+		&exerciseLog.ExerciseLog{UserID: userId, ExerciseID: bench.ID.Hex(), DateTime: time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC), Sets: []exerciseLog.SetLog{
+			{Weight: 85, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet},
+		}},
+	}
+	_, err = db.Collection("exerciseLogs").InsertMany(context.Background(), logs)
+	assert.NoError(t, err)
+
+	t.Run("Volume per week", func(t *testing.T) {
+		weeks, err := service.GetWeeklyVolume(userId, &exerciseLog.LogFilters{})
+		assert.NoError(t, err)
+		if assert.Len(t, weeks, 2) {
+			assert.True(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Equal(weeks[0].Week))
+			assert.Equal(t, 400.0+400+270, weeks[0].Volume)
+			assert.Equal(t, 3, weeks[0].Sets)
+			assert.Equal(t, 2, weeks[0].Logs)
+			assert.Equal(t, 425.0, weeks[1].Volume)
+		}
+
+		weeks, err = service.GetWeeklyVolume(userId, &exerciseLog.LogFilters{SetType: exerciseLog.WorkingSet})
+		assert.NoError(t, err)
+		assert.Equal(t, 670.0, weeks[0].Volume)
+	})
+
+	t.Run("Working sets per muscle per week", func(t *testing.T) {
+		weeks, err := service.GetWeeklyMuscleSets(userId, &exerciseLog.LogFilters{Muscle: exerciseEnums.TricepsBrachii})
+		assert.NoError(t, err)
+		if assert.Len(t, weeks, 2) {
+			assert.Equal(t, exerciseEnums.TricepsBrachii, weeks[0].Muscle)
+			assert.Equal(t, 2, weeks[0].Sets)
+			assert.Equal(t, 1, weeks[1].Sets)
+		}
+
+		weeks, err = service.GetWeeklyMuscleSets(userId, &exerciseLog.LogFilters{})
+		assert.NoError(t, err)
+		assert.Len(t, weeks, 4)
+	})
+
+	t.Run("Best set counts reps in reserve", func(t *testing.T) {
+		sets, err := service.GetBestSets(userId, &exerciseLog.LogFilters{})
+		assert.NoError(t, err)
+		if assert.Len(t, sets, 1) {
+			assert.Equal(t, bench.ID.Hex(), sets[0].ExerciseID)
+			assert.Equal(t, 90.0, sets[0].Load)
+			assert.Equal(t, 3, sets[0].Reps)
+			assert.InDelta(t, 101.26, sets[0].EstimatedOneRM, 0.01)
+		}
+	})
+
+	t.Run("Unknown timezone", func(t *testing.T) {
+		_, err := service.GetWeeklyVolume(userId, &exerciseLog.LogFilters{Timezone: "Nowhere/City"})
+		assert.Equal(t, exerciseLog.ErrInvalidTimezone, err)
+	})
+}
+
 func TestUpdateLog(t *testing.T) {
 	db := setupTestDB(t)
 	service := &exerciseLog.ExerciseLogService{DB: db}
@@ -289,3 +582,21 @@ func TestDeleteLog(t *testing.T) {
 		assert.Equal(t, mongo.ErrNoDocuments, err)
 	})
 }
+
+func TestNextSet(t *testing.T) {
+	previous := []exerciseLog.SetLog{{SetNumber: 1}, {SetNumber: 2}}
+
+	t.Run("First new set by number", func(t *testing.T) {
+		sets := []exerciseLog.SetLog{{SetNumber: 1}, {SetNumber: 2}, {SetNumber: 4}, {SetNumber: 3}}
+		assert.Equal(t, 3, exerciseLog.NextSet(sets, previous))
+	})
+
+	t.Run("Every set of a new log is new", func(t *testing.T) {
+		sets := []exerciseLog.SetLog{{SetNumber: 1}, {SetNumber: 2}}
+		assert.Equal(t, 0, exerciseLog.NextSet(sets, nil))
+	})
+
+	t.Run("No new set", func(t *testing.T) {
+		assert.Equal(t, -1, exerciseLog.NextSet(previous, previous))
+	})
+}
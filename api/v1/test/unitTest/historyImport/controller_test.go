@@ -0,0 +1,252 @@
+package historyImport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/historyImport"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock service
+type MockHistoryImportService struct {
+	mock.Mock
+}
+
+func (m *MockHistoryImportService) CreateImport(file io.Reader, dto *historyImport.CreateImportDto, userId string) (*historyImport.HistoryImport, error) {
+	content, _ := io.ReadAll(file)
+	args := m.Called(string(content), dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*historyImport.HistoryImport), args.Error(1)
+}
+
+func (m *MockHistoryImportService) GetImport(id string, userId string) (*historyImport.HistoryImport, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*historyImport.HistoryImport), args.Error(1)
+}
+
+func (m *MockHistoryImportService) MapExercises(id string, dto *historyImport.MapExercisesDto, userId string) (*historyImport.HistoryImport, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*historyImport.HistoryImport), args.Error(1)
+}
+
+func (m *MockHistoryImportService) CommitImport(id string, userId string) (*historyImport.HistoryImport, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*historyImport.HistoryImport), args.Error(1)
+}
+
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+func setupTest() (*fiber.App, *MockHistoryImportService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockHistoryImportService)
+	controller := &historyImport.HistoryImportController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+// newUpload builds a multipart body with the file, when there is one, and form fields
+func newUpload(t *testing.T, content string, fields map[string]string) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		assert.NoError(t, writer.WriteField(key, value))
+	}
+	if content != "" {
+		part, err := writer.CreateFormFile("file", "export.csv")
+		assert.NoError(t, err)
+		part.Write([]byte(content))
+	}
+	assert.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestCreateImportHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully upload a file", func(t *testing.T) {
+		dto := &historyImport.CreateImportDto{Unit: unitEnums.ExerciseWeightUnitPound, Timezone: "Asia/Bangkok"}
+		expected := &historyImport.HistoryImport{
+			Source:       historyImport.StrongSource,
+			Unit:         unitEnums.ExerciseWeightUnitPound,
+			Status:       historyImport.StatusMapping,
+			WorkoutCount: 2,
+			Mappings: []historyImport.ExerciseMapping{
+				{Name: "Pull Up (Assisted)", Status: historyImport.UnmatchedMapping, Sets: 2},
+			},
+		}
+		mockService.On("CreateImport", strongExport, dto, "test_user").Return(expected, nil).Once()
+
+		body, contentType := newUpload(t, strongExport, map[string]string{"unit": "lbs", "timezone": "Asia/Bangkok"})
+		req := httptest.NewRequest("POST", "/api/v1/history-import", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var result historyImport.HistoryImport
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, historyImport.StatusMapping, result.Status)
+		assert.Len(t, result.Mappings, 1)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("No file", func(t *testing.T) {
+		body, contentType := newUpload(t, "", map[string]string{"source": "strong"})
+		req := httptest.NewRequest("POST", "/api/v1/history-import", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Unknown source", func(t *testing.T) {
+		body, contentType := newUpload(t, strongExport, map[string]string{"source": "myapp"})
+		req := httptest.NewRequest("POST", "/api/v1/history-import", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Not an export", func(t *testing.T) {
+		mockService.On("CreateImport", "a,b\n1,2\n", &historyImport.CreateImportDto{}, "test_user").Return(nil, historyImport.ErrUnknownFormat).Once()
+
+		body, contentType := newUpload(t, "a,b\n1,2\n", nil)
+		req := httptest.NewRequest("POST", "/api/v1/history-import", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestGetImportHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Import not found", func(t *testing.T) {
+		mockService.On("GetImport", "507f1f77bcf86cd799439011", "test_user").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/history-import/507f1f77bcf86cd799439011", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestMapExercisesHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully map names", func(t *testing.T) {
+		dto := &historyImport.MapExercisesDto{Mappings: []historyImport.ExerciseMappingDto{
+			{Name: "Pull Up (Assisted)", ExerciseID: "507f1f77bcf86cd799439012"},
+			{Name: "Treadmill", Skip: true},
+		}}
+		expected := &historyImport.HistoryImport{Status: historyImport.StatusReady}
+		mockService.On("MapExercises", "507f1f77bcf86cd799439011", dto, "test_user").Return(expected, nil).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/history-import/507f1f77bcf86cd799439011/mappings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result historyImport.HistoryImport
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, historyImport.StatusReady, result.Status)
+	})
+
+	t.Run("Neither an exercise nor skipped", func(t *testing.T) {
+		body := []byte(`{"mappings":[{"name":"Pull Up (Assisted)"}]}`)
+		req := httptest.NewRequest("PUT", "/api/v1/history-import/507f1f77bcf86cd799439011/mappings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestCommitImportHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully commit", func(t *testing.T) {
+		expected := &historyImport.HistoryImport{
+			Status: historyImport.StatusCompleted,
+			Result: &historyImport.ImportResult{Sessions: 2, Logs: 3, Sets: 12},
+		}
+		mockService.On("CommitImport", "507f1f77bcf86cd799439011", "test_user").Return(expected, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/history-import/507f1f77bcf86cd799439011/commit", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result historyImport.HistoryImport
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 2, result.Result.Sessions)
+	})
+
+	t.Run("Names still unmapped", func(t *testing.T) {
+		mockService.On("CommitImport", "507f1f77bcf86cd799439013", "test_user").Return(nil, historyImport.ErrUnmappedExercises).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/history-import/507f1f77bcf86cd799439013/commit", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+}
@@ -0,0 +1,261 @@
+package historyImport_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/user"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/historyImport"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const strongExport = "\xef\xbb\xbf" + `Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes,Workout Notes,RPE
+2023-01-15 08:30:00,"Push Day",1h 5m,"Bench Press (Barbell)",W,40,10,0,0,"","Felt good",
+2023-01-15 08:30:00,"Push Day",1h 5m,"Bench Press (Barbell)",1,100,5,0,0,"Paused reps","Felt good",8.5
+2023-01-15 08:30:00,"Push Day",1h 5m,"Bench Press (Barbell)",2,100,5,0,0,"","Felt good",
+2023-01-15 08:30:00,"Push Day",1h 5m,"Treadmill",1,0,0,2.5,900,"","Felt good",
+2023-01-15 08:30:00,"Push Day",1h 5m,"Rest Timer",Rest Timer,0,0,0,90,"","Felt good",
+2023-01-17 18:00:00,"Pull Day",45m,"Pull Up (Assisted)",1,-20,8,0,0,"","",
+2023-01-17 18:00:00,"Pull Day",45m,"Pull Up (Assisted)",F,-20,6,0,0,"","",
+`
+
+const hevyExport = `"title","start_time","end_time","description","exercise_title","superset_id","exercise_notes","set_index","set_type","weight_lbs","reps","distance_miles","duration_seconds","rpe"
+"Leg Day","15 Jan 2023, 08:30","15 Jan 2023, 09:40","","Squat (Barbell)",,"",0,"warmup",135,5,,,
+"Leg Day","15 Jan 2023, 08:30","15 Jan 2023, 09:40","","Squat (Barbell)",,"",1,"normal",225,5,,,9
+"Leg Day","15 Jan 2023, 08:30","15 Jan 2023, 09:40","","Squat (Barbell)",,"",2,"dropset",185,8,,,
+`
+
+const fitNotesExport = `Date,Exercise,Category,Weight (kgs),Reps,Distance,Distance Unit,Time
+2023-01-15,Flat Barbell Bench Press,Chest,100.0,5,,,
+2023-01-15,Flat Barbell Bench Press,Chest,100.0,5,,,
+2023-01-16,Deadlift,Back,140.0,3,,,
+`
+
+func setupTestDB(t *testing.T) *mongo.Database {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	db := client.Database("testdb_" + primitive.NewObjectID().Hex())
+
+	t.Cleanup(func() {
+		if err := db.Drop(context.Background()); err != nil {
+			t.Errorf("Failed to drop test database: %v", err)
+		}
+		if err := client.Disconnect(context.Background()); err != nil {
+			t.Errorf("Failed to disconnect from MongoDB: %v", err)
+		}
+	})
+
+	return db
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Strong", func(t *testing.T) {
+		history, err := historyImport.Parse(strings.NewReader(strongExport), historyImport.ParseOptions{Unit: unitEnums.ExerciseWeightUnitKg})
+		assert.NoError(t, err)
+		assert.Equal(t, historyImport.StrongSource, history.Source)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitKg, history.Unit)
+		if !assert.Len(t, history.Workouts, 2) {
+			return
+		}
+
+		push := history.Workouts[0]
+		assert.Equal(t, "Push Day", push.Name)
+		assert.Equal(t, "Felt good", push.Notes)
+		assert.Equal(t, time.Date(2023, 1, 15, 8, 30, 0, 0, time.UTC), push.StartTime)
+		assert.Equal(t, 65*time.Minute, push.EndTime.Sub(push.StartTime))
+		// Treadmill has no reps and rest timer rows no sets
+		if assert.Len(t, push.Exercises, 1) {
+			bench := push.Exercises[0]
+			assert.Equal(t, "Paused reps", bench.Notes)
+			assert.Len(t, bench.Sets, 3)
+			assert.Equal(t, exerciseLog.WarmUpSet, bench.Sets[0].Type)
+			assert.Equal(t, 100.0, bench.Sets[1].Weight)
+			assert.Equal(t, 8.5, *bench.Sets[1].RPE)
+			assert.Nil(t, bench.Sets[2].RPE)
+		}
+
+		pull := history.Workouts[1].Exercises[0]
+		assert.Equal(t, -20.0, pull.Sets[0].Weight)
+		assert.Equal(t, exerciseLog.FailureSet, pull.Sets[1].Type)
+	})
+
+	t.Run("Hevy in a timezone", func(t *testing.T) {
+		bangkok, _ := time.LoadLocation("Asia/Bangkok")
+		history, err := historyImport.Parse(strings.NewReader(hevyExport), historyImport.ParseOptions{Location: bangkok})
+		assert.NoError(t, err)
+		assert.Equal(t, historyImport.HevySource, history.Source)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitPound, history.Unit)
+		if assert.Len(t, history.Workouts, 1) {
+			workout := history.Workouts[0]
+			assert.Equal(t, time.Date(2023, 1, 15, 1, 30, 0, 0, time.UTC), workout.StartTime.UTC())
+			assert.Equal(t, 70*time.Minute, workout.EndTime.Sub(workout.StartTime))
+			sets := workout.Exercises[0].Sets
+			assert.Equal(t, []exerciseLog.SetType{exerciseLog.WarmUpSet, exerciseLog.WorkingSet, exerciseLog.DropSet},
+				[]exerciseLog.SetType{sets[0].Type, sets[1].Type, sets[2].Type})
+		}
+	})
+
+	t.Run("FitNotes days are workouts", func(t *testing.T) {
+		history, err := historyImport.Parse(strings.NewReader(fitNotesExport), historyImport.ParseOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, historyImport.FitNotesSource, history.Source)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitKg, history.Unit)
+		if assert.Len(t, history.Workouts, 2) {
+			assert.Len(t, history.Workouts[0].Exercises[0].Sets, 2)
+			assert.Equal(t, "Deadlift", history.Workouts[1].Exercises[0].Name)
+		}
+	})
+
+	t.Run("Semicolons and decimal commas", func(t *testing.T) {
+		csv := "Date;Exercise;Category;Weight (kgs);Reps\n2023-01-15;Squat;Legs;102,5;5\n"
+		history, err := historyImport.Parse(strings.NewReader(csv), historyImport.ParseOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 102.5, history.Workouts[0].Exercises[0].Sets[0].Weight)
+	})
+
+	t.Run("Same workout, same key", func(t *testing.T) {
+		first, _ := historyImport.Parse(strings.NewReader(strongExport), historyImport.ParseOptions{})
+		second, _ := historyImport.Parse(strings.NewReader(strongExport), historyImport.ParseOptions{})
+		assert.Equal(t, first.Workouts[0].Key, second.Workouts[0].Key)
+		assert.NotEqual(t, first.Workouts[0].Key, first.Workouts[1].Key)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		_, err := historyImport.Parse(strings.NewReader("a,b,c\n1,2,3\n"), historyImport.ParseOptions{})
+		assert.Equal(t, historyImport.ErrUnknownFormat, err)
+	})
+
+	t.Run("Bad date", func(t *testing.T) {
+		csv := "Date,Exercise,Category,Weight (kgs),Reps\n15/01/2023,Squat,Legs,100,5\n"
+		_, err := historyImport.Parse(strings.NewReader(csv), historyImport.ParseOptions{})
+		assert.ErrorIs(t, err, historyImport.ErrInvalidRow)
+	})
+
+	t.Run("No sets", func(t *testing.T) {
+		csv := "Date,Exercise,Category,Weight (kgs),Reps\n2023-01-15,Running,Cardio,0,0\n"
+		_, err := historyImport.Parse(strings.NewReader(csv), historyImport.ParseOptions{})
+		assert.Equal(t, historyImport.ErrNoWorkouts, err)
+	})
+}
+
+func TestHistoryImport(t *testing.T) {
+	db := setupTestDB(t)
+	exerciseLogService := &exerciseLog.ExerciseLogService{DB: db, UnitService: &unit.UnitService{}}
+	service := &historyImport.HistoryImportService{
+		DB:                 db,
+		ExerciseService:    &exercise.ExerciseService{DB: db},
+		ExerciseLogService: exerciseLogService,
+	}
+
+	_, err := db.Collection("workoutSessions").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "userid", Value: 1}, {Key: "import_key", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+			bson.D{{Key: "import_key", Value: bson.D{{Key: "$exists", Value: true}}}}),
+	})
+	assert.NoError(t, err)
+
+	bench := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Bench Press", Equipment: exerciseEnums.Barbell}
+	pullUp := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Pull-up", Equipment: exerciseEnums.Assisted}
+	_, err = db.Collection("exercises").InsertMany(context.Background(), []interface{}{bench, pullUp})
+	assert.NoError(t, err)
+
+	// Assisted pull-ups need the user's body weight
+	userOid := primitive.NewObjectID()
+	_, err = db.Collection("users").InsertOne(context.Background(), &user.User{ID: userOid, Weight: 80})
+	assert.NoError(t, err)
+	userId := userOid.Hex()
+	dto := &historyImport.CreateImportDto{Unit: unitEnums.ExerciseWeightUnitPound}
+
+	created, err := service.CreateImport(strings.NewReader(strongExport), dto, userId)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("Unmatched names wait for mapping", func(t *testing.T) {
+		assert.Equal(t, historyImport.StatusMapping, created.Status)
+		assert.Equal(t, 2, created.WorkoutCount)
+		assert.Equal(t, 5, created.SetCount)
+		if assert.Len(t, created.Mappings, 2) {
+			assert.Equal(t, historyImport.MatchedMapping, created.Mappings[0].Status)
+			assert.Equal(t, bench.ID.Hex(), created.Mappings[0].ExerciseID)
+			assert.Equal(t, historyImport.UnmatchedMapping, created.Mappings[1].Status)
+			assert.NotEmpty(t, created.Mappings[1].Candidates)
+		}
+
+		_, err := service.CommitImport(created.ID.Hex(), userId)
+		assert.Equal(t, historyImport.ErrUnmappedExercises, err)
+	})
+
+	t.Run("Mapping a name that is not in the file", func(t *testing.T) {
+		_, err := service.MapExercises(created.ID.Hex(), &historyImport.MapExercisesDto{
+			Mappings: []historyImport.ExerciseMappingDto{{Name: "Deadlift", Skip: true}},
+		}, userId)
+		assert.ErrorIs(t, err, historyImport.ErrUnknownName)
+	})
+
+	t.Run("Commit creates sessions and logs", func(t *testing.T) {
+		mapped, err := service.MapExercises(created.ID.Hex(), &historyImport.MapExercisesDto{
+			Mappings: []historyImport.ExerciseMappingDto{{Name: "Pull Up (Assisted)", ExerciseID: pullUp.ID.Hex()}},
+		}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, historyImport.StatusReady, mapped.Status)
+
+		committed, err := service.CommitImport(created.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, historyImport.StatusCompleted, committed.Status)
+		assert.Equal(t, &historyImport.ImportResult{Sessions: 2, Logs: 2, Sets: 5}, committed.Result)
+
+		var sessions []workoutSession.WorkoutSession
+		cursor, err := db.Collection("workoutSessions").Find(context.Background(), bson.D{{Key: "userid", Value: userId}},
+			options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}}))
+		assert.NoError(t, err)
+		assert.NoError(t, cursor.All(context.Background(), &sessions))
+		if assert.Len(t, sessions, 2) {
+			assert.Equal(t, workoutSession.LoggedSession, sessions[0].Type)
+			assert.Equal(t, workoutSession.StatusCompleted, sessions[0].Status)
+			assert.Equal(t, "Push Day\nFelt good", sessions[0].Notes)
+			assert.Equal(t, 65*60, sessions[0].Duration)
+		}
+
+		logs, err := exerciseLogService.GetLogsByUser(userId)
+		assert.NoError(t, err)
+		if assert.Len(t, logs, 2) {
+			// Newest first, pounds stored in kg and assistance kept positive
+			assert.Equal(t, unitEnums.ExerciseWeightUnitPound, logs[0].Unit)
+			assert.Equal(t, 20.0, logs[0].Sets[0].EnteredWeight)
+			assert.Equal(t, time.Date(2023, 1, 15, 8, 30, 0, 0, time.UTC), logs[1].DateTime.UTC())
+			assert.InDelta(t, 45.36, logs[1].Sets[1].Weight, 0.01)
+		}
+	})
+
+	t.Run("Importing the same history again creates nothing", func(t *testing.T) {
+		again, err := service.CreateImport(strings.NewReader(strongExport), dto, userId)
+		assert.NoError(t, err)
+		// The mapping chosen before is kept
+		assert.Equal(t, historyImport.StatusReady, again.Status)
+		assert.Equal(t, historyImport.MappedMapping, again.Mappings[1].Status)
+
+		committed, err := service.CommitImport(again.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, &historyImport.ImportResult{Skipped: 2}, committed.Result)
+
+		count, err := db.Collection("exerciseLogs").CountDocuments(context.Background(), bson.D{{Key: "userid", Value: userId}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
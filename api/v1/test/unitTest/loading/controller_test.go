@@ -0,0 +1,257 @@
+package loading_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	"github.com/Npwskp/GymsbroBackend/api/v1/dashboard/loading"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock service
+type MockLoadingService struct {
+	mock.Mock
+}
+
+func (m *MockLoadingService) GetSettings(userId string) (*loading.LoadingSettings, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*loading.LoadingSettings), args.Error(1)
+}
+
+func (m *MockLoadingService) UpdateSettings(dto *loading.UpdateLoadingSettingsDto, userId string) (*loading.LoadingSettings, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*loading.LoadingSettings), args.Error(1)
+}
+
+func (m *MockLoadingService) GetWarmUp(query *loading.WarmUpQuery, userId string) (*loading.WarmUpResponse, error) {
+	args := m.Called(query, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*loading.WarmUpResponse), args.Error(1)
+}
+
+func (m *MockLoadingService) GetPlates(query *loading.PlatesQuery, userId string) (*loading.PlatesResponse, error) {
+	args := m.Called(query, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*loading.PlatesResponse), args.Error(1)
+}
+
+func (m *MockLoadingService) WarmUpTargets(userId string, weights map[string]float64) (map[string][]workout.TargetSet, error) {
+	args := m.Called(userId, weights)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]workout.TargetSet), args.Error(1)
+}
+
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+func setupTest() (*fiber.App, *MockLoadingService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockLoadingService)
+	controller := &loading.LoadingController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+func TestUpdateLoadingSettingsHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully update settings", func(t *testing.T) {
+		barWeight := 15.0
+		autoWarmUp := true
+		dto := &loading.UpdateLoadingSettingsDto{
+			BarWeight:  &barWeight,
+			Plates:     []dashboardFunctions.PlateCount{{Weight: 20, Count: 4}, {Weight: 5, Count: 2}},
+			AutoWarmUp: &autoWarmUp,
+		}
+		expected := loading.DefaultSettings("test_user", unitEnums.ExerciseWeightUnitKg)
+		expected.BarWeight = barWeight
+		expected.Plates = dto.Plates
+		expected.AutoWarmUp = true
+
+		mockService.On("UpdateSettings", dto, "test_user").Return(expected, nil).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/loading/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result loading.LoadingSettings
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, barWeight, result.BarWeight)
+		assert.True(t, result.AutoWarmUp)
+	})
+
+	t.Run("Invalid plate", func(t *testing.T) {
+		body := []byte(`{"plates":[{"weight":0,"count":2}]}`)
+		req := httptest.NewRequest("PUT", "/api/v1/loading/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Too many plates", func(t *testing.T) {
+		body := []byte(`{"plates":[{"weight":20,"count":101}]}`)
+		req := httptest.NewRequest("PUT", "/api/v1/loading/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Custom scheme without steps", func(t *testing.T) {
+		dto := &loading.UpdateLoadingSettingsDto{WarmUpScheme: dashboardFunctions.CustomWarmUp}
+		mockService.On("UpdateSettings", dto, "test_user").Return(nil, loading.ErrNoCustomSteps).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/loading/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestGetWarmUpHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully get warm-up sets", func(t *testing.T) {
+		query := &loading.WarmUpQuery{Weight: 225, Unit: unitEnums.ExerciseWeightUnitPound, Scheme: dashboardFunctions.MinimalWarmUp}
+		expected := &loading.WarmUpResponse{
+			Unit:          unitEnums.ExerciseWeightUnitPound,
+			WorkingWeight: 225,
+			Scheme:        dashboardFunctions.MinimalWarmUp,
+			Sets: []dashboardFunctions.WarmUpSet{
+				{SetNumber: 1, Weight: 105, Reps: 5, Percent: 50},
+				{SetNumber: 2, Weight: 165, Reps: 3, Percent: 75},
+			},
+		}
+		mockService.On("GetWarmUp", query, "test_user").Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/loading/warm-up?weight=225&unit=lbs&scheme=minimal", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result loading.WarmUpResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result.Sets, 2)
+		assert.Equal(t, 165.0, result.Sets[1].Weight)
+	})
+
+	t.Run("Missing weight", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/loading/warm-up", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Unknown scheme", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/loading/warm-up?weight=100&scheme=unknown", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestGetPlatesHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully get plates", func(t *testing.T) {
+		query := &loading.PlatesQuery{Weight: 102.5}
+		expected := &loading.PlatesResponse{
+			Unit:   unitEnums.ExerciseWeightUnitKg,
+			Target: 102.5,
+			PlateBreakdown: dashboardFunctions.PlateBreakdown{
+				Bar:     20,
+				PerSide: []dashboardFunctions.PlateCount{{Weight: 25, Count: 1}, {Weight: 15, Count: 1}, {Weight: 1.25, Count: 1}},
+				Total:   102.5,
+			},
+		}
+		mockService.On("GetPlates", query, "test_user").Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/loading/plates?weight=102.5", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result loading.PlatesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 102.5, result.Total)
+		assert.Len(t, result.PerSide, 3)
+	})
+
+	t.Run("Too heavy", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/loading/plates?weight=1000.5", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Lighter than the bar", func(t *testing.T) {
+		query := &loading.PlatesQuery{Weight: 10}
+		mockService.On("GetPlates", query, "test_user").Return(nil, loading.ErrBelowBar).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/loading/plates?weight=10", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,188 @@
+package loading_test
+
+import (
+	"context"
+	"testing"
+
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	"github.com/Npwskp/GymsbroBackend/api/v1/dashboard/loading"
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/user"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func setupTestDB(t *testing.T) *mongo.Database {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	db := client.Database("testdb_" + primitive.NewObjectID().Hex())
+
+	t.Cleanup(func() {
+		if err := db.Drop(context.Background()); err != nil {
+			t.Errorf("Failed to drop test database: %v", err)
+		}
+		if err := client.Disconnect(context.Background()); err != nil {
+			t.Errorf("Failed to disconnect from MongoDB: %v", err)
+		}
+	})
+
+	return db
+}
+
+func TestCalculatePlates(t *testing.T) {
+	kgPlates := loading.DefaultSettings("user", unitEnums.ExerciseWeightUnitKg).Plates
+
+	t.Run("Fewest plates for an exact load", func(t *testing.T) {
+		breakdown, err := dashboardFunctions.CalculatePlates(102.5, 20, kgPlates)
+		assert.NoError(t, err)
+		assert.Equal(t, []dashboardFunctions.PlateCount{{Weight: 25, Count: 1}, {Weight: 15, Count: 1}, {Weight: 1.25, Count: 1}}, breakdown.PerSide)
+		assert.Equal(t, 102.5, breakdown.Total)
+		assert.Equal(t, 0.0, breakdown.Remainder)
+	})
+
+	t.Run("Limited plates are searched past the heaviest", func(t *testing.T) {
+		plates := []dashboardFunctions.PlateCount{{Weight: 25, Count: 2}, {Weight: 20, Count: 4}}
+		breakdown, err := dashboardFunctions.CalculatePlates(100, 20, plates)
+		assert.NoError(t, err)
+		assert.Equal(t, []dashboardFunctions.PlateCount{{Weight: 20, Count: 2}}, breakdown.PerSide)
+		assert.Equal(t, 100.0, breakdown.Total)
+	})
+
+	t.Run("Closest load below an unreachable target", func(t *testing.T) {
+		breakdown, err := dashboardFunctions.CalculatePlates(61, 20, kgPlates)
+		assert.NoError(t, err)
+		assert.Equal(t, 60.0, breakdown.Total)
+		assert.Equal(t, 1.0, breakdown.Remainder)
+	})
+
+	t.Run("Pounds", func(t *testing.T) {
+		lbs := loading.DefaultSettings("user", unitEnums.ExerciseWeightUnitPound)
+		breakdown, err := dashboardFunctions.CalculatePlates(315, lbs.BarWeight, lbs.Plates)
+		assert.NoError(t, err)
+		assert.Equal(t, []dashboardFunctions.PlateCount{{Weight: 45, Count: 3}}, breakdown.PerSide)
+	})
+
+	t.Run("Full racks of odd plates", func(t *testing.T) {
+		plates := []dashboardFunctions.PlateCount{{Weight: 20.01, Count: 100}, {Weight: 0.37, Count: 100}, {Weight: 1.13, Count: 100}}
+		breakdown, err := dashboardFunctions.CalculatePlates(1000, 20, plates)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, breakdown.Total, 1000.0)
+		assert.Less(t, breakdown.Remainder, 0.1)
+	})
+
+	t.Run("Lighter than the bar", func(t *testing.T) {
+		_, err := dashboardFunctions.CalculatePlates(15, 20, kgPlates)
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerateWarmUp(t *testing.T) {
+	settings := loading.DefaultSettings("user", unitEnums.ExerciseWeightUnitKg)
+
+	t.Run("Standard ramp on the bar", func(t *testing.T) {
+		sets, err := dashboardFunctions.GenerateWarmUp(100, dashboardFunctions.WarmUpSchemes[dashboardFunctions.StandardWarmUp], settings.Loading(true))
+		assert.NoError(t, err)
+		if assert.Len(t, sets, 4) {
+			assert.Equal(t, 20.0, sets[0].Weight)
+			assert.Equal(t, 10, sets[0].Reps)
+			assert.Equal(t, 40.0, sets[1].Weight)
+			assert.Equal(t, 60.0, sets[2].Weight)
+			assert.Equal(t, 80.0, sets[3].Weight)
+			assert.Equal(t, 4, sets[3].SetNumber)
+			assert.Equal(t, []dashboardFunctions.PlateCount{{Weight: 25, Count: 1}, {Weight: 5, Count: 1}}, sets[3].Plates.PerSide)
+		}
+	})
+
+	t.Run("Steps that round to the same weight are left out", func(t *testing.T) {
+		sets, err := dashboardFunctions.GenerateWarmUp(50, dashboardFunctions.WarmUpSchemes[dashboardFunctions.StandardWarmUp], settings.Loading(true))
+		assert.NoError(t, err)
+		weights := make([]float64, 0, len(sets))
+		for _, set := range sets {
+			weights = append(weights, set.Weight)
+		}
+		assert.Equal(t, []float64{20, 30, 40}, weights)
+	})
+
+	t.Run("Fixed increments without a bar", func(t *testing.T) {
+		sets, err := dashboardFunctions.GenerateWarmUp(32, dashboardFunctions.WarmUpSchemes[dashboardFunctions.MinimalWarmUp], settings.Loading(false))
+		assert.NoError(t, err)
+		if assert.Len(t, sets, 2) {
+			assert.Equal(t, 15.0, sets[0].Weight)
+			assert.Equal(t, 22.5, sets[1].Weight)
+			assert.Nil(t, sets[0].Plates)
+		}
+	})
+
+	t.Run("No warm-up below the bar", func(t *testing.T) {
+		sets, err := dashboardFunctions.GenerateWarmUp(20, dashboardFunctions.WarmUpSchemes[dashboardFunctions.StandardWarmUp], settings.Loading(true))
+		assert.NoError(t, err)
+		assert.Empty(t, sets)
+	})
+
+	t.Run("Working weight required", func(t *testing.T) {
+		_, err := dashboardFunctions.GenerateWarmUp(0, dashboardFunctions.WarmUpSchemes[dashboardFunctions.StandardWarmUp], settings.Loading(true))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadingSettings(t *testing.T) {
+	db := setupTestDB(t)
+	service := &loading.LoadingService{DB: db, UnitService: &unit.UnitService{}}
+
+	userOid := primitive.NewObjectID()
+	_, err := db.Collection("users").InsertOne(context.Background(), &user.User{ID: userOid, WeightUnit: unitEnums.ExerciseWeightUnitPound})
+	assert.NoError(t, err)
+	userId := userOid.Hex()
+
+	t.Run("Defaults follow the user's weight unit", func(t *testing.T) {
+		settings, err := service.GetSettings(userId)
+		assert.NoError(t, err)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitPound, settings.Unit)
+		assert.Equal(t, 45.0, settings.BarWeight)
+		assert.False(t, settings.AutoWarmUp)
+	})
+
+	t.Run("Plates are worked out in the settings' unit", func(t *testing.T) {
+		plates, err := service.GetPlates(&loading.PlatesQuery{Weight: 100, Unit: unitEnums.ExerciseWeightUnitKg}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, unitEnums.ExerciseWeightUnitPound, plates.Unit)
+		assert.InDelta(t, 220.46, plates.Target, 0.01)
+		assert.LessOrEqual(t, plates.Total, plates.Target)
+	})
+
+	t.Run("Changing the unit switches to its equipment", func(t *testing.T) {
+		autoWarmUp := true
+		settings, err := service.UpdateSettings(&loading.UpdateLoadingSettingsDto{Unit: unitEnums.ExerciseWeightUnitKg, AutoWarmUp: &autoWarmUp}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 20.0, settings.BarWeight)
+		assert.True(t, settings.AutoWarmUp)
+	})
+
+	t.Run("Custom scheme needs steps", func(t *testing.T) {
+		_, err := service.UpdateSettings(&loading.UpdateLoadingSettingsDto{WarmUpScheme: dashboardFunctions.CustomWarmUp}, userId)
+		assert.Equal(t, loading.ErrNoCustomSteps, err)
+	})
+
+	t.Run("Warm-up targets load barbells on the bar", func(t *testing.T) {
+		barbell := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Squat", Equipment: exerciseEnums.Barbell}
+		dumbbell := &exercise.Exercise{ID: primitive.NewObjectID(), Name: "Curl", Equipment: exerciseEnums.Dumbbell}
+		_, err := db.Collection("exercises").InsertMany(context.Background(), []interface{}{barbell, dumbbell})
+		assert.NoError(t, err)
+
+		targets, err := service.WarmUpTargets(userId, map[string]float64{barbell.ID.Hex(): 100, dumbbell.ID.Hex(): 14})
+		assert.NoError(t, err)
+		assert.Len(t, targets[barbell.ID.Hex()], 4)
+		assert.Equal(t, 20.0, targets[barbell.ID.Hex()][0].Weight)
+		if assert.NotEmpty(t, targets[dumbbell.ID.Hex()]) {
+			assert.Equal(t, 7.5, targets[dumbbell.ID.Hex()][1].Weight)
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package notification_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/notification"
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock service
+type MockNotificationService struct {
+	mock.Mock
+}
+
+func (m *MockNotificationService) GetSettings(userId string) (*notification.NotificationSettings, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*notification.NotificationSettings), args.Error(1)
+}
+
+func (m *MockNotificationService) UpdateSettings(dto *notification.UpdateSettingsDto, userId string) (*notification.NotificationSettings, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*notification.NotificationSettings), args.Error(1)
+}
+
+func (m *MockNotificationService) GetInbox(filters *notification.InboxFilters, userId string) (*notification.Inbox, error) {
+	args := m.Called(filters, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*notification.Inbox), args.Error(1)
+}
+
+func (m *MockNotificationService) MarkRead(id string, userId string) (*notification.Notification, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*notification.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) MarkAllRead(userId string) (int64, error) {
+	args := m.Called(userId)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationService) DeleteNotification(id string, userId string) error {
+	args := m.Called(id, userId)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) SendTest(userId string) (*notification.Notification, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*notification.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) GetVapidPublicKey() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockNotificationService) Notify(userId string, event notification.Event) (*notification.Notification, bool, error) {
+	args := m.Called(userId, event)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*notification.Notification), args.Bool(1), args.Error(2)
+}
+
+func (m *MockNotificationService) ScheduleRestOver(userId string, sessionId string, timer workoutSession.RestTimer) error {
+	args := m.Called(userId, sessionId, timer)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) CancelRestOver(userId string, sessionId string, timer workoutSession.RestTimer) error {
+	args := m.Called(userId, sessionId, timer)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) RunReminders(now time.Time) error {
+	args := m.Called(now)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) DeliverDue(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+// Test middleware to simulate authentication
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+// Test setup helper
+func setupTest() (*fiber.App, *MockNotificationService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockNotificationService)
+	controller := &notification.NotificationController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+func TestGetInboxHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get unread notifications", func(t *testing.T) {
+		inbox := &notification.Inbox{Notifications: []*notification.Notification{testNotification()}, Unread: 1}
+		mockService.On("GetInbox", &notification.InboxFilters{Unread: true, Limit: 20}, "test_user").Return(inbox, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/notifications?unread=true&limit=20", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result notification.Inbox
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, int64(1), result.Unread)
+		assert.Len(t, result.Notifications, 1)
+	})
+
+	t.Run("Limit too large", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/notifications?limit=500", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestMarkReadHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Mark one notification read", func(t *testing.T) {
+		n := testNotification()
+		now := time.Now()
+		n.ReadAt = &now
+		mockService.On("MarkRead", n.ID.Hex(), "test_user").Return(n, nil).Once()
+
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/notifications/%s/read", n.ID.Hex()), nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Notification not found", func(t *testing.T) {
+		mockService.On("MarkRead", "missing", "test_user").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/notifications/missing/read", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Mark all read", func(t *testing.T) {
+		mockService.On("MarkAllRead", "test_user").Return(int64(3), nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/notifications/read", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result map[string]int64
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, int64(3), result["marked"])
+	})
+}
+
+func TestUpdateSettingsHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully update settings", func(t *testing.T) {
+		dto := notification.UpdateSettingsDto{
+			Channel:         notificationEnums.WebhookChannel,
+			WebhookURL:      "https://example.com/hook",
+			Timezone:        "Asia/Bangkok",
+			QuietHours:      &notification.QuietHours{Start: "22:00", End: "07:00"},
+			FoodLogReminder: notification.Reminder{Enabled: true, Time: "20:00"},
+		}
+		expected := &notification.NotificationSettings{UserID: "test_user", Channel: dto.Channel, WebhookURL: dto.WebhookURL}
+		mockService.On("UpdateSettings", &dto, "test_user").Return(expected, nil).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/notifications/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Unknown channel", func(t *testing.T) {
+		body, _ := json.Marshal(notification.UpdateSettingsDto{Channel: "pigeon"})
+		req := httptest.NewRequest("PUT", "/api/v1/notifications/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		dto := notification.UpdateSettingsDto{Channel: notificationEnums.EmailChannel}
+		mockService.On("UpdateSettings", &dto, "test_user").Return(nil, fmt.Errorf("%w: the email channel needs an email", notification.ErrInvalidSettings)).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/notifications/settings", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestGetVapidPublicKeyHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	mockService.On("GetVapidPublicKey").Return("").Once()
+	req := httptest.NewRequest("GET", "/api/v1/notifications/push/key", nil)
+	req.Header.Set("userid", "test_user")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	mockService.On("GetVapidPublicKey").Return("BPublicKey").Once()
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/v1/notifications/push/key", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,350 @@
+package notification_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/notification"
+	notificationEnums "github.com/Npwskp/GymsbroBackend/api/v1/notification/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testNotification() *notification.Notification {
+	return &notification.Notification{
+		ID:        primitive.NewObjectID(),
+		UserID:    "test_user",
+		Type:      notificationEnums.FoodLogReminder,
+		EventKey:  "food_log_reminder:2024-01-10",
+		Title:     "Log your meals",
+		Body:      "You have not logged anything to eat today.",
+		CreatedAt: time.Date(2024, 1, 10, 20, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	assert.NoError(t, err)
+	settings := notification.DefaultSettings("test_user")
+	settings.Timezone = "Asia/Bangkok"
+	settings.QuietHours = &notification.QuietHours{Start: "22:00", End: "07:00"}
+
+	t.Run("Ranges may cross midnight", func(t *testing.T) {
+		assert.True(t, notification.InQuietHours(settings.QuietHours, time.Date(2024, 1, 10, 23, 30, 0, 0, bangkok)))
+		assert.True(t, notification.InQuietHours(settings.QuietHours, time.Date(2024, 1, 10, 6, 59, 0, 0, bangkok)))
+		assert.False(t, notification.InQuietHours(settings.QuietHours, time.Date(2024, 1, 10, 7, 0, 0, 0, bangkok)))
+		assert.True(t, notification.InQuietHours(&notification.QuietHours{Start: "13:00", End: "14:00"}, time.Date(2024, 1, 10, 13, 30, 0, 0, bangkok)))
+		assert.False(t, notification.InQuietHours(nil, time.Date(2024, 1, 10, 13, 30, 0, 0, bangkok)))
+	})
+
+	t.Run("Delivery waits for the end of quiet hours", func(t *testing.T) {
+		// 23:30 in Bangkok, delivered at 07:00 the next morning
+		now := time.Date(2024, 1, 10, 16, 30, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), notification.DeliverAt(settings, now))
+
+		// 05:00 in Bangkok, delivered at 07:00 the same morning
+		now = time.Date(2024, 1, 10, 22, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), notification.DeliverAt(settings, now))
+
+		now = time.Date(2024, 1, 10, 5, 0, 0, 0, time.UTC)
+		assert.Equal(t, now, notification.DeliverAt(settings, now))
+	})
+}
+
+func TestReminders(t *testing.T) {
+	reminder := notification.Reminder{Enabled: true, Time: "20:00"}
+	day := func(hour int, minute int) time.Time {
+		return time.Date(2024, 1, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	t.Run("Due from its time until the window ends", func(t *testing.T) {
+		assert.False(t, notification.ReminderDue(reminder, day(19, 59)))
+		assert.True(t, notification.ReminderDue(reminder, day(20, 0)))
+		assert.True(t, notification.ReminderDue(reminder, day(21, 59)))
+		assert.False(t, notification.ReminderDue(reminder, day(22, 0)))
+		assert.False(t, notification.ReminderDue(notification.Reminder{Time: "20:00"}, day(20, 30)))
+	})
+
+	t.Run("Events are keyed per day", func(t *testing.T) {
+		event := notification.WorkoutReminderEvent("w1", "Push", "2024-01-10")
+		assert.Equal(t, "workout_reminder:w1:2024-01-10", event.Key)
+		assert.Contains(t, event.Body, "Push")
+		assert.Equal(t, "food_log_reminder:2024-01-10", notification.FoodLogReminderEvent("2024-01-10").Key)
+		assert.Equal(t, "weigh_in_reminder:2024-01-10", notification.WeighInReminderEvent("2024-01-10").Key)
+	})
+
+	t.Run("Weigh-in days", func(t *testing.T) {
+		settings := notification.DefaultSettings("test_user")
+		assert.False(t, notification.WeighInDay(settings, day(8, 0))) // A Wednesday
+		assert.True(t, notification.WeighInDay(settings, day(8, 0).AddDate(0, 0, 5)))
+	})
+}
+
+func TestValidateSettings(t *testing.T) {
+	settings := notification.DefaultSettings("test_user")
+	assert.NoError(t, notification.ValidateSettings(settings))
+
+	settings.Channel = notificationEnums.WebhookChannel
+	assert.ErrorIs(t, notification.ValidateSettings(settings), notification.ErrInvalidSettings)
+	settings.WebhookURL = "https://example.com/hook"
+	assert.NoError(t, notification.ValidateSettings(settings))
+
+	settings.Timezone = "Mars/Olympus"
+	assert.ErrorIs(t, notification.ValidateSettings(settings), notification.ErrInvalidSettings)
+	settings.Timezone = "Europe/Berlin"
+
+	settings.QuietHours = &notification.QuietHours{Start: "25:00", End: "07:00"}
+	assert.ErrorIs(t, notification.ValidateSettings(settings), notification.ErrInvalidSettings)
+}
+
+func TestSettingsUpdate(t *testing.T) {
+	settings := notification.DefaultSettings("test_user")
+	settings.Channel = notificationEnums.WebhookChannel
+	settings.WebhookURL = "https://example.com/hook"
+
+	t.Run("Keep the stored secret when none is given", func(t *testing.T) {
+		update := notification.SettingsUpdate(settings, &notification.UpdateSettingsDto{})
+		assert.NotContains(t, update["$set"], "webhook_secret")
+		assert.NotContains(t, update["$unset"], "webhook_secret")
+		assert.Equal(t, "https://example.com/hook", update["$set"].(bson.M)["webhook_url"])
+		assert.Contains(t, update["$unset"], "email")
+	})
+
+	t.Run("Rotate the secret", func(t *testing.T) {
+		update := notification.SettingsUpdate(settings, &notification.UpdateSettingsDto{WebhookSecret: "rotated"})
+		assert.Equal(t, "rotated", update["$set"].(bson.M)["webhook_secret"])
+	})
+
+	t.Run("Clear the secret", func(t *testing.T) {
+		update := notification.SettingsUpdate(settings, &notification.UpdateSettingsDto{ClearWebhookSecret: true})
+		assert.Contains(t, update["$unset"], "webhook_secret")
+	})
+}
+
+func TestWebhookSender(t *testing.T) {
+	var payload notification.WebhookPayload
+	var signature string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(notification.WebhookSignatureHeader)
+		assert.Equal(t, notification.SignWebhook("secret", body), signature)
+		json.Unmarshal(body, &payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := &notification.WebhookSender{Client: server.Client()}
+	settings := &notification.NotificationSettings{WebhookURL: server.URL, WebhookSecret: "secret"}
+	n := testNotification()
+
+	assert.NoError(t, sender.Send(context.Background(), settings, n))
+	assert.Equal(t, n.EventKey, payload.EventKey)
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+
+	gone := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer gone.Close()
+	settings.WebhookURL = gone.URL
+	assert.ErrorIs(t, sender.Send(context.Background(), settings, n), notification.ErrSubscriptionGone)
+
+	t.Run("Refuse plain http", func(t *testing.T) {
+		settings.WebhookURL = "http://example.com/hook"
+		assert.ErrorIs(t, sender.Send(context.Background(), settings, n), notification.ErrUnsafeURL)
+	})
+
+	t.Run("Refuse to dial a loopback address", func(t *testing.T) {
+		settings.WebhookURL = server.URL
+		assert.ErrorIs(t, (&notification.WebhookSender{}).Send(context.Background(), settings, n), notification.ErrUnsafeURL)
+	})
+}
+
+func TestCheckOutboundURL(t *testing.T) {
+	assert.NoError(t, notification.CheckOutboundURL(context.Background(), "https://93.184.216.34/hook"))
+	assert.NoError(t, notification.CheckOutboundURL(context.Background(), "https://100.128.0.1/hook"))
+
+	for _, raw := range []string{
+		"http://93.184.216.34/hook",
+		"https://127.0.0.1/hook",
+		"https://10.0.0.8/hook",
+		"https://100.64.0.1/hook",
+		"https://100.127.255.254/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https:///hook",
+	} {
+		assert.ErrorIs(t, notification.CheckOutboundURL(context.Background(), raw), notification.ErrUnsafeURL, raw)
+	}
+}
+
+func TestEmailSender(t *testing.T) {
+	var to []string
+	var message string
+	sender := &notification.EmailSender{
+		Host: "smtp.example.com",
+		Port: "587",
+		From: "reminders@example.com",
+		SendMail: func(ctx context.Context, addr string, a smtp.Auth, from string, recipients []string, msg []byte) error {
+			assert.Equal(t, "smtp.example.com:587", addr)
+			to, message = recipients, string(msg)
+			return nil
+		},
+	}
+
+	n := testNotification()
+	n.Title = "Log your meals\r\nBcc: someone@example.com"
+	assert.NoError(t, sender.Send(context.Background(), &notification.NotificationSettings{Email: "user@example.com"}, n))
+	assert.Equal(t, []string{"user@example.com"}, to)
+	assert.NotContains(t, message, "\r\nBcc:")
+	assert.Contains(t, message, n.Body)
+
+	assert.ErrorIs(t, sender.Send(context.Background(), &notification.NotificationSettings{}, n), notification.ErrChannelNotConfigured)
+
+	t.Run("Give up on a server that does not answer by the deadline", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			// Accepts without ever greeting
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+		silent := &notification.EmailSender{Host: host, Port: port, From: "reminders@example.com"}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		assert.Error(t, silent.Send(ctx, &notification.NotificationSettings{Email: "user@example.com"}, n))
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+}
+
+func TestPushSender(t *testing.T) {
+	// The browser side of the subscription
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	// The server's VAPID keys
+	vapidPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	vapidPublic := base64.RawURLEncoding.EncodeToString(vapidPrivate.PublicKey().Bytes())
+
+	var received []byte
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		authorization = r.Header.Get("Authorization")
+		assert.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender := &notification.PushSender{
+		PublicKey:  vapidPublic,
+		PrivateKey: base64.RawURLEncoding.EncodeToString(vapidPrivate.Bytes()),
+		Subject:    "mailto:admin@example.com",
+		Client:     server.Client(),
+	}
+	settings := &notification.NotificationSettings{PushSubscription: &notification.PushSubscription{
+		Endpoint: server.URL + "/push/abc",
+		Keys: notification.PushKeys{
+			P256dh: base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+			Auth:   base64.RawURLEncoding.EncodeToString(authSecret),
+		},
+	}}
+	n := testNotification()
+
+	assert.NoError(t, sender.Send(context.Background(), settings, n))
+
+	t.Run("The browser can decrypt the payload", func(t *testing.T) {
+		plaintext, err := notification.DecryptPushPayload(uaPrivate, authSecret, received)
+		assert.NoError(t, err)
+
+		var payload notification.WebhookPayload
+		assert.NoError(t, json.Unmarshal(plaintext, &payload))
+		assert.Equal(t, n.Title, payload.Title)
+	})
+
+	t.Run("The VAPID token is signed for the endpoint origin", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(authorization, "vapid t="))
+		assert.True(t, strings.HasSuffix(authorization, ", k="+vapidPublic))
+
+		raw := strings.TrimSuffix(strings.TrimPrefix(authorization, "vapid t="), ", k="+vapidPublic)
+		key, err := notification.VapidSigningKey(sender.PrivateKey)
+		assert.NoError(t, err)
+
+		token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, server.URL, token.Claims.(jwt.MapClaims)["aud"])
+	})
+}
+
+func TestMemorySender(t *testing.T) {
+	sender := &notification.MemorySender{}
+	n := testNotification()
+
+	assert.NoError(t, sender.Send(context.Background(), &notification.NotificationSettings{}, n))
+	assert.Len(t, sender.Sent(), 1)
+	assert.Equal(t, n.EventKey, sender.Sent()[0].EventKey)
+}
+
+func TestScheduler(t *testing.T) {
+	var runs int32
+	scheduler := notification.Scheduler{Jobs: []notification.Job{{
+		Name:     "count",
+		Interval: 10 * time.Millisecond,
+		Run: func(now time.Time) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		},
+	}}}
+
+	scheduler.Start()
+	time.Sleep(35 * time.Millisecond)
+	scheduler.Stop()
+
+	counted := atomic.LoadInt32(&runs)
+	assert.GreaterOrEqual(t, counted, int32(2))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, counted, atomic.LoadInt32(&runs))
+}
+
+func TestRestOverEvent(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	timer := workoutSession.NewRestTimer("squat", 2, 120, workoutSession.PrescriptionRest, start)
+
+	event := notification.RestOverEvent("session1", *timer)
+	assert.Equal(t, notificationEnums.RestOver, event.Type)
+	assert.Equal(t, "2", event.Data["set_number"])
+	assert.True(t, event.Type.IgnoresQuietHours())
+
+	extended := *timer
+	extended.Extend(30, start.Add(time.Minute))
+	assert.NotEqual(t, event.Key, notification.RestOverEvent("session1", extended).Key)
+}
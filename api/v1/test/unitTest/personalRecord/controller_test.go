@@ -0,0 +1,208 @@
+package personalRecord_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock service
+type MockPersonalRecordService struct {
+	mock.Mock
+}
+
+func (m *MockPersonalRecordService) SyncRecords(userId string, exerciseId string, logId string) ([]personalRecordEnums.AchievedRecord, error) {
+	args := m.Called(userId, exerciseId, logId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]personalRecordEnums.AchievedRecord), args.Error(1)
+}
+
+func (m *MockPersonalRecordService) GetRecordsByUser(userId string) ([]*personalRecord.PersonalRecord, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*personalRecord.PersonalRecord), args.Error(1)
+}
+
+func (m *MockPersonalRecordService) GetRecordsByExercise(exerciseId string, userId string) (*personalRecord.PersonalRecord, error) {
+	args := m.Called(exerciseId, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*personalRecord.PersonalRecord), args.Error(1)
+}
+
+func (m *MockPersonalRecordService) GetRecordHistory(userId string, exerciseId string) ([]*personalRecord.RecordHistory, error) {
+	args := m.Called(userId, exerciseId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*personalRecord.RecordHistory), args.Error(1)
+}
+
+// TestMiddleware sets up the test context with a mock user
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+// Test setup helper
+func setupTest() (*fiber.App, *MockPersonalRecordService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockPersonalRecordService)
+	controller := &personalRecord.PersonalRecordController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+func TestGetUserRecordsHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get user records successfully", func(t *testing.T) {
+		expectedRecords := []*personalRecord.PersonalRecord{
+			{
+				ID:             primitive.NewObjectID(),
+				UserID:         "test_user",
+				ExerciseID:     primitive.NewObjectID().Hex(),
+				EstimatedOneRM: personalRecord.RecordValue{Value: 120},
+			},
+		}
+
+		mockService.On("GetRecordsByUser", "test_user").Return(expectedRecords, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/personal-record", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []*personalRecord.PersonalRecord
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result, 1)
+		assert.Equal(t, float64(120), result[0].EstimatedOneRM.Value)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		mockService.On("GetRecordsByUser", "error_user").Return(nil, fmt.Errorf("database error")).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/personal-record", nil)
+		req.Header.Set("userid", "error_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
+func TestGetExerciseRecordsHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get exercise records successfully", func(t *testing.T) {
+		exerciseId := primitive.NewObjectID().Hex()
+		expectedRecord := &personalRecord.PersonalRecord{
+			ID:         primitive.NewObjectID(),
+			UserID:     "test_user",
+			ExerciseID: exerciseId,
+			SetVolume:  personalRecord.RecordValue{Value: 1000},
+		}
+
+		mockService.On("GetRecordsByExercise", exerciseId, "test_user").Return(expectedRecord, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/personal-record/exercise/"+exerciseId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result personalRecord.PersonalRecord
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, exerciseId, result.ExerciseID)
+		assert.Equal(t, float64(1000), result.SetVolume.Value)
+	})
+
+	t.Run("No records for exercise", func(t *testing.T) {
+		exerciseId := primitive.NewObjectID().Hex()
+		mockService.On("GetRecordsByExercise", exerciseId, "test_user").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/personal-record/exercise/"+exerciseId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestGetRecordHistoryHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get history for an exercise", func(t *testing.T) {
+		exerciseId := primitive.NewObjectID().Hex()
+		expectedHistory := []*personalRecord.RecordHistory{
+			{
+				ID:         primitive.NewObjectID(),
+				UserID:     "test_user",
+				ExerciseID: exerciseId,
+				Type:       personalRecordEnums.RepMaxRecord,
+				Reps:       5,
+				Value:      100,
+				Previous:   95,
+			},
+		}
+
+		mockService.On("GetRecordHistory", "test_user", exerciseId).Return(expectedHistory, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/personal-record/history?exerciseId="+exerciseId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []*personalRecord.RecordHistory
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result, 1)
+		assert.Equal(t, personalRecordEnums.RepMaxRecord, result[0].Type)
+		assert.Equal(t, float64(95), result[0].Previous)
+	})
+
+	t.Run("Get history for all exercises", func(t *testing.T) {
+		mockService.On("GetRecordHistory", "test_user", "").Return([]*personalRecord.RecordHistory{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/personal-record/history", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,179 @@
+package personalRecord_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Setup functions
+func setupTestDB(t *testing.T) *mongo.Database {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	db := client.Database("testdb_" + primitive.NewObjectID().Hex())
+
+	t.Cleanup(func() {
+		if err := db.Drop(context.Background()); err != nil {
+			t.Errorf("Failed to drop test database: %v", err)
+		}
+		if err := client.Disconnect(context.Background()); err != nil {
+			t.Errorf("Failed to disconnect from MongoDB: %v", err)
+		}
+	})
+
+	return db
+}
+
+func TestCalculateRecords(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Track bests and history in chronological order", func(t *testing.T) {
+		logs := []exerciseLog.ExerciseLog{
+			{
+				ID:       primitive.NewObjectID(),
+				DateTime: now.Add(-48 * time.Hour),
+				Sets: []exerciseLog.SetLog{
+					{Weight: 60, Reps: 10, SetNumber: 1, Type: exerciseLog.WarmUpSet},
+					{Weight: 100, Reps: 5, SetNumber: 2, Type: exerciseLog.WorkingSet},
+				},
+			},
+			{
+				ID:       primitive.NewObjectID(),
+				DateTime: now,
+				Sets: []exerciseLog.SetLog{
+					{Weight: 105, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet},
+					{Weight: 90, Reps: 8, SetNumber: 2, Type: exerciseLog.WorkingSet},
+				},
+			},
+		}
+
+		record, history := personalRecord.CalculateRecords(logs)
+
+		// Warm-up sets do not count, so there is no 10 rep max
+		assert.Equal(t, float64(0), record.RepMaxes[9].Value)
+		assert.Equal(t, float64(105), record.RepMaxes[4].Value)
+		assert.Equal(t, logs[1].ID.Hex(), record.RepMaxes[4].ExerciseLogID)
+		assert.Equal(t, float64(90), record.RepMaxes[7].Value)
+		assert.Equal(t, float64(720), record.SetVolume.Value)
+		assert.Equal(t, float64(1245), record.SessionVolume.Value)
+		assert.Greater(t, record.EstimatedOneRM.Value, float64(105))
+
+		var fiveRepMaxes []personalRecord.RecordHistory
+		for _, entry := range history {
+			if entry.Type == personalRecordEnums.RepMaxRecord && entry.Reps == 5 {
+				fiveRepMaxes = append(fiveRepMaxes, entry)
+			}
+		}
+		assert.Len(t, fiveRepMaxes, 2)
+		assert.Equal(t, float64(100), fiveRepMaxes[1].Previous)
+		assert.Equal(t, float64(105), fiveRepMaxes[1].Value)
+	})
+
+	t.Run("Use effective load for body weight exercises", func(t *testing.T) {
+		logs := []exerciseLog.ExerciseLog{
+			{
+				ID:       primitive.NewObjectID(),
+				DateTime: now,
+				Sets: []exerciseLog.SetLog{
+					{Weight: 10, EffectiveWeight: 90, Reps: 3, SetNumber: 1, Type: exerciseLog.WorkingSet},
+				},
+			},
+		}
+
+		record, _ := personalRecord.CalculateRecords(logs)
+		assert.Equal(t, float64(90), record.RepMaxes[2].Value)
+		assert.Equal(t, float64(270), record.SetVolume.Value)
+	})
+}
+
+func TestSyncRecords(t *testing.T) {
+	db := setupTestDB(t)
+	logService := &exerciseLog.ExerciseLogService{DB: db}
+	service := &personalRecord.PersonalRecordService{DB: db}
+	userId := "test_user"
+	exerciseId := primitive.NewObjectID().Hex()
+
+	createLog := func(weight float64, reps int, at time.Time) *exerciseLog.ExerciseLog {
+		log, err := logService.CreateLog(&exerciseLog.CreateExerciseLogDto{
+			ExerciseID: exerciseId,
+			DateTime:   at,
+			Sets: []exerciseLog.SetLog{
+				{Weight: weight, Reps: reps, SetNumber: 1, Type: exerciseLog.WorkingSet},
+			},
+		}, userId)
+		assert.NoError(t, err)
+		return log
+	}
+
+	t.Run("Return records achieved by a log", func(t *testing.T) {
+		first := createLog(100, 5, time.Now().Add(-24*time.Hour))
+		achieved, err := service.SyncRecords(userId, exerciseId, first.ID.Hex())
+		assert.NoError(t, err)
+		assert.NotEmpty(t, achieved)
+
+		second := createLog(95, 5, time.Now())
+		achieved, err = service.SyncRecords(userId, exerciseId, second.ID.Hex())
+		assert.NoError(t, err)
+		assert.Empty(t, achieved)
+
+		record, err := service.GetRecordsByExercise(exerciseId, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(100), record.RepMaxes[4].Value)
+		assert.Equal(t, first.ID.Hex(), record.RepMaxes[4].ExerciseLogID)
+	})
+
+	t.Run("History entries keep their IDs when synced again", func(t *testing.T) {
+		before, err := service.GetRecordHistory(userId, exerciseId)
+		assert.NoError(t, err)
+
+		_, err = service.SyncRecords(userId, exerciseId, "")
+		assert.NoError(t, err)
+
+		after, err := service.GetRecordHistory(userId, exerciseId)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, historyIds(before), historyIds(after))
+	})
+
+	t.Run("Recompute records after a log is deleted", func(t *testing.T) {
+		logs, err := logService.GetLogsByExercise(exerciseId, userId)
+		assert.NoError(t, err)
+
+		for _, log := range logs {
+			if log.Sets[0].Weight == 100 {
+				assert.NoError(t, logService.DeleteLog(log.ID.Hex(), userId))
+			}
+		}
+
+		_, err = service.SyncRecords(userId, exerciseId, "")
+		assert.NoError(t, err)
+
+		record, err := service.GetRecordsByExercise(exerciseId, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(95), record.RepMaxes[4].Value)
+
+		history, err := service.GetRecordHistory(userId, exerciseId)
+		assert.NoError(t, err)
+		for _, entry := range history {
+			assert.NotEqual(t, float64(100), entry.Value)
+		}
+	})
+}
+
+func historyIds(history []*personalRecord.RecordHistory) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(history))
+	for _, entry := range history {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
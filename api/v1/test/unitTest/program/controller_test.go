@@ -0,0 +1,293 @@
+package program_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock service
+type MockProgramService struct {
+	mock.Mock
+}
+
+func (m *MockProgramService) CreateProgram(dto *program.CreateProgramDto, userId string) (*program.Program, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*program.Program), args.Error(1)
+}
+
+func (m *MockProgramService) GetPrograms(userId string) ([]*program.Program, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*program.Program), args.Error(1)
+}
+
+func (m *MockProgramService) GetProgram(id string, userId string) (*program.Program, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*program.Program), args.Error(1)
+}
+
+func (m *MockProgramService) DeleteProgram(id string, userId string) error {
+	args := m.Called(id, userId)
+	return args.Error(0)
+}
+
+func (m *MockProgramService) StartProgram(id string, dto *program.StartProgramDto, userId string) (*program.ProgramProgress, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*program.ProgramProgress), args.Error(1)
+}
+
+func (m *MockProgramService) GetProgress(userId string) (*program.ProgramProgress, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*program.ProgramProgress), args.Error(1)
+}
+
+func (m *MockProgramService) AdvanceWeek(dto *program.MoveWeekDto, userId string) (*program.ProgramProgress, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*program.ProgramProgress), args.Error(1)
+}
+
+func (m *MockProgramService) RepeatWeek(dto *program.MoveWeekDto, userId string) (*program.ProgramProgress, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*program.ProgramProgress), args.Error(1)
+}
+
+func (m *MockProgramService) GetWeekModifiers(userId string, workoutId string, at time.Time) (float64, float64, error) {
+	args := m.Called(userId, workoutId, at)
+	return args.Get(0).(float64), args.Get(1).(float64), args.Error(2)
+}
+
+// TestMiddleware sets up the test context with a mock user
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+// Test setup helper
+func setupTest() (*fiber.App, *MockProgramService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockProgramService)
+	controller := &program.ProgramController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+func TestCreateProgramHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Valid program creation", func(t *testing.T) {
+		createDto := program.CreateProgramDto{
+			Name: "Strength Block",
+			Blocks: []program.ProgramBlock{
+				{
+					Name: "Accumulation",
+					Weeks: []program.ProgramWeek{
+						{Days: []program.ProgramDay{{Day: 0, WorkoutID: primitive.NewObjectID().Hex()}}},
+						{Deload: true, Days: []program.ProgramDay{{Day: 0, WorkoutID: primitive.NewObjectID().Hex()}}},
+					},
+				},
+			},
+		}
+
+		expectedResponse := &program.Program{
+			ID:     primitive.NewObjectID(),
+			UserID: "test_user",
+			Name:   createDto.Name,
+			Blocks: createDto.Blocks,
+		}
+
+		mockService.On("CreateProgram",
+			mock.MatchedBy(func(dto *program.CreateProgramDto) bool {
+				return dto.Name == createDto.Name && len(dto.Blocks[0].Weeks) == 2
+			}),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(createDto)
+		req := httptest.NewRequest("POST", "/api/v1/program", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var result program.Program
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, createDto.Name, result.Name)
+	})
+
+	t.Run("Reject day outside the week", func(t *testing.T) {
+		createDto := program.CreateProgramDto{
+			Name: "Broken Program",
+			Blocks: []program.ProgramBlock{
+				{
+					Name:  "Block",
+					Weeks: []program.ProgramWeek{{Days: []program.ProgramDay{{Day: 7, WorkoutID: primitive.NewObjectID().Hex()}}}},
+				},
+			},
+		}
+
+		body, _ := json.Marshal(createDto)
+		req := httptest.NewRequest("POST", "/api/v1/program", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestStartProgramHandler(t *testing.T) {
+	app, mockService := setupTest()
+	programId := primitive.NewObjectID().Hex()
+
+	t.Run("Start program without a body", func(t *testing.T) {
+		expectedProgress := &program.ProgramProgress{ProgramName: "Strength Block", Week: 1, TotalWeeks: 4, Block: 1}
+
+		mockService.On("StartProgram", programId, mock.AnythingOfType("*program.StartProgramDto"), "test_user").Return(expectedProgress, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/program/"+programId+"/start", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var result program.ProgramProgress
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 1, result.Week)
+		assert.Equal(t, 4, result.TotalWeeks)
+	})
+
+	t.Run("Program not found", func(t *testing.T) {
+		missingId := primitive.NewObjectID().Hex()
+		mockService.On("StartProgram", missingId, mock.AnythingOfType("*program.StartProgramDto"), "test_user").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/program/"+missingId+"/start", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Days taken by other workouts", func(t *testing.T) {
+		conflict := fmt.Errorf("%w on 2025-03-10", program.ErrScheduleConflict)
+		mockService.On("StartProgram", programId, mock.MatchedBy(func(dto *program.StartProgramDto) bool {
+			return dto.Timezone == "Asia/Bangkok" && !dto.AllowConflicts
+		}), "test_user").Return(nil, conflict).Once()
+
+		body, _ := json.Marshal(program.StartProgramDto{Timezone: "Asia/Bangkok"})
+		req := httptest.NewRequest("POST", "/api/v1/program/"+programId+"/start", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Unknown timezone", func(t *testing.T) {
+		mockService.On("StartProgram", programId, mock.AnythingOfType("*program.StartProgramDto"), "test_user").Return(nil, program.ErrInvalidTimezone).Once()
+
+		body, _ := json.Marshal(program.StartProgramDto{Timezone: "Mars/Olympus"})
+		req := httptest.NewRequest("POST", "/api/v1/program/"+programId+"/start", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestProgressHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get active progress", func(t *testing.T) {
+		mockService.On("GetProgress", "test_user").Return(&program.ProgramProgress{Week: 3, BlockName: "Intensification"}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/program/active", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result program.ProgramProgress
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, "Intensification", result.BlockName)
+	})
+
+	t.Run("Advance and repeat weeks", func(t *testing.T) {
+		mockService.On("AdvanceWeek", mock.AnythingOfType("*program.MoveWeekDto"), "test_user").Return(&program.ProgramProgress{Week: 4}, nil).Once()
+		mockService.On("RepeatWeek", mock.AnythingOfType("*program.MoveWeekDto"), "test_user").Return(&program.ProgramProgress{Week: 4}, nil).Once()
+
+		for _, action := range []string{"advance", "repeat"} {
+			req := httptest.NewRequest("PUT", "/api/v1/program/active/"+action, nil)
+			req.Header.Set("userid", "test_user")
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		}
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("No active program", func(t *testing.T) {
+		mockService.On("GetProgress", "idle_user").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/program/active", nil)
+		req.Header.Set("userid", "idle_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,188 @@
+package program_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutPlan"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Setup functions
+func setupTestDB(t *testing.T) *mongo.Database {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	db := client.Database("testdb_" + primitive.NewObjectID().Hex())
+
+	t.Cleanup(func() {
+		if err := db.Drop(context.Background()); err != nil {
+			t.Errorf("Failed to drop test database: %v", err)
+		}
+		if err := client.Disconnect(context.Background()); err != nil {
+			t.Errorf("Failed to disconnect from MongoDB: %v", err)
+		}
+	})
+
+	return db
+}
+
+// twoBlockProgram has a three week block ending in a deload and a one week block
+func twoBlockProgram(heavyId, lightId string) *program.Program {
+	week := program.ProgramWeek{Days: []program.ProgramDay{{Day: 0, WorkoutID: heavyId}, {Day: 3, WorkoutID: lightId}}}
+	overload := week
+	overload.VolumeModifier = 1.25
+	deload := week
+	deload.Deload = true
+
+	return &program.Program{
+		Name: "Two Blocks",
+		Blocks: []program.ProgramBlock{
+			{Name: "Accumulation", Weeks: []program.ProgramWeek{week, overload, deload}},
+			{Name: "Peak", Weeks: []program.ProgramWeek{{IntensityModifier: 1.05, Days: week.Days}}},
+		},
+	}
+}
+
+func TestProgressAt(t *testing.T) {
+	p := twoBlockProgram("heavy", "light")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	enrollment := &program.ProgramEnrollment{CurrentWeek: 0, CurrentWeekStart: start}
+
+	t.Run("First week", func(t *testing.T) {
+		progress := program.ProgressAt(p, enrollment, start.Add(time.Hour))
+		assert.Equal(t, 1, progress.Week)
+		assert.Equal(t, 4, progress.TotalWeeks)
+		assert.Equal(t, "Accumulation", progress.BlockName)
+		assert.Equal(t, 1.0, progress.VolumeModifier)
+	})
+
+	t.Run("Deload week", func(t *testing.T) {
+		progress := program.ProgressAt(p, enrollment, start.AddDate(0, 0, 15))
+		assert.Equal(t, 3, progress.Week)
+		assert.Equal(t, 3, progress.WeekInBlock)
+		assert.True(t, progress.Deload)
+		assert.Equal(t, program.DeloadVolumeModifier, progress.VolumeModifier)
+		assert.Equal(t, program.DeloadIntensityModifier, progress.IntensityModifier)
+		assert.Equal(t, start.AddDate(0, 0, 14), progress.WeekStart)
+	})
+
+	t.Run("Second block", func(t *testing.T) {
+		progress := program.ProgressAt(p, enrollment, start.AddDate(0, 0, 22))
+		assert.Equal(t, 2, progress.Block)
+		assert.Equal(t, 1, progress.WeekInBlock)
+		assert.Equal(t, 1.05, progress.IntensityModifier)
+	})
+}
+
+func TestPlanDates(t *testing.T) {
+	p := twoBlockProgram("heavy", "light")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	dates := program.PlanDates(p, &program.ProgramEnrollment{CurrentWeek: 2, CurrentWeekStart: start})
+	assert.Equal(t, []time.Time{start, start.AddDate(0, 0, 7)}, dates["heavy"])
+	assert.Equal(t, []time.Time{start.AddDate(0, 0, 3), start.AddDate(0, 0, 10)}, dates["light"])
+}
+
+func TestStartProgram(t *testing.T) {
+	db := setupTestDB(t)
+	service := &program.ProgramService{DB: db, Schedule: &workoutPlan.WorkoutPlanService{DB: db}}
+	userId := "test_user"
+
+	workoutIds := make([]string, 2)
+	for i := range workoutIds {
+		result, err := db.Collection("workout").InsertOne(context.Background(), workout.Workout{UserID: userId, Name: "Workout"})
+		assert.NoError(t, err)
+		workoutIds[i] = result.InsertedID.(primitive.ObjectID).Hex()
+	}
+
+	template := twoBlockProgram(workoutIds[0], workoutIds[1])
+	created, err := service.CreateProgram(&program.CreateProgramDto{Name: template.Name, Blocks: template.Blocks}, userId)
+	assert.NoError(t, err)
+
+	t.Run("Plan every week of the program", func(t *testing.T) {
+		progress, err := service.StartProgram(created.ID.Hex(), &program.StartProgramDto{}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, progress.Week)
+
+		var plans []workoutPlan.WorkoutPlan
+		cursor, err := db.Collection("workoutPlan").Find(context.Background(), bson.D{{Key: "programid", Value: created.ID.Hex()}})
+		assert.NoError(t, err)
+		assert.NoError(t, cursor.All(context.Background(), &plans))
+		assert.Len(t, plans, 2)
+		for _, plan := range plans {
+			assert.Len(t, plan.Dates, 4)
+		}
+	})
+
+	t.Run("Restart without planning a date twice", func(t *testing.T) {
+		_, err := service.StartProgram(created.ID.Hex(), &program.StartProgramDto{}, userId)
+		assert.NoError(t, err)
+
+		var plans []workoutPlan.WorkoutPlan
+		cursor, err := db.Collection("workoutPlan").Find(context.Background(), bson.D{{Key: "programid", Value: created.ID.Hex()}})
+		assert.NoError(t, err)
+		assert.NoError(t, cursor.All(context.Background(), &plans))
+		for _, plan := range plans {
+			assert.Len(t, plan.Dates, 4)
+		}
+	})
+
+	t.Run("Advance to the next week", func(t *testing.T) {
+		progress, err := service.AdvanceWeek(&program.MoveWeekDto{}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, progress.Week)
+
+		volume, _, err := service.GetWeekModifiers(userId, workoutIds[0], time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1.25, volume)
+	})
+
+	t.Run("Refuse days other workouts take", func(t *testing.T) {
+		start := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+		_, err := db.Collection("workoutPlan").InsertOne(context.Background(), workoutPlan.WorkoutPlan{
+			UserID:    userId,
+			WorkoutID: primitive.NewObjectID().Hex(),
+			Dates:     []time.Time{start},
+		})
+		assert.NoError(t, err)
+
+		_, err = service.StartProgram(created.ID.Hex(), &program.StartProgramDto{StartDate: start}, userId)
+		assert.ErrorIs(t, err, program.ErrScheduleConflict)
+
+		progress, err := service.StartProgram(created.ID.Hex(), &program.StartProgramDto{StartDate: start, AllowConflicts: true}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, start, progress.Enrollment.StartDate)
+	})
+
+	t.Run("Count days in the user's timezone", func(t *testing.T) {
+		// 20:00 UTC on 3 March is already 4 March in Bangkok
+		progress, err := service.StartProgram(created.ID.Hex(), &program.StartProgramDto{
+			StartDate:      time.Date(2031, time.March, 3, 20, 0, 0, 0, time.UTC),
+			Timezone:       "Asia/Bangkok",
+			AllowConflicts: true,
+		}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2031, time.March, 4, 0, 0, 0, 0, time.UTC), progress.Enrollment.StartDate)
+
+		_, err = service.StartProgram(created.ID.Hex(), &program.StartProgramDto{Timezone: "Mars/Olympus"}, userId)
+		assert.Equal(t, program.ErrInvalidTimezone, err)
+	})
+
+	t.Run("Reject unknown workouts", func(t *testing.T) {
+		_, err := service.CreateProgram(&program.CreateProgramDto{
+			Name:   "Missing Workouts",
+			Blocks: twoBlockProgram(primitive.NewObjectID().Hex(), workoutIds[0]).Blocks,
+		}, userId)
+		assert.Error(t, err)
+	})
+}
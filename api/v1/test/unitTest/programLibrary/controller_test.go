@@ -0,0 +1,155 @@
+package programLibrary_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/programLibrary"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock service
+type MockProgramLibraryService struct {
+	mock.Mock
+}
+
+func (m *MockProgramLibraryService) GetPrograms(filters *programLibrary.SearchLibraryFilters) ([]*programLibrary.LibraryProgram, error) {
+	args := m.Called(filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*programLibrary.LibraryProgram), args.Error(1)
+}
+
+func (m *MockProgramLibraryService) GetProgram(slug string) (*programLibrary.LibraryProgram, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*programLibrary.LibraryProgram), args.Error(1)
+}
+
+func (m *MockProgramLibraryService) InstallProgram(slug string, userId string) (*programLibrary.InstalledProgram, error) {
+	args := m.Called(slug, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*programLibrary.InstalledProgram), args.Error(1)
+}
+
+// TestMiddleware sets up the test context with a mock user
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+// Test setup helper
+func setupTest() (*fiber.App, *MockProgramLibraryService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockProgramLibraryService)
+	controller := &programLibrary.ProgramLibraryController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+func TestGetProgramsHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Filter programs by level", func(t *testing.T) {
+		expected := []*programLibrary.LibraryProgram{{Slug: "stronglifts-5x5", Version: 1, Level: "beginner"}}
+		mockService.On("GetPrograms", &programLibrary.SearchLibraryFilters{Level: "beginner"}).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/programs?level=beginner", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []*programLibrary.LibraryProgram
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "stronglifts-5x5", result[0].Slug)
+	})
+}
+
+func TestGetProgramHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Program not found", func(t *testing.T) {
+		mockService.On("GetProgram", "unknown").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/programs/unknown", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestInstallProgramHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Install program successfully", func(t *testing.T) {
+		workoutId := primitive.NewObjectID()
+		expected := &programLibrary.InstalledProgram{
+			Program: &program.Program{
+				ID:     primitive.NewObjectID(),
+				UserID: "test_user",
+				Name:   "Stronglifts 5x5",
+				Blocks: []program.ProgramBlock{{Name: "Linear progression", Weeks: []program.ProgramWeek{
+					{Days: []program.ProgramDay{{Day: 0, WorkoutID: workoutId.Hex()}}},
+				}}},
+			},
+			Workouts: []*workout.Workout{{ID: workoutId, UserID: "test_user", Name: "5x5 Workout A"}},
+		}
+		mockService.On("InstallProgram", "stronglifts-5x5", "test_user").Return(expected, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/programs/stronglifts-5x5/install", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var result programLibrary.InstalledProgram
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, "test_user", result.Program.UserID)
+		assert.Equal(t, workoutId, result.Workouts[0].ID)
+	})
+
+	t.Run("Missing exercise", func(t *testing.T) {
+		mockService.On("InstallProgram", "push-pull-legs", "test_user").Return(nil, fmt.Errorf("exercise Squat (Barbell) not found")).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/programs/push-pull-legs/install", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,117 @@
+package programLibrary_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/programLibrary"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/go-playground/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func testProgram() *programLibrary.LibraryProgram {
+	prescription := &workout.Prescription{Sets: 5, MinReps: 5, MaxReps: 5, RestSeconds: 180}
+	return &programLibrary.LibraryProgram{
+		Slug:        "test-program",
+		Version:     1,
+		Name:        "Test Program",
+		Level:       "beginner",
+		DaysPerWeek: 3,
+		Workouts: []programLibrary.LibraryWorkout{
+			{Key: "A", Name: "Workout A", Exercises: []programLibrary.LibraryExercise{
+				{Name: "Squat", Equipment: exerciseEnums.Barbell, Prescription: prescription},
+				{Name: "Bench Press", Equipment: exerciseEnums.Barbell, Prescription: prescription},
+			}},
+			{Key: "B", Name: "Workout B", Exercises: []programLibrary.LibraryExercise{
+				{Name: "Squat", Equipment: exerciseEnums.Barbell, Prescription: prescription},
+				{Name: "Deadlift", Equipment: exerciseEnums.Barbell, Prescription: prescription},
+			}},
+		},
+		Blocks: []programLibrary.LibraryBlock{
+			{Name: "Base", Repeat: 2, Weeks: []programLibrary.LibraryWeek{
+				{Days: []programLibrary.LibraryDay{{Day: 0, Workout: "A"}, {Day: 2, Workout: "B"}}},
+				{Deload: true, Days: []programLibrary.LibraryDay{{Day: 0, Workout: "B"}}},
+			}},
+		},
+	}
+}
+
+func TestBuildWorkouts(t *testing.T) {
+	p := testProgram()
+	exerciseIds := map[string]string{
+		programLibrary.ExerciseKey("Squat", exerciseEnums.Barbell):       "squat",
+		programLibrary.ExerciseKey("Bench Press", exerciseEnums.Barbell): "bench",
+		programLibrary.ExerciseKey("Deadlift", exerciseEnums.Barbell):    "deadlift",
+	}
+
+	t.Run("Resolve exercises of every workout", func(t *testing.T) {
+		assert.Len(t, p.Exercises(), 3)
+
+		dtos, err := programLibrary.BuildWorkouts(p, exerciseIds)
+		assert.NoError(t, err)
+		assert.Len(t, dtos, 2)
+		assert.Equal(t, "Workout B", dtos[1].Name)
+		assert.Equal(t, "deadlift", dtos[1].Exercises[1].ExerciseID)
+		assert.Equal(t, 1, dtos[1].Exercises[1].Order)
+		assert.Equal(t, 5, dtos[1].Exercises[1].Prescription.Sets)
+	})
+
+	t.Run("Fail when an exercise is not found", func(t *testing.T) {
+		delete(exerciseIds, programLibrary.ExerciseKey("Deadlift", exerciseEnums.Barbell))
+
+		_, err := programLibrary.BuildWorkouts(p, exerciseIds)
+		assert.Error(t, err)
+	})
+}
+
+func TestBuildBlocks(t *testing.T) {
+	p := testProgram()
+
+	t.Run("Repeat weeks and use installed workout IDs", func(t *testing.T) {
+		blocks, err := programLibrary.BuildBlocks(p, map[string]string{"A": "workout-a", "B": "workout-b"})
+		assert.NoError(t, err)
+		assert.Len(t, blocks, 1)
+		assert.Len(t, blocks[0].Weeks, 4)
+		assert.Equal(t, "workout-a", blocks[0].Weeks[2].Days[0].WorkoutID)
+		assert.True(t, blocks[0].Weeks[3].Deload)
+	})
+
+	t.Run("Fail on a workout that was not installed", func(t *testing.T) {
+		_, err := programLibrary.BuildBlocks(p, map[string]string{"A": "workout-a"})
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateProgram(t *testing.T) {
+	t.Run("Reject an unknown workout key", func(t *testing.T) {
+		p := testProgram()
+		p.Blocks[0].Weeks[0].Days[0].Workout = "C"
+		assert.Error(t, programLibrary.ValidateProgram(p))
+	})
+
+	t.Run("Reject a malformed group", func(t *testing.T) {
+		p := testProgram()
+		p.Workouts[0].Groups = []workout.ExerciseGroup{{ID: "g1", Type: workout.GiantSetGroup, Rounds: 3}}
+		p.Workouts[0].Exercises[0].GroupID = "g1"
+		p.Workouts[0].Exercises[1].GroupID = "g1"
+		assert.Error(t, programLibrary.ValidateProgram(p))
+	})
+
+	t.Run("Shipped library is valid", func(t *testing.T) {
+		data, err := os.ReadFile("../../../workout/programLibrary/json/programs.json")
+		assert.NoError(t, err)
+
+		var content programLibrary.LibraryFileContent
+		assert.NoError(t, json.Unmarshal(data, &content))
+		assert.NotEmpty(t, content.Programs)
+
+		validate := validator.New()
+		for _, p := range content.Programs {
+			assert.NoError(t, validate.Struct(p), p.Slug)
+			assert.NoError(t, programLibrary.ValidateProgram(&p), p.Slug)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package progression_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/progression"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock service
+type MockProgressionService struct {
+	mock.Mock
+}
+
+func (m *MockProgressionService) EvaluateSession(userId string, workoutId string, sessionId string, logs []*exerciseLog.ExerciseLog) ([]*progression.ProgressionTarget, error) {
+	args := m.Called(userId, workoutId, sessionId, logs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*progression.ProgressionTarget), args.Error(1)
+}
+
+func (m *MockProgressionService) GetTargets(userId string, workoutId string) ([]*progression.ProgressionTarget, error) {
+	args := m.Called(userId, workoutId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*progression.ProgressionTarget), args.Error(1)
+}
+
+func (m *MockProgressionService) GetTarget(userId string, workoutId string, exerciseId string) (*progression.ProgressionTarget, error) {
+	args := m.Called(userId, workoutId, exerciseId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*progression.ProgressionTarget), args.Error(1)
+}
+
+// TestMiddleware sets up the test context with a mock user
+func testMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := jwt.MapClaims{
+			"sub": c.Get("userid", ""),
+		}
+		token := &jwt.Token{
+			Claims: claims,
+		}
+		c.Locals("user", token)
+		return c.Next()
+	}
+}
+
+// Test setup helper
+func setupTest() (*fiber.App, *MockProgressionService) {
+	app := fiber.New()
+	api := app.Group("/api/v1", testMiddleware())
+
+	mockService := new(MockProgressionService)
+	controller := &progression.ProgressionController{
+		Instance: api,
+		Service:  mockService,
+	}
+	controller.Handle()
+	return app, mockService
+}
+
+func TestGetTargetsHandler(t *testing.T) {
+	app, mockService := setupTest()
+	workoutId := primitive.NewObjectID().Hex()
+
+	t.Run("Get workout targets successfully", func(t *testing.T) {
+		expectedTargets := []*progression.ProgressionTarget{
+			{
+				UserID:     "test_user",
+				WorkoutID:  workoutId,
+				ExerciseID: primitive.NewObjectID().Hex(),
+				Weight:     102.5,
+				Reps:       5,
+				History: []progression.TargetChange{
+					{Outcome: progression.SuccessOutcome, PreviousWeight: 100, Weight: 102.5, Reason: "completed 3 x 5 at 100kg, adding 2.5kg"},
+				},
+			},
+		}
+
+		mockService.On("GetTargets", "test_user", workoutId).Return(expectedTargets, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/progression/workout/"+workoutId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []*progression.ProgressionTarget
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result, 1)
+		assert.Equal(t, 102.5, result[0].Weight)
+		assert.Equal(t, progression.SuccessOutcome, result[0].History[0].Outcome)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		mockService.On("GetTargets", "error_user", workoutId).Return(nil, fmt.Errorf("database error")).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/progression/workout/"+workoutId, nil)
+		req.Header.Set("userid", "error_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
+func TestGetTargetHandler(t *testing.T) {
+	app, mockService := setupTest()
+	workoutId := primitive.NewObjectID().Hex()
+	exerciseId := primitive.NewObjectID().Hex()
+
+	t.Run("Get exercise target successfully", func(t *testing.T) {
+		expectedTarget := &progression.ProgressionTarget{
+			UserID:     "test_user",
+			WorkoutID:  workoutId,
+			ExerciseID: exerciseId,
+			Weight:     60,
+			Reps:       9,
+		}
+
+		mockService.On("GetTarget", "test_user", workoutId, exerciseId).Return(expectedTarget, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/progression/workout/"+workoutId+"/exercise/"+exerciseId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result progression.ProgressionTarget
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 9, result.Reps)
+	})
+
+	t.Run("No target yet", func(t *testing.T) {
+		mockService.On("GetTarget", "new_user", workoutId, exerciseId).Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/progression/workout/"+workoutId+"/exercise/"+exerciseId, nil)
+		req.Header.Set("userid", "new_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,168 @@
+package progression_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/progression"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/go-playground/validator"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Setup functions
+func setupTestDB(t *testing.T) *mongo.Database {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	db := client.Database("testdb_" + primitive.NewObjectID().Hex())
+
+	t.Cleanup(func() {
+		if err := db.Drop(context.Background()); err != nil {
+			t.Errorf("Failed to drop test database: %v", err)
+		}
+		if err := client.Disconnect(context.Background()); err != nil {
+			t.Errorf("Failed to disconnect from MongoDB: %v", err)
+		}
+	})
+
+	return db
+}
+
+// sessionLog builds a log with one working set per entry of reps, all at weight
+func sessionLog(weight float64, reps ...int) *exerciseLog.ExerciseLog {
+	log := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID()}
+	for i, r := range reps {
+		log.Sets = append(log.Sets, exerciseLog.SetLog{Weight: weight, Reps: r, SetNumber: i + 1, Type: exerciseLog.WorkingSet})
+	}
+	return log
+}
+
+func TestEvaluate(t *testing.T) {
+	prescription := workout.Prescription{Sets: 3, MinReps: 5, MaxReps: 5, TargetWeight: 100}
+
+	t.Run("Linear progression adds the increment on success", func(t *testing.T) {
+		rule := workout.Progression{Scheme: workout.LinearProgression, Increment: 5}
+
+		target := progression.Evaluate(prescription, rule, progression.ProgressionTarget{}, sessionLog(100, 5, 5, 5), "session")
+		assert.Equal(t, float64(105), target.Weight)
+		assert.Len(t, target.History, 1)
+		assert.Equal(t, progression.SuccessOutcome, target.History[0].Outcome)
+		assert.Equal(t, float64(100), target.History[0].PreviousWeight)
+		assert.NotEmpty(t, target.History[0].Reason)
+	})
+
+	t.Run("Deload after three failed sessions", func(t *testing.T) {
+		rule := workout.Progression{Scheme: workout.LinearProgression, DeloadAfterFailures: 3, DeloadPercent: 10}
+		target := progression.ProgressionTarget{Weight: 100, Reps: 5}
+
+		for i := 0; i < 2; i++ {
+			target = progression.Evaluate(prescription, rule, target, sessionLog(100, 5, 5, 3), "session")
+			assert.Equal(t, float64(100), target.Weight)
+			assert.Equal(t, progression.FailureOutcome, target.History[i].Outcome)
+		}
+
+		target = progression.Evaluate(prescription, rule, target, sessionLog(100, 5, 4, 3), "session")
+		assert.Equal(t, float64(90), target.Weight)
+		assert.Equal(t, 0, target.FailedSessions)
+		assert.Equal(t, progression.DeloadOutcome, target.History[2].Outcome)
+	})
+
+	t.Run("Keep only the latest changes", func(t *testing.T) {
+		rule := workout.Progression{Scheme: workout.LinearProgression, Increment: 5}
+		target := progression.ProgressionTarget{Weight: 100, Reps: 5}
+		for i := 0; i < progression.HistoryLimit; i++ {
+			target.History = append(target.History, progression.TargetChange{SessionID: fmt.Sprint(i)})
+		}
+
+		target = progression.Evaluate(prescription, rule, target, sessionLog(100, 5, 5, 5), "latest")
+		assert.Len(t, target.History, progression.HistoryLimit)
+		assert.Equal(t, "1", target.History[0].SessionID)
+		assert.Equal(t, "latest", target.History[progression.HistoryLimit-1].SessionID)
+	})
+
+	t.Run("A deloading rule needs a deload percent", func(t *testing.T) {
+		validate := validator.New()
+		assert.Error(t, validate.Struct(workout.Progression{Scheme: workout.LinearProgression, DeloadAfterFailures: 3}))
+		assert.NoError(t, validate.Struct(workout.Progression{Scheme: workout.LinearProgression, DeloadAfterFailures: 3, DeloadPercent: 10}))
+		assert.NoError(t, validate.Struct(workout.Progression{Scheme: workout.LinearProgression}))
+	})
+
+	t.Run("Double progression adds reps before load", func(t *testing.T) {
+		rangePrescription := workout.Prescription{Sets: 2, MinReps: 8, MaxReps: 9, TargetWeight: 50}
+		rule := workout.Progression{Scheme: workout.DoubleProgression, Increment: 2.5}
+
+		target := progression.Evaluate(rangePrescription, rule, progression.ProgressionTarget{}, sessionLog(50, 8, 8), "first")
+		assert.Equal(t, float64(50), target.Weight)
+		assert.Equal(t, 9, target.Reps)
+
+		target = progression.Evaluate(rangePrescription, rule, target, sessionLog(50, 9, 9), "second")
+		assert.Equal(t, 52.5, target.Weight)
+		assert.Equal(t, 8, target.Reps)
+	})
+
+	t.Run("Percentage wave moves to the next step", func(t *testing.T) {
+		rule := workout.Progression{Scheme: workout.PercentageWave, Wave: []float64{70, 80, 90}}
+		wavePrescription := workout.Prescription{Sets: 1, MinReps: 1, MaxReps: 1}
+
+		// A single of 100kg estimates a 100kg 1RM
+		target := progression.Evaluate(wavePrescription, rule, progression.ProgressionTarget{}, sessionLog(100, 1), "session")
+		assert.Equal(t, 1, target.WaveStep)
+		assert.Equal(t, float64(80), target.Weight)
+	})
+}
+
+func TestApplyTarget(t *testing.T) {
+	prescription := workout.Prescription{Sets: 3, MinReps: 8, MaxReps: 10, PercentOneRM: 70}
+
+	applied := progression.ApplyTarget(prescription, &progression.ProgressionTarget{Weight: 62.5, Reps: 9})
+	assert.Equal(t, 62.5, applied.TargetWeight)
+	assert.Equal(t, 9, applied.MinReps)
+	assert.Equal(t, 10, applied.MaxReps)
+
+	assert.Equal(t, prescription, progression.ApplyTarget(prescription, nil))
+}
+
+func TestEvaluateSession(t *testing.T) {
+	db := setupTestDB(t)
+	service := &progression.ProgressionService{DB: db}
+	userId := "test_user"
+	exerciseId := primitive.NewObjectID().Hex()
+
+	result, err := db.Collection("workout").InsertOne(context.Background(), workout.Workout{
+		UserID: userId,
+		Name:   "Linear Workout",
+		Exercises: []workout.WorkoutExercise{
+			{
+				ExerciseID:   exerciseId,
+				Prescription: &workout.Prescription{Sets: 3, MinReps: 5, MaxReps: 5, TargetWeight: 100},
+				Progression:  &workout.Progression{Scheme: workout.LinearProgression, Increment: 2.5},
+			},
+		},
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+	workoutId := result.InsertedID.(primitive.ObjectID).Hex()
+
+	t.Run("Store the next target after a session", func(t *testing.T) {
+		log := sessionLog(100, 5, 5, 5)
+		log.ExerciseID = exerciseId
+
+		targets, err := service.EvaluateSession(userId, workoutId, "session1", []*exerciseLog.ExerciseLog{log})
+		assert.NoError(t, err)
+		assert.Len(t, targets, 1)
+
+		target, err := service.GetTarget(userId, workoutId, exerciseId)
+		assert.NoError(t, err)
+		assert.Equal(t, 102.5, target.Weight)
+		assert.Equal(t, "session1", target.History[0].SessionID)
+	})
+}
@@ -128,6 +128,74 @@ func TestCreateWorkoutHandler(t *testing.T) {
 		json.NewDecoder(resp.Body).Decode(&result)
 		assert.Equal(t, expectedResponse.Name, result.Name)
 	})
+
+	t.Run("Reject rep range with max below min", func(t *testing.T) {
+		workoutDto := &workout.CreateWorkoutDto{
+			Name: "Prescribed Workout",
+			Exercises: []workout.WorkoutExercise{
+				{
+					ExerciseID:   primitive.NewObjectID().Hex(),
+					Order:        0,
+					Prescription: &workout.Prescription{Sets: 4, MinReps: 10, MaxReps: 8},
+				},
+			},
+		}
+
+		body, _ := json.Marshal(workoutDto)
+		req := httptest.NewRequest("POST", "/api/v1/workout", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Reject superset with a single exercise", func(t *testing.T) {
+		workoutDto := &workout.CreateWorkoutDto{
+			Name: "Superset Workout",
+			Exercises: []workout.WorkoutExercise{
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 0, GroupID: "a"},
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 1},
+			},
+			Groups: []workout.ExerciseGroup{
+				{ID: "a", Type: workout.SupersetGroup, Rounds: 3, RestBetweenRounds: 90},
+			},
+		}
+
+		body, _ := json.Marshal(workoutDto)
+		req := httptest.NewRequest("POST", "/api/v1/workout", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Accept superset without rounds", func(t *testing.T) {
+		workoutDto := &workout.CreateWorkoutDto{
+			Name: "Superset Workout",
+			Exercises: []workout.WorkoutExercise{
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 0, GroupID: "b"},
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 1, GroupID: "b"},
+			},
+			Groups: []workout.ExerciseGroup{
+				{ID: "b", Type: workout.SupersetGroup},
+			},
+		}
+
+		mockService.On("CreateWorkout", workoutDto, "test_user").Return(&workout.Workout{Name: workoutDto.Name}, nil)
+
+		body, _ := json.Marshal(workoutDto)
+		req := httptest.NewRequest("POST", "/api/v1/workout", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	})
 }
 
 func TestGetWorkoutHandler(t *testing.T) {
@@ -340,3 +340,54 @@ func TestSearchWorkouts(t *testing.T) {
 		assert.Len(t, results, 2)
 	})
 }
+
+func TestExpandPrescription(t *testing.T) {
+	t.Run("Use the target weight as is", func(t *testing.T) {
+		sets := workout.ExpandPrescription(workout.Prescription{
+			Sets: 3, MinReps: 5, MaxReps: 5, TargetWeight: 82.5, PercentOneRM: 80, RestSeconds: 180,
+		}, 120)
+		assert.Len(t, sets, 3)
+		for i, set := range sets {
+			assert.Equal(t, i+1, set.SetNumber)
+			assert.Equal(t, 82.5, set.Weight)
+			assert.Equal(t, 180, set.RestSeconds)
+		}
+	})
+
+	t.Run("Work out percentage of 1RM", func(t *testing.T) {
+		sets := workout.ExpandPrescription(workout.Prescription{
+			Sets: 4, MinReps: 8, MaxReps: 8, PercentOneRM: 70,
+		}, 143)
+		// 70% of 143 is 100.1, rounded to the nearest 2.5kg
+		assert.Equal(t, float64(100), sets[0].Weight)
+	})
+
+	t.Run("Work out weight from RPE", func(t *testing.T) {
+		sets := workout.ExpandPrescription(workout.Prescription{
+			Sets: 1, MinReps: 6, MaxReps: 8, TargetRPE: 8,
+		}, 100)
+		// 8 reps at RPE 8 is a 10 rep max
+		assert.Equal(t, 75.0, sets[0].Weight)
+		assert.Equal(t, 8.0, sets[0].RPE)
+	})
+
+	t.Run("Leave weight empty without a 1RM", func(t *testing.T) {
+		sets := workout.ExpandPrescription(workout.Prescription{
+			Sets: 2, MinReps: 8, MaxReps: 12, PercentOneRM: 70,
+		}, 0)
+		assert.Equal(t, float64(0), sets[1].Weight)
+	})
+}
+
+func TestScaleTargetSets(t *testing.T) {
+	sets := workout.ExpandPrescription(workout.Prescription{Sets: 4, MinReps: 5, MaxReps: 5, TargetWeight: 100}, 0)
+
+	deload := workout.ScaleTargetSets(sets, 0.5, 0.9)
+	assert.Len(t, deload, 2)
+	assert.Equal(t, float64(90), deload[1].Weight)
+	assert.Equal(t, 2, deload[1].SetNumber)
+
+	overload := workout.ScaleTargetSets(sets, 1.25, 1)
+	assert.Len(t, overload, 5)
+	assert.Equal(t, float64(100), overload[4].Weight)
+}
@@ -3,6 +3,7 @@ package workoutPlan_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Mock service
@@ -44,6 +46,119 @@ func (m *MockWorkoutPlanService) GetWorkoutPlansByUser(userId string) ([]workout
 	return args.Get(0).([]workoutPlan.WorkoutPlan), args.Error(1)
 }
 
+func (m *MockWorkoutPlanService) MatchSession(userId string, workoutId string, sessionId string, performedAt time.Time) error {
+	args := m.Called(userId, workoutId, sessionId, performedAt)
+	return args.Error(0)
+}
+
+func (m *MockWorkoutPlanService) UnmatchSession(userId string, sessionId string) error {
+	args := m.Called(userId, sessionId)
+	return args.Error(0)
+}
+
+func (m *MockWorkoutPlanService) GetAdherence(userId string, startDate time.Time, endDate time.Time) (*workoutPlan.Adherence, error) {
+	args := m.Called(userId, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.Adherence), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) GetCalendar(userId string, startDate time.Time, endDate time.Time) ([]workoutPlan.CalendarDay, error) {
+	args := m.Called(userId, startDate, endDate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]workoutPlan.CalendarDay), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) MovePlanDate(id string, dto *workoutPlan.MovePlanDateDto, userId string) (*workoutPlan.WorkoutPlan, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.WorkoutPlan), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) SkipPlanDate(id string, dto *workoutPlan.SkipPlanDateDto, userId string) (*workoutPlan.WorkoutPlan, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.WorkoutPlan), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) ShiftPlans(dto *workoutPlan.ShiftPlansDto, userId string) ([]workoutPlan.WorkoutPlan, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]workoutPlan.WorkoutPlan), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) SwapPlanDays(dto *workoutPlan.SwapPlanDaysDto, userId string) ([]workoutPlan.WorkoutPlan, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]workoutPlan.WorkoutPlan), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) DeletePlan(id string, userId string) error {
+	args := m.Called(id, userId)
+	return args.Error(0)
+}
+
+func (m *MockWorkoutPlanService) GetCalendarFeed(userId string) (*workoutPlan.CalendarFeed, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.CalendarFeed), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) RotateCalendarFeed(userId string) (*workoutPlan.CalendarFeed, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.CalendarFeed), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) GetPlanCalendar(token string) (string, error) {
+	args := m.Called(token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) GetSessionHistoryCalendar(userId string) (string, error) {
+	args := m.Called(userId)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) PreviewPlanByDaysOfWeek(dto *workoutPlan.CreatePlanByDaysOfWeekDto, userId string) (*workoutPlan.PlanPreview, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.PlanPreview), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) PreviewPlanByCyclicWorkout(dto *workoutPlan.CreatePlanByCyclicWorkoutDto, userId string) (*workoutPlan.PlanPreview, error) {
+	args := m.Called(dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutPlan.PlanPreview), args.Error(1)
+}
+
+func (m *MockWorkoutPlanService) CommitPlanPreview(id string, userId string) ([]workoutPlan.WorkoutPlan, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]workoutPlan.WorkoutPlan), args.Error(1)
+}
+
 // TestMiddleware sets up the test context with a mock user
 func testMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -262,3 +377,312 @@ func TestGetWorkoutPlansHandler(t *testing.T) {
 		assert.Empty(t, result)
 	})
 }
+
+func TestGetAdherenceHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get adherence for a date range", func(t *testing.T) {
+		startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		endDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+		expected := &workoutPlan.Adherence{
+			StartDate:      startDate,
+			EndDate:        endDate,
+			Planned:        4,
+			Completed:      3,
+			Missed:         1,
+			CompletionRate: 0.75,
+			CurrentStreak:  2,
+			LongestStreak:  2,
+		}
+
+		mockService.On("GetAdherence", "test_user", startDate, endDate).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/workoutPlan/adherence?startDate=2024-01-01%2000:00:00&endDate=2024-01-31%2000:00:00", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutPlan.Adherence
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 0.75, result.CompletionRate)
+		assert.Equal(t, 2, result.CurrentStreak)
+	})
+
+	t.Run("Start date after end date", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/workoutPlan/adherence?startDate=2024-02-01%2000:00:00&endDate=2024-01-31%2000:00:00", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestGetCalendarHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get calendar for a date range", func(t *testing.T) {
+		startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		endDate := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+		expected := []workoutPlan.CalendarDay{
+			{
+				Date:      startDate,
+				Planned:   []workoutPlan.PlannedDay{{Date: startDate, WorkoutID: "workout", Status: workoutPlan.PlannedCompleted, SessionID: "session"}},
+				Performed: []workoutPlan.PerformedWorkout{{SessionID: "session", WorkoutID: "workout", StartTime: startDate}},
+			},
+		}
+
+		mockService.On("GetCalendar", "test_user", startDate, endDate).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/workoutPlan/calendar?startDate=2024-01-01%2000:00:00&endDate=2024-01-07%2000:00:00", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []workoutPlan.CalendarDay
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result, 1)
+		assert.Equal(t, workoutPlan.PlannedCompleted, result[0].Planned[0].Status)
+		assert.Equal(t, "session", result[0].Performed[0].SessionID)
+	})
+
+	t.Run("Missing dates", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/workoutPlan/calendar", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestMovePlanDateHandler(t *testing.T) {
+	app, mockService := setupTest()
+	planId := primitive.NewObjectID()
+
+	t.Run("Move a planned date", func(t *testing.T) {
+		dto := &workoutPlan.MovePlanDateDto{
+			From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		}
+		expected := &workoutPlan.WorkoutPlan{ID: planId, UserID: "test_user", Dates: []time.Time{dto.To}}
+		mockService.On("MovePlanDate", planId.Hex(), dto, "test_user").Return(expected, nil).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/workoutPlan/"+planId.Hex()+"/move", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Conflict with another workout", func(t *testing.T) {
+		dto := &workoutPlan.MovePlanDateDto{
+			From: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		}
+		mockService.On("MovePlanDate", planId.Hex(), dto, "test_user").Return(nil, fmt.Errorf("%w on 2024-01-04", workoutPlan.ErrScheduleConflict)).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/workoutPlan/"+planId.Hex()+"/move", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+}
+
+func TestShiftPlansHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Shift the schedule", func(t *testing.T) {
+		dto := &workoutPlan.ShiftPlansDto{Days: 7}
+		mockService.On("ShiftPlans", dto, "test_user").Return([]workoutPlan.WorkoutPlan{}, nil).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/workoutPlan/shift", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Missing number of days", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/api/v1/workoutPlan/shift", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestSwapPlanDaysHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Conflict with another workout", func(t *testing.T) {
+		dto := &workoutPlan.SwapPlanDaysDto{
+			First:  time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			Second: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		}
+		mockService.On("SwapPlanDays", dto, "test_user").Return(nil, fmt.Errorf("%w on 2024-01-04", workoutPlan.ErrScheduleConflict)).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("PUT", "/api/v1/workoutPlan/swap", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestDeletePlanHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Delete a plan", func(t *testing.T) {
+		planId := primitive.NewObjectID().Hex()
+		mockService.On("DeletePlan", planId, "test_user").Return(nil).Once()
+
+		req := httptest.NewRequest("DELETE", "/api/v1/workoutPlan/"+planId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("Plan not found", func(t *testing.T) {
+		planId := primitive.NewObjectID().Hex()
+		mockService.On("DeletePlan", planId, "test_user").Return(mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("DELETE", "/api/v1/workoutPlan/"+planId, nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCalendarFeedHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Get feed with its subscription URL", func(t *testing.T) {
+		mockService.On("GetCalendarFeed", "test_user").Return(&workoutPlan.CalendarFeed{UserID: "test_user", Token: "secret"}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/workoutPlan/feed", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutPlan.CalendarFeed
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, "secret", result.Token)
+		assert.Contains(t, result.URL, "/api/v1/workoutPlan/feed/secret/plan.ics")
+	})
+
+	t.Run("Export session history", func(t *testing.T) {
+		mockService.On("GetSessionHistoryCalendar", "test_user").Return("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/workoutPlan/history.ics", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+	})
+
+	t.Run("Serve the public feed by token", func(t *testing.T) {
+		public := fiber.New()
+		controller := &workoutPlan.CalendarFeedController{Instance: public.Group("/api/v1"), Service: mockService}
+		controller.Handle()
+
+		mockService.On("GetPlanCalendar", "secret").Return("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", nil).Once()
+		mockService.On("GetPlanCalendar", "unknown").Return("", mongo.ErrNoDocuments).Once()
+
+		resp, err := public.Test(httptest.NewRequest("GET", "/api/v1/workoutPlan/feed/secret/plan.ics", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		resp, err = public.Test(httptest.NewRequest("GET", "/api/v1/workoutPlan/feed/unknown/plan.ics", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestPreviewPlanHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Preview a cyclic plan", func(t *testing.T) {
+		dto := &workoutPlan.CreatePlanByCyclicWorkoutDto{
+			WorkoutIDs:    []string{primitive.NewObjectID().Hex()},
+			WeeksDuration: 1,
+		}
+		expected := &workoutPlan.PlanPreview{
+			ID:       primitive.NewObjectID(),
+			Revision: "revision",
+			Diff: workoutPlan.PlanDiff{
+				Added: []workoutPlan.PlanChange{{WorkoutID: dto.WorkoutIDs[0], Date: time.Now()}},
+			},
+		}
+		mockService.On("PreviewPlanByCyclicWorkout", dto, "test_user").Return(expected, nil).Once()
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("POST", "/api/v1/workoutPlan/byCyclicWorkout/preview", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutPlan.PlanPreview
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, expected.ID, result.ID)
+		assert.Len(t, result.Diff.Added, 1)
+	})
+
+	t.Run("Commit fails when plans changed", func(t *testing.T) {
+		previewId := primitive.NewObjectID().Hex()
+		mockService.On("CommitPlanPreview", previewId, "test_user").Return(nil, workoutPlan.ErrPlansChanged).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/workoutPlan/preview/"+previewId+"/commit", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Commit an expired preview", func(t *testing.T) {
+		previewId := primitive.NewObjectID().Hex()
+		mockService.On("CommitPlanPreview", previewId, "test_user").Return(nil, mongo.ErrNoDocuments).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/workoutPlan/preview/"+previewId+"/commit", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -2,9 +2,11 @@ package workoutPlan_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutPlan"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -227,3 +229,333 @@ func TestGetWorkoutPlansByUser(t *testing.T) {
 		assert.Empty(t, results)
 	})
 }
+
+func TestMatchDate(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 9, 0, 0, 0, time.UTC)
+	}
+	dates := []time.Time{day(1), day(3), day(5)}
+
+	t.Run("Match the closest planned date", func(t *testing.T) {
+		date, ok := workoutPlan.MatchDate(dates, nil, day(4).Add(10*time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, day(3), date)
+	})
+
+	t.Run("Skip dates that are already completed", func(t *testing.T) {
+		completions := []workoutPlan.PlanCompletion{{Date: day(3), SessionID: "session"}}
+		date, ok := workoutPlan.MatchDate(dates, completions, day(3))
+		assert.True(t, ok)
+		assert.Equal(t, day(1), date)
+	})
+
+	t.Run("No planned date within the tolerance", func(t *testing.T) {
+		_, ok := workoutPlan.MatchDate(dates, nil, day(9))
+		assert.False(t, ok)
+	})
+}
+
+func TestCalculateAdherence(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 9, 0, 0, 0, time.UTC)
+	}
+	plans := []workoutPlan.WorkoutPlan{
+		{
+			ID:        primitive.NewObjectID(),
+			WorkoutID: "a",
+			Dates:     []time.Time{day(1), day(3), day(5), day(7), day(9), day(11)},
+			Completions: []workoutPlan.PlanCompletion{
+				{Date: day(1), SessionID: "s1", PerformedAt: day(1)},
+				{Date: day(5), SessionID: "s2", PerformedAt: day(6)},
+				{Date: day(7), SessionID: "s3", PerformedAt: day(7)},
+			},
+		},
+	}
+
+	days := workoutPlan.PlannedDays(plans, day(1), day(31), day(11))
+	assert.Len(t, days, 6)
+	assert.Equal(t, workoutPlan.PlannedCompleted, days[0].Status)
+	assert.Equal(t, workoutPlan.PlannedMissed, days[1].Status)
+	assert.Equal(t, workoutPlan.PlannedLate, days[2].Status)
+	assert.Equal(t, workoutPlan.PlannedUpcoming, days[4].Status)
+
+	adherence := workoutPlan.CalculateAdherence(days, day(1), day(31))
+	assert.Equal(t, 4, adherence.Planned)
+	assert.Equal(t, 2, adherence.Completed)
+	assert.Equal(t, 1, adherence.Late)
+	assert.Equal(t, 2, adherence.Upcoming)
+	assert.Equal(t, 0.75, adherence.CompletionRate)
+	assert.Equal(t, 2, adherence.CurrentStreak)
+	assert.Equal(t, 2, adherence.LongestStreak)
+	assert.Len(t, adherence.MissedDays, 1)
+	assert.Equal(t, day(3), adherence.MissedDays[0].Date)
+
+	calendar := workoutPlan.BuildCalendar(days, []workoutPlan.PerformedWorkout{{SessionID: "s2", StartTime: day(6)}})
+	assert.Len(t, calendar, 7)
+	assert.Equal(t, "s2", calendar[3].Performed[0].SessionID)
+	assert.Empty(t, calendar[3].Planned)
+}
+
+func TestScheduleEdits(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 9, 0, 0, 0, time.UTC)
+	}
+	newPlans := func() []workoutPlan.WorkoutPlan {
+		return []workoutPlan.WorkoutPlan{
+			{
+				ID:          primitive.NewObjectID(),
+				WorkoutID:   "a",
+				Dates:       []time.Time{day(1), day(3), day(5)},
+				Completions: []workoutPlan.PlanCompletion{{Date: day(1), SessionID: "s1", PerformedAt: day(1)}},
+			},
+			{ID: primitive.NewObjectID(), WorkoutID: "b", Dates: []time.Time{day(2), day(4)}},
+		}
+	}
+
+	t.Run("Move a date and detect conflicts", func(t *testing.T) {
+		plans := newPlans()
+		assert.NoError(t, workoutPlan.MoveDate(&plans[0], day(3), day(4)))
+		assert.Equal(t, day(4), plans[0].Dates[1])
+
+		conflict, ok := workoutPlan.FindConflict(plans, []time.Time{day(4)})
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), conflict)
+	})
+
+	t.Run("Workouts in the AM and PM slots of a day do not conflict", func(t *testing.T) {
+		slot := func(d int, hour int) time.Time {
+			return time.Date(2024, 1, d, hour, 0, 0, 0, time.UTC)
+		}
+		plans := []workoutPlan.WorkoutPlan{
+			{ID: primitive.NewObjectID(), WorkoutID: "a", Dates: []time.Time{slot(3, workoutPlan.MorningSlotHour)}},
+			{ID: primitive.NewObjectID(), WorkoutID: "b", Dates: []time.Time{slot(4, workoutPlan.EveningSlotHour)}},
+		}
+		assert.NoError(t, workoutPlan.MoveDate(&plans[0], slot(3, workoutPlan.MorningSlotHour), slot(4, 0)))
+		_, ok := workoutPlan.FindConflict(plans, []time.Time{slot(4, 0)})
+		assert.False(t, ok)
+
+		plans[1].Dates[0] = slot(4, workoutPlan.MorningSlotHour)
+		_, ok = workoutPlan.FindConflict(plans, []time.Time{slot(4, 0)})
+		assert.True(t, ok)
+	})
+
+	t.Run("Completed and unplanned dates cannot be moved", func(t *testing.T) {
+		plans := newPlans()
+		assert.ErrorIs(t, workoutPlan.MoveDate(&plans[0], day(1), day(6)), workoutPlan.ErrDateCompleted)
+		assert.ErrorIs(t, workoutPlan.MoveDate(&plans[0], day(2), day(6)), workoutPlan.ErrDateNotPlanned)
+	})
+
+	t.Run("Skip a date", func(t *testing.T) {
+		plans := newPlans()
+		assert.NoError(t, workoutPlan.SkipDate(&plans[0], day(3)))
+		assert.Equal(t, []time.Time{day(1), day(5)}, plans[0].Dates)
+		assert.Equal(t, []time.Time{day(3)}, plans[0].Skipped)
+
+		days := workoutPlan.PlannedDays(plans[:1], day(1), day(31), day(10))
+		adherence := workoutPlan.CalculateAdherence(days, day(1), day(31))
+		assert.Equal(t, 1, adherence.Skipped)
+		assert.Equal(t, 2, adherence.Planned)
+	})
+
+	t.Run("Shift remaining dates", func(t *testing.T) {
+		plans := newPlans()
+		changed, landed, stayed := workoutPlan.ShiftDates(plans, day(3), 7)
+		assert.Equal(t, []int{0, 1}, changed)
+		assert.Len(t, landed, 3)
+		assert.Len(t, stayed, 2)
+		assert.Equal(t, []time.Time{day(1), day(10), day(12)}, plans[0].Dates)
+		assert.Equal(t, []time.Time{day(2), day(11)}, plans[1].Dates)
+
+		_, ok := workoutPlan.FindOverlap(landed, stayed)
+		assert.False(t, ok)
+
+		plans = newPlans()
+		_, landed, stayed = workoutPlan.ShiftDates(plans, day(3), -1)
+		conflict, ok := workoutPlan.FindOverlap(landed, stayed)
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), conflict)
+	})
+
+	t.Run("Swap two days", func(t *testing.T) {
+		plans := newPlans()
+		changed, err := workoutPlan.SwapDays(plans, day(3), day(4))
+		assert.NoError(t, err)
+		assert.Len(t, changed, 2)
+		assert.Equal(t, day(4), plans[0].Dates[1])
+		assert.Equal(t, day(3), plans[1].Dates[1])
+
+		_, err = workoutPlan.SwapDays(plans, day(1), day(2))
+		assert.ErrorIs(t, err, workoutPlan.ErrDateCompleted)
+	})
+}
+
+func TestRenderCalendar(t *testing.T) {
+	day := func(d int, hour int) time.Time {
+		return time.Date(2024, 1, d, hour, 0, 0, 0, time.UTC)
+	}
+	workoutId := primitive.NewObjectID()
+	exerciseId := primitive.NewObjectID().Hex()
+	workouts := map[string]*workout.Workout{
+		workoutId.Hex(): {
+			ID:   workoutId,
+			Name: "Push, heavy",
+			Exercises: []workout.WorkoutExercise{
+				{ExerciseID: exerciseId, Order: 0, Prescription: &workout.Prescription{Sets: 4, MinReps: 6, MaxReps: 8}},
+			},
+		},
+	}
+	names := map[string]string{exerciseId: "Bench Press"}
+
+	t.Run("Event UIDs only depend on the workout and the day", func(t *testing.T) {
+		before := []workoutPlan.WorkoutPlan{{ID: primitive.NewObjectID(), WorkoutID: workoutId.Hex(), Dates: []time.Time{day(1, 8), day(3, 8)}}}
+		after := []workoutPlan.WorkoutPlan{{ID: primitive.NewObjectID(), WorkoutID: workoutId.Hex(), Dates: []time.Time{day(3, 17)}}}
+
+		beforeEvents := workoutPlan.PlanEvents(before, workouts, names)
+		afterEvents := workoutPlan.PlanEvents(after, workouts, names)
+		assert.Len(t, beforeEvents, 2)
+		assert.Equal(t, beforeEvents[1].UID, afterEvents[0].UID)
+		assert.Contains(t, afterEvents[0].Description, "Bench Press: 4 x 6-8")
+	})
+
+	t.Run("Render escaped all-day events", func(t *testing.T) {
+		plans := []workoutPlan.WorkoutPlan{{WorkoutID: workoutId.Hex(), Dates: []time.Time{day(1, 8)}}}
+		calendar := workoutPlan.RenderCalendar("Planned workouts", workoutPlan.PlanEvents(plans, workouts, names), day(1, 0))
+
+		assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\n"))
+		assert.Contains(t, calendar, "SUMMARY:Push\\, heavy\r\n")
+		assert.Contains(t, calendar, "DTSTART;VALUE=DATE:20240101\r\n")
+		assert.Contains(t, calendar, "DTEND;VALUE=DATE:20240102\r\n")
+		assert.Contains(t, calendar, "UID:"+workoutPlan.PlanEventUID(workoutId.Hex(), day(1, 0)))
+		for _, line := range strings.Split(calendar, "\r\n") {
+			assert.LessOrEqual(t, len(line), 75)
+		}
+	})
+}
+
+func TestPlanPreview(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC) // A Wednesday
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC)
+	}
+	plans := []workoutPlan.WorkoutPlan{
+		{ID: primitive.NewObjectID(), UserID: "test_user", WorkoutID: "a", Dates: []time.Time{day(8), day(11)}},
+		{ID: primitive.NewObjectID(), UserID: "test_user", WorkoutID: "old", Dates: []time.Time{day(9), day(12)}},
+	}
+
+	workoutIds, workoutDates := workoutPlan.CyclicDates(&workoutPlan.CreatePlanByCyclicWorkoutDto{
+		WorkoutIDs:    []string{"a", "b"},
+		WeeksDuration: 1,
+	}, now)
+	assert.Len(t, workoutDates["a"], 4)
+	assert.Len(t, workoutDates["b"], 3)
+
+	after := workoutPlan.ApplySchedule(plans, "test_user", workoutIds, workoutDates, now)
+	assert.Len(t, after, 3)
+	assert.Equal(t, append([]time.Time{day(11)}, workoutDates["a"]...), after[0].Dates)
+	assert.Equal(t, []time.Time{day(9)}, after[1].Dates)
+	assert.Equal(t, "b", after[2].WorkoutID)
+
+	diff := workoutPlan.DiffPlans(plans, after, now)
+	assert.Len(t, diff.Added, 7)
+	assert.Len(t, diff.Removed, 2) // The past date of a and the upcoming date of old
+	assert.Len(t, diff.Unchanged, 1)
+	assert.Equal(t, day(11), diff.Unchanged[0].Date)
+
+	t.Run("Applying the diff gives the previewed plans", func(t *testing.T) {
+		applied, err := workoutPlan.ApplyDiff(plans, "test_user", diff, now)
+		assert.NoError(t, err)
+		assert.Len(t, applied, 3)
+		for i := range after {
+			assert.Equal(t, len(after[i].Dates), len(applied[i].Dates))
+			assert.ElementsMatch(t, after[i].Dates, applied[i].Dates)
+		}
+		assert.Len(t, plans[0].Dates, 2) // The plans themselves are left alone
+	})
+
+	t.Run("Revision changes with the schedule", func(t *testing.T) {
+		revision := workoutPlan.PlansRevision(plans)
+		assert.Equal(t, revision, workoutPlan.PlansRevision([]workoutPlan.WorkoutPlan{plans[1], plans[0]}))
+
+		changed := []workoutPlan.WorkoutPlan{plans[0], plans[1]}
+		changed[1].Dates = []time.Time{day(9)}
+		assert.NotEqual(t, revision, workoutPlan.PlansRevision(changed))
+	})
+
+	t.Run("Days of the week follow the weekday", func(t *testing.T) {
+		_, dates := workoutPlan.DaysOfWeekDates(&workoutPlan.CreatePlanByDaysOfWeekDto{
+			MondayWorkoutID: "mon", TuesdayWorkoutID: "tue", WednesdayWorkoutID: "wed", ThursdayWorkoutID: "thu",
+			FridayWorkoutID: "fri", SaturdayWorkoutID: "sat", SundayWorkoutID: "sun", WeeksDuration: 2,
+		}, now)
+		assert.Len(t, dates["wed"], 2)
+		assert.Equal(t, time.Wednesday, dates["wed"][0].Weekday())
+		assert.Equal(t, time.Monday, dates["mon"][0].Weekday())
+	})
+
+	t.Run("Empty days are rest days", func(t *testing.T) {
+		workoutIds, dates := workoutPlan.DaysOfWeekDates(&workoutPlan.CreatePlanByDaysOfWeekDto{
+			MondayWorkoutID: "mon", ThursdayWorkoutID: "thu", WeeksDuration: 2,
+		}, now)
+		assert.Equal(t, []string{"thu", "mon", workoutPlan.RestDayID}, workoutIds)
+		assert.Len(t, dates[workoutPlan.RestDayID], 10)
+		assert.Equal(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), dates[workoutPlan.RestDayID][0])
+
+		after := workoutPlan.ApplySchedule(nil, "user", workoutIds, dates, now)
+		assert.Len(t, after, 3)
+		assert.True(t, after[2].Rest)
+		assert.False(t, after[0].Rest)
+
+		again := workoutPlan.ApplySchedule(after, "user", workoutIds, dates, now)
+		assert.Len(t, again[2].Dates, 10)
+	})
+
+	t.Run("Days hold several workouts and repeat every few weeks", func(t *testing.T) {
+		workoutIds, dates := workoutPlan.DaysOfWeekDates(&workoutPlan.CreatePlanByDaysOfWeekDto{
+			Days: []workoutPlan.PlanDayDto{
+				{Day: time.Monday, Workouts: []workoutPlan.PlanDayWorkoutDto{
+					{WorkoutID: "run", Slot: workoutPlan.MorningSlot},
+					{WorkoutID: "lift", Slot: workoutPlan.EveningSlot},
+				}},
+				{Day: time.Wednesday, Workouts: []workoutPlan.PlanDayWorkoutDto{{WorkoutID: "long"}}, EveryWeeks: 2},
+			},
+			WeeksDuration: 4,
+		}, now)
+		assert.Equal(t, []string{"long", "run", "lift", workoutPlan.RestDayID}, workoutIds)
+		assert.Equal(t, time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC), dates["run"][0])
+		assert.Equal(t, time.Date(2024, 1, 15, 17, 0, 0, 0, time.UTC), dates["lift"][0])
+		assert.Equal(t, []time.Time{now, now.AddDate(0, 0, 14)}, dates["long"])
+		assert.Len(t, dates[workoutPlan.RestDayID], 28-4-2)
+	})
+
+	t.Run("Empty cyclic entries are rest days", func(t *testing.T) {
+		_, dates := workoutPlan.CyclicDates(&workoutPlan.CreatePlanByCyclicWorkoutDto{
+			WorkoutIDs: []string{"a", "b", ""}, WeeksDuration: 1,
+		}, now)
+		assert.Len(t, dates["a"], 3)
+		assert.Len(t, dates[workoutPlan.RestDayID], 2)
+	})
+}
+
+func TestRestDays(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	plans := []workoutPlan.WorkoutPlan{
+		{ID: primitive.NewObjectID(), WorkoutID: "a", Dates: []time.Time{day(1), day(3), day(5)}},
+		{ID: primitive.NewObjectID(), Rest: true, Dates: []time.Time{day(2), day(4)}},
+	}
+
+	days := workoutPlan.PlannedDays(plans, day(1), day(31), day(20))
+	assert.Len(t, days, 5)
+	assert.Equal(t, workoutPlan.PlannedRest, days[1].Status)
+
+	adherence := workoutPlan.CalculateAdherence(days, day(1), day(31))
+	assert.Equal(t, 2, adherence.RestDays)
+	assert.Equal(t, 3, adherence.Planned)
+	assert.Equal(t, 3, adherence.Missed)
+
+	_, ok := workoutPlan.FindConflict(append(plans, workoutPlan.WorkoutPlan{Rest: true, Dates: []time.Time{day(1)}}), []time.Time{day(1)})
+	assert.False(t, ok)
+
+	calendar := workoutPlan.BuildCalendar(days, nil)
+	assert.Len(t, calendar, 5)
+}
@@ -3,16 +3,21 @@ package workoutSession_test
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Mock service
@@ -81,6 +86,147 @@ func (m *MockWorkoutSessionService) LogSession(dto *workoutSession.LoggedSession
 	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
 }
 
+func (m *MockWorkoutSessionService) ReorderExercises(id string, dto *workoutSession.ReorderExercisesDto, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) CompleteExercise(id string, exerciseId string, dto *workoutSession.CompleteExerciseDto, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, exerciseId, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) SubscribeLive(id string, userId string) (*workoutSession.WorkoutSession, <-chan workoutSession.LiveEvent, func(), error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, nil, nil, args.Error(3)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Get(1).(chan workoutSession.LiveEvent), args.Get(2).(func()), args.Error(3)
+}
+
+func (m *MockWorkoutSessionService) RelayLiveEvent(id string, dto *workoutSession.LiveEventDto, userId string) (*workoutSession.LiveEvent, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.LiveEvent), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) PauseSession(id string, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) ResumeSession(id string, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) AbandonSession(id string, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) StartRest(id string, dto *workoutSession.StartRestDto, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) ExtendRest(id string, dto *workoutSession.ExtendRestDto, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) SkipRest(id string, userId string) (*workoutSession.WorkoutSession, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.WorkoutSession), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) GetRestDefaults(userId string) ([]*workoutSession.RestDefault, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*workoutSession.RestDefault), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) SetRestDefault(exerciseId string, dto *workoutSession.RestDefaultDto, userId string) (*workoutSession.RestDefault, error) {
+	args := m.Called(exerciseId, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.RestDefault), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) DeleteRestDefault(exerciseId string, userId string) error {
+	args := m.Called(exerciseId, userId)
+	return args.Error(0)
+}
+
+func (m *MockWorkoutSessionService) AddSet(id string, exerciseId string, dto *workoutSession.SessionSetDto, userId string) (*workoutSession.SetChange, error) {
+	args := m.Called(id, exerciseId, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.SetChange), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) UpdateSet(id string, exerciseId string, setNumber int, dto *workoutSession.SessionSetDto, userId string) (*workoutSession.SetChange, error) {
+	args := m.Called(id, exerciseId, setNumber, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.SetChange), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) DeleteSet(id string, exerciseId string, setNumber int, version *int64, userId string) (*workoutSession.SetChange, error) {
+	args := m.Called(id, exerciseId, setNumber, version, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.SetChange), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) GetSessionSummary(id string, userId string) (*workoutSession.SessionSummary, error) {
+	args := m.Called(id, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workoutSession.SessionSummary), args.Error(1)
+}
+
+func (m *MockWorkoutSessionService) SaveAsWorkout(id string, dto *workoutSession.SaveAsWorkoutDto, userId string) (*workout.Workout, error) {
+	args := m.Called(id, dto, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*workout.Workout), args.Error(1)
+}
+
 // TestMiddleware sets up the test context with a mock user
 func testMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -228,6 +374,33 @@ func TestUpdateSessionHandler(t *testing.T) {
 		assert.Equal(t, expectedResponse.Notes, result.Notes)
 		assert.Equal(t, len(expectedResponse.Exercises), len(result.Exercises))
 	})
+
+	t.Run("Conflict on outdated version", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		version := int64(2)
+		updateDto := workoutSession.UpdateWorkoutSessionDto{Notes: "From phone", Version: &version}
+		current := &workoutSession.WorkoutSession{ID: sessionId, UserID: "test_user", Notes: "From watch", Version: 3}
+
+		mockService.On("UpdateSession", sessionId.Hex(), mock.Anything, "test_user").
+			Return(nil, workoutSession.ErrVersionConflict)
+		mockService.On("GetSession", sessionId.Hex(), "test_user").Return(current, nil)
+
+		body, _ := json.Marshal(updateDto)
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex(), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+		var result struct {
+			Session workoutSession.WorkoutSession `json:"session"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, int64(3), result.Session.Version)
+		assert.Equal(t, "From watch", result.Session.Notes)
+	})
 }
 
 func TestGetSessionHandler(t *testing.T) {
@@ -396,4 +569,666 @@ func TestLogSessionHandler(t *testing.T) {
 		assert.Equal(t, expectedResponse.Notes, result.Notes)
 		assert.Equal(t, len(expectedResponse.Exercises), len(result.Exercises))
 	})
+	t.Run("Reject exercises split from their group", func(t *testing.T) {
+		logDto := workoutSession.LoggedSessionDto{
+			StartTime: time.Now().Add(-1 * time.Hour),
+			EndTime:   time.Now(),
+			Status:    workoutSession.StatusCompleted,
+			Exercises: []workoutSession.SessionExercise{
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 0, GroupID: "a"},
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 1},
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 2, GroupID: "a"},
+			},
+			Groups: []workout.ExerciseGroup{
+				{ID: "a", Type: workout.SupersetGroup, Rounds: 3},
+			},
+		}
+
+		body, _ := json.Marshal(logDto)
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/log", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestReorderExercisesHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully reorder exercises", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		reorderDto := workoutSession.ReorderExercisesDto{
+			Exercises: []workoutSession.ExerciseOrder{
+				{GroupID: "a", Order: 0},
+			},
+		}
+
+		expectedResponse := &workoutSession.WorkoutSession{
+			ID:     sessionId,
+			UserID: "test_user",
+			Exercises: []workoutSession.SessionExercise{
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 0, GroupID: "a"},
+				{ExerciseID: primitive.NewObjectID().Hex(), Order: 1, GroupID: "a"},
+			},
+		}
+
+		mockService.On("ReorderExercises", sessionId.Hex(),
+			mock.MatchedBy(func(dto *workoutSession.ReorderExercisesDto) bool {
+				return len(dto.Exercises) == 1 && dto.Exercises[0].GroupID == "a"
+			}),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(reorderDto)
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/reorder", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutSession.WorkoutSession
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 2, len(result.Exercises))
+	})
+
+	t.Run("Reject entry without exercise or group", func(t *testing.T) {
+		reorderDto := workoutSession.ReorderExercisesDto{
+			Exercises: []workoutSession.ExerciseOrder{{Order: 1}},
+		}
+
+		body, _ := json.Marshal(reorderDto)
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+primitive.NewObjectID().Hex()+"/reorder", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestCompleteExerciseHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully complete exercise", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		exerciseId := primitive.NewObjectID().Hex()
+		logId := primitive.NewObjectID().Hex()
+		expectedResponse := &workoutSession.WorkoutSession{
+			ID:        sessionId,
+			UserID:    "test_user",
+			Exercises: []workoutSession.SessionExercise{{ExerciseID: exerciseId, ExerciseLogID: logId}},
+			Version:   4,
+		}
+
+		mockService.On("CompleteExercise", sessionId.Hex(), exerciseId,
+			mock.MatchedBy(func(dto *workoutSession.CompleteExerciseDto) bool {
+				return dto.ExerciseLogID == logId
+			}),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(workoutSession.CompleteExerciseDto{ExerciseLogID: logId})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/exercises/"+exerciseId+"/complete", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutSession.WorkoutSession
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, int64(4), result.Version)
+	})
+}
+
+func TestLiveSessionHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Stream snapshot and events until the session ends", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		session := &workoutSession.WorkoutSession{ID: sessionId, UserID: "test_user", Status: workoutSession.StatusInProgress, Version: 2}
+
+		events := make(chan workoutSession.LiveEvent, 2)
+		events <- workoutSession.LiveEvent{Type: workoutSession.LiveNotesUpdated, SessionID: sessionId.Hex(), Version: 3, Data: "Felt strong"}
+		events <- workoutSession.LiveEvent{Type: workoutSession.LiveSessionEnded, SessionID: sessionId.Hex(), Version: 4}
+		unsubscribed := false
+		unsubscribe := func() { unsubscribed = true }
+
+		mockService.On("SubscribeLive", sessionId.Hex(), "test_user").Return(session, events, unsubscribe, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/workout-session/"+sessionId.Hex()+"/live", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(resp.Body)
+		stream := string(body)
+		assert.Contains(t, stream, "id: 2\nevent: snapshot\n")
+		assert.Contains(t, stream, "id: 3\nevent: notes_updated\n")
+		assert.Contains(t, stream, "id: 4\nevent: session_ended\n")
+		assert.True(t, strings.Index(stream, "snapshot") < strings.Index(stream, "notes_updated"))
+		assert.True(t, unsubscribed)
+	})
+
+	t.Run("Conflict when session is not in progress", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		mockService.On("SubscribeLive", sessionId.Hex(), "test_user").
+			Return(nil, nil, nil, workoutSession.ErrSessionNotInProgress)
+
+		req := httptest.NewRequest("GET", "/api/v1/workout-session/"+sessionId.Hex()+"/live", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+}
+
+func TestRelayLiveEventHandler(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully relay rest timer", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		dto := workoutSession.LiveEventDto{
+			Type: workoutSession.LiveRestTimerStarted,
+			Data: map[string]interface{}{"seconds": 90},
+		}
+		expectedResponse := &workoutSession.LiveEvent{Type: dto.Type, SessionID: sessionId.Hex(), Version: 5, Data: dto.Data}
+
+		mockService.On("RelayLiveEvent", sessionId.Hex(),
+			mock.MatchedBy(func(d *workoutSession.LiveEventDto) bool {
+				return d.Type == workoutSession.LiveRestTimerStarted
+			}),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(dto)
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+sessionId.Hex()+"/live/events", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+
+		var result workoutSession.LiveEvent
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, int64(5), result.Version)
+	})
+
+	t.Run("Reject events that change the session", func(t *testing.T) {
+		body, _ := json.Marshal(workoutSession.LiveEventDto{Type: workoutSession.LiveNotesUpdated})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+primitive.NewObjectID().Hex()+"/live/events", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestSessionStatusHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully pause session", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		pausedAt := time.Now()
+		expectedResponse := &workoutSession.WorkoutSession{
+			ID:         sessionId,
+			UserID:     "test_user",
+			Status:     workoutSession.StatusPaused,
+			ActiveTime: 600,
+			PausedAt:   &pausedAt,
+		}
+		mockService.On("PauseSession", sessionId.Hex(), "test_user").Return(expectedResponse, nil)
+
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/pause", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutSession.WorkoutSession
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, workoutSession.StatusPaused, result.Status)
+		assert.Equal(t, 600, result.ActiveTime)
+	})
+
+	t.Run("Resume a session that is not paused", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		mockService.On("ResumeSession", sessionId.Hex(), "test_user").Return(nil, workoutSession.ErrSessionNotPaused)
+
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/resume", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Successfully abandon session", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		expectedResponse := &workoutSession.WorkoutSession{
+			ID:       sessionId,
+			UserID:   "test_user",
+			Status:   workoutSession.StatusAbandoned,
+			Duration: 300,
+		}
+		mockService.On("AbandonSession", sessionId.Hex(), "test_user").Return(expectedResponse, nil)
+
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/abandon", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutSession.WorkoutSession
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, workoutSession.StatusAbandoned, result.Status)
+	})
+
+	t.Run("End a session that already ended", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		mockService.On("EndSession", sessionId.Hex(), "test_user").Return(nil, workoutSession.ErrSessionEnded)
+
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/end", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Reject reopening an ended session through update", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		mockService.On("UpdateSession", sessionId.Hex(), mock.Anything, "test_user").Return(nil, workoutSession.ErrSessionEnded)
+
+		body, _ := json.Marshal(workoutSession.UpdateWorkoutSessionDto{Status: workoutSession.StatusInProgress})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex(), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Reject an unknown status", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+
+		body, _ := json.Marshal(workoutSession.UpdateWorkoutSessionDto{Status: "finished"})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex(), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestRestTimerHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully start rest", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		exerciseId := primitive.NewObjectID().Hex()
+		timer := workoutSession.NewRestTimer(exerciseId, 1, 120, workoutSession.PrescriptionRest, time.Now())
+		expectedResponse := &workoutSession.WorkoutSession{ID: sessionId, UserID: "test_user", RestTimer: timer}
+
+		mockService.On("StartRest", sessionId.Hex(),
+			mock.MatchedBy(func(dto *workoutSession.StartRestDto) bool {
+				return dto.ExerciseID == exerciseId && dto.SetNumber == 1 && dto.Seconds == 0
+			}),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(workoutSession.StartRestDto{ExerciseID: exerciseId, SetNumber: 1})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/rest/start", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutSession.WorkoutSession
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 120, result.RestTimer.TargetSeconds)
+		assert.Equal(t, workoutSession.PrescriptionRest, result.RestTimer.Source)
+	})
+
+	t.Run("Reject start without set number", func(t *testing.T) {
+		body, _ := json.Marshal(workoutSession.StartRestDto{ExerciseID: primitive.NewObjectID().Hex()})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+primitive.NewObjectID().Hex()+"/rest/start", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Extend without a running rest", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		mockService.On("ExtendRest", sessionId.Hex(), mock.Anything, "test_user").Return(nil, workoutSession.ErrNoRestTimer)
+
+		body, _ := json.Marshal(workoutSession.ExtendRestDto{Seconds: 30})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/rest/extend", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Successfully skip rest", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		now := time.Now()
+		timer := workoutSession.NewRestTimer("squat", 1, 90, workoutSession.DefaultRest, now.Add(-time.Minute))
+		timer.StoppedAt = &now
+		mockService.On("SkipRest", sessionId.Hex(), "test_user").Return(&workoutSession.WorkoutSession{ID: sessionId, RestTimer: timer}, nil)
+
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/rest/skip", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result workoutSession.WorkoutSession
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.NotNil(t, result.RestTimer.StoppedAt)
+	})
+}
+
+func TestRestDefaultHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully set rest default", func(t *testing.T) {
+		exerciseId := primitive.NewObjectID().Hex()
+		expectedResponse := &workoutSession.RestDefault{UserID: "test_user", ExerciseID: exerciseId, Seconds: 180}
+		mockService.On("SetRestDefault", exerciseId,
+			mock.MatchedBy(func(dto *workoutSession.RestDefaultDto) bool { return dto.Seconds == 180 }),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(workoutSession.RestDefaultDto{Seconds: 180})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/rest-defaults/"+exerciseId, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Get rest defaults", func(t *testing.T) {
+		mockService.On("GetRestDefaults", "test_user").Return([]*workoutSession.RestDefault{{ExerciseID: "squat", Seconds: 180}}, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/workout-session/rest-defaults", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result []workoutSession.RestDefault
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("Delete missing rest default", func(t *testing.T) {
+		mockService.On("DeleteRestDefault", "bench", "test_user").Return(mongo.ErrNoDocuments)
+
+		req := httptest.NewRequest("DELETE", "/api/v1/workout-session/rest-defaults/bench", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestSessionSetHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Successfully log set", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		exerciseId := primitive.NewObjectID().Hex()
+		log := &exerciseLog.ExerciseLog{
+			ID:          primitive.NewObjectID(),
+			ExerciseID:  exerciseId,
+			TotalVolume: 500,
+			Sets:        []exerciseLog.SetLog{{Weight: 100, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet}},
+		}
+		expectedResponse := &workoutSession.SetChange{
+			Session: &workoutSession.WorkoutSession{
+				ID:          sessionId,
+				Exercises:   []workoutSession.SessionExercise{{ExerciseID: exerciseId, ExerciseLogID: log.ID.Hex()}},
+				TotalVolume: 500,
+			},
+			Log: log,
+		}
+
+		mockService.On("AddSet", sessionId.Hex(), exerciseId,
+			mock.MatchedBy(func(dto *workoutSession.SessionSetDto) bool {
+				return dto.Weight == 100 && dto.Reps == 5
+			}),
+			"test_user",
+		).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(workoutSession.SessionSetDto{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+sessionId.Hex()+"/exercises/"+exerciseId+"/sets", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var result workoutSession.SetChange
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, 500.0, result.Session.TotalVolume)
+		assert.Len(t, result.Log.Sets, 1)
+	})
+
+	t.Run("Reject set with invalid tempo", func(t *testing.T) {
+		body, _ := json.Marshal(workoutSession.SessionSetDto{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet, Tempo: "fast"})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+primitive.NewObjectID().Hex()+"/exercises/squat/sets", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Update missing set", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		mockService.On("UpdateSet", sessionId.Hex(), "squat", 3, mock.Anything, "test_user").Return(nil, workoutSession.ErrSetNotFound)
+
+		body, _ := json.Marshal(workoutSession.SessionSetDto{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet})
+		req := httptest.NewRequest("PUT", "/api/v1/workout-session/"+sessionId.Hex()+"/exercises/squat/sets/3", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Delete set on an outdated version", func(t *testing.T) {
+		sessionId := primitive.NewObjectID()
+		current := &workoutSession.WorkoutSession{ID: sessionId, Version: 8}
+		mockService.On("DeleteSet", sessionId.Hex(), "squat", 2,
+			mock.MatchedBy(func(version *int64) bool { return version != nil && *version == 7 }),
+			"test_user",
+		).Return(nil, workoutSession.ErrVersionConflict)
+		mockService.On("GetSession", sessionId.Hex(), "test_user").Return(current, nil)
+
+		req := httptest.NewRequest("DELETE", "/api/v1/workout-session/"+sessionId.Hex()+"/exercises/squat/sets/2?version=7", nil)
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+}
+
+func TestGetSessionSummaryHandler(t *testing.T) {
+	app, mockService := setupTest()
+	sessionId := primitive.NewObjectID().Hex()
+	summary := &workoutSession.SessionSummary{
+		SessionID:   sessionId,
+		Title:       "Push",
+		StartTime:   time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC),
+		Duration:    3000,
+		TotalVolume: 4200,
+		TotalSets:   12,
+	}
+	mockService.On("GetSessionSummary", sessionId, "test_user").Return(summary, nil)
+
+	request := func(query string) (int, string, string) {
+		req := httptest.NewRequest("GET", "/api/v1/workout-session/"+sessionId+"/summary"+query, nil)
+		req.Header.Set("userid", "test_user")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	t.Run("JSON by default", func(t *testing.T) {
+		status, _, body := request("")
+		assert.Equal(t, fiber.StatusOK, status)
+
+		var result workoutSession.SessionSummary
+		json.Unmarshal([]byte(body), &result)
+		assert.Equal(t, 4200.0, result.TotalVolume)
+	})
+
+	t.Run("Markdown card", func(t *testing.T) {
+		status, contentType, body := request("?format=markdown")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Contains(t, contentType, "text/markdown")
+		assert.Contains(t, body, "## Push")
+	})
+
+	t.Run("Image card", func(t *testing.T) {
+		status, contentType, body := request("?format=svg")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "image/svg+xml", contentType)
+		assert.True(t, strings.HasPrefix(body, "<svg"))
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		status, _, _ := request("?format=pdf")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("Missing session", func(t *testing.T) {
+		missingId := primitive.NewObjectID().Hex()
+		mockService.On("GetSessionSummary", missingId, "test_user").Return(nil, mongo.ErrNoDocuments)
+
+		req := httptest.NewRequest("GET", "/api/v1/workout-session/"+missingId+"/summary", nil)
+		req.Header.Set("userid", "test_user")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestRepeatSessionHandlers(t *testing.T) {
+	app, mockService := setupTest()
+
+	t.Run("Start from a past session", func(t *testing.T) {
+		sourceId := primitive.NewObjectID().Hex()
+		mockService.On("StartSession", mock.MatchedBy(func(dto *workoutSession.CreateWorkoutSessionDto) bool {
+			return dto.FromSessionID == sourceId
+		}), "test_user").Return(&workoutSession.WorkoutSession{ID: primitive.NewObjectID(), RepeatedFrom: sourceId}, nil)
+
+		body, _ := json.Marshal(workoutSession.CreateWorkoutSessionDto{Type: workoutSession.CustomSession, FromSessionID: sourceId})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("Repeating an ongoing session conflicts", func(t *testing.T) {
+		sourceId := primitive.NewObjectID().Hex()
+		mockService.On("StartSession", mock.MatchedBy(func(dto *workoutSession.CreateWorkoutSessionDto) bool {
+			return dto.FromSessionID == sourceId
+		}), "test_user").Return(nil, workoutSession.ErrRepeatOngoing)
+
+		body, _ := json.Marshal(workoutSession.CreateWorkoutSessionDto{Type: workoutSession.CustomSession, FromSessionID: sourceId})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Save as workout", func(t *testing.T) {
+		sessionId := primitive.NewObjectID().Hex()
+		mockService.On("SaveAsWorkout", sessionId, &workoutSession.SaveAsWorkoutDto{Name: "Tuesday"}, "test_user").
+			Return(&workout.Workout{ID: primitive.NewObjectID(), Name: "Tuesday"}, nil)
+
+		body, _ := json.Marshal(workoutSession.SaveAsWorkoutDto{Name: "Tuesday"})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+sessionId+"/workout", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var result workout.Workout
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Equal(t, "Tuesday", result.Name)
+	})
+
+	t.Run("Save without a name", func(t *testing.T) {
+		body, _ := json.Marshal(workoutSession.SaveAsWorkoutDto{})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+primitive.NewObjectID().Hex()+"/workout", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Planned sessions are not saved", func(t *testing.T) {
+		sessionId := primitive.NewObjectID().Hex()
+		mockService.On("SaveAsWorkout", sessionId, mock.Anything, "test_user").Return(nil, workoutSession.ErrNotSavable)
+
+		body, _ := json.Marshal(workoutSession.SaveAsWorkoutDto{Name: "Push"})
+		req := httptest.NewRequest("POST", "/api/v1/workout-session/"+sessionId+"/workout", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("userid", "test_user")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
 }
@@ -2,9 +2,15 @@ package workoutSession_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
@@ -76,6 +82,47 @@ func TestStartSession(t *testing.T) {
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "already has an ongoing session")
 	})
+
+	t.Run("Expand prescriptions into target sets", func(t *testing.T) {
+		userId := "prescription_user"
+		exerciseId := primitive.NewObjectID().Hex()
+
+		// Latest log estimates a 1RM of 100kg
+		_, err := db.Collection("exerciseLogs").InsertOne(context.Background(), exerciseLog.ExerciseLog{
+			UserID:     userId,
+			ExerciseID: exerciseId,
+			DateTime:   time.Now().Add(-24 * time.Hour),
+			Sets: []exerciseLog.SetLog{
+				{Weight: 100, Reps: 1, SetNumber: 1, Type: exerciseLog.WorkingSet},
+			},
+		})
+		assert.NoError(t, err)
+
+		workoutResult, err := db.Collection("workout").InsertOne(context.Background(), workout.Workout{
+			UserID: userId,
+			Name:   "Prescribed Workout",
+			Exercises: []workout.WorkoutExercise{
+				{
+					ExerciseID: exerciseId,
+					Order:      0,
+					Prescription: &workout.Prescription{
+						Sets: 4, MinReps: 8, MaxReps: 8, PercentOneRM: 70, RestSeconds: 90,
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		result, err := service.StartSession(&workoutSession.CreateWorkoutSessionDto{
+			WorkoutID: workoutResult.InsertedID.(primitive.ObjectID).Hex(),
+			Type:      workoutSession.PlannedSession,
+		}, userId)
+		assert.NoError(t, err)
+		assert.Len(t, result.Exercises, 1)
+		assert.Len(t, result.Exercises[0].TargetSets, 4)
+		assert.Equal(t, float64(70), result.Exercises[0].TargetSets[0].Weight)
+		assert.Equal(t, 90, result.Exercises[0].TargetSets[3].RestSeconds)
+	})
 }
 
 func TestEndSession(t *testing.T) {
@@ -109,6 +156,33 @@ func TestEndSession(t *testing.T) {
 		assert.NotNil(t, result.EndTime)
 	})
 
+	t.Run("Volume is that of the linked logs", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		logs := []interface{}{
+			&exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), UserID: userId, ExerciseID: "squat", TotalVolume: 500},
+			&exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), UserID: userId, ExerciseID: "bench", TotalVolume: 300},
+		}
+		_, err := db.Collection("exerciseLogs").InsertMany(context.Background(), logs)
+		assert.NoError(t, err)
+
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			StartTime: time.Now().Add(-1 * time.Hour),
+			Status:    workoutSession.StatusInProgress,
+			Exercises: []workoutSession.SessionExercise{
+				{ExerciseID: "squat", ExerciseLogID: logs[0].(*exerciseLog.ExerciseLog).ID.Hex()},
+				{ExerciseID: "bench", ExerciseLogID: logs[1].(*exerciseLog.ExerciseLog).ID.Hex()},
+			},
+		}
+		_, err = db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		result, err := service.EndSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 800.0, result.TotalVolume)
+	})
+
 	t.Run("Fail to end non-existent session", func(t *testing.T) {
 		userId := "test_user"
 		nonExistentId := primitive.NewObjectID().Hex()
@@ -154,6 +228,97 @@ func TestUpdateSession(t *testing.T) {
 		assert.Equal(t, len(updateDto.Exercises), len(result.Exercises))
 	})
 
+	t.Run("A new status goes through its transition", func(t *testing.T) {
+		userId := "status_user"
+		start := time.Now().Add(-1 * time.Hour)
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: start,
+			ResumedAt: &start,
+			Status:    workoutSession.StatusInProgress,
+			Version:   1,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		result, err := service.UpdateSession(session.ID.Hex(), &workoutSession.UpdateWorkoutSessionDto{Status: workoutSession.StatusCompleted, Notes: "Done"}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusCompleted, result.Status)
+		assert.Equal(t, "Done", result.Notes)
+		assert.InDelta(t, 3600, result.Duration, 5)
+
+		_, err = service.UpdateSession(session.ID.Hex(), &workoutSession.UpdateWorkoutSessionDto{Status: workoutSession.StatusInProgress}, userId)
+		assert.ErrorIs(t, err, workoutSession.ErrSessionEnded)
+	})
+
+	t.Run("Linked logs must be the user's logs of the exercise", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		own := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), UserID: userId, ExerciseID: "squat", TotalVolume: 500}
+		foreign := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), UserID: "other_user", ExerciseID: "squat", TotalVolume: 800}
+		_, err := db.Collection("exerciseLogs").InsertMany(context.Background(), []interface{}{own, foreign})
+		assert.NoError(t, err)
+
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: time.Now(),
+			Status:    workoutSession.StatusInProgress,
+		}
+		_, err = db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		for _, linked := range []workoutSession.SessionExercise{
+			{ExerciseID: "squat", ExerciseLogID: foreign.ID.Hex()},
+			{ExerciseID: "bench", ExerciseLogID: own.ID.Hex()},
+			{ExerciseID: "squat", ExerciseLogID: primitive.NewObjectID().Hex()},
+		} {
+			_, err := service.UpdateSession(session.ID.Hex(), &workoutSession.UpdateWorkoutSessionDto{Exercises: []workoutSession.SessionExercise{linked}}, userId)
+			assert.ErrorIs(t, err, workoutSession.ErrInvalidLinkedLog)
+		}
+
+		result, err := service.UpdateSession(session.ID.Hex(), &workoutSession.UpdateWorkoutSessionDto{
+			Exercises: []workoutSession.SessionExercise{{ExerciseID: "squat", ExerciseLogID: own.ID.Hex()}},
+		}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 500.0, result.TotalVolume)
+	})
+
+	t.Run("Content and status are written in one versioned update", func(t *testing.T) {
+		userId := "pause_user"
+		start := time.Now().Add(-30 * time.Minute)
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: start,
+			ResumedAt: &start,
+			Status:    workoutSession.StatusInProgress,
+			Version:   1,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		stale := int64(0)
+		_, err = service.UpdateSession(session.ID.Hex(), &workoutSession.UpdateWorkoutSessionDto{Status: workoutSession.StatusPaused, Notes: "Break", Version: &stale}, userId)
+		assert.ErrorIs(t, err, workoutSession.ErrVersionConflict)
+
+		unchanged, err := service.GetSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusInProgress, unchanged.Status)
+		assert.Empty(t, unchanged.Notes)
+
+		version := int64(1)
+		result, err := service.UpdateSession(session.ID.Hex(), &workoutSession.UpdateWorkoutSessionDto{Status: workoutSession.StatusPaused, Notes: "Break", Version: &version}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusPaused, result.Status)
+		assert.Equal(t, "Break", result.Notes)
+		assert.Equal(t, int64(2), result.Version)
+		assert.NotNil(t, result.PausedAt)
+	})
+
 	t.Run("Error - Non-Existing Session", func(t *testing.T) {
 		updateDto := &workoutSession.UpdateWorkoutSessionDto{
 			Status: workoutSession.StatusInProgress,
@@ -272,6 +437,41 @@ func TestDeleteSession(t *testing.T) {
 		err = db.Collection("workoutSessions").FindOne(context.Background(), bson.D{{Key: "_id", Value: session.ID}}).Decode(&deletedSession)
 		assert.Equal(t, mongo.ErrNoDocuments, err)
 	})
+
+	t.Run("Linked logs go with the session unless another session links them", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		own := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), UserID: userId, ExerciseID: "squat", TotalVolume: 500}
+		shared := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), UserID: userId, ExerciseID: "bench", TotalVolume: 300}
+		_, err := db.Collection("exerciseLogs").InsertMany(context.Background(), []interface{}{own, shared})
+		assert.NoError(t, err)
+
+		session := &workoutSession.WorkoutSession{
+			ID:     primitive.NewObjectID(),
+			UserID: userId,
+			Status: workoutSession.StatusCompleted,
+			Exercises: []workoutSession.SessionExercise{
+				{ExerciseID: "squat", ExerciseLogID: own.ID.Hex()},
+				{ExerciseID: "bench", ExerciseLogID: shared.ID.Hex()},
+			},
+		}
+		other := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Status:    workoutSession.StatusCompleted,
+			Exercises: []workoutSession.SessionExercise{{ExerciseID: "bench", ExerciseLogID: shared.ID.Hex()}},
+		}
+		_, err = db.Collection("workoutSessions").InsertMany(context.Background(), []interface{}{session, other})
+		assert.NoError(t, err)
+
+		assert.NoError(t, service.DeleteSession(session.ID.Hex(), userId))
+
+		count, err := db.Collection("exerciseLogs").CountDocuments(context.Background(), bson.D{{Key: "_id", Value: own.ID}})
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+		count, err = db.Collection("exerciseLogs").CountDocuments(context.Background(), bson.D{{Key: "_id", Value: shared.ID}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
 }
 
 func TestLogSession(t *testing.T) {
@@ -306,5 +506,712 @@ func TestLogSession(t *testing.T) {
 		assert.Equal(t, endTime.Unix(), result.EndTime.Unix())
 		assert.Equal(t, logDto.Notes, result.Notes)
 		assert.Equal(t, len(logDto.Exercises), len(result.Exercises))
+		assert.InDelta(t, 3600, result.Duration, 1)
+	})
+
+	t.Run("Durations kept in minutes move to seconds", func(t *testing.T) {
+		start := time.Date(2024, time.May, 6, 18, 0, 0, 0, time.UTC)
+		legacy := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    "legacy_user",
+			Type:      workoutSession.LoggedSession,
+			Status:    workoutSession.StatusCompleted,
+			StartTime: start,
+			EndTime:   start.Add(65 * time.Minute),
+			Duration:  65,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), legacy)
+		assert.NoError(t, err)
+
+		migrated, err := service.MigrateLoggedDurations()
+		assert.NoError(t, err)
+		assert.NotZero(t, migrated)
+
+		result, err := service.GetSession(legacy.ID.Hex(), "legacy_user")
+		assert.NoError(t, err)
+		assert.Equal(t, 65*60, result.Duration)
+
+		migrated, err = service.MigrateLoggedDurations()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, migrated)
+	})
+}
+
+func TestReorderSessionExercises(t *testing.T) {
+	exercises := []workoutSession.SessionExercise{
+		{ExerciseID: "squat", Order: 0},
+		{ExerciseID: "bench", Order: 1, GroupID: "a"},
+		{ExerciseID: "row", Order: 2, GroupID: "a"},
+		{ExerciseID: "curl", Order: 3},
+	}
+
+	t.Run("Move a group as one unit", func(t *testing.T) {
+		result, err := workoutSession.ReorderSessionExercises(exercises, []workoutSession.ExerciseOrder{
+			{GroupID: "a", Order: 0},
+			{ExerciseID: "curl", Order: 1},
+		})
+		assert.NoError(t, err)
+
+		ids := make([]string, len(result))
+		for i, exercise := range result {
+			ids[i] = exercise.ExerciseID
+			assert.Equal(t, i, exercise.Order)
+		}
+		assert.Equal(t, []string{"bench", "row", "curl", "squat"}, ids)
+	})
+
+	t.Run("Reject moving a grouped exercise on its own", func(t *testing.T) {
+		_, err := workoutSession.ReorderSessionExercises(exercises, []workoutSession.ExerciseOrder{
+			{ExerciseID: "row", Order: 0},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Reject unknown group", func(t *testing.T) {
+		_, err := workoutSession.ReorderSessionExercises(exercises, []workoutSession.ExerciseOrder{
+			{GroupID: "b", Order: 0},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestSummariseGroups(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	exercises := []workoutSession.SessionExercise{
+		{ExerciseID: "pushup", ExerciseLogID: "log1", Order: 0, GroupID: "circuit"},
+		{ExerciseID: "squat", ExerciseLogID: "log2", Order: 1, GroupID: "circuit"},
+	}
+	groups := []workout.ExerciseGroup{{ID: "circuit", Type: workout.CircuitGroup, Rounds: 3, RestBetweenRounds: 60}}
+	logs := map[string]*exerciseLog.ExerciseLog{
+		"log1": {TotalVolume: 300, Sets: []exerciseLog.SetLog{
+			{Reps: 10, Type: exerciseLog.WorkingSet, Round: 1, CompletedAt: start},
+			{Reps: 10, Type: exerciseLog.WorkingSet, Round: 2, CompletedAt: start.Add(3 * time.Minute)},
+			{Reps: 10, Type: exerciseLog.WorkingSet, Round: 3, CompletedAt: start.Add(6 * time.Minute)},
+		}},
+		"log2": {TotalVolume: 1000, Sets: []exerciseLog.SetLog{
+			{Reps: 10, Type: exerciseLog.WorkingSet, Round: 1, CompletedAt: start.Add(time.Minute)},
+			{Reps: 10, Type: exerciseLog.WorkingSet, Round: 2, CompletedAt: start.Add(4 * time.Minute)},
+		}},
+	}
+
+	summaries := workoutSession.SummariseGroups(exercises, groups, logs)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, "circuit", summaries[0].GroupID)
+	assert.Equal(t, 2, summaries[0].CompletedRounds)
+	assert.Equal(t, float64(1300), summaries[0].TotalVolume)
+	assert.Equal(t, 360, summaries[0].Duration)
+
+	t.Run("A skipped exercise completes no round", func(t *testing.T) {
+		skipped := append(exercises, workoutSession.SessionExercise{ExerciseID: "row", Order: 2, GroupID: "circuit"})
+		summaries := workoutSession.SummariseGroups(skipped, groups, logs)
+		assert.Equal(t, 0, summaries[0].CompletedRounds)
+		assert.Equal(t, float64(1300), summaries[0].TotalVolume)
+	})
+}
+
+func TestLiveHub(t *testing.T) {
+	t.Run("Publish reaches subscribers of the session only", func(t *testing.T) {
+		hub := workoutSession.NewLiveHub()
+		events, unsubscribe := hub.Subscribe("a")
+		other, unsubscribeOther := hub.Subscribe("b")
+		defer unsubscribeOther()
+
+		hub.Publish(workoutSession.LiveEvent{Type: workoutSession.LiveRestTimerStarted, SessionID: "a", Version: 1})
+
+		event := <-events
+		assert.Equal(t, workoutSession.LiveRestTimerStarted, event.Type)
+		assert.False(t, event.At.IsZero())
+		assert.Len(t, other, 0)
+
+		unsubscribe()
+		_, open := <-events
+		assert.False(t, open)
+		assert.Equal(t, 0, hub.Subscribers("a"))
+		unsubscribe()
+	})
+
+	t.Run("Drop subscribers that fall behind", func(t *testing.T) {
+		hub := workoutSession.NewLiveHub()
+		events, unsubscribe := hub.Subscribe("a")
+		defer unsubscribe()
+
+		for i := 0; i <= workoutSession.LiveSubscriberBuffer; i++ {
+			hub.Publish(workoutSession.LiveEvent{Type: workoutSession.LiveSessionUpdated, SessionID: "a", Version: int64(i)})
+		}
+
+		assert.Equal(t, 0, hub.Subscribers("a"))
+		received := 0
+		for range events {
+			received++
+		}
+		assert.Equal(t, workoutSession.LiveSubscriberBuffer, received)
+	})
+}
+
+func TestChangeEvents(t *testing.T) {
+	before := &workoutSession.WorkoutSession{
+		ID: primitive.NewObjectID(),
+		Exercises: []workoutSession.SessionExercise{
+			{ExerciseID: "squat", Order: 0},
+			{ExerciseID: "bench", Order: 1},
+		},
+		Notes: "",
+	}
+
+	t.Run("No events without changes", func(t *testing.T) {
+		assert.Empty(t, workoutSession.ChangeEvents(before, before))
+	})
+
+	t.Run("Completion, reorder and notes", func(t *testing.T) {
+		after := &workoutSession.WorkoutSession{
+			ID: before.ID,
+			Exercises: []workoutSession.SessionExercise{
+				{ExerciseID: "squat", Order: 1, ExerciseLogID: "log1"},
+				{ExerciseID: "bench", Order: 0},
+			},
+			Notes:   "Deload week",
+			Version: 7,
+		}
+
+		events := workoutSession.ChangeEvents(before, after)
+		assert.Len(t, events, 3)
+		assert.Equal(t, workoutSession.LiveExerciseCompleted, events[0].Type)
+		assert.Equal(t, workoutSession.LiveExercisesReordered, events[1].Type)
+		assert.Equal(t, workoutSession.LiveNotesUpdated, events[2].Type)
+		for _, event := range events {
+			assert.Equal(t, int64(7), event.Version)
+			assert.Equal(t, before.ID.Hex(), event.SessionID)
+		}
+	})
+}
+
+func TestFormatLiveEvent(t *testing.T) {
+	message, err := workoutSession.FormatLiveEvent(workoutSession.LiveEvent{
+		Type:      workoutSession.LiveRestTimerStopped,
+		SessionID: "a",
+		Version:   3,
+		At:        time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 3\nevent: rest_timer_stopped\ndata: {\"type\":\"rest_timer_stopped\",\"sessionid\":\"a\",\"version\":3,\"at\":\"2024-05-06T07:00:00Z\"}\n\n", string(message))
+}
+
+func TestActiveSeconds(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+
+	t.Run("Session without pauses counts from its start", func(t *testing.T) {
+		session := &workoutSession.WorkoutSession{StartTime: start, Status: workoutSession.StatusInProgress}
+		assert.Equal(t, 1800, workoutSession.ActiveSeconds(session, start.Add(30*time.Minute)))
+	})
+
+	t.Run("Resumed session adds the running stretch to the accumulated time", func(t *testing.T) {
+		resumedAt := start.Add(time.Hour)
+		session := &workoutSession.WorkoutSession{StartTime: start, Status: workoutSession.StatusInProgress, ActiveTime: 1200, ResumedAt: &resumedAt}
+		assert.Equal(t, 1500, workoutSession.ActiveSeconds(session, resumedAt.Add(5*time.Minute)))
+	})
+
+	t.Run("Paused session keeps its accumulated time", func(t *testing.T) {
+		session := &workoutSession.WorkoutSession{StartTime: start, Status: workoutSession.StatusPaused, ActiveTime: 1200}
+		assert.Equal(t, 1200, workoutSession.ActiveSeconds(session, start.Add(3*time.Hour)))
+	})
+}
+
+func TestIdleSessions(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	session := &workoutSession.WorkoutSession{
+		StartTime: start,
+		UpdatedAt: start.Add(20 * time.Minute),
+		Status:    workoutSession.StatusInProgress,
+		Exercises: []workoutSession.SessionExercise{{ExerciseID: "squat"}},
+	}
+
+	t.Run("Idle after the timeout since the last change", func(t *testing.T) {
+		assert.Equal(t, start.Add(20*time.Minute), workoutSession.LastActivity(session))
+		assert.False(t, workoutSession.IsIdle(session, start.Add(time.Hour), time.Hour))
+		assert.True(t, workoutSession.IsIdle(session, start.Add(2*time.Hour), time.Hour))
+	})
+
+	t.Run("Ended sessions are never idle", func(t *testing.T) {
+		ended := *session
+		ended.Status = workoutSession.StatusAbandoned
+		assert.False(t, workoutSession.IsIdle(&ended, start.Add(48*time.Hour), time.Hour))
+	})
+
+	t.Run("Timed out sessions complete only with logged exercises", func(t *testing.T) {
+		assert.Equal(t, workoutSession.StatusAbandoned, workoutSession.TimeoutStatus(session))
+
+		logged := *session
+		logged.Exercises = []workoutSession.SessionExercise{{ExerciseID: "squat", ExerciseLogID: primitive.NewObjectID().Hex()}}
+		assert.Equal(t, workoutSession.StatusCompleted, workoutSession.TimeoutStatus(&logged))
+	})
+}
+
+func TestPauseResumeSession(t *testing.T) {
+	db := setupTestDB(t)
+	service := &workoutSession.WorkoutSessionService{DB: db}
+
+	t.Run("Pauses are left out of the duration", func(t *testing.T) {
+		userId := "test_user"
+		start := time.Now().Add(-1 * time.Hour)
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: start,
+			ResumedAt: &start,
+			Status:    workoutSession.StatusInProgress,
+			Version:   1,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		paused, err := service.PauseSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusPaused, paused.Status)
+		assert.InDelta(t, 3600, paused.ActiveTime, 5)
+
+		_, err = service.PauseSession(session.ID.Hex(), userId)
+		assert.ErrorIs(t, err, workoutSession.ErrSessionNotInProgress)
+
+		resumed, err := service.ResumeSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusInProgress, resumed.Status)
+		assert.NotNil(t, resumed.ResumedAt)
+
+		ended, err := service.EndSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusCompleted, ended.Status)
+		assert.InDelta(t, 3600, ended.Duration, 5)
+	})
+
+	t.Run("Sweep idle sessions", func(t *testing.T) {
+		userId := "idle_user"
+		start := time.Now().Add(-10 * time.Hour)
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: start,
+			ResumedAt: &start,
+			Status:    workoutSession.StatusInProgress,
+			UpdatedAt: start.Add(30 * time.Minute),
+			Version:   1,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		ended, err := service.SweepIdleSessions(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, ended)
+
+		result, err := service.GetSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, workoutSession.StatusAbandoned, result.Status)
+		assert.True(t, result.AutoClosed)
+		assert.Equal(t, 1800, result.Duration)
+	})
+
+	t.Run("Logs written for a session keep it active", func(t *testing.T) {
+		userId := "touched_user"
+		start := time.Now().Add(-10 * time.Hour)
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: start,
+			ResumedAt: &start,
+			Status:    workoutSession.StatusInProgress,
+			Exercises: []workoutSession.SessionExercise{{ExerciseID: "squat"}},
+			UpdatedAt: start,
+			Version:   1,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		assert.NoError(t, service.TouchSession(userId, "bench", time.Now()))
+		result, err := service.GetSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.True(t, result.UpdatedAt.Equal(start.Truncate(time.Millisecond)))
+
+		assert.NoError(t, service.TouchSession(userId, "squat", time.Now()))
+		ended, err := service.SweepIdleSessions(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, ended)
+	})
+}
+
+func TestRestTimer(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	session := &workoutSession.WorkoutSession{
+		Exercises: []workoutSession.SessionExercise{
+			{ExerciseID: "squat", TargetSets: []workout.TargetSet{{SetNumber: 1, RestSeconds: 180}, {SetNumber: 2}}},
+		},
+	}
+
+	t.Run("Target comes from the prescription, then the user's default", func(t *testing.T) {
+		seconds, source := workoutSession.RestTarget(session, "squat", 1, 120)
+		assert.Equal(t, 180, seconds)
+		assert.Equal(t, workoutSession.PrescriptionRest, source)
+
+		seconds, source = workoutSession.RestTarget(session, "squat", 2, 120)
+		assert.Equal(t, 120, seconds)
+		assert.Equal(t, workoutSession.ExerciseDefaultRest, source)
+
+		seconds, source = workoutSession.RestTarget(session, "bench", 1, 0)
+		assert.Equal(t, workoutSession.DefaultRestSeconds, seconds)
+		assert.Equal(t, workoutSession.DefaultRest, source)
+	})
+
+	t.Run("Extend moves the end, from now once over", func(t *testing.T) {
+		timer := workoutSession.NewRestTimer("squat", 1, 90, workoutSession.DefaultRest, start)
+		timer.Extend(30, start.Add(time.Minute))
+		assert.Equal(t, start.Add(2*time.Minute), timer.EndsAt)
+		assert.Equal(t, 120, timer.TargetSeconds)
+
+		timer.Extend(30, start.Add(5*time.Minute))
+		assert.Equal(t, start.Add(5*time.Minute+30*time.Second), timer.EndsAt)
+		assert.Equal(t, 330, timer.TargetSeconds)
+	})
+
+	t.Run("Rest taken runs until skipped or the next set", func(t *testing.T) {
+		timer := workoutSession.NewRestTimer("squat", 1, 90, workoutSession.DefaultRest, start)
+		assert.True(t, timer.Running())
+		assert.Equal(t, 150, timer.Taken(start.Add(150*time.Second)))
+
+		stopped := start.Add(time.Minute)
+		timer.StoppedAt = &stopped
+		assert.False(t, timer.Running())
+		assert.Equal(t, 60, timer.Taken(start.Add(150*time.Second)))
+	})
+}
+
+func TestSessionSetEdits(t *testing.T) {
+	logged := []exerciseLog.SetLog{
+		{Weight: 45.36, EnteredWeight: 100, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet},
+		{Weight: 45.36, EnteredWeight: 100, Reps: 5, SetNumber: 2, Type: exerciseLog.WorkingSet},
+		{Weight: 0, Reps: 10, SetNumber: 3, Type: exerciseLog.WorkingSet},
+	}
+
+	t.Run("Entered sets carry the weight in the log's unit", func(t *testing.T) {
+		sets := workoutSession.EnteredSets(logged)
+		assert.Equal(t, 100.0, sets[0].Weight)
+		assert.Equal(t, 0.0, sets[2].Weight)
+		assert.Equal(t, 45.36, logged[0].Weight)
+	})
+
+	t.Run("Append numbers the set after the last one", func(t *testing.T) {
+		sets := workoutSession.AppendSet(workoutSession.EnteredSets(logged), workoutSession.SessionSetDto{Weight: 110, Reps: 3, Type: exerciseLog.WorkingSet})
+		assert.Len(t, sets, 4)
+		assert.Equal(t, 4, sets[3].SetNumber)
+
+		first := workoutSession.AppendSet(nil, workoutSession.SessionSetDto{Reps: 8, Type: exerciseLog.WarmUpSet})
+		assert.Equal(t, 1, first[0].SetNumber)
+	})
+
+	t.Run("Replace keeps the set number", func(t *testing.T) {
+		sets, err := workoutSession.ReplaceSet(workoutSession.EnteredSets(logged), 2, workoutSession.SessionSetDto{Weight: 105, Reps: 4, Type: exerciseLog.WorkingSet})
+		assert.NoError(t, err)
+		assert.Equal(t, 105.0, sets[1].Weight)
+		assert.Equal(t, 2, sets[1].SetNumber)
+
+		_, err = workoutSession.ReplaceSet(workoutSession.EnteredSets(logged), 9, workoutSession.SessionSetDto{})
+		assert.ErrorIs(t, err, workoutSession.ErrSetNotFound)
+	})
+
+	t.Run("Remove moves the later sets up", func(t *testing.T) {
+		sets, err := workoutSession.RemoveSet(workoutSession.EnteredSets(logged), 1)
+		assert.NoError(t, err)
+		assert.Len(t, sets, 2)
+		assert.Equal(t, 1, sets[0].SetNumber)
+		assert.Equal(t, 2, sets[1].SetNumber)
+		assert.Equal(t, 10, sets[1].Reps)
+
+		_, err = workoutSession.RemoveSet(sets, 5)
+		assert.ErrorIs(t, err, workoutSession.ErrSetNotFound)
+	})
+}
+
+func TestAddSet(t *testing.T) {
+	db := setupTestDB(t)
+	logService := &exerciseLog.ExerciseLogService{DB: db, UnitService: &unit.UnitService{}}
+	service := &workoutSession.WorkoutSessionService{DB: db, ExerciseLogService: logService}
+
+	t.Run("First set starts the log and later sets keep the volume current", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		exerciseId := primitive.NewObjectID().Hex()
+		now := time.Now()
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: now,
+			ResumedAt: &now,
+			Status:    workoutSession.StatusInProgress,
+			Exercises: []workoutSession.SessionExercise{{ExerciseID: exerciseId}},
+			Version:   1,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		first, err := service.AddSet(session.ID.Hex(), exerciseId, &workoutSession.SessionSetDto{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet, Unit: "kg"}, userId)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, first.Session.Exercises[0].ExerciseLogID)
+		assert.Equal(t, 500.0, first.Session.TotalVolume)
+
+		second, err := service.AddSet(session.ID.Hex(), exerciseId, &workoutSession.SessionSetDto{Weight: 100, Reps: 3, Type: exerciseLog.WorkingSet}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, first.Log.ID, second.Log.ID)
+		assert.Equal(t, 800.0, second.Session.TotalVolume)
+
+		deleted, err := service.DeleteSet(session.ID.Hex(), exerciseId, 1, nil, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 300.0, deleted.Session.TotalVolume)
+		assert.Equal(t, 1, deleted.Log.Sets[0].SetNumber)
+
+		stale := int64(1)
+		_, err = service.DeleteSet(session.ID.Hex(), exerciseId, 1, &stale, userId)
+		assert.ErrorIs(t, err, workoutSession.ErrVersionConflict)
+	})
+
+	t.Run("The set takes the rest in the same write", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		exerciseId := primitive.NewObjectID().Hex()
+		now := time.Now()
+		session := &workoutSession.WorkoutSession{
+			ID:        primitive.NewObjectID(),
+			UserID:    userId,
+			Type:      workoutSession.CustomSession,
+			StartTime: now,
+			ResumedAt: &now,
+			Status:    workoutSession.StatusInProgress,
+			Exercises: []workoutSession.SessionExercise{{ExerciseID: exerciseId}},
+			RestTimer: workoutSession.NewRestTimer(exerciseId, 1, 90, workoutSession.DefaultRest, now.Add(-time.Minute)),
+			Version:   3,
+		}
+		_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+		assert.NoError(t, err)
+
+		stale := int64(2)
+		_, err = service.AddSet(session.ID.Hex(), exerciseId, &workoutSession.SessionSetDto{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet, Unit: "kg", Version: &stale}, userId)
+		assert.ErrorIs(t, err, workoutSession.ErrVersionConflict)
+
+		current := int64(3)
+		change, err := service.AddSet(session.ID.Hex(), exerciseId, &workoutSession.SessionSetDto{Weight: 100, Reps: 5, Type: exerciseLog.WorkingSet, Unit: "kg", Version: &current}, userId)
+		assert.NoError(t, err)
+		assert.InDelta(t, 60, change.Log.Sets[0].RestSeconds, 5)
+		assert.Nil(t, change.Session.RestTimer)
+		assert.Equal(t, int64(4), change.Session.Version)
+	})
+}
+
+func TestRestHandover(t *testing.T) {
+	db := setupTestDB(t)
+	logService := &exerciseLog.ExerciseLogService{DB: db, UnitService: &unit.UnitService{}}
+	service := &workoutSession.WorkoutSessionService{DB: db, ExerciseLogService: logService}
+	logService.RestTracker = service
+
+	userId := primitive.NewObjectID().Hex()
+	squat, bench := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+	now := time.Now()
+	session := &workoutSession.WorkoutSession{
+		ID:        primitive.NewObjectID(),
+		UserID:    userId,
+		Type:      workoutSession.CustomSession,
+		StartTime: now,
+		ResumedAt: &now,
+		Status:    workoutSession.StatusInProgress,
+		Exercises: []workoutSession.SessionExercise{{ExerciseID: squat}, {ExerciseID: bench}},
+		RestTimer: workoutSession.NewRestTimer(squat, 1, 90, workoutSession.DefaultRest, now.Add(-time.Minute)),
+		Version:   1,
+	}
+	_, err := db.Collection("workoutSessions").InsertOne(context.Background(), session)
+	assert.NoError(t, err)
+
+	t.Run("A set of another exercise leaves the rest alone", func(t *testing.T) {
+		log, err := logService.CreateLog(&exerciseLog.CreateExerciseLogDto{
+			ExerciseID: bench,
+			Unit:       "kg",
+			Sets:       []exerciseLog.SetLog{{Weight: 60, Reps: 8, SetNumber: 1, Type: exerciseLog.WorkingSet}},
+		}, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, log.Sets[0].RestSeconds)
+
+		result, err := service.GetSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.NotNil(t, result.RestTimer)
+	})
+
+	t.Run("The next set of the exercise takes the rest", func(t *testing.T) {
+		log, err := logService.CreateLog(&exerciseLog.CreateExerciseLogDto{
+			ExerciseID: squat,
+			Unit:       "kg",
+			Sets:       []exerciseLog.SetLog{{Weight: 100, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet}},
+		}, userId)
+		assert.NoError(t, err)
+		assert.InDelta(t, 60, log.Sets[0].RestSeconds, 5)
+
+		result, err := service.GetSession(session.ID.Hex(), userId)
+		assert.NoError(t, err)
+		assert.Nil(t, result.RestTimer)
+	})
+}
+
+func TestSummarise(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	bench := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), ExerciseID: "bench", TotalVolume: 2000, Sets: []exerciseLog.SetLog{
+		{Weight: 40, Reps: 10, SetNumber: 1, Type: exerciseLog.WarmUpSet},
+		{Weight: 100, Reps: 5, SetNumber: 2, Type: exerciseLog.WorkingSet},
+		{Weight: 100, Reps: 5, SetNumber: 3, Type: exerciseLog.WorkingSet},
+		{Weight: 100, Reps: 3, SetNumber: 4, Type: exerciseLog.WorkingSet},
+	}}
+	previousBench := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), ExerciseID: "bench", TotalVolume: 1800, Sets: []exerciseLog.SetLog{
+		{Weight: 95, Reps: 5, SetNumber: 1, Type: exerciseLog.WorkingSet},
+	}}
+
+	session := &workoutSession.WorkoutSession{
+		ID:          primitive.NewObjectID(),
+		WorkoutID:   "push",
+		Status:      workoutSession.StatusCompleted,
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		Duration:    3000,
+		TotalVolume: 2000,
+		Exercises: []workoutSession.SessionExercise{
+			{ExerciseID: "bench", ExerciseLogID: bench.ID.Hex(), TargetSets: []workout.TargetSet{
+				{SetNumber: 1, MinReps: 5, MaxReps: 5, Weight: 100},
+				{SetNumber: 2, MinReps: 5, MaxReps: 5, Weight: 100},
+				{SetNumber: 3, MinReps: 5, MaxReps: 5, Weight: 100},
+				{SetNumber: 4, MinReps: 5, MaxReps: 5, Weight: 100},
+			}},
+			{ExerciseID: "dips", Order: 1},
+		},
+	}
+	previous := &workoutSession.WorkoutSession{
+		ID:          primitive.NewObjectID(),
+		StartTime:   start.AddDate(0, 0, -7),
+		TotalVolume: 1800,
+		Exercises:   []workoutSession.SessionExercise{{ExerciseID: "bench", ExerciseLogID: previousBench.ID.Hex()}},
+	}
+
+	summary := workoutSession.Summarise(workoutSession.SummaryInput{
+		Session: session,
+		Title:   "Push",
+		Logs:    map[string]*exerciseLog.ExerciseLog{bench.ID.Hex(): bench},
+		Exercises: map[string]workoutSession.SummaryExercise{
+			"bench": {Name: "Bench Press", Muscles: []exerciseEnums.TargetMuscle{exerciseEnums.PectoralisMajorSternal, exerciseEnums.TricepsBrachii}},
+		},
+		Records: map[string][]personalRecordEnums.AchievedRecord{
+			bench.ID.Hex(): {{Type: personalRecordEnums.RepMaxRecord, Reps: 5, Value: 100, Previous: 95}},
+		},
+		Previous:     previous,
+		PreviousLogs: map[string]*exerciseLog.ExerciseLog{previousBench.ID.Hex(): previousBench},
+	})
+
+	t.Run("Sets and volume per muscle leave warm-ups out", func(t *testing.T) {
+		assert.Equal(t, 3000, summary.Duration)
+		assert.Equal(t, 3, summary.TotalSets)
+		assert.Len(t, summary.Muscles, 2)
+		assert.Equal(t, 3, summary.Muscles[0].Sets)
+		assert.Equal(t, 2000.0, summary.Muscles[0].Volume)
+		assert.Len(t, summary.Records, 1)
+		assert.Equal(t, "Bench Press", summary.Records[0].Name)
+	})
+
+	t.Run("Compared to the previous session", func(t *testing.T) {
+		assert.Equal(t, 200.0, summary.Comparison.VolumeDelta)
+		assert.Len(t, summary.Comparison.Exercises, 1)
+		assert.Greater(t, summary.Comparison.Exercises[0].EstimatedOneRMDelta, 0.0)
+	})
+
+	t.Run("Misses against targets", func(t *testing.T) {
+		assert.Len(t, summary.Misses, 2)
+		assert.Equal(t, workoutSession.MissedReps, summary.Misses[0].Reason)
+		assert.Equal(t, 3, summary.Misses[0].SetNumber)
+		assert.Equal(t, workoutSession.MissedSet, summary.Misses[1].Reason)
+	})
+
+	t.Run("Weighted sets are held to their load", func(t *testing.T) {
+		dips := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), ExerciseID: "dips", Sets: []exerciseLog.SetLog{
+			{Weight: 10, EffectiveWeight: 90, Reps: 8, SetNumber: 1, Type: exerciseLog.WorkingSet},
+			{Weight: 5, EffectiveWeight: 85, Reps: 8, SetNumber: 2, Type: exerciseLog.WorkingSet},
+		}}
+		weighted := workoutSession.Summarise(workoutSession.SummaryInput{
+			Session: &workoutSession.WorkoutSession{
+				Status: workoutSession.StatusCompleted,
+				Exercises: []workoutSession.SessionExercise{{ExerciseID: "dips", ExerciseLogID: dips.ID.Hex(), TargetSets: []workout.TargetSet{
+					{SetNumber: 1, MinReps: 8, MaxReps: 8, Weight: 90},
+					{SetNumber: 2, MinReps: 8, MaxReps: 8, Weight: 90},
+				}}},
+			},
+			Logs: map[string]*exerciseLog.ExerciseLog{dips.ID.Hex(): dips},
+		})
+		if assert.Len(t, weighted.Misses, 1) {
+			assert.Equal(t, workoutSession.MissedWeight, weighted.Misses[0].Reason)
+			assert.Equal(t, 2, weighted.Misses[0].SetNumber)
+			assert.Equal(t, 85.0, weighted.Misses[0].Weight)
+		}
+	})
+
+	t.Run("Cards", func(t *testing.T) {
+		markdown := workoutSession.MarkdownCard(summary)
+		assert.Contains(t, markdown, "## Push · 6 May 2024")
+		assert.Contains(t, markdown, "Bench Press: 5RM 100 kg (was 95 kg)")
+		assert.Contains(t, markdown, "- Volume: +200 kg")
+		assert.Contains(t, markdown, "Bench Press set 3: 3 of 5 reps")
+
+		summary.Title = "Push & <Pull>"
+		svg := workoutSession.SVGCard(summary)
+		assert.True(t, strings.HasPrefix(svg, "<svg"))
+		assert.Contains(t, svg, "Push &amp; &lt;Pull&gt;")
+	})
+}
+
+func TestRepeatSession(t *testing.T) {
+	rpe := 8.0
+	squat := &exerciseLog.ExerciseLog{ID: primitive.NewObjectID(), ExerciseID: "squat", Sets: []exerciseLog.SetLog{
+		{Weight: 60, EffectiveWeight: 60, Reps: 5, SetNumber: 1, Type: exerciseLog.WarmUpSet},
+		{Weight: 100, EffectiveWeight: 100, Reps: 5, SetNumber: 2, Type: exerciseLog.WorkingSet, RPE: &rpe, RestSeconds: 120},
+		{Weight: 110, EffectiveWeight: 110, Reps: 3, SetNumber: 3, Type: exerciseLog.WorkingSet, RestSeconds: 180},
+	}}
+	source := &workoutSession.WorkoutSession{
+		Status: workoutSession.StatusCompleted,
+		Type:   workoutSession.CustomSession,
+		Exercises: []workoutSession.SessionExercise{
+			{ExerciseID: "curl", Order: 1, TargetSets: []workout.TargetSet{{SetNumber: 1, MinReps: 10, MaxReps: 12}}},
+			{ExerciseID: "squat", Order: 0, ExerciseLogID: squat.ID.Hex(), GroupID: "g1"},
+		},
+		Groups: []workout.ExerciseGroup{{ID: "g1", Type: workout.SupersetGroup, Rounds: 1}},
+	}
+	logs := map[string]*exerciseLog.ExerciseLog{squat.ID.Hex(): squat}
+
+	t.Run("Repeating copies the order with the sets done as targets", func(t *testing.T) {
+		exercises := workoutSession.RepeatExercises(source, logs)
+		assert.Len(t, exercises, 2)
+		assert.Equal(t, "squat", exercises[0].ExerciseID)
+		assert.Equal(t, "g1", exercises[0].GroupID)
+		assert.Empty(t, exercises[0].ExerciseLogID)
+		assert.Equal(t, []workout.TargetSet{
+			{SetNumber: 1, MinReps: 5, MaxReps: 5, Weight: 100, RPE: 8, RestSeconds: 120},
+			{SetNumber: 2, MinReps: 3, MaxReps: 3, Weight: 110, RestSeconds: 180},
+		}, exercises[0].TargetSets)
+
+		// Without a log the exercise keeps its targets
+		assert.Equal(t, 1, exercises[1].Order)
+		assert.Equal(t, 10, exercises[1].TargetSets[0].MinReps)
+	})
+
+	t.Run("Targets and prescriptions take the load of weighted sets", func(t *testing.T) {
+		dips := []exerciseLog.SetLog{{Weight: 10, EffectiveWeight: 90, Reps: 8, SetNumber: 1, Type: exerciseLog.WorkingSet}}
+		assert.Equal(t, float64(90), workoutSession.TargetsFromSets(dips)[0].Weight)
+		assert.Equal(t, float64(90), workoutSession.PrescriptionFromSets(dips).TargetWeight)
+	})
+
+	t.Run("Saving as a workout prescribes the sets done", func(t *testing.T) {
+		dto := workoutSession.WorkoutFromSession(source, logs, &workoutSession.SaveAsWorkoutDto{Name: "Tuesday"})
+		assert.Equal(t, "Tuesday", dto.Name)
+		assert.Len(t, dto.Groups, 1)
+		assert.Equal(t, "squat", dto.Exercises[0].ExerciseID)
+		assert.Equal(t, &workout.Prescription{Sets: 2, MinReps: 3, MaxReps: 5, TargetWeight: 110, RestSeconds: 150}, dto.Exercises[0].Prescription)
+		assert.Nil(t, dto.Exercises[1].Prescription)
 	})
 }
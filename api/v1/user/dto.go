@@ -2,6 +2,7 @@ package user
 
 import (
 	authEnums "github.com/Npwskp/GymsbroBackend/api/v1/auth/enums"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
 	userFitnessPreferenceEnums "github.com/Npwskp/GymsbroBackend/api/v1/user/enums"
 )
 
@@ -34,4 +35,5 @@ type UpdateBodyDto struct {
 	NutritionInfo   userFitnessPreferenceEnums.NutritionInfo       `json:"nutrition_info"`
 	BodyComposition userFitnessPreferenceEnums.BodyCompositionInfo `json:"body_composition"`
 	Macronutrients  userFitnessPreferenceEnums.Macronutrients      `json:"macronutrients"`
+	WeightUnit      unitEnums.ExerciseWeightUnit                   `json:"weight_unit" validate:"omitempty,oneof=kg lbs"`
 }
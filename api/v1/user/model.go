@@ -4,6 +4,7 @@ import (
 	"time"
 
 	authEnums "github.com/Npwskp/GymsbroBackend/api/v1/auth/enums"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
 	userFitnessPreferenceEnums "github.com/Npwskp/GymsbroBackend/api/v1/user/enums"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -20,6 +21,7 @@ type User struct {
 	NutritionInfo   userFitnessPreferenceEnums.NutritionInfo       `json:"nutrition_info" bson:"nutrition_info"`
 	BodyComposition userFitnessPreferenceEnums.BodyCompositionInfo `json:"body_composition" bson:"body_composition"`
 	Macronutrients  userFitnessPreferenceEnums.Macronutrients      `json:"macronutrients" bson:"macronutrients"`
+	WeightUnit      unitEnums.ExerciseWeightUnit                   `json:"weight_unit" bson:"weight_unit" default:"kg"`
 	OAuthProvider   string                                         `json:"oauth_provider,omitempty" bson:"oauth_provider,omitempty"`
 	OAuthID         string                                         `json:"oauth_id,omitempty" bson:"oauth_id,omitempty"`
 	Picture         string                                         `json:"picture,omitempty" bson:"picture,omitempty"`
@@ -35,6 +37,7 @@ func CreateUserModel(user *CreateUserDto) *User {
 		Password:     user.Password,
 		Age:          user.Age,
 		Gender:       user.Gender,
+		WeightUnit:   unitEnums.ExerciseWeightUnitKg,
 		IsFirstLogin: true,
 		CreatedAt:    time.Now(),
 	}
@@ -240,6 +240,7 @@ func (us *UserService) UpdateBody(doc *UpdateBodyDto, id string) (*User, error)
 			{Key: "nutrition_info", Value: new_nutrition_info},
 			{Key: "body_composition", Value: new_body_composition},
 			{Key: "macronutrients", Value: new_macronutrients},
+			{Key: "weight_unit", Value: function.Coalesce(doc.WeightUnit, user.WeightUnit)},
 			{Key: "updated_at", Value: time.Now()},
 		}},
 	}
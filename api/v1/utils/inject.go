@@ -2,10 +2,13 @@ package utils
 
 import (
 	"log"
+	"time"
 
 	"github.com/Npwskp/GymsbroBackend/api/v1/auth"
 	"github.com/Npwskp/GymsbroBackend/api/v1/dashboard"
+	"github.com/Npwskp/GymsbroBackend/api/v1/dashboard/loading"
 	"github.com/Npwskp/GymsbroBackend/api/v1/middleware"
+	"github.com/Npwskp/GymsbroBackend/api/v1/notification"
 	foodlog "github.com/Npwskp/GymsbroBackend/api/v1/nutrition/foodLog"
 	"github.com/Npwskp/GymsbroBackend/api/v1/nutrition/ingredient"
 	"github.com/Npwskp/GymsbroBackend/api/v1/nutrition/meal"
@@ -16,6 +19,11 @@ import (
 	macronutrientLog "github.com/Npwskp/GymsbroBackend/api/v1/userLog/userMacronutrient"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/historyImport"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/programLibrary"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/progression"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutPlan"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
@@ -47,6 +55,11 @@ func InjectApp(app *fiber.App, db *mongo.Database) {
 	unitController := unit.UnitController{Instance: public, Service: &unitService}
 	unitController.Handle()
 
+	// Calendar feed (public, protected by its own token)
+	workoutPlanService := workoutPlan.WorkoutPlanService{DB: db}
+	calendarFeedController := workoutPlan.CalendarFeedController{Instance: public, Service: &workoutPlanService}
+	calendarFeedController.Handle()
+
 	// Protected routes group (requires auth)
 	protected := api.Group("")
 	protected.Use(middleware.AuthMiddleware())
@@ -82,19 +95,77 @@ func InjectApp(app *fiber.App, db *mongo.Database) {
 	workoutController := workout.WorkoutController{Instance: protected, Service: &workoutService}
 	workoutController.Handle()
 
-	exerciseLogService := exerciseLog.ExerciseLogService{DB: db}
+	personalRecordService := personalRecord.PersonalRecordService{DB: db}
+	personalRecordController := personalRecord.PersonalRecordController{Instance: protected, Service: &personalRecordService}
+	personalRecordController.Handle()
+
+	exerciseLogService := exerciseLog.ExerciseLogService{DB: db, UnitService: &unitService, RecordTracker: &personalRecordService}
 	exerciseLogController := exerciseLog.ExerciseLogController{Instance: protected, Service: &exerciseLogService}
 	exerciseLogController.Handle()
 
-	workoutSessionService := workoutSession.WorkoutSessionService{DB: db}
-	workoutSessionController := workoutSession.WorkoutSessionController{Instance: protected, Service: &workoutSessionService}
-	workoutSessionController.Handle()
+	historyImportService := historyImport.HistoryImportService{DB: db, ExerciseService: &exerciseService, ExerciseLogService: &exerciseLogService}
+	historyImportController := historyImport.HistoryImportController{Instance: protected, Service: &historyImportService}
+	historyImportController.Handle()
+
+	progressionService := progression.ProgressionService{DB: db}
+	progressionController := progression.ProgressionController{Instance: protected, Service: &progressionService}
+	progressionController.Handle()
+
+	programService := program.ProgramService{DB: db, Schedule: &workoutPlanService}
+	programController := program.ProgramController{Instance: protected, Service: &programService}
+	programController.Handle()
+
+	programLibraryService := programLibrary.ProgramLibraryService{DB: db, WorkoutService: &workoutService, ProgramService: &programService}
+	programLibraryController := programLibrary.ProgramLibraryController{Instance: protected, Service: &programLibraryService}
+	programLibraryController.Handle()
+
+	loadingService := loading.LoadingService{DB: db, UnitService: &unitService}
+	loadingController := loading.LoadingController{Instance: protected, Service: &loadingService}
+	loadingController.Handle()
 
-	workoutPlanService := workoutPlan.WorkoutPlanService{DB: db}
 	workoutPlanController := workoutPlan.WorkoutPlanController{Instance: protected, Service: &workoutPlanService}
 	workoutPlanController.Handle()
 
+	workoutSessionService := workoutSession.WorkoutSessionService{
+		DB:                 db,
+		ProgressionService: &progressionService,
+		ProgramService:     &programService,
+		PlanTracker:        &workoutPlanService,
+		Live:               workoutSession.NewLiveHub(),
+		IdleTimeout:        workoutSession.IdleTimeoutFromEnv(),
+		ExerciseLogService: &exerciseLogService,
+		WorkoutService:     &workoutService,
+		WarmUpPlanner:      &loadingService,
+	}
+	workoutSessionController := workoutSession.WorkoutSessionController{Instance: protected, Service: &workoutSessionService}
+	workoutSessionController.Handle()
+	// Sets logged during a session get the rest its timer measured and keep the session active
+	exerciseLogService.RestTracker = &workoutSessionService
+	exerciseLogService.SessionTracker = &workoutSessionService
+
 	dashboardService := dashboard.DashboardService{DB: db}
 	dashboardController := dashboard.DashboardController{Instance: protected, Service: &dashboardService}
 	dashboardController.Handle()
+
+	notificationDeps := notification.InjectDependencies()
+	notificationService := notification.NotificationService{
+		DB:             db,
+		Channels:       notificationDeps.Channels,
+		VapidPublicKey: notificationDeps.VapidPublicKey,
+	}
+	notificationController := notification.NotificationController{Instance: protected, Service: &notificationService}
+	notificationController.Handle()
+	workoutSessionService.RestNotifier = &notificationService
+
+	// Reminders, notification delivery and the idle session sweeper run in the background for as long as the app does
+	jobs := append(notificationService.Jobs(), notification.Job{
+		Name:     "idle sessions",
+		Interval: workoutSession.SweepInterval,
+		Run: func(now time.Time) error {
+			_, err := workoutSessionService.SweepIdleSessions(now)
+			return err
+		},
+	})
+	scheduler := notification.Scheduler{Jobs: jobs}
+	scheduler.Start()
 }
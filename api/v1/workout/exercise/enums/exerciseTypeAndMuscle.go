@@ -105,6 +105,23 @@ func GetAllEquipment() []Equipment {
 	}
 }
 
+// IsBodyWeightLoaded reports whether the load of an exercise with this equipment
+// is the lifter's body weight, adjusted by any added weight or assistance
+func IsBodyWeightLoaded(e Equipment) bool {
+	switch e {
+	case BodyWeight, Weighted, Assisted, BandAssisted:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAssistedEquipment reports whether the logged weight is assistance that is
+// subtracted from the lifter's body weight rather than added to it
+func IsAssistedEquipment(e Equipment) bool {
+	return e == Assisted || e == BandAssisted
+}
+
 func GetAllMechanics() []Mechanics {
 	return []Mechanics{Compound, Isolated}
 }
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/Npwskp/GymsbroBackend/api/v1/function"
 	minio "github.com/Npwskp/GymsbroBackend/api/v1/storage"
@@ -478,6 +479,96 @@ func (es *ExerciseService) FindSimilarExercises(id string, userId string, limit
 	return result, nil
 }
 
+// NameMatch is a catalog exercise matching a name from another app. Score goes from 0 to 1.
+type NameMatch struct {
+	ExerciseID string                  `json:"exerciseid" bson:"exerciseid"`
+	Name       string                  `json:"name" bson:"name"`
+	Equipment  exerciseEnums.Equipment `json:"equipment" bson:"equipment"`
+	Score      float64                 `json:"score" bson:"score"`
+}
+
+// MatchExerciseName ranks exercises by how closely their names match a name from another app, best
+// first. Names such as "Bench Press (Barbell)" carry the equipment in brackets, exercises with
+// that equipment rank above the others.
+func MatchExerciseName(name string, exercises []*Exercise, limit int) []NameMatch {
+	base, hint := splitEquipmentHint(name)
+	base = normaliseName(base)
+	sortedBase := sortWords(base)
+
+	matches := make([]NameMatch, 0, len(exercises))
+	for _, exercise := range exercises {
+		exerciseName := normaliseName(exercise.Name)
+		score := math.Max(
+			calculateStringSimilarity(base, exerciseName),
+			calculateStringSimilarity(sortedBase, sortWords(exerciseName)),
+		)
+		if hint != "" {
+			equipmentScore := 0.0
+			if matchesEquipment(hint, exercise.Equipment) {
+				equipmentScore = 1.0
+			}
+			score = score*0.85 + equipmentScore*0.15
+		}
+
+		matches = append(matches, NameMatch{
+			ExerciseID: exercise.ID.Hex(),
+			Name:       exercise.Name,
+			Equipment:  exercise.Equipment,
+			Score:      math.Round(score*1000) / 1000,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if limit > 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// splitEquipmentHint splits "Bench Press (Barbell)" into "Bench Press" and "barbell"
+func splitEquipmentHint(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	open := strings.LastIndex(name, "(")
+	if open <= 0 || !strings.HasSuffix(name, ")") {
+		return name, ""
+	}
+	return strings.TrimSpace(name[:open]), normaliseName(name[open+1 : len(name)-1])
+}
+
+// matchesEquipment tells whether an equipment hint such as "machine" or "bodyweight" names the equipment
+func matchesEquipment(hint string, equipment exerciseEnums.Equipment) bool {
+	name := normaliseName(string(equipment))
+	switch {
+	case strings.ReplaceAll(hint, " ", "") == strings.ReplaceAll(name, " ", ""):
+		return true
+	case hint == "machine":
+		return strings.HasPrefix(name, "lever")
+	case hint == "band":
+		return equipment == exerciseEnums.BandAssisted
+	}
+	return strings.Contains(name, hint) || strings.HasPrefix(hint, name+" ")
+}
+
+// normaliseName lowercases a name and keeps only its letters and digits, single spaced
+func normaliseName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// sortWords sorts the words of a name, so "Squat Front" matches "Front Squat"
+func sortWords(name string) string {
+	words := strings.Fields(name)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
 // calculateStringSimilarity calculates similarity between two strings
 // using Levenshtein distance normalized to [0,1]
 func calculateStringSimilarity(s1, s2 string) float64 {
@@ -26,7 +26,7 @@ type ExerciseLogController struct {
 // @Router      /exercise-log [post]
 func (c *ExerciseLogController) CreateLogHandler(ctx *fiber.Ctx) error {
 	userId := function.GetUserIDFromContext(ctx)
-	validate := validator.New()
+	validate := NewValidator()
 	dto := new(CreateExerciseLogDto)
 
 	if err := ctx.BodyParser(dto); err != nil {
@@ -94,6 +94,51 @@ func (c *ExerciseLogController) GetExerciseLogsHandler(ctx *fiber.Ctx) error {
 	return ctx.JSON(logs)
 }
 
+// @Summary     Get a page of logs
+// @Description Get a page of the user's exercise logs, newest first, narrowed down by the filters
+// @Tags        exerciseLogs
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId query string false "Exercise ID"
+// @Param       muscle query string false "Target muscle of the exercise"
+// @Param       equipment query string false "Equipment of the exercise"
+// @Param       setType query string false "Logs with a set of the type" Enums(warm_up, working, drop, failure)
+// @Param       startDate query string false "Start Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       endDate query string false "End Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       cursor query string false "Next cursor of the previous page"
+// @Param       limit query int false "Logs per page, 20 by default"
+// @Success     200 {object} LogPage
+// @Failure     400 {object} Error
+// @Router      /exercise-log/page [get]
+func (c *ExerciseLogController) GetLogsPageHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	filters, err := parseFilters(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	page := new(LogPageQuery)
+	if err := ctx.QueryParser(page); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err := validator.New().Struct(page); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	logs, err := c.Service.GetLogs(userId, filters, page)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	return ctx.JSON(logs)
+}
+
 // @Summary     Get logs by date range
 // @Description Get exercise logs within a date range
 // @Tags        exerciseLogs
@@ -130,6 +175,101 @@ func (c *ExerciseLogController) GetLogsByDateRangeHandler(ctx *fiber.Ctx) error
 	return ctx.JSON(logs)
 }
 
+// @Summary     Get weekly volume
+// @Description Sum the volume of the user's logs per week, starting on Monday
+// @Tags        exerciseLogs
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId query string false "Exercise ID"
+// @Param       muscle query string false "Target muscle of the exercise"
+// @Param       equipment query string false "Equipment of the exercise"
+// @Param       setType query string false "Only sets of the type" Enums(warm_up, working, drop, failure)
+// @Param       startDate query string false "Start Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       endDate query string false "End Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       timezone query string false "Timezone weeks start in, UTC by default"
+// @Success     200 {array} WeeklyVolume
+// @Failure     400 {object} Error
+// @Router      /exercise-log/stats/weekly-volume [get]
+func (c *ExerciseLogController) GetWeeklyVolumeHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	filters, err := parseFilters(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	weeks, err := c.Service.GetWeeklyVolume(userId, filters)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	return ctx.JSON(weeks)
+}
+
+// @Summary     Get weekly sets per muscle
+// @Description Count the working sets each target muscle got per week, starting on Monday
+// @Tags        exerciseLogs
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId query string false "Exercise ID"
+// @Param       muscle query string false "Target muscle"
+// @Param       equipment query string false "Equipment of the exercise"
+// @Param       setType query string false "Only sets of the type" Enums(warm_up, working, drop, failure)
+// @Param       startDate query string false "Start Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       endDate query string false "End Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       timezone query string false "Timezone weeks start in, UTC by default"
+// @Success     200 {array} WeeklyMuscleSets
+// @Failure     400 {object} Error
+// @Router      /exercise-log/stats/weekly-muscle-sets [get]
+func (c *ExerciseLogController) GetWeeklyMuscleSetsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	filters, err := parseFilters(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	weeks, err := c.Service.GetWeeklyMuscleSets(userId, filters)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	return ctx.JSON(weeks)
+}
+
+// @Summary     Get best sets
+// @Description Get the working set with the highest estimated 1RM of each exercise
+// @Tags        exerciseLogs
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId query string false "Exercise ID"
+// @Param       muscle query string false "Target muscle of the exercise"
+// @Param       equipment query string false "Equipment of the exercise"
+// @Param       setType query string false "Only sets of the type" Enums(warm_up, working, drop, failure)
+// @Param       startDate query string false "Start Date (YYYY-MM-DD HH:mm:ss)"
+// @Param       endDate query string false "End Date (YYYY-MM-DD HH:mm:ss)"
+// @Success     200 {array} BestSet
+// @Failure     400 {object} Error
+// @Router      /exercise-log/stats/best-sets [get]
+func (c *ExerciseLogController) GetBestSetsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	filters, err := parseFilters(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	sets, err := c.Service.GetBestSets(userId, filters)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+
+	return ctx.JSON(sets)
+}
+
 // @Summary     Update exercise log
 // @Description Update an existing exercise log
 // @Tags        exerciseLogs
@@ -143,7 +283,7 @@ func (c *ExerciseLogController) GetLogsByDateRangeHandler(ctx *fiber.Ctx) error
 func (c *ExerciseLogController) UpdateLogHandler(ctx *fiber.Ctx) error {
 	userId := function.GetUserIDFromContext(ctx)
 	logId := ctx.Params("id")
-	validate := validator.New()
+	validate := NewValidator()
 	dto := new(UpdateExerciseLogDto)
 
 	if err := ctx.BodyParser(dto); err != nil {
@@ -190,6 +330,27 @@ func (c *ExerciseLogController) DeleteLogHandler(ctx *fiber.Ctx) error {
 	return ctx.SendStatus(fiber.StatusNoContent)
 }
 
+func parseFilters(ctx *fiber.Ctx) (*LogFilters, error) {
+	filters := new(LogFilters)
+	if err := ctx.QueryParser(filters); err != nil {
+		return nil, err
+	}
+	if err := validator.New().Struct(filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+func queryError(ctx *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	if err == ErrInvalidCursor || err == ErrInvalidDate || err == ErrInvalidTimezone {
+		status = fiber.StatusBadRequest
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
 func (c *ExerciseLogController) Handle() {
 	g := c.Instance.Group("/exercise-log")
 
@@ -197,6 +358,10 @@ func (c *ExerciseLogController) Handle() {
 	g.Get("/", c.GetUserLogsHandler)
 	g.Get("/exercise/:exerciseId", c.GetExerciseLogsHandler)
 	g.Get("/range", c.GetLogsByDateRangeHandler)
+	g.Get("/page", c.GetLogsPageHandler)
+	g.Get("/stats/weekly-volume", c.GetWeeklyVolumeHandler)
+	g.Get("/stats/weekly-muscle-sets", c.GetWeeklyMuscleSetsHandler)
+	g.Get("/stats/best-sets", c.GetBestSetsHandler)
 	g.Put("/:id", c.UpdateLogHandler)
 	g.Delete("/:id", c.DeleteLogHandler)
 }
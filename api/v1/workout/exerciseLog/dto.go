@@ -1,16 +1,103 @@
 package exerciseLog
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/go-playground/validator"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type CreateExerciseLogDto struct {
-	ExerciseID string    `json:"exerciseId" validate:"required"`
-	DateTime   time.Time `json:"dateTime"`
-	Sets       []SetLog  `json:"sets" validate:"required,dive"`
-	Notes      string    `json:"notes"`
+	ExerciseID string                       `json:"exerciseId" validate:"required"`
+	DateTime   time.Time                    `json:"dateTime"`
+	Unit       unitEnums.ExerciseWeightUnit `json:"unit" validate:"omitempty,oneof=kg lbs"` // Defaults to the user's weight unit
+	Sets       []SetLog                     `json:"sets" validate:"required,dive"`
+	Notes      string                       `json:"notes"`
+	GroupID    string                       `json:"groupId"`
+	ImportKey  string                       `json:"-"` // Set by the history import for the workout the log comes from
 }
 
 type UpdateExerciseLogDto struct {
-	Sets     []SetLog  `json:"sets" validate:"required,dive"`
-	DateTime time.Time `json:"dateTime"`
-	Notes    string    `json:"notes"`
+	Sets     []SetLog                     `json:"sets" validate:"required,dive"`
+	DateTime time.Time                    `json:"dateTime"`
+	Unit     unitEnums.ExerciseWeightUnit `json:"unit" validate:"omitempty,oneof=kg lbs"` // Defaults to the log's current unit
+	Notes    string                       `json:"notes"`
+	GroupID  string                       `json:"groupId"`
+}
+
+// NewValidator returns a validator that also understands the set log specific tags
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterValidation("tempo", validateTempo)
+	return validate
+}
+
+// validateTempo checks a four phase tempo such as "3010" or "20X1",
+// where each phase is a number of seconds or X for explosive
+func validateTempo(fl validator.FieldLevel) bool {
+	tempo := fl.Field().String()
+	if len(tempo) != 4 {
+		return false
+	}
+	for _, phase := range strings.ToUpper(tempo) {
+		if (phase < '0' || phase > '9') && phase != 'X' {
+			return false
+		}
+	}
+	return true
+}
+
+// LogFilters narrow down the logs of a user. Muscle and equipment match the logged exercise,
+// SetType keeps the logs and, in stats, the sets of that type. Dates are YYYY-MM-DD HH:mm:ss.
+type LogFilters struct {
+	ExerciseID string                     `query:"exerciseId"`
+	Muscle     exerciseEnums.TargetMuscle `query:"muscle"`
+	Equipment  exerciseEnums.Equipment    `query:"equipment"`
+	SetType    SetType                    `query:"setType" validate:"omitempty,oneof=warm_up working drop failure"`
+	StartDate  string                     `query:"startDate"`
+	EndDate    string                     `query:"endDate"`
+	Timezone   string                     `query:"timezone"` // Where weeks start in stats, UTC when not set
+}
+
+type LogPageQuery struct {
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"min=0,max=100"` // DefaultPageSize when not set
+}
+
+// LogPage is a page of logs, newest first. NextCursor fetches the page after it and is empty on
+// the last page.
+type LogPage struct {
+	Logs       []*ExerciseLog `json:"logs"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// WeeklyVolume is the work logged in a week starting on Monday. Volumes are in kg.
+type WeeklyVolume struct {
+	Week   time.Time `json:"week" bson:"week"`
+	Volume float64   `json:"volume" bson:"volume"`
+	Sets   int       `json:"sets" bson:"sets"`
+	Logs   int       `json:"logs" bson:"logs"`
+}
+
+// WeeklyMuscleSets counts the sets a target muscle got in a week. An exercise counts fully for
+// each of its muscles.
+type WeeklyMuscleSets struct {
+	Week   time.Time                  `json:"week" bson:"week"`
+	Muscle exerciseEnums.TargetMuscle `json:"muscle" bson:"muscle"`
+	Sets   int                        `json:"sets" bson:"sets"`
+	Volume float64                    `json:"volume" bson:"volume"`
+}
+
+// BestSet is the set of an exercise with the highest estimated 1RM. Load and the estimate are in kg.
+type BestSet struct {
+	ExerciseID     string             `json:"exerciseid" bson:"_id"`
+	LogID          primitive.ObjectID `json:"logid" bson:"logid"`
+	DateTime       time.Time          `json:"datetime" bson:"datetime"`
+	Load           float64            `json:"load" bson:"load"`
+	Reps           int                `json:"reps" bson:"reps"`
+	Type           SetType            `json:"type" bson:"type"`
+	EstimatedOneRM float64            `json:"estimated_one_rm" bson:"estimated_one_rm"`
 }
@@ -1,23 +1,33 @@
 package exerciseLog
 
 import (
+	"math"
 	"time"
 
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ExerciseLog struct {
-	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	UserID        string             `json:"userid" bson:"userid" validate:"required"`
-	ExerciseID    string             `json:"exerciseid" bson:"exerciseid" validate:"required"`
-	CompletedSets int                `json:"completed_sets" bson:"completed_sets"`
-	TotalVolume   float64            `json:"total_volume" bson:"total_volume"`
-	Notes         string             `json:"notes" bson:"notes"`
-	Duration      int                `json:"duration" bson:"duration"`
-	DateTime      time.Time          `json:"datetime" bson:"datetime"`
-	Sets          []SetLog           `json:"sets" validate:"dive"`
-	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	ID            primitive.ObjectID           `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID        string                       `json:"userid" bson:"userid" validate:"required"`
+	ExerciseID    string                       `json:"exerciseid" bson:"exerciseid" validate:"required"`
+	CompletedSets int                          `json:"completed_sets" bson:"completed_sets"`
+	TotalVolume   float64                      `json:"total_volume" bson:"total_volume"`
+	Unit          unitEnums.ExerciseWeightUnit `json:"unit" bson:"unit"`
+	Notes         string                       `json:"notes" bson:"notes"`
+	Duration      int                          `json:"duration" bson:"duration"`
+	BodyWeight    float64                      `json:"bodyweight" bson:"bodyweight"`
+	GroupID       string                       `json:"groupid,omitempty" bson:"groupid,omitempty"`       // Superset, giant set or circuit of the session this log belongs to
+	ImportKey     string                       `json:"import_key,omitempty" bson:"import_key,omitempty"` // Workout of another app the log was imported from
+	DateTime      time.Time                    `json:"datetime" bson:"datetime"`
+	Sets          []SetLog                     `json:"sets" validate:"dive"`
+	CreatedAt     time.Time                    `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at" bson:"updated_at"`
+	// PersonalRecords lists the records set by this log, it is only filled in on create and update responses
+	PersonalRecords []personalRecordEnums.AchievedRecord `json:"personal_records,omitempty" bson:"-"`
 }
 
 type SetType string
@@ -29,9 +39,66 @@ const (
 	FailureSet SetType = "failure"
 )
 
+// SetLog is a single logged set. Weight and EffectiveWeight are stored in kg, while
+// EnteredWeight keeps the value as entered in the log's unit for display. Sets written to a log
+// are read in the log's unit from EnteredWeight, or from Weight when they give no entered weight.
+// For body weight loaded equipment (see exerciseEnums.IsBodyWeightLoaded) Weight is the
+// added weight or, for assisted equipment, the assistance; EffectiveWeight is the load actually moved.
+// RPE, RIR, Tempo, RestSeconds and CompletedAt are optional effort and pacing details.
+// Round is the round of the log's group the set was performed in, 0 when the log is not grouped.
 type SetLog struct {
-	Weight    float64 `json:"weight" validate:"required,min=1"`
-	Reps      int     `json:"reps" validate:"required,min=1"`
-	SetNumber int     `json:"setNumber" validate:"required,min=1"`
-	Type      SetType `json:"type" validate:"required,oneof=warm_up working drop failure"`
+	Weight          float64   `json:"weight" validate:"min=0"`
+	EnteredWeight   float64   `json:"enteredWeight" validate:"min=0"`
+	EffectiveWeight float64   `json:"effectiveWeight"`
+	Reps            int       `json:"reps" validate:"required,min=1"`
+	SetNumber       int       `json:"setNumber" validate:"required,min=1"`
+	Type            SetType   `json:"type" validate:"required,oneof=warm_up working drop failure"`
+	RPE             *float64  `json:"rpe,omitempty" bson:"rpe,omitempty" validate:"omitempty,min=1,max=10"`
+	RIR             *int      `json:"rir,omitempty" bson:"rir,omitempty" validate:"omitempty,min=0,max=10"`
+	Tempo           string    `json:"tempo,omitempty" bson:"tempo,omitempty" validate:"omitempty,tempo"`
+	RestSeconds     int       `json:"restSeconds,omitempty" bson:"restseconds,omitempty" validate:"min=0"`
+	CompletedAt     time.Time `json:"completedAt,omitempty" bson:"completedat,omitempty"`
+	Round           int       `json:"round,omitempty" bson:"round,omitempty" validate:"min=0"`
+}
+
+// EstimatedOneRepMax returns the best estimated 1RM of the working sets of the log, 0 when none can be worked out
+func (l ExerciseLog) EstimatedOneRepMax() float64 {
+	var best float64
+	for _, set := range l.Sets {
+		if set.Type == WarmUpSet || set.Load() <= 0 {
+			continue
+		}
+		oneRM, err := dashboardFunctions.CalculateOneRepMax(set.Load(), set.RepsToFailure())
+		if err != nil {
+			continue
+		}
+		best = math.Max(best, oneRM)
+	}
+	return best
+}
+
+// Load returns the weight moved in the set, falling back to Weight for logs
+// recorded before effective weights were stored
+func (s SetLog) Load() float64 {
+	if s.EffectiveWeight > 0 {
+		return s.EffectiveWeight
+	}
+	return s.Weight
+}
+
+// RepsInReserve returns the reps left in the tank, taken from RIR when given and
+// otherwise derived from RPE (RIR = 10 - RPE). Sets without either count as taken to failure.
+func (s SetLog) RepsInReserve() float64 {
+	if s.RIR != nil {
+		return float64(*s.RIR)
+	}
+	if s.RPE != nil {
+		return math.Max(10-*s.RPE, 0)
+	}
+	return 0
+}
+
+// RepsToFailure returns the number of reps the set could have reached, used for 1RM estimation
+func (s SetLog) RepsToFailure() float64 {
+	return float64(s.Reps) + s.RepsInReserve()
 }
@@ -0,0 +1,285 @@
+package exerciseLog
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultPageSize is the number of logs on a page when no limit is asked for
+const DefaultPageSize = 20
+
+var (
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrInvalidDate     = errors.New("invalid date format. Expected format: YYYY-MM-DD HH:mm:ss")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+// encodeCursor points past a log in the newest first order of a page
+func encodeCursor(log *ExerciseLog) string {
+	raw := strconv.FormatInt(log.DateTime.UnixMilli(), 10) + "_" + log.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	millis, id, found := strings.Cut(string(raw), "_")
+	if !found {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+	return time.UnixMilli(ms).UTC(), oid, nil
+}
+
+// logFilter matches the logs of a user that pass the filters. Muscle and equipment are resolved to
+// the exercises that have them.
+func (s *ExerciseLogService) logFilter(userId string, filters *LogFilters) (bson.D, error) {
+	filter := bson.D{{Key: "userid", Value: userId}}
+
+	exerciseFilter := bson.D{}
+	if filters.ExerciseID != "" {
+		exerciseFilter = append(exerciseFilter, bson.E{Key: "$eq", Value: filters.ExerciseID})
+	}
+	if filters.Muscle != "" || filters.Equipment != "" {
+		ids, err := s.exerciseIdsWith(filters)
+		if err != nil {
+			return nil, err
+		}
+		exerciseFilter = append(exerciseFilter, bson.E{Key: "$in", Value: ids})
+	}
+	if len(exerciseFilter) > 0 {
+		filter = append(filter, bson.E{Key: "exerciseid", Value: exerciseFilter})
+	}
+
+	if filters.SetType != "" {
+		filter = append(filter, bson.E{Key: "sets.type", Value: filters.SetType})
+	}
+
+	dateFilter := bson.D{}
+	if filters.StartDate != "" {
+		startDate, err := time.Parse("2006-01-02 15:04:05", filters.StartDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		dateFilter = append(dateFilter, bson.E{Key: "$gte", Value: startDate})
+	}
+	if filters.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02 15:04:05", filters.EndDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		dateFilter = append(dateFilter, bson.E{Key: "$lte", Value: endDate})
+	}
+	if len(dateFilter) > 0 {
+		filter = append(filter, bson.E{Key: "datetime", Value: dateFilter})
+	}
+
+	return filter, nil
+}
+
+// exerciseIdsWith returns the IDs of the exercises with the muscle and equipment of the filters
+func (s *ExerciseLogService) exerciseIdsWith(filters *LogFilters) ([]string, error) {
+	filter := bson.D{}
+	if filters.Muscle != "" {
+		filter = append(filter, bson.E{Key: "target_muscle", Value: filters.Muscle})
+	}
+	if filters.Equipment != "" {
+		filter = append(filter, bson.E{Key: "equipment", Value: filters.Equipment})
+	}
+
+	opts := options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := s.DB.Collection("exercises").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var exercises []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(context.Background(), &exercises); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(exercises))
+	for _, ex := range exercises {
+		ids = append(ids, ex.ID.Hex())
+	}
+	return ids, nil
+}
+
+func (f *LogFilters) timezone() (string, error) {
+	if f.Timezone == "" {
+		return "UTC", nil
+	}
+	if _, err := time.LoadLocation(f.Timezone); err != nil {
+		return "", ErrInvalidTimezone
+	}
+	return f.Timezone, nil
+}
+
+// setsStage keeps the sets of the filtered type, or the sets that are not warm-ups when
+// warmUps is false
+func (f *LogFilters) setsStage(warmUps bool) bson.D {
+	if f.SetType != "" {
+		return bson.D{{Key: "$match", Value: bson.D{{Key: "sets.type", Value: f.SetType}}}}
+	}
+	if warmUps {
+		return bson.D{{Key: "$match", Value: bson.D{}}}
+	}
+	return bson.D{{Key: "$match", Value: bson.D{{Key: "sets.type", Value: bson.D{{Key: "$ne", Value: WarmUpSet}}}}}}
+}
+
+// setLoad is SetLog.Load on an unwound set
+var setLoad = bson.D{{Key: "$cond", Value: bson.A{
+	bson.D{{Key: "$gt", Value: bson.A{"$sets.effectiveweight", 0}}},
+	"$sets.effectiveweight",
+	"$sets.weight",
+}}}
+
+var setVolume = bson.D{{Key: "$multiply", Value: bson.A{"$sets.reps", setLoad}}}
+
+// setRepsToFailure is SetLog.RepsToFailure on an unwound set
+var setRepsToFailure = bson.D{{Key: "$add", Value: bson.A{
+	"$sets.reps",
+	bson.D{{Key: "$ifNull", Value: bson.A{
+		"$sets.rir",
+		bson.D{{Key: "$max", Value: bson.A{bson.D{{Key: "$subtract", Value: bson.A{10, "$sets.rpe"}}}, 0}}},
+	}}},
+}}}
+
+// isoWeek groups by the ISO week of a log's date in a timezone
+func isoWeek(timezone string) bson.D {
+	date := bson.D{{Key: "date", Value: "$datetime"}, {Key: "timezone", Value: timezone}}
+	return bson.D{
+		{Key: "year", Value: bson.D{{Key: "$isoWeekYear", Value: date}}},
+		{Key: "week", Value: bson.D{{Key: "$isoWeek", Value: date}}},
+	}
+}
+
+// weekStart is the Monday that starts a week grouped by isoWeek
+func weekStart(timezone string) bson.D {
+	return bson.D{{Key: "$dateFromParts", Value: bson.D{
+		{Key: "isoWeekYear", Value: "$_id.year"},
+		{Key: "isoWeek", Value: "$_id.week"},
+		{Key: "isoDayOfWeek", Value: 1},
+		{Key: "timezone", Value: timezone},
+	}}}
+}
+
+// weeklyVolumePipeline sums the volume of the sets of each week, warm-ups included like in a log's
+// total volume
+func weeklyVolumePipeline(match bson.D, filters *LogFilters, timezone string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$sets"}},
+		filters.setsStage(true),
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: isoWeek(timezone)},
+			{Key: "volume", Value: bson.D{{Key: "$sum", Value: setVolume}}},
+			{Key: "sets", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "logs", Value: bson.D{{Key: "$addToSet", Value: "$_id"}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "week", Value: weekStart(timezone)},
+			{Key: "volume", Value: 1},
+			{Key: "sets", Value: 1},
+			{Key: "logs", Value: bson.D{{Key: "$size", Value: "$logs"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "week", Value: 1}}}},
+	}
+}
+
+// weeklyMuscleSetsPipeline counts the working sets of each target muscle per week
+func weeklyMuscleSetsPipeline(match bson.D, filters *LogFilters, timezone string) mongo.Pipeline {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$sets"}},
+		filters.setsStage(false),
+		{{Key: "$addFields", Value: bson.D{{Key: "exerciseoid", Value: bson.D{{Key: "$convert", Value: bson.D{
+			{Key: "input", Value: "$exerciseid"},
+			{Key: "to", Value: "objectId"},
+			{Key: "onError", Value: nil},
+			{Key: "onNull", Value: nil},
+		}}}}}}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "exercises"},
+			{Key: "localField", Value: "exerciseoid"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "exercise"},
+		}}},
+		{{Key: "$unwind", Value: "$exercise"}},
+		{{Key: "$unwind", Value: "$exercise.target_muscle"}},
+	}
+	if filters.Muscle != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{{Key: "exercise.target_muscle", Value: filters.Muscle}}}})
+	}
+
+	group := isoWeek(timezone)
+	group = append(group, bson.E{Key: "muscle", Value: "$exercise.target_muscle"})
+	return append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: group},
+			{Key: "sets", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "volume", Value: bson.D{{Key: "$sum", Value: setVolume}}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "week", Value: weekStart(timezone)},
+			{Key: "muscle", Value: "$_id.muscle"},
+			{Key: "sets", Value: 1},
+			{Key: "volume", Value: 1},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "week", Value: 1}, {Key: "sets", Value: -1}, {Key: "muscle", Value: 1}}}},
+	)
+}
+
+// bestSetsPipeline finds the working set with the highest estimated 1RM of each exercise, using
+// the Brzycki formula of dashboardFunctions.CalculateOneRepMax
+func bestSetsPipeline(match bson.D, filters *LogFilters) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$sets"}},
+		filters.setsStage(false),
+		{{Key: "$addFields", Value: bson.D{
+			{Key: "load", Value: setLoad},
+			{Key: "repstofailure", Value: setRepsToFailure},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "load", Value: bson.D{{Key: "$gt", Value: 0}}},
+			{Key: "repstofailure", Value: bson.D{{Key: "$gte", Value: 1}, {Key: "$lte", Value: 36}}},
+		}}},
+		{{Key: "$addFields", Value: bson.D{{Key: "estimated_one_rm", Value: bson.D{{Key: "$divide", Value: bson.A{
+			"$load",
+			bson.D{{Key: "$subtract", Value: bson.A{1.0278, bson.D{{Key: "$multiply", Value: bson.A{0.0278, "$repstofailure"}}}}}},
+		}}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "estimated_one_rm", Value: -1}, {Key: "datetime", Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$exerciseid"},
+			{Key: "logid", Value: bson.D{{Key: "$first", Value: "$_id"}}},
+			{Key: "datetime", Value: bson.D{{Key: "$first", Value: "$datetime"}}},
+			{Key: "load", Value: bson.D{{Key: "$first", Value: "$load"}}},
+			{Key: "reps", Value: bson.D{{Key: "$first", Value: "$sets.reps"}}},
+			{Key: "type", Value: bson.D{{Key: "$first", Value: "$sets.type"}}},
+			{Key: "estimated_one_rm", Value: bson.D{{Key: "$first", Value: "$estimated_one_rm"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "estimated_one_rm", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+}
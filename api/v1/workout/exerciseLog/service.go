@@ -2,16 +2,55 @@ package exerciseLog
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/unit"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/user"
+	bodyCompositionLog "github.com/Npwskp/GymsbroBackend/api/v1/userLog/userBodyComposition"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// RecordTracker keeps personal records in sync with the logs of an exercise
+type RecordTracker interface {
+	SyncRecords(userId string, exerciseId string, logId string) ([]personalRecordEnums.AchievedRecord, error)
+}
+
+// RestTracker hands over the rest timed by the user's ongoing session after a set of an exercise,
+// so it lands on the next set of that exercise. The rest is only taken once that set is written.
+type RestTracker interface {
+	PendingRest(userId string, exerciseId string, at time.Time) (*PendingRest, error)
+	TakeRest(userId string, rest *PendingRest) error
+}
+
+// PendingRest is a rest timed by a session, waiting for the next set of its exercise
+type PendingRest struct {
+	ExerciseID string
+	StartedAt  time.Time // Identifies the timer, a rest started since is left alone
+	Seconds    int       // How long the rest lasted until the set
+}
+
+// SessionTracker keeps the user's ongoing session active while its exercises are logged outside
+// of it, so the idle sweeper does not end it
+type SessionTracker interface {
+	TouchSession(userId string, exerciseId string, at time.Time) error
+}
+
 type ExerciseLogService struct {
-	DB *mongo.Database
+	DB             *mongo.Database
+	UnitService    unit.IUnitService
+	RecordTracker  RecordTracker
+	RestTracker    RestTracker
+	SessionTracker SessionTracker
 }
 
 type IExerciseLogService interface {
@@ -19,44 +58,92 @@ type IExerciseLogService interface {
 	GetLogsByUser(userId string) ([]*ExerciseLog, error)
 	GetLogsByExercise(exerciseId string, userId string) ([]*ExerciseLog, error)
 	GetLogsByDateRange(userId string, startDate, endDate time.Time) ([]*ExerciseLog, error)
+	GetLogs(userId string, filters *LogFilters, page *LogPageQuery) (*LogPage, error)
+	GetWeeklyVolume(userId string, filters *LogFilters) ([]*WeeklyVolume, error)
+	GetWeeklyMuscleSets(userId string, filters *LogFilters) ([]*WeeklyMuscleSets, error)
+	GetBestSets(userId string, filters *LogFilters) ([]*BestSet, error)
 	UpdateLog(id string, log *UpdateExerciseLogDto, userId string) (*ExerciseLog, error)
 	DeleteLog(id string, userId string) error
+	CreateLogContext(ctx context.Context, log *CreateExerciseLogDto, userId string) (*ExerciseLog, error)
+	UpdateLogContext(ctx context.Context, id string, log *UpdateExerciseLogDto, userId string) (*ExerciseLog, error)
+	DeleteLogContext(ctx context.Context, id string, userId string) (*ExerciseLog, error)
+	RefreshRecords(userId string, exerciseId string, logId string) []personalRecordEnums.AchievedRecord
+}
+
+// CalculateEffectiveWeight returns the load moved in a set performed with the given equipment.
+// Body weight exercises add the logged weight to the lifter's body weight, assisted ones subtract it.
+func CalculateEffectiveWeight(equipment exerciseEnums.Equipment, weight, bodyWeight float64) float64 {
+	if !exerciseEnums.IsBodyWeightLoaded(equipment) {
+		return weight
+	}
+	if exerciseEnums.IsAssistedEquipment(equipment) {
+		return math.Max(bodyWeight-weight, 0)
+	}
+	return bodyWeight + weight
 }
 
 func (s *ExerciseLogService) CreateLog(dto *CreateExerciseLogDto, userId string) (*ExerciseLog, error) {
-	// Calculate total volume and completed sets
-	var totalVolume float64
-	completedSets := len(dto.Sets)
+	rest := s.applyRest(dto.Sets, nil, dto.ExerciseID, userId)
 
-	for _, set := range dto.Sets {
-		totalVolume += float64(set.Reps) * set.Weight
+	createdLog, err := s.CreateLogContext(context.Background(), dto, userId)
+	if err != nil {
+		return nil, err
 	}
+	s.takeRest(userId, rest)
+
+	createdLog.PersonalRecords = s.RefreshRecords(userId, createdLog.ExerciseID, createdLog.ID.Hex())
+	s.touchSession(userId, createdLog.ExerciseID)
 
+	return createdLog, nil
+}
+
+// CreateLogContext creates a log with ctx, so it can be part of a transaction. It leaves the
+// rest timer and personal records alone, RefreshRecords brings the records up to date once the
+// log is committed.
+func (s *ExerciseLogService) CreateLogContext(ctx context.Context, dto *CreateExerciseLogDto, userId string) (*ExerciseLog, error) {
 	if dto.DateTime.IsZero() {
 		dto.DateTime = time.Now()
 	}
 
+	if dto.Unit == "" {
+		dto.Unit = s.getPreferredWeightUnit(userId)
+	}
+
+	// Store weights in kg, then calculate effective load, total volume and completed sets
+	if err := s.normaliseWeights(dto.Sets, dto.Unit); err != nil {
+		return nil, err
+	}
+
+	bodyWeight, err := s.applyEffectiveWeights(dto.Sets, dto.ExerciseID, userId, dto.DateTime)
+	if err != nil {
+		return nil, err
+	}
+
 	log := &ExerciseLog{
 		UserID:        userId,
 		ExerciseID:    dto.ExerciseID,
-		CompletedSets: completedSets,
-		TotalVolume:   totalVolume,
+		CompletedSets: len(dto.Sets),
+		TotalVolume:   calculateTotalVolume(dto.Sets),
+		Unit:          dto.Unit,
 		Notes:         dto.Notes,
 		Duration:      0, // This will be updated when the session ends
+		BodyWeight:    bodyWeight,
+		GroupID:       dto.GroupID,
+		ImportKey:     dto.ImportKey,
 		DateTime:      dto.DateTime,
 		Sets:          dto.Sets,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
 
-	result, err := s.DB.Collection("exerciseLogs").InsertOne(context.Background(), log)
+	result, err := s.DB.Collection("exerciseLogs").InsertOne(ctx, log)
 	if err != nil {
 		return nil, err
 	}
 
 	filter := bson.D{{Key: "_id", Value: result.InsertedID}}
 	createdLog := &ExerciseLog{}
-	if err := s.DB.Collection("exerciseLogs").FindOne(context.Background(), filter).Decode(createdLog); err != nil {
+	if err := s.DB.Collection("exerciseLogs").FindOne(ctx, filter).Decode(createdLog); err != nil {
 		return nil, err
 	}
 
@@ -123,11 +210,148 @@ func (s *ExerciseLogService) GetLogsByDateRange(userId string, startDate, endDat
 	return logs, nil
 }
 
+// GetLogs returns a page of the user's logs that pass the filters, newest first
+func (s *ExerciseLogService) GetLogs(userId string, filters *LogFilters, page *LogPageQuery) (*LogPage, error) {
+	filter, err := s.logFilter(userId, filters)
+	if err != nil {
+		return nil, err
+	}
+	if page.Cursor != "" {
+		at, id, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "datetime", Value: bson.D{{Key: "$lt", Value: at}}}},
+			bson.D{{Key: "datetime", Value: at}, {Key: "_id", Value: bson.D{{Key: "$lt", Value: id}}}},
+		}})
+	}
+
+	limit := page.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	// One more than the page tells whether there is a next one
+	opts := options.Find().
+		SetSort(bson.D{{Key: "datetime", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+
+	cursor, err := s.DB.Collection("exerciseLogs").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*ExerciseLog, 0)
+	if err := cursor.All(context.Background(), &logs); err != nil {
+		return nil, err
+	}
+
+	result := &LogPage{Logs: logs}
+	if len(logs) > limit {
+		result.Logs = logs[:limit]
+		result.NextCursor = encodeCursor(result.Logs[limit-1])
+	}
+	return result, nil
+}
+
+// GetWeeklyVolume sums the volume of the user's logs per week
+func (s *ExerciseLogService) GetWeeklyVolume(userId string, filters *LogFilters) ([]*WeeklyVolume, error) {
+	match, timezone, err := s.statsMatch(userId, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := make([]*WeeklyVolume, 0)
+	if err := s.aggregate(weeklyVolumePipeline(match, filters, timezone), &weeks); err != nil {
+		return nil, err
+	}
+	for _, week := range weeks {
+		week.Volume = math.Round(week.Volume*100) / 100
+	}
+	return weeks, nil
+}
+
+// GetWeeklyMuscleSets counts the working sets per target muscle per week
+func (s *ExerciseLogService) GetWeeklyMuscleSets(userId string, filters *LogFilters) ([]*WeeklyMuscleSets, error) {
+	match, timezone, err := s.statsMatch(userId, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := make([]*WeeklyMuscleSets, 0)
+	if err := s.aggregate(weeklyMuscleSetsPipeline(match, filters, timezone), &weeks); err != nil {
+		return nil, err
+	}
+	for _, week := range weeks {
+		week.Volume = math.Round(week.Volume*100) / 100
+	}
+	return weeks, nil
+}
+
+// GetBestSets returns the best working set of each exercise the user logged, strongest first
+func (s *ExerciseLogService) GetBestSets(userId string, filters *LogFilters) ([]*BestSet, error) {
+	match, _, err := s.statsMatch(userId, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([]*BestSet, 0)
+	if err := s.aggregate(bestSetsPipeline(match, filters), &sets); err != nil {
+		return nil, err
+	}
+	for _, set := range sets {
+		set.EstimatedOneRM = math.Round(set.EstimatedOneRM*100) / 100
+	}
+	return sets, nil
+}
+
+func (s *ExerciseLogService) statsMatch(userId string, filters *LogFilters) (bson.D, string, error) {
+	timezone, err := filters.timezone()
+	if err != nil {
+		return nil, "", err
+	}
+	match, err := s.logFilter(userId, filters)
+	if err != nil {
+		return nil, "", err
+	}
+	return match, timezone, nil
+}
+
+func (s *ExerciseLogService) aggregate(pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := s.DB.Collection("exerciseLogs").Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(context.Background(), results)
+}
+
 func (s *ExerciseLogService) UpdateLog(id string, dto *UpdateExerciseLogDto, userId string) (*ExerciseLog, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+	result, rest, err := s.updateLog(context.Background(), id, dto, userId, true)
 	if err != nil {
 		return nil, err
 	}
+	s.takeRest(userId, rest)
+
+	result.PersonalRecords = s.RefreshRecords(userId, result.ExerciseID, result.ID.Hex())
+	s.touchSession(userId, result.ExerciseID)
+
+	return result, nil
+}
+
+// UpdateLogContext updates a log with ctx, leaving the rest timer and personal records alone like
+// CreateLogContext
+func (s *ExerciseLogService) UpdateLogContext(ctx context.Context, id string, dto *UpdateExerciseLogDto, userId string) (*ExerciseLog, error) {
+	log, _, err := s.updateLog(ctx, id, dto, userId, false)
+	return log, err
+}
+
+// updateLog updates a log. With withRest, the pending rest of the session goes to the first new
+// set and is returned, for the caller to take once the update is committed.
+func (s *ExerciseLogService) updateLog(ctx context.Context, id string, dto *UpdateExerciseLogDto, userId string, withRest bool) (*ExerciseLog, *PendingRest, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if dto.DateTime.IsZero() {
 		dto.DateTime = time.Now()
@@ -138,43 +362,347 @@ func (s *ExerciseLogService) UpdateLog(id string, dto *UpdateExerciseLogDto, use
 		{Key: "userid", Value: userId},
 	}
 
+	existingLog := &ExerciseLog{}
+	if err := s.DB.Collection("exerciseLogs").FindOne(ctx, filter).Decode(existingLog); err != nil {
+		return nil, nil, err
+	}
+
+	if dto.Unit == "" {
+		dto.Unit = existingLog.Unit
+	}
+	if dto.GroupID == "" {
+		dto.GroupID = existingLog.GroupID
+	}
+	if dto.Unit == "" {
+		dto.Unit = s.getPreferredWeightUnit(userId)
+	}
+
+	if err := s.normaliseWeights(dto.Sets, dto.Unit); err != nil {
+		return nil, nil, err
+	}
+
+	bodyWeight, err := s.applyEffectiveWeights(dto.Sets, existingLog.ExerciseID, userId, dto.DateTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rest *PendingRest
+	if withRest {
+		rest = s.applyRest(dto.Sets, existingLog.Sets, existingLog.ExerciseID, userId)
+	}
+
 	update := bson.D{{Key: "$set", Value: bson.D{
 		{Key: "sets", Value: dto.Sets},
 		{Key: "notes", Value: dto.Notes},
 		{Key: "datetime", Value: dto.DateTime},
+		{Key: "completed_sets", Value: len(dto.Sets)},
+		{Key: "total_volume", Value: calculateTotalVolume(dto.Sets)},
+		{Key: "bodyweight", Value: bodyWeight},
+		{Key: "unit", Value: dto.Unit},
+		{Key: "groupid", Value: dto.GroupID},
+		{Key: "updated_at", Value: time.Now()},
 	}}}
 
 	after := options.After
 	opts := options.FindOneAndUpdate().SetReturnDocument(after)
 
 	result := &ExerciseLog{}
-	err = s.DB.Collection("exerciseLogs").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(result)
+	err = s.DB.Collection("exerciseLogs").FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return result, nil
+	return result, rest, nil
 }
 
 func (s *ExerciseLogService) DeleteLog(id string, userId string) error {
-	oid, err := primitive.ObjectIDFromHex(id)
+	deletedLog, err := s.DeleteLogContext(context.Background(), id, userId)
 	if err != nil {
 		return err
 	}
 
+	s.RefreshRecords(userId, deletedLog.ExerciseID, "")
+
+	return nil
+}
+
+// DeleteLogContext deletes a log with ctx and returns it, leaving personal records alone like
+// CreateLogContext
+func (s *ExerciseLogService) DeleteLogContext(ctx context.Context, id string, userId string) (*ExerciseLog, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
 	filter := bson.D{
 		{Key: "_id", Value: oid},
 		{Key: "userid", Value: userId},
 	}
 
-	result, err := s.DB.Collection("exerciseLogs").DeleteOne(context.Background(), filter)
+	deletedLog := &ExerciseLog{}
+	if err := s.DB.Collection("exerciseLogs").FindOneAndDelete(ctx, filter).Decode(deletedLog); err != nil {
+		return nil, err
+	}
+
+	return deletedLog, nil
+}
+
+// RefreshRecords updates the personal records of an exercise after one of its logs changed.
+// A failure here must not fail the log write, so it is only reported.
+func (s *ExerciseLogService) RefreshRecords(userId string, exerciseId string, logId string) []personalRecordEnums.AchievedRecord {
+	if s.RecordTracker == nil {
+		return nil
+	}
+
+	achieved, err := s.RecordTracker.SyncRecords(userId, exerciseId, logId)
 	if err != nil {
-		return err
+		fmt.Printf("Error syncing personal records: %v\n", err)
+		return nil
+	}
+
+	return achieved
+}
+
+// applyRest records the rest the ongoing session timed after a set of the exercise on the first
+// newly logged set, unless that set has a rest of its own. It returns the rest recorded, to take
+// once the set is written.
+func (s *ExerciseLogService) applyRest(sets []SetLog, previous []SetLog, exerciseId string, userId string) *PendingRest {
+	next := NextSet(sets, previous)
+	if s.RestTracker == nil || next < 0 || sets[next].RestSeconds > 0 {
+		return nil
 	}
 
-	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+	at := sets[next].CompletedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	rest, err := s.RestTracker.PendingRest(userId, exerciseId, at)
+	if err != nil {
+		fmt.Printf("Error reading rest: %v\n", err)
+		return nil
+	}
+	if rest != nil {
+		sets[next].RestSeconds = rest.Seconds
+	}
+	return rest
+}
+
+// takeRest clears the rest recorded by applyRest once its set is written
+func (s *ExerciseLogService) takeRest(userId string, rest *PendingRest) {
+	if rest == nil {
+		return
+	}
+	if err := s.RestTracker.TakeRest(userId, rest); err != nil {
+		fmt.Printf("Error taking rest: %v\n", err)
+	}
+}
+
+// touchSession marks the ongoing session holding the exercise as active. The log is already
+// written, so failures are only reported.
+func (s *ExerciseLogService) touchSession(userId string, exerciseId string) {
+	if s.SessionTracker == nil {
+		return
+	}
+	if err := s.SessionTracker.TouchSession(userId, exerciseId, time.Now()); err != nil {
+		fmt.Printf("Error touching session: %v\n", err)
+	}
+}
+
+// NextSet returns the index of the lowest numbered set that is not among the previous sets, -1
+// when no set is new
+func NextSet(sets []SetLog, previous []SetLog) int {
+	logged := make(map[int]bool, len(previous))
+	for _, set := range previous {
+		logged[set.SetNumber] = true
 	}
 
+	next := -1
+	for i, set := range sets {
+		if logged[set.SetNumber] {
+			continue
+		}
+		if next < 0 || set.SetNumber < sets[next].SetNumber {
+			next = i
+		}
+	}
+	return next
+}
+
+// normaliseWeights keeps the entered weight of each set and converts Weight to kg. A set giving
+// its entered weight is read from it, so a log read back and sent unchanged, or a write retried,
+// converts its weights once; other sets are read from Weight.
+func (s *ExerciseLogService) normaliseWeights(sets []SetLog, weightUnit unitEnums.ExerciseWeightUnit) error {
+	for i := range sets {
+		if sets[i].EnteredWeight == 0 {
+			sets[i].EnteredWeight = sets[i].Weight
+		}
+		sets[i].Weight = sets[i].EnteredWeight
+		if weightUnit == unitEnums.ExerciseWeightUnitKg {
+			continue
+		}
+
+		weightInKg, err := s.UnitService.ConvertUnits(sets[i].EnteredWeight, string(weightUnit), string(unitEnums.ExerciseWeightUnitKg), "scale")
+		if err != nil {
+			return err
+		}
+		sets[i].Weight = math.Round(weightInKg*100) / 100
+	}
 	return nil
 }
+
+// getPreferredWeightUnit returns the user's weight unit, defaulting to kg
+func (s *ExerciseLogService) getPreferredWeightUnit(userId string) unitEnums.ExerciseWeightUnit {
+	userOid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+
+	userObj := &user.User{}
+	if err := s.DB.Collection("users").FindOne(context.Background(), bson.D{{Key: "_id", Value: userOid}}).Decode(userObj); err != nil {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+	if userObj.WeightUnit == "" {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+
+	return userObj.WeightUnit
+}
+
+// MigrateWeightUnits tags logs recorded before weight units existed with weightUnit, the unit their
+// weights were entered in, and normalises their stored weights to kg. The user's weight unit is
+// not used, it was chosen after those logs were entered. Logs that already have a unit are left
+// untouched, logs that cannot be migrated are reported and skipped so they are tried again on the
+// next run. Body weight loaded sets keep no effective weight when the log has no body weight to
+// work it out.
+func (s *ExerciseLogService) MigrateWeightUnits(weightUnit unitEnums.ExerciseWeightUnit) (int, error) {
+	filter := bson.D{{Key: "unit", Value: bson.D{{Key: "$exists", Value: false}}}}
+	cursor, err := s.DB.Collection("exerciseLogs").Find(context.Background(), filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	migrated := 0
+	for cursor.Next(context.Background()) {
+		log := &ExerciseLog{}
+		if err := cursor.Decode(log); err != nil {
+			fmt.Printf("Error migrating exercise log %v: %v\n", cursor.Current.Lookup("_id"), err)
+			continue
+		}
+		if err := s.migrateWeightUnit(log, weightUnit); err != nil {
+			fmt.Printf("Error migrating exercise log %s: %v\n", log.ID.Hex(), err)
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, cursor.Err()
+}
+
+func (s *ExerciseLogService) migrateWeightUnit(log *ExerciseLog, weightUnit unitEnums.ExerciseWeightUnit) error {
+	if err := s.normaliseWeights(log.Sets, weightUnit); err != nil {
+		return err
+	}
+
+	equipment, err := s.getExerciseEquipment(log.ExerciseID)
+	if err != nil {
+		return err
+	}
+	if !exerciseEnums.IsBodyWeightLoaded(equipment) || log.BodyWeight > 0 {
+		for i := range log.Sets {
+			log.Sets[i].EffectiveWeight = CalculateEffectiveWeight(equipment, log.Sets[i].Weight, log.BodyWeight)
+		}
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "sets", Value: log.Sets},
+		{Key: "unit", Value: weightUnit},
+		{Key: "total_volume", Value: calculateTotalVolume(log.Sets)},
+	}}}
+	_, err = s.DB.Collection("exerciseLogs").UpdateByID(context.Background(), log.ID, update)
+	return err
+}
+
+// applyEffectiveWeights fills in the effective weight of each set and returns the body weight
+// used, which is zero when the exercise is not body weight loaded
+func (s *ExerciseLogService) applyEffectiveWeights(sets []SetLog, exerciseId string, userId string, at time.Time) (float64, error) {
+	equipment, err := s.getExerciseEquipment(exerciseId)
+	if err != nil {
+		return 0, err
+	}
+
+	var bodyWeight float64
+	if exerciseEnums.IsBodyWeightLoaded(equipment) {
+		bodyWeight, err = s.getBodyWeightAt(userId, at)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for i := range sets {
+		sets[i].EffectiveWeight = CalculateEffectiveWeight(equipment, sets[i].Weight, bodyWeight)
+	}
+
+	return bodyWeight, nil
+}
+
+func (s *ExerciseLogService) getExerciseEquipment(exerciseId string) (exerciseEnums.Equipment, error) {
+	oid, err := primitive.ObjectIDFromHex(exerciseId)
+	if err != nil {
+		return "", err
+	}
+
+	ex := &exercise.Exercise{}
+	err = s.DB.Collection("exercises").FindOne(context.Background(), bson.D{{Key: "_id", Value: oid}}).Decode(ex)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Unknown exercises are treated as externally loaded
+			return "", nil
+		}
+		return "", err
+	}
+
+	return ex.Equipment, nil
+}
+
+// getBodyWeightAt returns the user's latest logged body weight at the given time,
+// falling back to the weight on the user's profile
+func (s *ExerciseLogService) getBodyWeightAt(userId string, at time.Time) (float64, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "weight", Value: bson.D{{Key: "$gt", Value: 0}}},
+		{Key: "created_at", Value: bson.D{{Key: "$lte", Value: at}}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	bodyLog := &bodyCompositionLog.UserBodyCompositionLog{}
+	err := s.DB.Collection("bodyCompositionLog").FindOne(context.Background(), filter, opts).Decode(bodyLog)
+	if err == nil {
+		return bodyLog.Weight, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+
+	userOid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	userObj := &user.User{}
+	if err := s.DB.Collection("users").FindOne(context.Background(), bson.D{{Key: "_id", Value: userOid}}).Decode(userObj); err != nil {
+		return 0, err
+	}
+	if userObj.Weight <= 0 {
+		return 0, errors.New("body weight is required to log body weight exercises")
+	}
+
+	return userObj.Weight, nil
+}
+
+func calculateTotalVolume(sets []SetLog) float64 {
+	var totalVolume float64
+	for _, set := range sets {
+		totalVolume += float64(set.Reps) * set.Load()
+	}
+	return totalVolume
+}
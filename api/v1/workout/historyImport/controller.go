@@ -0,0 +1,170 @@
+package historyImport
+
+import (
+	"errors"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Error error
+
+type HistoryImportController struct {
+	Instance fiber.Router
+	Service  IHistoryImportService
+}
+
+// @Summary     Import workout history
+// @Description Read a Strong, Hevy or FitNotes CSV export and match its exercise names to exercises. Names that match none closely are left for the mapping step.
+// @Tags        history-import
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       file formData file true "CSV export"
+// @Param       source formData string false "App the file comes from, detected when not set" Enums(strong, hevy, fitnotes)
+// @Param       unit formData string false "Weight unit of Strong exports, the user's when not set" Enums(kg, lbs)
+// @Param       timezone formData string false "Timezone of the dates in the file, UTC when not set"
+// @Success     201 {object} HistoryImport
+// @Failure     400 {object} Error
+// @Router      /history-import [post]
+func (c *HistoryImportController) CreateImportHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(CreateImportDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No file uploaded",
+		})
+	}
+	content, err := file.Open()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to process file",
+		})
+	}
+	defer content.Close()
+
+	history, err := c.Service.CreateImport(content, dto, userId)
+	if err != nil {
+		return importError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(history)
+}
+
+// @Summary     Get a history import
+// @Description Get an import with how its exercise names are mapped
+// @Tags        history-import
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Import ID"
+// @Success     200 {object} HistoryImport
+// @Failure     404 {object} Error
+// @Router      /history-import/{id} [get]
+func (c *HistoryImportController) GetImportHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	history, err := c.Service.GetImport(ctx.Params("id"), userId)
+	if err != nil {
+		return importError(ctx, err)
+	}
+
+	return ctx.JSON(history)
+}
+
+// @Summary     Map exercise names of an import
+// @Description Map exercise names of the file to exercises, or skip them to leave their sets out
+// @Tags        history-import
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Import ID"
+// @Param       mappings body MapExercisesDto true "Mappings"
+// @Success     200 {object} HistoryImport
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /history-import/{id}/mappings [put]
+func (c *HistoryImportController) MapExercisesHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(MapExercisesDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	history, err := c.Service.MapExercises(ctx.Params("id"), dto, userId)
+	if err != nil {
+		return importError(ctx, err)
+	}
+
+	return ctx.JSON(history)
+}
+
+// @Summary     Commit a history import
+// @Description Create a logged session with its exercise logs for every workout of the import. Workouts imported before are skipped, so committing an import of the same history again creates nothing twice.
+// @Tags        history-import
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Import ID"
+// @Success     200 {object} HistoryImport
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /history-import/{id}/commit [post]
+func (c *HistoryImportController) CommitImportHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	history, err := c.Service.CommitImport(ctx.Params("id"), userId)
+	if err != nil {
+		return importError(ctx, err)
+	}
+
+	return ctx.JSON(history)
+}
+
+func importError(ctx *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnknownFormat), errors.Is(err, ErrNoWorkouts), errors.Is(err, ErrInvalidRow),
+		errors.Is(err, ErrInvalidTimezone), errors.Is(err, ErrUnknownName), errors.Is(err, ErrUnknownExercise),
+		err == primitive.ErrInvalidHex:
+		status = fiber.StatusBadRequest
+	case err == mongo.ErrNoDocuments:
+		status = fiber.StatusNotFound
+	case err == ErrUnmappedExercises, err == ErrImportCompleted:
+		status = fiber.StatusConflict
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
+func (c *HistoryImportController) Handle() {
+	g := c.Instance.Group("/history-import")
+
+	g.Post("/", c.CreateImportHandler)
+	g.Get("/:id", c.GetImportHandler)
+	g.Put("/:id/mappings", c.MapExercisesHandler)
+	g.Post("/:id/commit", c.CommitImportHandler)
+}
@@ -0,0 +1,25 @@
+package historyImport
+
+import (
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+)
+
+// CreateImportDto comes with the uploaded file. Source is detected from the file when not set, and
+// Unit is the weight unit of files that do not name one, the user's weight unit when not set.
+// Dates of the file are read in Timezone, UTC when not set.
+type CreateImportDto struct {
+	Source   Source                       `form:"source" validate:"omitempty,oneof=strong hevy fitnotes"`
+	Unit     unitEnums.ExerciseWeightUnit `form:"unit" validate:"omitempty,oneof=kg lbs"`
+	Timezone string                       `form:"timezone"`
+}
+
+type MapExercisesDto struct {
+	Mappings []ExerciseMappingDto `json:"mappings" validate:"required,min=1,dive"`
+}
+
+// ExerciseMappingDto maps an exercise name of the file to an exercise, or skips it
+type ExerciseMappingDto struct {
+	Name       string `json:"name" validate:"required"`
+	ExerciseID string `json:"exerciseId" validate:"required_without=Skip"`
+	Skip       bool   `json:"skip"`
+}
@@ -0,0 +1,97 @@
+package historyImport
+
+import (
+	"time"
+
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Source string
+
+const (
+	StrongSource   Source = "strong"
+	HevySource     Source = "hevy"
+	FitNotesSource Source = "fitnotes"
+)
+
+type ImportStatus string
+
+const (
+	StatusMapping   ImportStatus = "mapping" // Some exercise names still need an exercise
+	StatusReady     ImportStatus = "ready"
+	StatusCompleted ImportStatus = "completed"
+)
+
+type MappingStatus string
+
+const (
+	MatchedMapping   MappingStatus = "matched" // Matched by name
+	MappedMapping    MappingStatus = "mapped"  // Chosen by the user
+	UnmatchedMapping MappingStatus = "unmatched"
+	SkippedMapping   MappingStatus = "skipped" // Left out of the import
+)
+
+// HistoryImport is a workout history exported from another app. It is kept until its exercise
+// names are mapped to exercises and it is committed. Weights of its sets are in Unit.
+type HistoryImport struct {
+	ID           primitive.ObjectID           `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID       string                       `json:"userid" bson:"userid"`
+	Source       Source                       `json:"source" bson:"source"`
+	Unit         unitEnums.ExerciseWeightUnit `json:"unit" bson:"unit"`
+	Status       ImportStatus                 `json:"status" bson:"status"`
+	WorkoutCount int                          `json:"workout_count" bson:"workout_count"`
+	SetCount     int                          `json:"set_count" bson:"set_count"`
+	FirstWorkout time.Time                    `json:"first_workout" bson:"first_workout"`
+	LastWorkout  time.Time                    `json:"last_workout" bson:"last_workout"`
+	Mappings     []ExerciseMapping            `json:"mappings" bson:"mappings"`
+	Workouts     []ImportedWorkout            `json:"-" bson:"workouts"`
+	Result       *ImportResult                `json:"result,omitempty" bson:"result,omitempty"` // Set once committed
+	CreatedAt    time.Time                    `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time                    `json:"updated_at" bson:"updated_at"`
+}
+
+// ImportedWorkout is a workout read from the file. Key is the same for the workout in every
+// import of it, which is how it gets imported only once.
+type ImportedWorkout struct {
+	Key       string             `json:"key" bson:"key"`
+	Name      string             `json:"name" bson:"name"`
+	StartTime time.Time          `json:"start_time" bson:"start_time"`
+	EndTime   time.Time          `json:"end_time" bson:"end_time"`
+	Notes     string             `json:"notes" bson:"notes"`
+	Exercises []ImportedExercise `json:"exercises" bson:"exercises"`
+}
+
+type ImportedExercise struct {
+	Name  string        `json:"name" bson:"name"` // As named in the file
+	Notes string        `json:"notes" bson:"notes"`
+	Sets  []ImportedSet `json:"sets" bson:"sets"`
+}
+
+// ImportedSet is a set read from the file. Negative weights are assistance.
+type ImportedSet struct {
+	Weight float64             `json:"weight" bson:"weight"`
+	Reps   int                 `json:"reps" bson:"reps"`
+	Type   exerciseLog.SetType `json:"type" bson:"type"`
+	RPE    *float64            `json:"rpe,omitempty" bson:"rpe,omitempty"`
+}
+
+// ExerciseMapping is the exercise an exercise name of the file is imported as
+type ExerciseMapping struct {
+	Name         string               `json:"name" bson:"name"`
+	Status       MappingStatus        `json:"status" bson:"status"`
+	ExerciseID   string               `json:"exerciseid,omitempty" bson:"exerciseid,omitempty"`
+	ExerciseName string               `json:"exercise_name,omitempty" bson:"exercise_name,omitempty"`
+	Score        float64              `json:"score,omitempty" bson:"score,omitempty"`           // How closely the names match when matched by name
+	Candidates   []exercise.NameMatch `json:"candidates,omitempty" bson:"candidates,omitempty"` // Closest exercises of unmatched names
+	Sets         int                  `json:"sets" bson:"sets"`
+}
+
+type ImportResult struct {
+	Sessions int `json:"sessions" bson:"sessions"`
+	Logs     int `json:"logs" bson:"logs"`
+	Sets     int `json:"sets" bson:"sets"`
+	Skipped  int `json:"skipped" bson:"skipped"` // Workouts imported before or with only skipped exercises
+}
@@ -0,0 +1,414 @@
+package historyImport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+)
+
+var (
+	ErrUnknownFormat = errors.New("the file is not a Strong, Hevy or FitNotes CSV export")
+	ErrNoWorkouts    = errors.New("the file has no workouts")
+	ErrInvalidRow    = errors.New("the file has a row that cannot be read")
+)
+
+var (
+	strongDateLayouts   = []string{"2006-01-02 15:04:05", "2006-01-02 15:04"}
+	hevyDateLayouts     = []string{"2 Jan 2006, 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05"}
+	fitNotesDateLayouts = []string{"2006-01-02"}
+	durationPart        = regexp.MustCompile(`(\d+)\s*([hms])`)
+)
+
+// ParseOptions tell how to read a file. Source is detected from the header when not set, Unit is
+// the weight unit of files that do not name one and dates are read in Location, UTC when nil.
+type ParseOptions struct {
+	Source   Source
+	Unit     unitEnums.ExerciseWeightUnit
+	Location *time.Location
+}
+
+// ParsedHistory is the workouts of a file in the order they appear. Unit is empty when neither
+// the file nor the options name one.
+type ParsedHistory struct {
+	Source   Source
+	Unit     unitEnums.ExerciseWeightUnit
+	Workouts []ImportedWorkout
+}
+
+// Parse reads a Strong, Hevy or FitNotes CSV export. Sets without reps, such as cardio, are left out.
+func Parse(r io.Reader, opts ParseOptions) (*ParsedHistory, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	table, err := readTable(r)
+	if err != nil {
+		return nil, err
+	}
+
+	source := opts.Source
+	if source == "" {
+		source = table.detectSource()
+	}
+
+	parser := &historyParser{source: source, location: opts.Location, workouts: make(map[string]*ImportedWorkout)}
+	var unit unitEnums.ExerciseWeightUnit
+	switch source {
+	case StrongSource:
+		unit, err = parser.parseStrong(table, opts.Unit)
+	case HevySource:
+		unit, err = parser.parseHevy(table)
+	case FitNotesSource:
+		unit, err = parser.parseFitNotes(table)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(parser.order) == 0 {
+		return nil, ErrNoWorkouts
+	}
+
+	return &ParsedHistory{Source: source, Unit: unit, Workouts: parser.result()}, nil
+}
+
+// WorkoutKey identifies a workout of another app by when it started and its name
+func WorkoutKey(source Source, start time.Time, name string) string {
+	sum := sha256.Sum256([]byte(start.UTC().Format(time.RFC3339) + "|" + strings.TrimSpace(name)))
+	return string(source) + ":" + hex.EncodeToString(sum[:12])
+}
+
+type table struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// readTable reads a CSV file with a header row, separated by commas or, in some locales, semicolons
+func readTable(r io.Reader) (*table, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRow, err)
+	}
+	if len(records) == 0 {
+		return nil, ErrUnknownFormat
+	}
+
+	t := &table{columns: make(map[string]int), rows: records[1:]}
+	for i, column := range records[0] {
+		t.columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	return t, nil
+}
+
+func (t *table) has(columns ...string) bool {
+	for _, column := range columns {
+		if _, ok := t.columns[column]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *table) value(row []string, column string) string {
+	i, ok := t.columns[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func (t *table) detectSource() Source {
+	switch {
+	case t.has("exercise_title", "start_time"):
+		return HevySource
+	case t.has("exercise name", "set order"):
+		return StrongSource
+	case t.has("date", "exercise", "category"):
+		return FitNotesSource
+	}
+	return ""
+}
+
+// unitColumn is a column weights can be in, with their unit
+type unitColumn struct {
+	name string
+	unit unitEnums.ExerciseWeightUnit
+}
+
+// weightColumn finds the first of the weight columns the table has
+func (t *table) weightColumn(columns ...unitColumn) (string, unitEnums.ExerciseWeightUnit, bool) {
+	for _, column := range columns {
+		if t.has(column.name) {
+			return column.name, column.unit, true
+		}
+	}
+	return "", "", false
+}
+
+// historyParser groups the rows of a file into workouts and their exercises
+type historyParser struct {
+	source   Source
+	location *time.Location
+	workouts map[string]*ImportedWorkout
+	order    []string
+}
+
+// add adds a set to the exercise of a workout, creating both when they are new
+func (p *historyParser) add(start, end time.Time, name, notes, exerciseName, exerciseNotes string, set ImportedSet) {
+	key := WorkoutKey(p.source, start, name)
+	workout, ok := p.workouts[key]
+	if !ok {
+		workout = &ImportedWorkout{Key: key, Name: name, StartTime: start, EndTime: end, Notes: notes}
+		p.workouts[key] = workout
+		p.order = append(p.order, key)
+	}
+	if end.After(workout.EndTime) {
+		workout.EndTime = end
+	}
+	if workout.Notes == "" {
+		workout.Notes = notes
+	}
+
+	for i := range workout.Exercises {
+		if workout.Exercises[i].Name == exerciseName {
+			if workout.Exercises[i].Notes == "" {
+				workout.Exercises[i].Notes = exerciseNotes
+			}
+			workout.Exercises[i].Sets = append(workout.Exercises[i].Sets, set)
+			return
+		}
+	}
+	workout.Exercises = append(workout.Exercises, ImportedExercise{Name: exerciseName, Notes: exerciseNotes, Sets: []ImportedSet{set}})
+}
+
+func (p *historyParser) result() []ImportedWorkout {
+	workouts := make([]ImportedWorkout, 0, len(p.order))
+	for _, key := range p.order {
+		workout := p.workouts[key]
+		if workout.EndTime.Before(workout.StartTime) {
+			workout.EndTime = workout.StartTime
+		}
+		workouts = append(workouts, *workout)
+	}
+	return workouts
+}
+
+// parseStrong reads a Strong export. Its weights are in the unit set in the app, which the file
+// does not name.
+func (p *historyParser) parseStrong(t *table, unit unitEnums.ExerciseWeightUnit) (unitEnums.ExerciseWeightUnit, error) {
+	if !t.has("date", "workout name", "exercise name", "set order", "reps") {
+		return "", ErrUnknownFormat
+	}
+	weightColumn, weightUnit, ok := t.weightColumn(
+		unitColumn{"weight (kg)", unitEnums.ExerciseWeightUnitKg},
+		unitColumn{"weight (lbs)", unitEnums.ExerciseWeightUnitPound},
+		unitColumn{"weight", unit},
+	)
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+
+	for i, row := range t.rows {
+		setOrder := strings.ToUpper(t.value(row, "set order"))
+		setType := exerciseLog.WorkingSet
+		switch setOrder {
+		case "W":
+			setType = exerciseLog.WarmUpSet
+		case "D":
+			setType = exerciseLog.DropSet
+		case "F":
+			setType = exerciseLog.FailureSet
+		default:
+			// Rest timer and note rows
+			if _, err := strconv.Atoi(setOrder); err != nil {
+				continue
+			}
+		}
+
+		set, ok, err := readSet(t, row, weightColumn, "reps", "rpe", setType)
+		if err != nil {
+			return "", rowError(i, err)
+		}
+		if !ok {
+			continue
+		}
+
+		start, err := parseDate(t.value(row, "date"), strongDateLayouts, p.location)
+		if err != nil {
+			return "", rowError(i, err)
+		}
+		end := start.Add(parseDuration(t.value(row, "duration")))
+		p.add(start, end, t.value(row, "workout name"), t.value(row, "workout notes"), t.value(row, "exercise name"), t.value(row, "notes"), set)
+	}
+	return weightUnit, nil
+}
+
+// parseHevy reads a Hevy export, which has its weights in kg or lbs columns
+func (p *historyParser) parseHevy(t *table) (unitEnums.ExerciseWeightUnit, error) {
+	if !t.has("title", "start_time", "end_time", "exercise_title", "reps") {
+		return "", ErrUnknownFormat
+	}
+	weightColumn, unit, ok := t.weightColumn(
+		unitColumn{"weight_kg", unitEnums.ExerciseWeightUnitKg},
+		unitColumn{"weight_lbs", unitEnums.ExerciseWeightUnitPound},
+	)
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+
+	for i, row := range t.rows {
+		setType := exerciseLog.WorkingSet
+		switch t.value(row, "set_type") {
+		case "warmup":
+			setType = exerciseLog.WarmUpSet
+		case "dropset":
+			setType = exerciseLog.DropSet
+		case "failure":
+			setType = exerciseLog.FailureSet
+		}
+
+		set, ok, err := readSet(t, row, weightColumn, "reps", "rpe", setType)
+		if err != nil {
+			return "", rowError(i, err)
+		}
+		if !ok {
+			continue
+		}
+
+		start, err := parseDate(t.value(row, "start_time"), hevyDateLayouts, p.location)
+		if err != nil {
+			return "", rowError(i, err)
+		}
+		end, err := parseDate(t.value(row, "end_time"), hevyDateLayouts, p.location)
+		if err != nil {
+			end = start
+		}
+		p.add(start, end, t.value(row, "title"), t.value(row, "description"), t.value(row, "exercise_title"), t.value(row, "exercise_notes"), set)
+	}
+	return unit, nil
+}
+
+// parseFitNotes reads a FitNotes export. It has no workouts, every day is taken as one.
+func (p *historyParser) parseFitNotes(t *table) (unitEnums.ExerciseWeightUnit, error) {
+	if !t.has("date", "exercise", "reps") {
+		return "", ErrUnknownFormat
+	}
+	weightColumn, unit, ok := t.weightColumn(
+		unitColumn{"weight (kgs)", unitEnums.ExerciseWeightUnitKg},
+		unitColumn{"weight (kg)", unitEnums.ExerciseWeightUnitKg},
+		unitColumn{"weight (lbs)", unitEnums.ExerciseWeightUnitPound},
+	)
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+
+	for i, row := range t.rows {
+		set, ok, err := readSet(t, row, weightColumn, "reps", "", exerciseLog.WorkingSet)
+		if err != nil {
+			return "", rowError(i, err)
+		}
+		if !ok {
+			continue
+		}
+
+		start, err := parseDate(t.value(row, "date"), fitNotesDateLayouts, p.location)
+		if err != nil {
+			return "", rowError(i, err)
+		}
+		p.add(start, start, "", "", t.value(row, "exercise"), t.value(row, "comment"), set)
+	}
+	return unit, nil
+}
+
+// readSet reads the set of a row, false for rows without reps
+func readSet(t *table, row []string, weightColumn, repsColumn, rpeColumn string, setType exerciseLog.SetType) (ImportedSet, bool, error) {
+	reps, err := parseNumber(t.value(row, repsColumn))
+	if err != nil {
+		return ImportedSet{}, false, err
+	}
+	if reps < 1 {
+		return ImportedSet{}, false, nil
+	}
+	weight, err := parseNumber(t.value(row, weightColumn))
+	if err != nil {
+		return ImportedSet{}, false, err
+	}
+
+	set := ImportedSet{Weight: weight, Reps: int(reps), Type: setType}
+	if rpe, err := parseNumber(t.value(row, rpeColumn)); err == nil && rpe >= 1 && rpe <= 10 {
+		set.RPE = &rpe
+	}
+	return set, true, nil
+}
+
+// parseNumber reads a number written with a decimal point or comma, 0 when empty
+func parseNumber(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+}
+
+func parseDate(value string, layouts []string, location *time.Location) (time.Time, error) {
+	for _, layout := range layouts {
+		if date, err := time.ParseInLocation(layout, value, location); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date %q", value)
+}
+
+// parseDuration reads durations such as "1h 5m" or a number of seconds, 0 when it cannot
+func parseDuration(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	var duration time.Duration
+	for _, part := range durationPart.FindAllStringSubmatch(value, -1) {
+		amount, _ := strconv.Atoi(part[1])
+		switch part[2] {
+		case "h":
+			duration += time.Duration(amount) * time.Hour
+		case "m":
+			duration += time.Duration(amount) * time.Minute
+		case "s":
+			duration += time.Duration(amount) * time.Second
+		}
+	}
+	return duration
+}
+
+// rowError names the line of the file a row is on, the header being line 1
+func rowError(i int, err error) error {
+	return fmt.Errorf("%w: line %d: %v", ErrInvalidRow, i+2, err)
+}
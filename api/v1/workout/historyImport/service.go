@@ -0,0 +1,488 @@
+package historyImport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/user"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AutoMatchScore = 0.8 // Names matching an exercise this closely are mapped without asking
+	MaxCandidates  = 5
+)
+
+var (
+	ErrInvalidTimezone   = errors.New("unknown timezone")
+	ErrUnknownName       = errors.New("the import has no exercise with this name")
+	ErrUnknownExercise   = errors.New("exercise not found")
+	ErrUnmappedExercises = errors.New("some exercise names are not mapped to an exercise yet")
+	ErrImportCompleted   = errors.New("the import is already completed")
+)
+
+type HistoryImportService struct {
+	DB                 *mongo.Database
+	ExerciseService    exercise.IExerciseService
+	ExerciseLogService exerciseLog.IExerciseLogService
+}
+
+type IHistoryImportService interface {
+	CreateImport(file io.Reader, dto *CreateImportDto, userId string) (*HistoryImport, error)
+	GetImport(id string, userId string) (*HistoryImport, error)
+	MapExercises(id string, dto *MapExercisesDto, userId string) (*HistoryImport, error)
+	CommitImport(id string, userId string) (*HistoryImport, error)
+}
+
+// CreateImport reads an exported history and maps its exercise names to exercises. Names the
+// user mapped in an earlier import keep that mapping, the others are matched by name.
+func (s *HistoryImportService) CreateImport(file io.Reader, dto *CreateImportDto, userId string) (*HistoryImport, error) {
+	location := time.UTC
+	if dto.Timezone != "" {
+		loc, err := time.LoadLocation(dto.Timezone)
+		if err != nil {
+			return nil, ErrInvalidTimezone
+		}
+		location = loc
+	}
+
+	parsed, err := Parse(file, ParseOptions{Source: dto.Source, Unit: dto.Unit, Location: location})
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Unit == "" {
+		parsed.Unit = s.weightUnit(userId)
+	}
+
+	exercises, err := s.ExerciseService.GetAllExercises(userId)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.previousMappings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &HistoryImport{
+		UserID:       userId,
+		Source:       parsed.Source,
+		Unit:         parsed.Unit,
+		WorkoutCount: len(parsed.Workouts),
+		FirstWorkout: parsed.Workouts[0].StartTime,
+		LastWorkout:  parsed.Workouts[0].StartTime,
+		Workouts:     parsed.Workouts,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	sets := make(map[string]int)
+	names := make([]string, 0)
+	for _, w := range parsed.Workouts {
+		if w.StartTime.Before(history.FirstWorkout) {
+			history.FirstWorkout = w.StartTime
+		}
+		if w.StartTime.After(history.LastWorkout) {
+			history.LastWorkout = w.StartTime
+		}
+		for _, ex := range w.Exercises {
+			if _, ok := sets[ex.Name]; !ok {
+				names = append(names, ex.Name)
+			}
+			sets[ex.Name] += len(ex.Sets)
+			history.SetCount += len(ex.Sets)
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, ex := range exercises {
+		ids[ex.ID.Hex()] = true
+	}
+	for _, name := range names {
+		mapping, ok := previous[name]
+		if !ok || (mapping.Status == MappedMapping && !ids[mapping.ExerciseID]) {
+			mapping = matchName(name, exercises)
+		}
+		mapping.Sets = sets[name]
+		history.Mappings = append(history.Mappings, mapping)
+	}
+	history.Status = mappingStatus(history.Mappings)
+
+	result, err := s.DB.Collection("historyImports").InsertOne(context.Background(), history)
+	if err != nil {
+		return nil, err
+	}
+	history.ID = result.InsertedID.(primitive.ObjectID)
+	return history, nil
+}
+
+func (s *HistoryImportService) GetImport(id string, userId string) (*HistoryImport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &HistoryImport{}
+	filter := bson.D{{Key: "_id", Value: oid}, {Key: "userid", Value: userId}}
+	if err := s.DB.Collection("historyImports").FindOne(context.Background(), filter).Decode(history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// MapExercises maps exercise names of an import to exercises or leaves them out of it
+func (s *HistoryImportService) MapExercises(id string, dto *MapExercisesDto, userId string) (*HistoryImport, error) {
+	history, err := s.GetImport(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if history.Status == StatusCompleted {
+		return nil, ErrImportCompleted
+	}
+
+	for _, m := range dto.Mappings {
+		i := findMapping(history.Mappings, m.Name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownName, m.Name)
+		}
+
+		mapping := ExerciseMapping{Name: m.Name, Status: SkippedMapping, Sets: history.Mappings[i].Sets}
+		if !m.Skip {
+			ex, err := s.ExerciseService.GetExercise(m.ExerciseID, userId)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownExercise, m.ExerciseID)
+			}
+			mapping.Status = MappedMapping
+			mapping.ExerciseID = ex.ID.Hex()
+			mapping.ExerciseName = ex.Name
+		}
+		history.Mappings[i] = mapping
+	}
+	history.Status = mappingStatus(history.Mappings)
+	history.UpdatedAt = time.Now()
+
+	filter := bson.D{{Key: "_id", Value: history.ID}, {Key: "userid", Value: userId}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "mappings", Value: history.Mappings},
+		{Key: "status", Value: history.Status},
+		{Key: "updated_at", Value: history.UpdatedAt},
+	}}}
+	if _, err := s.DB.Collection("historyImports").UpdateOne(context.Background(), filter, update); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// CommitImport creates a logged session, and a log per exercise, for every workout of an import
+// whose exercise names are all mapped or skipped. Workouts imported before, by this import or
+// another one of the same history, are skipped, so committing again changes nothing.
+func (s *HistoryImportService) CommitImport(id string, userId string) (*HistoryImport, error) {
+	history, err := s.GetImport(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	switch history.Status {
+	case StatusCompleted:
+		return history, nil
+	case StatusMapping:
+		return nil, ErrUnmappedExercises
+	}
+
+	exercises, err := s.mappedExercises(history.Mappings, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	// Records are brought up to date once per exercise after all its logs are in, also when the
+	// import stops half way as the workouts it got through are skipped the next time
+	updated := make(map[string]bool)
+	var importErr error
+	for _, w := range history.Workouts {
+		imported, err := s.importWorkout(w, history.Unit, exercises, userId)
+		if err != nil {
+			importErr = err
+			break
+		}
+		if imported == nil {
+			result.Skipped++
+			continue
+		}
+
+		result.Sessions++
+		for _, log := range imported {
+			result.Logs++
+			result.Sets += len(log.Sets)
+			updated[log.ExerciseID] = true
+		}
+	}
+	for exerciseId := range updated {
+		s.ExerciseLogService.RefreshRecords(userId, exerciseId, "")
+	}
+	if importErr != nil {
+		return nil, importErr
+	}
+
+	history.Status = StatusCompleted
+	history.Result = result
+	history.UpdatedAt = time.Now()
+
+	filter := bson.D{{Key: "_id", Value: history.ID}, {Key: "userid", Value: userId}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: history.Status},
+		{Key: "result", Value: history.Result},
+		{Key: "updated_at", Value: history.UpdatedAt},
+	}}}
+	if _, err := s.DB.Collection("historyImports").UpdateOne(context.Background(), filter, update); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// importWorkout creates the session and logs of a workout, nil when it was imported before or
+// has only skipped exercises
+func (s *HistoryImportService) importWorkout(w ImportedWorkout, unit unitEnums.ExerciseWeightUnit, exercises map[string]*exercise.Exercise, userId string) ([]*exerciseLog.ExerciseLog, error) {
+	filter := bson.D{{Key: "userid", Value: userId}, {Key: "import_key", Value: w.Key}}
+	count, err := s.DB.Collection("workoutSessions").CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, nil
+	}
+
+	if len(logDtos(w, unit, exercises)) == 0 {
+		return nil, nil
+	}
+
+	var logs []*exerciseLog.ExerciseLog
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		logs = nil
+		// Logs left by an import that stopped half way
+		if _, err := s.DB.Collection("exerciseLogs").DeleteMany(ctx, filter); err != nil {
+			return err
+		}
+
+		session := &workoutSession.WorkoutSession{
+			UserID:    userId,
+			Type:      workoutSession.LoggedSession,
+			StartTime: w.StartTime,
+			EndTime:   w.EndTime,
+			Status:    workoutSession.StatusCompleted,
+			Duration:  int(w.EndTime.Sub(w.StartTime).Seconds()),
+			Exercises: []workoutSession.SessionExercise{},
+			Groups:    []workout.ExerciseGroup{},
+			Notes:     sessionNotes(w),
+			ImportKey: w.Key,
+			Version:   1,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		// Built on every attempt, as creating a log converts the weights of its dto
+		for i, dto := range logDtos(w, unit, exercises) {
+			log, err := s.ExerciseLogService.CreateLogContext(ctx, dto, userId)
+			if err != nil {
+				return err
+			}
+			logs = append(logs, log)
+			session.TotalVolume += log.TotalVolume
+			session.Exercises = append(session.Exercises, workoutSession.SessionExercise{
+				ExerciseID:    log.ExerciseID,
+				ExerciseLogID: log.ID.Hex(),
+				Order:         i,
+			})
+		}
+
+		_, err := s.DB.Collection("workoutSessions").InsertOne(ctx, session)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// Imported meanwhile by another commit, drop the logs made for it when there was no transaction
+		ids := make([]primitive.ObjectID, 0, len(logs))
+		for _, log := range logs {
+			ids = append(ids, log.ID)
+		}
+		if _, err := s.DB.Collection("exerciseLogs").DeleteMany(context.Background(), bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// logDtos builds a log per exercise of a workout, merging names mapped to the same exercise and
+// leaving skipped ones out. Negative weights are assistance, which only assisted exercises have.
+func logDtos(w ImportedWorkout, unit unitEnums.ExerciseWeightUnit, exercises map[string]*exercise.Exercise) []*exerciseLog.CreateExerciseLogDto {
+	dtos := make([]*exerciseLog.CreateExerciseLogDto, 0, len(w.Exercises))
+	byExercise := make(map[string]*exerciseLog.CreateExerciseLogDto)
+	for _, imported := range w.Exercises {
+		ex, ok := exercises[imported.Name]
+		if !ok {
+			continue
+		}
+
+		dto, ok := byExercise[ex.ID.Hex()]
+		if !ok {
+			dto = &exerciseLog.CreateExerciseLogDto{
+				ExerciseID: ex.ID.Hex(),
+				DateTime:   w.StartTime,
+				Unit:       unit,
+				Sets:       []exerciseLog.SetLog{},
+				Notes:      imported.Notes,
+				ImportKey:  w.Key,
+			}
+			byExercise[ex.ID.Hex()] = dto
+			dtos = append(dtos, dto)
+		}
+
+		for _, set := range imported.Sets {
+			weight := set.Weight
+			if weight < 0 {
+				weight = 0
+				if exerciseEnums.IsAssistedEquipment(ex.Equipment) {
+					weight = -set.Weight
+				}
+			}
+			dto.Sets = append(dto.Sets, exerciseLog.SetLog{
+				Weight:    weight,
+				Reps:      set.Reps,
+				SetNumber: len(dto.Sets) + 1,
+				Type:      set.Type,
+				RPE:       set.RPE,
+			})
+		}
+	}
+	return dtos
+}
+
+// sessionNotes keeps the name of the workout in the other app above its notes
+func sessionNotes(w ImportedWorkout) string {
+	switch {
+	case w.Name == "":
+		return w.Notes
+	case w.Notes == "":
+		return w.Name
+	}
+	return w.Name + "\n" + w.Notes
+}
+
+// matchName maps a name to the exercise it matches closely enough, or leaves it for the user with
+// the closest exercises
+func matchName(name string, exercises []*exercise.Exercise) ExerciseMapping {
+	matches := exercise.MatchExerciseName(name, exercises, MaxCandidates)
+	if len(matches) > 0 && matches[0].Score >= AutoMatchScore {
+		return ExerciseMapping{
+			Name:         name,
+			Status:       MatchedMapping,
+			ExerciseID:   matches[0].ExerciseID,
+			ExerciseName: matches[0].Name,
+			Score:        matches[0].Score,
+		}
+	}
+	return ExerciseMapping{Name: name, Status: UnmatchedMapping, Candidates: matches}
+}
+
+func mappingStatus(mappings []ExerciseMapping) ImportStatus {
+	for _, mapping := range mappings {
+		if mapping.Status == UnmatchedMapping {
+			return StatusMapping
+		}
+	}
+	return StatusReady
+}
+
+func findMapping(mappings []ExerciseMapping, name string) int {
+	for i, mapping := range mappings {
+		if mapping.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// previousMappings returns the latest mapping the user chose for each name in earlier imports
+func (s *HistoryImportService) previousMappings(userId string) (map[string]ExerciseMapping, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "mappings.status", Value: bson.D{{Key: "$in", Value: []MappingStatus{MappedMapping, SkippedMapping}}}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.D{{Key: "mappings", Value: 1}})
+
+	cursor, err := s.DB.Collection("historyImports").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var imports []HistoryImport
+	if err := cursor.All(context.Background(), &imports); err != nil {
+		return nil, err
+	}
+
+	mappings := make(map[string]ExerciseMapping)
+	for _, history := range imports {
+		for _, mapping := range history.Mappings {
+			if _, ok := mappings[mapping.Name]; ok {
+				continue
+			}
+			if mapping.Status == MappedMapping || mapping.Status == SkippedMapping {
+				mappings[mapping.Name] = mapping
+			}
+		}
+	}
+	return mappings, nil
+}
+
+// mappedExercises returns the exercises names are mapped to, keyed by name
+func (s *HistoryImportService) mappedExercises(mappings []ExerciseMapping, userId string) (map[string]*exercise.Exercise, error) {
+	exercises := make(map[string]*exercise.Exercise)
+	byId := make(map[string]*exercise.Exercise)
+	for _, mapping := range mappings {
+		if mapping.Status != MatchedMapping && mapping.Status != MappedMapping {
+			continue
+		}
+		ex, ok := byId[mapping.ExerciseID]
+		if !ok {
+			var err error
+			ex, err = s.ExerciseService.GetExercise(mapping.ExerciseID, userId)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownExercise, mapping.ExerciseID)
+			}
+			byId[mapping.ExerciseID] = ex
+		}
+		exercises[mapping.Name] = ex
+	}
+	return exercises, nil
+}
+
+// weightUnit returns the user's weight unit, defaulting to kg
+func (s *HistoryImportService) weightUnit(userId string) unitEnums.ExerciseWeightUnit {
+	userOid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+
+	userObj := &user.User{}
+	if err := s.DB.Collection("users").FindOne(context.Background(), bson.D{{Key: "_id", Value: userOid}}).Decode(userObj); err != nil {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+	if userObj.WeightUnit == "" {
+		return unitEnums.ExerciseWeightUnitKg
+	}
+	return userObj.WeightUnit
+}
@@ -0,0 +1,94 @@
+package personalRecord
+
+import (
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Error error
+
+type PersonalRecordController struct {
+	Instance fiber.Router
+	Service  IPersonalRecordService
+}
+
+// @Summary     Get user personal records
+// @Description Get the current personal records of every exercise for a user
+// @Tags        personalRecords
+// @Accept      json
+// @Produce     json
+// @Success     200 {array} PersonalRecord
+// @Failure     400 {object} Error
+// @Router      /personal-record [get]
+func (c *PersonalRecordController) GetUserRecordsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	records, err := c.Service.GetRecordsByUser(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(records)
+}
+
+// @Summary     Get exercise personal records
+// @Description Get the current personal records for a specific exercise
+// @Tags        personalRecords
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId path string true "Exercise ID"
+// @Success     200 {object} PersonalRecord
+// @Failure     404 {object} Error
+// @Router      /personal-record/exercise/{exerciseId} [get]
+func (c *PersonalRecordController) GetExerciseRecordsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	exerciseId := ctx.Params("exerciseId")
+
+	record, err := c.Service.GetRecordsByExercise(exerciseId, userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "no personal records found for this exercise",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(record)
+}
+
+// @Summary     Get personal record history
+// @Description Get every personal record improvement for a user, newest first
+// @Tags        personalRecords
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId query string false "Exercise ID"
+// @Success     200 {array} RecordHistory
+// @Failure     400 {object} Error
+// @Router      /personal-record/history [get]
+func (c *PersonalRecordController) GetRecordHistoryHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	exerciseId := ctx.Query("exerciseId")
+
+	history, err := c.Service.GetRecordHistory(userId, exerciseId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(history)
+}
+
+func (c *PersonalRecordController) Handle() {
+	g := c.Instance.Group("/personal-record")
+
+	g.Get("/", c.GetUserRecordsHandler)
+	g.Get("/history", c.GetRecordHistoryHandler)
+	g.Get("/exercise/:exerciseId", c.GetExerciseRecordsHandler)
+}
@@ -0,0 +1,21 @@
+package personalRecordEnums
+
+type RecordType string
+
+const (
+	RepMaxRecord         RecordType = "rep_max"          // Heaviest weight lifted for exactly Reps reps
+	EstimatedOneRMRecord RecordType = "estimated_one_rm" // Best estimated 1RM of a single set
+	SetVolumeRecord      RecordType = "set_volume"       // Best weight x reps of a single set
+	SessionVolumeRecord  RecordType = "session_volume"   // Best total volume of a single log
+)
+
+// MaxRepMaxReps is the highest rep count a rep max record is tracked for
+const MaxRepMaxReps = 12
+
+// AchievedRecord is a personal record set by an exercise log
+type AchievedRecord struct {
+	Type     RecordType `json:"type" bson:"type"`
+	Reps     int        `json:"reps,omitempty" bson:"reps,omitempty"` // Only set for rep max records
+	Value    float64    `json:"value" bson:"value"`
+	Previous float64    `json:"previous" bson:"previous"`
+}
@@ -0,0 +1,44 @@
+package personalRecord
+
+import (
+	"time"
+
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalRecord holds the current bests of a user for one exercise. Weights and volumes are in kg.
+type PersonalRecord struct {
+	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID         string             `json:"userid" bson:"userid"`
+	ExerciseID     string             `json:"exerciseid" bson:"exerciseid"`
+	RepMaxes       []RepMax           `json:"rep_maxes" bson:"rep_maxes"`
+	EstimatedOneRM RecordValue        `json:"estimated_one_rm" bson:"estimated_one_rm"`
+	SetVolume      RecordValue        `json:"set_volume" bson:"set_volume"`
+	SessionVolume  RecordValue        `json:"session_volume" bson:"session_volume"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type RecordValue struct {
+	Value         float64   `json:"value" bson:"value"`
+	ExerciseLogID string    `json:"exerciselogid" bson:"exerciselogid"`
+	AchievedAt    time.Time `json:"achieved_at" bson:"achieved_at"`
+}
+
+type RepMax struct {
+	Reps        int `json:"reps" bson:"reps"`
+	RecordValue `bson:",inline"`
+}
+
+// RecordHistory is an entry for every time a personal record was improved
+type RecordHistory struct {
+	ID            primitive.ObjectID             `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID        string                         `json:"userid" bson:"userid"`
+	ExerciseID    string                         `json:"exerciseid" bson:"exerciseid"`
+	ExerciseLogID string                         `json:"exerciselogid" bson:"exerciselogid"`
+	Type          personalRecordEnums.RecordType `json:"type" bson:"type"`
+	Reps          int                            `json:"reps,omitempty" bson:"reps,omitempty"`
+	Value         float64                        `json:"value" bson:"value"`
+	Previous      float64                        `json:"previous" bson:"previous"`
+	AchievedAt    time.Time                      `json:"achieved_at" bson:"achieved_at"`
+}
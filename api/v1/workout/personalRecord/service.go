@@ -0,0 +1,229 @@
+package personalRecord
+
+import (
+	"context"
+	"math"
+	"time"
+
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PersonalRecordService struct {
+	DB *mongo.Database
+}
+
+type IPersonalRecordService interface {
+	SyncRecords(userId string, exerciseId string, logId string) ([]personalRecordEnums.AchievedRecord, error)
+	GetRecordsByUser(userId string) ([]*PersonalRecord, error)
+	GetRecordsByExercise(exerciseId string, userId string) (*PersonalRecord, error)
+	GetRecordHistory(userId string, exerciseId string) ([]*RecordHistory, error)
+}
+
+// SyncRecords recomputes the records of an exercise from all of its logs, so creating, editing and
+// deleting logs all leave the records correct. It returns the records set by the log with logId.
+func (s *PersonalRecordService) SyncRecords(userId string, exerciseId string, logId string) ([]personalRecordEnums.AchievedRecord, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "exerciseid", Value: exerciseId},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "datetime", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := s.DB.Collection("exerciseLogs").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]exerciseLog.ExerciseLog, 0)
+	if err := cursor.All(context.Background(), &logs); err != nil {
+		return nil, err
+	}
+
+	record, history := CalculateRecords(logs)
+	record.UserID = userId
+	record.ExerciseID = exerciseId
+	record.UpdatedAt = time.Now()
+
+	achieved := make([]personalRecordEnums.AchievedRecord, 0)
+	for _, entry := range history {
+		if logId != "" && entry.ExerciseLogID == logId {
+			achieved = append(achieved, personalRecordEnums.AchievedRecord{
+				Type:     entry.Type,
+				Reps:     entry.Reps,
+				Value:    entry.Value,
+				Previous: entry.Previous,
+			})
+		}
+	}
+
+	// History entries are upserted by the improvement they record, so entries that still hold keep
+	// their IDs and the history is never left partly written
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		if len(logs) == 0 {
+			if _, err := s.DB.Collection("personalRecordHistory").DeleteMany(ctx, filter); err != nil {
+				return err
+			}
+			_, err := s.DB.Collection("personalRecords").DeleteOne(ctx, filter)
+			return err
+		}
+
+		upsert := options.Replace().SetUpsert(true)
+		if _, err := s.DB.Collection("personalRecords").ReplaceOne(ctx, filter, record, upsert); err != nil {
+			return err
+		}
+
+		ids := make([]primitive.ObjectID, 0, len(history))
+		for _, entry := range history {
+			entryFilter := bson.D{
+				{Key: "userid", Value: userId},
+				{Key: "exerciseid", Value: exerciseId},
+				{Key: "type", Value: entry.Type},
+				{Key: "reps", Value: entry.Reps},
+				{Key: "exerciselogid", Value: entry.ExerciseLogID},
+			}
+			update := bson.D{{Key: "$set", Value: bson.D{
+				{Key: "value", Value: entry.Value},
+				{Key: "previous", Value: entry.Previous},
+				{Key: "achieved_at", Value: entry.AchievedAt},
+			}}}
+			opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+			saved := &RecordHistory{}
+			if err := s.DB.Collection("personalRecordHistory").FindOneAndUpdate(ctx, entryFilter, update, opts).Decode(saved); err != nil {
+				return err
+			}
+			ids = append(ids, saved.ID)
+		}
+
+		stale := bson.D{
+			{Key: "userid", Value: userId},
+			{Key: "exerciseid", Value: exerciseId},
+			{Key: "_id", Value: bson.D{{Key: "$nin", Value: ids}}},
+		}
+		_, err := s.DB.Collection("personalRecordHistory").DeleteMany(ctx, stale)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return achieved, nil
+}
+
+func (s *PersonalRecordService) GetRecordsByUser(userId string) ([]*PersonalRecord, error) {
+	filter := bson.D{{Key: "userid", Value: userId}}
+
+	cursor, err := s.DB.Collection("personalRecords").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*PersonalRecord, 0)
+	if err := cursor.All(context.Background(), &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *PersonalRecordService) GetRecordsByExercise(exerciseId string, userId string) (*PersonalRecord, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "exerciseid", Value: exerciseId},
+	}
+
+	record := &PersonalRecord{}
+	if err := s.DB.Collection("personalRecords").FindOne(context.Background(), filter).Decode(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetRecordHistory returns the record history of a user, newest first, optionally for one exercise
+func (s *PersonalRecordService) GetRecordHistory(userId string, exerciseId string) ([]*RecordHistory, error) {
+	filter := bson.D{{Key: "userid", Value: userId}}
+	if exerciseId != "" {
+		filter = append(filter, bson.E{Key: "exerciseid", Value: exerciseId})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "achieved_at", Value: -1}})
+
+	cursor, err := s.DB.Collection("personalRecordHistory").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*RecordHistory, 0)
+	if err := cursor.All(context.Background(), &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// CalculateRecords walks logs in chronological order and returns the current bests along with
+// a history entry for every improvement. Warm-up sets never count towards a record.
+func CalculateRecords(logs []exerciseLog.ExerciseLog) (*PersonalRecord, []RecordHistory) {
+	record := &PersonalRecord{RepMaxes: make([]RepMax, personalRecordEnums.MaxRepMaxReps)}
+	for i := range record.RepMaxes {
+		record.RepMaxes[i].Reps = i + 1
+	}
+	history := make([]RecordHistory, 0)
+
+	improve := func(current *RecordValue, value float64, log exerciseLog.ExerciseLog, recordType personalRecordEnums.RecordType, reps int) {
+		value = math.Round(value*100) / 100
+		if value <= current.Value {
+			return
+		}
+		history = append(history, RecordHistory{
+			ExerciseLogID: log.ID.Hex(),
+			Type:          recordType,
+			Reps:          reps,
+			Value:         value,
+			Previous:      current.Value,
+			AchievedAt:    log.DateTime,
+		})
+		*current = RecordValue{Value: value, ExerciseLogID: log.ID.Hex(), AchievedAt: log.DateTime}
+	}
+
+	for _, log := range logs {
+		// Find the log's bests first so a log improving a record over several sets yields one entry
+		var bestRepMaxes [personalRecordEnums.MaxRepMaxReps]float64
+		var bestOneRM, bestSetVolume, sessionVolume float64
+		for _, set := range log.Sets {
+			if set.Type == exerciseLog.WarmUpSet || set.Reps < 1 {
+				continue
+			}
+
+			load := set.Load()
+			setVolume := load * float64(set.Reps)
+			sessionVolume += setVolume
+			bestSetVolume = math.Max(bestSetVolume, setVolume)
+
+			if set.Reps <= personalRecordEnums.MaxRepMaxReps {
+				bestRepMaxes[set.Reps-1] = math.Max(bestRepMaxes[set.Reps-1], load)
+			}
+
+			if load > 0 {
+				if oneRM, err := dashboardFunctions.CalculateOneRepMax(load, set.RepsToFailure()); err == nil {
+					bestOneRM = math.Max(bestOneRM, oneRM)
+				}
+			}
+		}
+
+		for i := range record.RepMaxes {
+			improve(&record.RepMaxes[i].RecordValue, bestRepMaxes[i], log, personalRecordEnums.RepMaxRecord, i+1)
+		}
+		improve(&record.EstimatedOneRM, bestOneRM, log, personalRecordEnums.EstimatedOneRMRecord, 0)
+		improve(&record.SetVolume, bestSetVolume, log, personalRecordEnums.SetVolumeRecord, 0)
+		improve(&record.SessionVolume, sessionVolume, log, personalRecordEnums.SessionVolumeRecord, 0)
+	}
+
+	return record, history
+}
@@ -0,0 +1,276 @@
+package program
+
+import (
+	"errors"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Error error
+
+type ProgramController struct {
+	Instance fiber.Router
+	Service  IProgramService
+}
+
+// @Summary     Create program
+// @Description Create a periodised program of blocks, weeks and days
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Param       program body CreateProgramDto true "Create Program"
+// @Success     201 {object} Program
+// @Failure     400 {object} Error
+// @Router      /program [post]
+func (c *ProgramController) CreateProgramHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	validate := validator.New()
+	dto := new(CreateProgramDto)
+
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	program, err := c.Service.CreateProgram(dto, userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(program)
+}
+
+// @Summary     Get user programs
+// @Description Get all programs of a user
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Success     200 {array} Program
+// @Failure     400 {object} Error
+// @Router      /program [get]
+func (c *ProgramController) GetProgramsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	programs, err := c.Service.GetPrograms(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(programs)
+}
+
+// @Summary     Get program
+// @Description Get a program by ID
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Program ID"
+// @Success     200 {object} Program
+// @Failure     404 {object} Error
+// @Router      /program/{id} [get]
+func (c *ProgramController) GetProgramHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	id := ctx.Params("id")
+
+	program, err := c.Service.GetProgram(id, userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Program not found",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(program)
+}
+
+// @Summary     Delete program
+// @Description Delete a program that is not in progress
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Program ID"
+// @Success     204 "No Content"
+// @Failure     400 {object} Error
+// @Router      /program/{id} [delete]
+func (c *ProgramController) DeleteProgramHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	id := ctx.Params("id")
+
+	if err := c.Service.DeleteProgram(id, userId); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Program not found",
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary     Start program
+// @Description Start a program from its first week and plan its workouts
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Program ID"
+// @Param       start body StartProgramDto false "Start Program"
+// @Success     201 {object} ProgramProgress
+// @Failure     400 {object} Error
+// @Failure     409 {object} Error
+// @Router      /program/{id}/start [post]
+func (c *ProgramController) StartProgramHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	id := ctx.Params("id")
+	dto := new(StartProgramDto)
+
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(dto); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+
+	progress, err := c.Service.StartProgram(id, dto, userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Program not found",
+			})
+		}
+		return c.progressError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(progress)
+}
+
+// @Summary     Get active program progress
+// @Description Get the block and week the user is in of their active program
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} ProgramProgress
+// @Failure     404 {object} Error
+// @Router      /program/active [get]
+func (c *ProgramController) GetProgressHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	progress, err := c.Service.GetProgress(userId)
+	if err != nil {
+		return c.progressError(ctx, err)
+	}
+
+	return ctx.JSON(progress)
+}
+
+// @Summary     Advance program week
+// @Description Move on to the next week of the active program, starting today
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Param       week body MoveWeekDto false "Move Week"
+// @Success     200 {object} ProgramProgress
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /program/active/advance [put]
+func (c *ProgramController) AdvanceWeekHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(MoveWeekDto)
+
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(dto); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+
+	progress, err := c.Service.AdvanceWeek(dto, userId)
+	if err != nil {
+		return c.progressError(ctx, err)
+	}
+
+	return ctx.JSON(progress)
+}
+
+// @Summary     Repeat program week
+// @Description Run the current week of the active program again, starting today
+// @Tags        programs
+// @Accept      json
+// @Produce     json
+// @Param       week body MoveWeekDto false "Move Week"
+// @Success     200 {object} ProgramProgress
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /program/active/repeat [put]
+func (c *ProgramController) RepeatWeekHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(MoveWeekDto)
+
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(dto); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+
+	progress, err := c.Service.RepeatWeek(dto, userId)
+	if err != nil {
+		return c.progressError(ctx, err)
+	}
+
+	return ctx.JSON(progress)
+}
+
+func (c *ProgramController) progressError(ctx *fiber.Ctx, err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No active program",
+		})
+	}
+
+	status := fiber.StatusInternalServerError
+	if err == ErrInvalidTimezone {
+		status = fiber.StatusBadRequest
+	} else if errors.Is(err, ErrScheduleConflict) {
+		status = fiber.StatusConflict
+	}
+	return ctx.Status(status).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
+func (c *ProgramController) Handle() {
+	g := c.Instance.Group("/program")
+
+	g.Post("/", c.CreateProgramHandler)
+	g.Get("/", c.GetProgramsHandler)
+	g.Get("/active", c.GetProgressHandler)
+	g.Put("/active/advance", c.AdvanceWeekHandler)
+	g.Put("/active/repeat", c.RepeatWeekHandler)
+	g.Get("/:id", c.GetProgramHandler)
+	g.Delete("/:id", c.DeleteProgramHandler)
+	g.Post("/:id/start", c.StartProgramHandler)
+}
@@ -0,0 +1,19 @@
+package program
+
+import "time"
+
+type CreateProgramDto struct {
+	Name        string         `json:"name" validate:"required"`
+	Description string         `json:"description"`
+	Blocks      []ProgramBlock `json:"blocks" validate:"required,min=1,dive"`
+}
+
+type StartProgramDto struct {
+	StartDate      time.Time `json:"startDate"`      // Defaults to today
+	Timezone       string    `json:"timezone"`       // Days of the program fall in it, UTC when not set
+	AllowConflicts bool      `json:"allowConflicts"` // Plan the program over days other workouts already take
+}
+
+type MoveWeekDto struct {
+	AllowConflicts bool `json:"allowConflicts"`
+}
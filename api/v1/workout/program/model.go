@@ -0,0 +1,132 @@
+package program
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Program is a periodised schedule of workouts made of blocks of weeks
+type Program struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      string             `json:"userid" bson:"userid"`
+	Name        string             `json:"name" bson:"name" validate:"required"`
+	Description string             `json:"description" bson:"description"`
+	Blocks      []ProgramBlock     `json:"blocks" bson:"blocks" validate:"required,min=1,dive"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type ProgramBlock struct {
+	Name  string        `json:"name" bson:"name" validate:"required"`
+	Weeks []ProgramWeek `json:"weeks" bson:"weeks" validate:"required,min=1,dive"`
+}
+
+// ProgramWeek scales the prescriptions of its workouts. VolumeModifier multiplies the number of
+// sets and IntensityModifier the weights; 0 keeps them as prescribed, or uses the deload defaults
+// for a deload week.
+type ProgramWeek struct {
+	Deload            bool         `json:"deload" bson:"deload"`
+	VolumeModifier    float64      `json:"volume_modifier" bson:"volume_modifier" validate:"min=0,max=3"`
+	IntensityModifier float64      `json:"intensity_modifier" bson:"intensity_modifier" validate:"min=0,max=2"`
+	Days              []ProgramDay `json:"days" bson:"days" validate:"dive"`
+}
+
+// ProgramDay schedules a workout on a day of the week, 0 being the first day of the week
+type ProgramDay struct {
+	Day       int    `json:"day" bson:"day" validate:"min=0,max=6"`
+	WorkoutID string `json:"workoutid" bson:"workoutid" validate:"required"`
+}
+
+const (
+	DeloadVolumeModifier    = 0.5
+	DeloadIntensityModifier = 0.9
+)
+
+// Modifiers returns the volume and intensity multipliers of the week
+func (w ProgramWeek) Modifiers() (float64, float64) {
+	volume, intensity := 1.0, 1.0
+	if w.Deload {
+		volume, intensity = DeloadVolumeModifier, DeloadIntensityModifier
+	}
+	if w.VolumeModifier > 0 {
+		volume = w.VolumeModifier
+	}
+	if w.IntensityModifier > 0 {
+		intensity = w.IntensityModifier
+	}
+	return volume, intensity
+}
+
+// ScheduledWeek is a week of a program with its position
+type ScheduledWeek struct {
+	ProgramWeek
+	Block       int // Index of the block
+	BlockName   string
+	WeekInBlock int // Index of the week in its block
+}
+
+// Weeks flattens the blocks of the program into its weeks, in order
+func (p Program) Weeks() []ScheduledWeek {
+	weeks := make([]ScheduledWeek, 0)
+	for b, block := range p.Blocks {
+		for w, week := range block.Weeks {
+			weeks = append(weeks, ScheduledWeek{ProgramWeek: week, Block: b, BlockName: block.Name, WeekInBlock: w})
+		}
+	}
+	return weeks
+}
+
+// WorkoutIDs returns every workout the program schedules, once each
+func (p Program) WorkoutIDs() []string {
+	seen := make(map[string]bool)
+	ids := make([]string, 0)
+	for _, week := range p.Weeks() {
+		for _, day := range week.Days {
+			if !seen[day.WorkoutID] {
+				seen[day.WorkoutID] = true
+				ids = append(ids, day.WorkoutID)
+			}
+		}
+	}
+	return ids
+}
+
+type EnrollmentStatus string
+
+const (
+	EnrollmentActive    EnrollmentStatus = "active"
+	EnrollmentStopped   EnrollmentStatus = "stopped"
+	EnrollmentCompleted EnrollmentStatus = "completed"
+)
+
+// ProgramEnrollment is a user running a program. CurrentWeek started on CurrentWeekStart and the
+// following weeks follow on every 7 days, until the week is advanced or repeated. Days are counted
+// in Timezone and stored as midnight UTC of the calendar day, like the dates of workout plans.
+type ProgramEnrollment struct {
+	ID               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID           string             `json:"userid" bson:"userid"`
+	ProgramID        string             `json:"programid" bson:"programid"`
+	Status           EnrollmentStatus   `json:"status" bson:"status"`
+	StartDate        time.Time          `json:"start_date" bson:"start_date"`
+	CurrentWeek      int                `json:"current_week" bson:"current_week"`
+	CurrentWeekStart time.Time          `json:"current_week_start" bson:"current_week_start"`
+	Timezone         string             `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ProgramProgress tells a user where they are in their program. Week numbers count from 1.
+type ProgramProgress struct {
+	Enrollment        ProgramEnrollment `json:"enrollment"`
+	ProgramName       string            `json:"program_name"`
+	Week              int               `json:"week"`
+	TotalWeeks        int               `json:"total_weeks"`
+	Block             int               `json:"block"`
+	BlockName         string            `json:"block_name"`
+	WeekInBlock       int               `json:"week_in_block"`
+	WeekStart         time.Time         `json:"week_start"`
+	Deload            bool              `json:"deload"`
+	VolumeModifier    float64           `json:"volume_modifier"`
+	IntensityModifier float64           `json:"intensity_modifier"`
+}
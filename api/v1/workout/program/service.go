@@ -0,0 +1,464 @@
+package program
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrScheduleConflict = errors.New("another workout is already planned")
+	ErrInvalidTimezone  = errors.New("unknown timezone")
+)
+
+// ScheduleChecker finds the first day on which dates about to be planned, keyed by workout ID,
+// clash with the user's other plans. Plans are read with ctx, inside the caller's transaction.
+type ScheduleChecker interface {
+	FindScheduleConflict(ctx context.Context, userId string, dates map[string][]time.Time) (time.Time, bool, error)
+}
+
+type ProgramService struct {
+	DB       *mongo.Database
+	Schedule ScheduleChecker
+}
+
+type IProgramService interface {
+	CreateProgram(dto *CreateProgramDto, userId string) (*Program, error)
+	GetPrograms(userId string) ([]*Program, error)
+	GetProgram(id string, userId string) (*Program, error)
+	DeleteProgram(id string, userId string) error
+	StartProgram(id string, dto *StartProgramDto, userId string) (*ProgramProgress, error)
+	GetProgress(userId string) (*ProgramProgress, error)
+	AdvanceWeek(dto *MoveWeekDto, userId string) (*ProgramProgress, error)
+	RepeatWeek(dto *MoveWeekDto, userId string) (*ProgramProgress, error)
+	GetWeekModifiers(userId string, workoutId string, at time.Time) (float64, float64, error)
+}
+
+func (s *ProgramService) CreateProgram(dto *CreateProgramDto, userId string) (*Program, error) {
+	program := &Program{
+		UserID:      userId,
+		Name:        dto.Name,
+		Description: dto.Description,
+		Blocks:      dto.Blocks,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.checkWorkouts(program.WorkoutIDs(), userId); err != nil {
+		return nil, err
+	}
+
+	result, err := s.DB.Collection("programs").InsertOne(context.Background(), program)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: result.InsertedID}}
+	createdProgram := &Program{}
+	if err := s.DB.Collection("programs").FindOne(context.Background(), filter).Decode(createdProgram); err != nil {
+		return nil, err
+	}
+
+	return createdProgram, nil
+}
+
+func (s *ProgramService) GetPrograms(userId string) ([]*Program, error) {
+	filter := bson.D{{Key: "userid", Value: userId}}
+
+	cursor, err := s.DB.Collection("programs").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make([]*Program, 0)
+	if err := cursor.All(context.Background(), &programs); err != nil {
+		return nil, err
+	}
+
+	return programs, nil
+}
+
+func (s *ProgramService) GetProgram(id string, userId string) (*Program, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: oid},
+		{Key: "userid", Value: userId},
+	}
+
+	program := &Program{}
+	if err := s.DB.Collection("programs").FindOne(context.Background(), filter).Decode(program); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+func (s *ProgramService) DeleteProgram(id string, userId string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := s.getActiveEnrollment(context.Background(), userId)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if enrollment != nil && enrollment.ProgramID == id {
+		return errors.New("program is in progress, start another program first")
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: oid},
+		{Key: "userid", Value: userId},
+	}
+
+	result, err := s.DB.Collection("programs").DeleteOne(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// StartProgram enrolls the user in a program from its first week and plans its workouts.
+// A program already in progress is stopped and its upcoming dates are removed.
+func (s *ProgramService) StartProgram(id string, dto *StartProgramDto, userId string) (*ProgramProgress, error) {
+	program, err := s.GetProgram(id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := loadLocation(dto.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := dto.StartDate
+	if startDate.IsZero() {
+		startDate = time.Now()
+	}
+	startDate = calendarDay(startDate, location)
+
+	var enrollment *ProgramEnrollment
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		current, err := s.getActiveEnrollment(ctx, userId)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+		if current != nil {
+			if err := s.clearPlannedDates(ctx, userId, current.ProgramID, startDate); err != nil {
+				return err
+			}
+			if err := s.setEnrollmentStatus(ctx, current.ID, EnrollmentStopped); err != nil {
+				return err
+			}
+		}
+
+		enrollment = &ProgramEnrollment{
+			UserID:           userId,
+			ProgramID:        id,
+			Status:           EnrollmentActive,
+			StartDate:        startDate,
+			CurrentWeek:      0,
+			CurrentWeekStart: startDate,
+			Timezone:         dto.Timezone,
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+
+		result, err := s.DB.Collection("programEnrollments").InsertOne(ctx, enrollment)
+		if err != nil {
+			return err
+		}
+		enrollment.ID = result.InsertedID.(primitive.ObjectID)
+
+		return s.planWeeks(ctx, program, enrollment, dto.AllowConflicts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ProgressAt(program, enrollment, time.Now()), nil
+}
+
+// GetProgress returns where the user is in their active program
+func (s *ProgramService) GetProgress(userId string) (*ProgramProgress, error) {
+	enrollment, err := s.getActiveEnrollment(context.Background(), userId)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := s.GetProgram(enrollment.ProgramID, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return ProgressAt(program, enrollment, time.Now()), nil
+}
+
+// AdvanceWeek moves on to the week after the current one, starting today
+func (s *ProgramService) AdvanceWeek(dto *MoveWeekDto, userId string) (*ProgramProgress, error) {
+	return s.moveWeek(userId, 1, dto.AllowConflicts)
+}
+
+// RepeatWeek runs the current week again, starting today
+func (s *ProgramService) RepeatWeek(dto *MoveWeekDto, userId string) (*ProgramProgress, error) {
+	return s.moveWeek(userId, 0, dto.AllowConflicts)
+}
+
+// GetWeekModifiers returns the volume and intensity modifiers that apply to a workout on a date,
+// both 1 when the workout is not part of the user's active program
+func (s *ProgramService) GetWeekModifiers(userId string, workoutId string, at time.Time) (float64, float64, error) {
+	enrollment, err := s.getActiveEnrollment(context.Background(), userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, 1, nil
+		}
+		return 0, 0, err
+	}
+
+	program, err := s.GetProgram(enrollment.ProgramID, userId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	isProgramWorkout := false
+	for _, id := range program.WorkoutIDs() {
+		isProgramWorkout = isProgramWorkout || id == workoutId
+	}
+	if !isProgramWorkout {
+		return 1, 1, nil
+	}
+
+	progress := ProgressAt(program, enrollment, at)
+	return progress.VolumeModifier, progress.IntensityModifier, nil
+}
+
+// ProgressAt works out the week of the program a date falls in. Weeks run on from the current
+// week every 7 days; past the last week the program counts as being in its last week.
+func ProgressAt(program *Program, enrollment *ProgramEnrollment, at time.Time) *ProgramProgress {
+	weeks := program.Weeks()
+	index := enrollment.CurrentWeek
+	if elapsed := at.Sub(enrollment.CurrentWeekStart); elapsed > 0 {
+		index += int(elapsed.Hours() / (24 * 7))
+	}
+	index = min(index, len(weeks)-1)
+
+	week := weeks[index]
+	volume, intensity := week.Modifiers()
+
+	return &ProgramProgress{
+		Enrollment:        *enrollment,
+		ProgramName:       program.Name,
+		Week:              index + 1,
+		TotalWeeks:        len(weeks),
+		Block:             week.Block + 1,
+		BlockName:         week.BlockName,
+		WeekInBlock:       week.WeekInBlock + 1,
+		WeekStart:         enrollment.CurrentWeekStart.AddDate(0, 0, 7*(index-enrollment.CurrentWeek)),
+		Deload:            week.Deload,
+		VolumeModifier:    volume,
+		IntensityModifier: intensity,
+	}
+}
+
+// PlanDates returns the dates of each workout from the enrollment's current week to the end of the program
+func PlanDates(program *Program, enrollment *ProgramEnrollment) map[string][]time.Time {
+	dates := make(map[string][]time.Time)
+	weeks := program.Weeks()
+	for i := enrollment.CurrentWeek; i < len(weeks); i++ {
+		weekStart := enrollment.CurrentWeekStart.AddDate(0, 0, 7*(i-enrollment.CurrentWeek))
+		for _, day := range weeks[i].Days {
+			dates[day.WorkoutID] = append(dates[day.WorkoutID], weekStart.AddDate(0, 0, day.Day))
+		}
+	}
+	return dates
+}
+
+func (s *ProgramService) moveWeek(userId string, step int, allowConflicts bool) (*ProgramProgress, error) {
+	enrollment, err := s.getActiveEnrollment(context.Background(), userId)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := s.GetProgram(enrollment.ProgramID, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := loadLocation(enrollment.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := calendarDay(now, location)
+	nextWeek := ProgressAt(program, enrollment, now).Week - 1 + step
+
+	updated := &ProgramEnrollment{}
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		if err := s.clearPlannedDates(ctx, userId, enrollment.ProgramID, today); err != nil {
+			return err
+		}
+
+		if nextWeek >= len(program.Weeks()) {
+			*updated = *enrollment
+			updated.Status = EnrollmentCompleted
+			return s.setEnrollmentStatus(ctx, enrollment.ID, EnrollmentCompleted)
+		}
+
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "current_week", Value: nextWeek},
+			{Key: "current_week_start", Value: today},
+			{Key: "updated_at", Value: now},
+		}}}
+
+		after := options.After
+		opts := options.FindOneAndUpdate().SetReturnDocument(after)
+
+		filter := bson.D{{Key: "_id", Value: enrollment.ID}, {Key: "status", Value: EnrollmentActive}}
+		if err := s.DB.Collection("programEnrollments").FindOneAndUpdate(ctx, filter, update, opts).Decode(updated); err != nil {
+			return err
+		}
+
+		return s.planWeeks(ctx, program, updated, allowConflicts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ProgressAt(program, updated, now), nil
+}
+
+// planWeeks adds the dates of the program from the enrollment's current week to the workout plans,
+// unless they clash with other workouts and conflicts are not allowed. A date already planned is
+// not added twice.
+func (s *ProgramService) planWeeks(ctx context.Context, program *Program, enrollment *ProgramEnrollment, allowConflicts bool) error {
+	planDates := PlanDates(program, enrollment)
+
+	if s.Schedule != nil && !allowConflicts {
+		day, ok, err := s.Schedule.FindScheduleConflict(ctx, enrollment.UserID, planDates)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return fmt.Errorf("%w on %s", ErrScheduleConflict, day.Format("2006-01-02"))
+		}
+	}
+
+	for workoutId, dates := range planDates {
+		filter := bson.D{
+			{Key: "userid", Value: enrollment.UserID},
+			{Key: "workoutid", Value: workoutId},
+			{Key: "programid", Value: enrollment.ProgramID},
+		}
+		update := bson.D{
+			{Key: "$addToSet", Value: bson.D{{Key: "dates", Value: bson.D{{Key: "$each", Value: dates}}}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: time.Now()}}},
+			{Key: "$setOnInsert", Value: bson.D{{Key: "created_at", Value: time.Now()}}},
+		}
+
+		_, err := s.DB.Collection("workoutPlan").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearPlannedDates removes the dates of a program from a day onwards
+func (s *ProgramService) clearPlannedDates(ctx context.Context, userId string, programId string, from time.Time) error {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "programid", Value: programId},
+	}
+	update := bson.D{
+		{Key: "$pull", Value: bson.D{{Key: "dates", Value: bson.D{{Key: "$gte", Value: from}}}}},
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: time.Now()}}},
+	}
+
+	_, err := s.DB.Collection("workoutPlan").UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (s *ProgramService) getActiveEnrollment(ctx context.Context, userId string) (*ProgramEnrollment, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "status", Value: EnrollmentActive},
+	}
+
+	enrollment := &ProgramEnrollment{}
+	if err := s.DB.Collection("programEnrollments").FindOne(ctx, filter).Decode(enrollment); err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+func (s *ProgramService) setEnrollmentStatus(ctx context.Context, id primitive.ObjectID, status EnrollmentStatus) error {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: status},
+		{Key: "updated_at", Value: time.Now()},
+	}}}
+
+	_, err := s.DB.Collection("programEnrollments").UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	return err
+}
+
+// loadLocation loads the timezone the days of a program are counted in, UTC when not set
+func loadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return location, nil
+}
+
+// calendarDay returns the day t falls on in location, as midnight UTC of that day
+func calendarDay(t time.Time, location *time.Location) time.Time {
+	year, month, day := t.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// checkWorkouts makes sure every workout exists and is the user's own or public
+func (s *ProgramService) checkWorkouts(workoutIds []string, userId string) error {
+	for _, id := range workoutIds {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return fmt.Errorf("invalid workout id %s", id)
+		}
+
+		filter := bson.D{
+			{Key: "_id", Value: oid},
+			{Key: "userid", Value: bson.D{{Key: "$in", Value: bson.A{userId, "", nil}}}},
+		}
+		if err := s.DB.Collection("workout").FindOne(context.Background(), filter).Decode(&workout.Workout{}); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return fmt.Errorf("workout %s not found", id)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package programLibrary
+
+import (
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Error error
+
+type ProgramLibraryController struct {
+	Instance fiber.Router
+	Service  IProgramLibraryService
+}
+
+// @Summary     Get library programs
+// @Description Browse the classic programs of the library
+// @Tags        programLibrary
+// @Accept      json
+// @Produce     json
+// @Param       level query string false "Level (beginner, intermediate or advanced)"
+// @Success     200 {array} LibraryProgram
+// @Failure     400 {object} Error
+// @Router      /programs [get]
+func (c *ProgramLibraryController) GetProgramsHandler(ctx *fiber.Ctx) error {
+	var filters SearchLibraryFilters
+	if err := ctx.QueryParser(&filters); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	programs, err := c.Service.GetPrograms(&filters)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(programs)
+}
+
+// @Summary     Get library program
+// @Description Get a classic program of the library with its workouts and schedule
+// @Tags        programLibrary
+// @Accept      json
+// @Produce     json
+// @Param       slug path string true "Program slug"
+// @Success     200 {object} LibraryProgram
+// @Failure     404 {object} Error
+// @Router      /programs/{slug} [get]
+func (c *ProgramLibraryController) GetProgramHandler(ctx *fiber.Ctx) error {
+	slug := ctx.Params("slug")
+
+	libraryProgram, err := c.Service.GetProgram(slug)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "program not found",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(libraryProgram)
+}
+
+// @Summary     Install library program
+// @Description Copy the workouts of a library program into the user's account and create a program scheduling them
+// @Tags        programLibrary
+// @Accept      json
+// @Produce     json
+// @Param       slug path string true "Program slug"
+// @Success     201 {object} InstalledProgram
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Router      /programs/{slug}/install [post]
+func (c *ProgramLibraryController) InstallProgramHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	slug := ctx.Params("slug")
+
+	installed, err := c.Service.InstallProgram(slug, userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "program not found",
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(installed)
+}
+
+func (c *ProgramLibraryController) Handle() {
+	g := c.Instance.Group("/programs")
+
+	g.Get("/", c.GetProgramsHandler)
+	g.Get("/:slug", c.GetProgramHandler)
+	g.Post("/:slug/install", c.InstallProgramHandler)
+}
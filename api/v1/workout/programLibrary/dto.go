@@ -0,0 +1,5 @@
+package programLibrary
+
+type SearchLibraryFilters struct {
+	Level string `json:"level" query:"level"`
+}
@@ -0,0 +1,76 @@
+package programLibrary
+
+import (
+	"time"
+
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LibraryFile holds the classic programs seeded into the library on start
+const LibraryFile = "api/v1/workout/programLibrary/json/programs.json"
+
+// LibraryProgram is a classic program shipped with the app. Its workouts reference public
+// exercises by name and equipment and its schedule references workouts by key, so that it
+// can be installed into any account. Raising Version replaces the seeded copy on next start.
+type LibraryProgram struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Slug        string             `json:"slug" bson:"slug" validate:"required"`
+	Version     int                `json:"version" bson:"version" validate:"min=1"`
+	Name        string             `json:"name" bson:"name" validate:"required"`
+	Description string             `json:"description" bson:"description"`
+	Level       string             `json:"level" bson:"level" validate:"oneof=beginner intermediate advanced"`
+	DaysPerWeek int                `json:"days_per_week" bson:"days_per_week" validate:"min=1,max=7"`
+	Workouts    []LibraryWorkout   `json:"workouts" bson:"workouts" validate:"required,min=1,dive"`
+	Blocks      []LibraryBlock     `json:"blocks" bson:"blocks" validate:"required,min=1,dive"`
+	SeededAt    time.Time          `json:"seeded_at" bson:"seeded_at"`
+}
+
+type LibraryWorkout struct {
+	Key         string                  `json:"key" bson:"key" validate:"required"`
+	Name        string                  `json:"name" bson:"name" validate:"required"`
+	Description string                  `json:"description" bson:"description"`
+	Exercises   []LibraryExercise       `json:"exercises" bson:"exercises" validate:"required,min=1,dive"`
+	Groups      []workout.ExerciseGroup `json:"groups,omitempty" bson:"groups,omitempty" validate:"dive"`
+}
+
+// LibraryExercise is resolved to a public exercise with the same name and equipment on install
+type LibraryExercise struct {
+	Name         string                  `json:"name" bson:"name" validate:"required"`
+	Equipment    exerciseEnums.Equipment `json:"equipment" bson:"equipment" validate:"required"`
+	GroupID      string                  `json:"groupid,omitempty" bson:"groupid,omitempty"`
+	Prescription *workout.Prescription   `json:"prescription,omitempty" bson:"prescription,omitempty" validate:"required_with=Progression"`
+	Progression  *workout.Progression    `json:"progression,omitempty" bson:"progression,omitempty"`
+}
+
+// LibraryBlock runs its weeks in order, Repeat times over
+type LibraryBlock struct {
+	Name   string        `json:"name" bson:"name" validate:"required"`
+	Repeat int           `json:"repeat" bson:"repeat" validate:"min=0"` // 0 runs the weeks once
+	Weeks  []LibraryWeek `json:"weeks" bson:"weeks" validate:"required,min=1,dive"`
+}
+
+type LibraryWeek struct {
+	Deload            bool         `json:"deload" bson:"deload"`
+	VolumeModifier    float64      `json:"volume_modifier" bson:"volume_modifier" validate:"min=0,max=3"`
+	IntensityModifier float64      `json:"intensity_modifier" bson:"intensity_modifier" validate:"min=0,max=2"`
+	Days              []LibraryDay `json:"days" bson:"days" validate:"dive"`
+}
+
+type LibraryDay struct {
+	Day     int    `json:"day" bson:"day" validate:"min=0,max=6"`
+	Workout string `json:"workout" bson:"workout" validate:"required"` // Key of a workout of the program
+}
+
+// LibraryFileContent is the layout of LibraryFile
+type LibraryFileContent struct {
+	Programs []LibraryProgram `json:"programs"`
+}
+
+// InstalledProgram is a library program copied into a user's account
+type InstalledProgram struct {
+	Program  *program.Program   `json:"program"`
+	Workouts []*workout.Workout `json:"workouts"`
+}
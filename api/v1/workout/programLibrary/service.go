@@ -0,0 +1,319 @@
+package programLibrary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/go-playground/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProgramLibraryService struct {
+	DB             *mongo.Database
+	WorkoutService workout.IWorkoutService
+	ProgramService program.IProgramService
+}
+
+type IProgramLibraryService interface {
+	GetPrograms(filters *SearchLibraryFilters) ([]*LibraryProgram, error)
+	GetProgram(slug string) (*LibraryProgram, error)
+	InstallProgram(slug string, userId string) (*InstalledProgram, error)
+}
+
+func (s *ProgramLibraryService) GetPrograms(filters *SearchLibraryFilters) ([]*LibraryProgram, error) {
+	filter := bson.D{}
+	if filters.Level != "" {
+		filter = append(filter, bson.E{Key: "level", Value: filters.Level})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := s.DB.Collection("programLibrary").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make([]*LibraryProgram, 0)
+	if err := cursor.All(context.Background(), &programs); err != nil {
+		return nil, err
+	}
+
+	return programs, nil
+}
+
+func (s *ProgramLibraryService) GetProgram(slug string) (*LibraryProgram, error) {
+	filter := bson.D{{Key: "slug", Value: slug}}
+
+	libraryProgram := &LibraryProgram{}
+	if err := s.DB.Collection("programLibrary").FindOne(context.Background(), filter).Decode(libraryProgram); err != nil {
+		return nil, err
+	}
+
+	return libraryProgram, nil
+}
+
+// InstallProgram copies the workouts of a library program into the user's account and creates
+// a program scheduling them, ready to be started
+func (s *ProgramLibraryService) InstallProgram(slug string, userId string) (*InstalledProgram, error) {
+	libraryProgram, err := s.GetProgram(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	exerciseIds, err := s.resolveExercises(libraryProgram.Exercises())
+	if err != nil {
+		return nil, err
+	}
+
+	workoutDtos, err := BuildWorkouts(libraryProgram, exerciseIds)
+	if err != nil {
+		return nil, err
+	}
+
+	workouts := make([]*workout.Workout, 0, len(workoutDtos))
+	workoutIds := make(map[string]string)
+	for i, dto := range workoutDtos {
+		created, err := s.WorkoutService.CreateWorkout(dto, userId)
+		if err != nil {
+			s.removeWorkouts(workouts, userId)
+			return nil, err
+		}
+		workouts = append(workouts, created)
+		workoutIds[libraryProgram.Workouts[i].Key] = created.ID.Hex()
+	}
+
+	blocks, err := BuildBlocks(libraryProgram, workoutIds)
+	if err != nil {
+		s.removeWorkouts(workouts, userId)
+		return nil, err
+	}
+
+	installed, err := s.ProgramService.CreateProgram(&program.CreateProgramDto{
+		Name:        libraryProgram.Name,
+		Description: libraryProgram.Description,
+		Blocks:      blocks,
+	}, userId)
+	if err != nil {
+		s.removeWorkouts(workouts, userId)
+		return nil, err
+	}
+
+	return &InstalledProgram{Program: installed, Workouts: workouts}, nil
+}
+
+// removeWorkouts deletes the workouts of an install that failed part way, so that no
+// workout is left behind without the program scheduling it
+func (s *ProgramLibraryService) removeWorkouts(workouts []*workout.Workout, userId string) {
+	for _, w := range workouts {
+		if err := s.WorkoutService.DeleteWorkout(w.ID.Hex(), userId); err != nil {
+			fmt.Printf("Error removing installed workout %s: %v\n", w.ID.Hex(), err)
+		}
+	}
+}
+
+// SeedLibrary loads the programs of a library file, replacing the seeded copy of a program
+// when the file has a newer version of it. It returns how many programs were written.
+func (s *ProgramLibraryService) SeedLibrary(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var content LibraryFileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return 0, err
+	}
+
+	validate := validator.New()
+	seeded := 0
+	for _, libraryProgram := range content.Programs {
+		if err := validate.Struct(libraryProgram); err != nil {
+			return seeded, fmt.Errorf("library program %s: %w", libraryProgram.Slug, err)
+		}
+		if err := ValidateProgram(&libraryProgram); err != nil {
+			return seeded, fmt.Errorf("library program %s: %w", libraryProgram.Slug, err)
+		}
+
+		current, err := s.GetProgram(libraryProgram.Slug)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return seeded, err
+		}
+		if current != nil && current.Version >= libraryProgram.Version {
+			continue
+		}
+
+		libraryProgram.SeededAt = time.Now()
+		filter := bson.D{{Key: "slug", Value: libraryProgram.Slug}}
+		opts := options.Replace().SetUpsert(true)
+		if _, err := s.DB.Collection("programLibrary").ReplaceOne(context.Background(), filter, libraryProgram, opts); err != nil {
+			return seeded, err
+		}
+		seeded++
+	}
+
+	return seeded, nil
+}
+
+// Exercises returns every exercise the program references, once each
+func (p *LibraryProgram) Exercises() []LibraryExercise {
+	seen := make(map[string]bool)
+	exercises := make([]LibraryExercise, 0)
+	for _, libraryWorkout := range p.Workouts {
+		for _, ex := range libraryWorkout.Exercises {
+			key := ExerciseKey(ex.Name, ex.Equipment)
+			if !seen[key] {
+				seen[key] = true
+				exercises = append(exercises, ex)
+			}
+		}
+	}
+	return exercises
+}
+
+// ExerciseKey identifies an exercise by its name and equipment
+func ExerciseKey(name string, equipment exerciseEnums.Equipment) string {
+	return name + "|" + string(equipment)
+}
+
+// ValidateProgram checks that the schedule only references workouts of the program and that
+// the groups of each workout are well formed
+func ValidateProgram(p *LibraryProgram) error {
+	keys := make(map[string]bool)
+	for _, libraryWorkout := range p.Workouts {
+		if keys[libraryWorkout.Key] {
+			return fmt.Errorf("duplicate workout key %s", libraryWorkout.Key)
+		}
+		keys[libraryWorkout.Key] = true
+	}
+
+	for _, block := range p.Blocks {
+		for _, week := range block.Weeks {
+			for _, day := range week.Days {
+				if !keys[day.Workout] {
+					return fmt.Errorf("unknown workout %s in block %s", day.Workout, block.Name)
+				}
+			}
+		}
+	}
+
+	// Groups only depend on the layout of the exercises, so placeholder IDs are enough here
+	for _, dto := range buildWorkouts(p, nil) {
+		if err := workout.ValidateWorkoutGroups(dto.Exercises, dto.Groups); err != nil {
+			return fmt.Errorf("workout %s: %w", dto.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// BuildWorkouts turns the workouts of a library program into workouts of the resolved
+// exercises, keyed by ExerciseKey
+func BuildWorkouts(p *LibraryProgram, exerciseIds map[string]string) ([]*workout.CreateWorkoutDto, error) {
+	for _, ex := range p.Exercises() {
+		if exerciseIds[ExerciseKey(ex.Name, ex.Equipment)] == "" {
+			return nil, fmt.Errorf("exercise %s (%s) not found", ex.Name, ex.Equipment)
+		}
+	}
+
+	return buildWorkouts(p, exerciseIds), nil
+}
+
+func buildWorkouts(p *LibraryProgram, exerciseIds map[string]string) []*workout.CreateWorkoutDto {
+	dtos := make([]*workout.CreateWorkoutDto, 0, len(p.Workouts))
+	for _, libraryWorkout := range p.Workouts {
+		exercises := make([]workout.WorkoutExercise, 0, len(libraryWorkout.Exercises))
+		for i, ex := range libraryWorkout.Exercises {
+			exerciseId := exerciseIds[ExerciseKey(ex.Name, ex.Equipment)]
+			if exerciseIds == nil {
+				exerciseId = ExerciseKey(ex.Name, ex.Equipment)
+			}
+			exercises = append(exercises, workout.WorkoutExercise{
+				ExerciseID:   exerciseId,
+				Order:        i,
+				GroupID:      ex.GroupID,
+				Prescription: ex.Prescription,
+				Progression:  ex.Progression,
+			})
+		}
+
+		dtos = append(dtos, &workout.CreateWorkoutDto{
+			Name:        libraryWorkout.Name,
+			Description: libraryWorkout.Description,
+			Exercises:   exercises,
+			Groups:      libraryWorkout.Groups,
+		})
+	}
+	return dtos
+}
+
+// BuildBlocks turns the schedule of a library program into program blocks, repeating the
+// weeks of each block and swapping workout keys for the installed workout IDs
+func BuildBlocks(p *LibraryProgram, workoutIds map[string]string) ([]program.ProgramBlock, error) {
+	blocks := make([]program.ProgramBlock, 0, len(p.Blocks))
+	for _, block := range p.Blocks {
+		weeks := make([]program.ProgramWeek, 0, len(block.Weeks)*max(block.Repeat, 1))
+		for r := 0; r < max(block.Repeat, 1); r++ {
+			for _, week := range block.Weeks {
+				days := make([]program.ProgramDay, 0, len(week.Days))
+				for _, day := range week.Days {
+					workoutId, ok := workoutIds[day.Workout]
+					if !ok {
+						return nil, fmt.Errorf("unknown workout %s in block %s", day.Workout, block.Name)
+					}
+					days = append(days, program.ProgramDay{Day: day.Day, WorkoutID: workoutId})
+				}
+
+				weeks = append(weeks, program.ProgramWeek{
+					Deload:            week.Deload,
+					VolumeModifier:    week.VolumeModifier,
+					IntensityModifier: week.IntensityModifier,
+					Days:              days,
+				})
+			}
+		}
+
+		blocks = append(blocks, program.ProgramBlock{Name: block.Name, Weeks: weeks})
+	}
+	return blocks, nil
+}
+
+// resolveExercises finds the public exercise matching each library exercise by name and
+// equipment, the same way the dashboard finds the exercises it considers
+func (s *ProgramLibraryService) resolveExercises(libraryExercises []LibraryExercise) (map[string]string, error) {
+	orConditions := make([]bson.D, len(libraryExercises))
+	for i, ex := range libraryExercises {
+		orConditions[i] = bson.D{
+			{Key: "name", Value: ex.Name},
+			{Key: "equipment", Value: ex.Equipment},
+		}
+	}
+
+	filter := bson.D{
+		{Key: "$or", Value: orConditions},
+		{Key: "userid", Value: bson.D{{Key: "$in", Value: bson.A{"", nil}}}},
+	}
+
+	var exercises []exercise.Exercise
+	cursor, err := s.DB.Collection("exercises").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.Background(), &exercises); err != nil {
+		return nil, err
+	}
+
+	exerciseIds := make(map[string]string)
+	for _, ex := range exercises {
+		exerciseIds[ExerciseKey(ex.Name, ex.Equipment)] = ex.ID.Hex()
+	}
+
+	return exerciseIds, nil
+}
@@ -0,0 +1,74 @@
+package progression
+
+import (
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Error error
+
+type ProgressionController struct {
+	Instance fiber.Router
+	Service  IProgressionService
+}
+
+// @Summary     Get workout progression targets
+// @Description Get the next targets of every exercise of a workout, with the history of why they changed
+// @Tags        progression
+// @Accept      json
+// @Produce     json
+// @Param       workoutId path string true "Workout ID"
+// @Success     200 {array} ProgressionTarget
+// @Failure     400 {object} Error
+// @Router      /progression/workout/{workoutId} [get]
+func (c *ProgressionController) GetTargetsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	workoutId := ctx.Params("workoutId")
+
+	targets, err := c.Service.GetTargets(userId, workoutId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(targets)
+}
+
+// @Summary     Get exercise progression target
+// @Description Get the next target of an exercise in a workout and why it changed after each session
+// @Tags        progression
+// @Accept      json
+// @Produce     json
+// @Param       workoutId path string true "Workout ID"
+// @Param       exerciseId path string true "Exercise ID"
+// @Success     200 {object} ProgressionTarget
+// @Failure     404 {object} Error
+// @Router      /progression/workout/{workoutId}/exercise/{exerciseId} [get]
+func (c *ProgressionController) GetTargetHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	workoutId := ctx.Params("workoutId")
+	exerciseId := ctx.Params("exerciseId")
+
+	target, err := c.Service.GetTarget(userId, workoutId, exerciseId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "no progression target found for this exercise",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(target)
+}
+
+func (c *ProgressionController) Handle() {
+	g := c.Instance.Group("/progression")
+
+	g.Get("/workout/:workoutId", c.GetTargetsHandler)
+	g.Get("/workout/:workoutId/exercise/:exerciseId", c.GetTargetHandler)
+}
@@ -0,0 +1,47 @@
+package progression
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HistoryLimit is how many of the latest changes a target keeps in its history
+const HistoryLimit = 50
+
+// ProgressionTarget is the next target of a user for an exercise of a workout, kept up to date
+// by the workout's progression rules. Weight is a load in kg. History holds the latest
+// HistoryLimit changes, oldest first.
+type ProgressionTarget struct {
+	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID         string             `json:"userid" bson:"userid"`
+	WorkoutID      string             `json:"workoutid" bson:"workoutid"`
+	ExerciseID     string             `json:"exerciseid" bson:"exerciseid"`
+	Weight         float64            `json:"weight" bson:"weight"`
+	Reps           int                `json:"reps" bson:"reps"`
+	WaveStep       int                `json:"wave_step" bson:"wave_step"`
+	FailedSessions int                `json:"failed_sessions" bson:"failed_sessions"`
+	History        []TargetChange     `json:"history" bson:"history"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type Outcome string
+
+const (
+	SuccessOutcome Outcome = "success"
+	FailureOutcome Outcome = "failure"
+	DeloadOutcome  Outcome = "deload"
+)
+
+// TargetChange records a session's evaluation and why the target moved, or did not
+type TargetChange struct {
+	SessionID      string    `json:"sessionid" bson:"sessionid"`
+	ExerciseLogID  string    `json:"exerciselogid" bson:"exerciselogid"`
+	Outcome        Outcome   `json:"outcome" bson:"outcome"`
+	PreviousWeight float64   `json:"previous_weight" bson:"previous_weight"`
+	Weight         float64   `json:"weight" bson:"weight"`
+	PreviousReps   int       `json:"previous_reps" bson:"previous_reps"`
+	Reps           int       `json:"reps" bson:"reps"`
+	Reason         string    `json:"reason" bson:"reason"`
+	ChangedAt      time.Time `json:"changed_at" bson:"changed_at"`
+}
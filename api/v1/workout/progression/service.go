@@ -0,0 +1,236 @@
+package progression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProgressionService struct {
+	DB *mongo.Database
+}
+
+type IProgressionService interface {
+	EvaluateSession(userId string, workoutId string, sessionId string, logs []*exerciseLog.ExerciseLog) ([]*ProgressionTarget, error)
+	GetTargets(userId string, workoutId string) ([]*ProgressionTarget, error)
+	GetTarget(userId string, workoutId string, exerciseId string) (*ProgressionTarget, error)
+}
+
+// EvaluateSession runs the progression rules of a workout against the logs of a finished session
+// and stores the next target of every exercise that was performed
+func (s *ProgressionService) EvaluateSession(userId string, workoutId string, sessionId string, logs []*exerciseLog.ExerciseLog) ([]*ProgressionTarget, error) {
+	workoutOid, err := primitive.ObjectIDFromHex(workoutId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: workoutOid}, {Key: "userid", Value: userId}}
+	template := &workout.Workout{}
+	if err := s.DB.Collection("workout").FindOne(context.Background(), filter).Decode(template); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("workout not found")
+		}
+		return nil, err
+	}
+
+	logsByExercise := make(map[string]*exerciseLog.ExerciseLog, len(logs))
+	for _, log := range logs {
+		logsByExercise[log.ExerciseID] = log
+	}
+
+	targets := make([]*ProgressionTarget, 0)
+	for _, ex := range template.Exercises {
+		log, ok := logsByExercise[ex.ExerciseID]
+		if ex.Prescription == nil || ex.Progression == nil || !ok {
+			continue
+		}
+
+		target, err := s.GetTarget(userId, workoutId, ex.ExerciseID)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				return nil, err
+			}
+			target = &ProgressionTarget{UserID: userId, WorkoutID: workoutId, ExerciseID: ex.ExerciseID}
+		}
+
+		next := Evaluate(*ex.Prescription, *ex.Progression, *target, log, sessionId)
+		next.UpdatedAt = time.Now()
+
+		filter := bson.D{
+			{Key: "userid", Value: userId},
+			{Key: "workoutid", Value: workoutId},
+			{Key: "exerciseid", Value: ex.ExerciseID},
+		}
+		upsert := options.Replace().SetUpsert(true)
+		if _, err := s.DB.Collection("progressionTargets").ReplaceOne(context.Background(), filter, next, upsert); err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, &next)
+	}
+
+	return targets, nil
+}
+
+func (s *ProgressionService) GetTargets(userId string, workoutId string) ([]*ProgressionTarget, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "workoutid", Value: workoutId},
+	}
+
+	cursor, err := s.DB.Collection("progressionTargets").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*ProgressionTarget, 0)
+	if err := cursor.All(context.Background(), &targets); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+func (s *ProgressionService) GetTarget(userId string, workoutId string, exerciseId string) (*ProgressionTarget, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "workoutid", Value: workoutId},
+		{Key: "exerciseid", Value: exerciseId},
+	}
+
+	target := &ProgressionTarget{}
+	if err := s.DB.Collection("progressionTargets").FindOne(context.Background(), filter).Decode(target); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// Evaluate applies a progression rule to the log of a session and returns the next target with the
+// change appended to its history, dropping the oldest changes past HistoryLimit. A target without
+// weight and reps starts from the prescription.
+func Evaluate(prescription workout.Prescription, rule workout.Progression, target ProgressionTarget, log *exerciseLog.ExerciseLog, sessionId string) ProgressionTarget {
+	if target.Weight == 0 && target.Reps == 0 {
+		target.Weight = initialWeight(prescription, rule, log)
+		target.Reps = prescription.MinReps
+	}
+
+	change := TargetChange{
+		SessionID:      sessionId,
+		ExerciseLogID:  log.ID.Hex(),
+		PreviousWeight: target.Weight,
+		PreviousReps:   target.Reps,
+		ChangedAt:      time.Now(),
+	}
+	goal := fmt.Sprintf("%d x %d at %gkg", prescription.Sets, target.Reps, target.Weight)
+
+	if !metTarget(prescription.Sets, target, log) {
+		target.FailedSessions++
+		if rule.DeloadAfterFailures > 0 && target.FailedSessions >= rule.DeloadAfterFailures {
+			target.Weight = workout.RoundToIncrement(target.Weight * (1 - rule.DeloadPercent/100))
+			change.Outcome = DeloadOutcome
+			change.Reason = fmt.Sprintf("missed %s %d sessions in a row, deloading by %g%%", goal, target.FailedSessions, rule.DeloadPercent)
+			target.FailedSessions = 0
+		} else {
+			change.Outcome = FailureOutcome
+			change.Reason = fmt.Sprintf("missed %s, repeating the target (%d failed in a row)", goal, target.FailedSessions)
+		}
+	} else {
+		target.FailedSessions = 0
+		change.Outcome = SuccessOutcome
+		increment := rule.LoadIncrement()
+
+		switch rule.Scheme {
+		case workout.LinearProgression:
+			target.Weight += increment
+			change.Reason = fmt.Sprintf("completed %s, adding %gkg", goal, increment)
+		case workout.DoubleProgression:
+			if target.Reps < prescription.MaxReps {
+				target.Reps++
+				change.Reason = fmt.Sprintf("completed %s, adding a rep", goal)
+			} else {
+				target.Weight += increment
+				target.Reps = prescription.MinReps
+				change.Reason = fmt.Sprintf("completed %s at the top of the rep range, adding %gkg and going back to %d reps", goal, increment, prescription.MinReps)
+			}
+		case workout.PercentageWave:
+			oneRM := log.EstimatedOneRepMax()
+			if len(rule.Wave) == 0 || oneRM <= 0 {
+				change.Reason = fmt.Sprintf("completed %s, keeping the load as there is no wave step to move to", goal)
+				break
+			}
+			target.WaveStep = (target.WaveStep + 1) % len(rule.Wave)
+			target.Weight = workout.RoundToIncrement(oneRM * rule.Wave[target.WaveStep] / 100)
+			change.Reason = fmt.Sprintf("completed %s, moving to wave step %d at %g%% of a %gkg estimated 1RM",
+				goal, target.WaveStep+1, rule.Wave[target.WaveStep], oneRM)
+		}
+	}
+
+	target.Weight = math.Round(target.Weight*100) / 100
+	change.Weight = target.Weight
+	change.Reps = target.Reps
+	target.History = append(target.History, change)
+	if len(target.History) > HistoryLimit {
+		target.History = target.History[len(target.History)-HistoryLimit:]
+	}
+
+	return target
+}
+
+// initialWeight is the target a first evaluation measures the session against
+func initialWeight(prescription workout.Prescription, rule workout.Progression, log *exerciseLog.ExerciseLog) float64 {
+	oneRM := log.EstimatedOneRepMax()
+	if rule.Scheme == workout.PercentageWave && len(rule.Wave) > 0 && oneRM > 0 {
+		return workout.RoundToIncrement(oneRM * rule.Wave[0] / 100)
+	}
+	if weight := workout.PrescribedWeight(prescription, oneRM); weight > 0 {
+		return weight
+	}
+
+	// Nothing prescribed, so whatever the heaviest working set was becomes the target
+	var heaviest float64
+	for _, set := range log.Sets {
+		if set.Type != exerciseLog.WarmUpSet {
+			heaviest = math.Max(heaviest, set.Load())
+		}
+	}
+	return heaviest
+}
+
+// metTarget reports whether enough working sets reached the target reps and weight
+func metTarget(sets int, target ProgressionTarget, log *exerciseLog.ExerciseLog) bool {
+	met := 0
+	for _, set := range log.Sets {
+		if set.Type == exerciseLog.WarmUpSet {
+			continue
+		}
+		if set.Reps >= target.Reps && set.Load() >= target.Weight-0.01 {
+			met++
+		}
+	}
+	return met >= sets
+}
+
+// ApplyTarget returns the prescription with the progression target's weight and reps in place
+func ApplyTarget(prescription workout.Prescription, target *ProgressionTarget) workout.Prescription {
+	if target == nil {
+		return prescription
+	}
+	if target.Weight > 0 {
+		prescription.TargetWeight = target.Weight
+	}
+	if target.Reps > 0 {
+		prescription.MinReps = target.Reps
+		prescription.MaxReps = int(math.Max(float64(prescription.MaxReps), float64(target.Reps)))
+	}
+	return prescription
+}
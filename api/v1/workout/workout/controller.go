@@ -37,6 +37,10 @@ func (wc *WorkoutController) CreateWorkoutHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 	}
 
+	if err := ValidateWorkoutGroups(workout.Exercises, workout.Groups); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
 	createdWorkout, err := wc.Service.CreateWorkout(workout, userId)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
@@ -110,6 +114,10 @@ func (wc *WorkoutController) UpdateWorkoutHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 	}
 
+	if err := ValidateWorkoutGroups(workout.Exercises, workout.Groups); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
 	updatedWorkout, err := wc.Service.UpdateWorkout(id, workout, userId)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -3,13 +3,15 @@ package workout
 type CreateWorkoutDto struct {
 	Name        string            `json:"name" validate:"required"`
 	Description string            `json:"description"`
-	Exercises   []WorkoutExercise `json:"exercises" validate:"required"`
+	Exercises   []WorkoutExercise `json:"exercises" validate:"required,dive"`
+	Groups      []ExerciseGroup   `json:"groups" validate:"dive"`
 }
 
 type UpdateWorkoutDto struct {
 	Name        string            `json:"name" validate:"required"`
 	Description string            `json:"description"`
-	Exercises   []WorkoutExercise `json:"exercises" validate:"required"`
+	Exercises   []WorkoutExercise `json:"exercises" validate:"required,dive"`
+	Groups      []ExerciseGroup   `json:"groups" validate:"dive"`
 }
 
 type SearchWorkoutFilters struct {
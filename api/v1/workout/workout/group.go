@@ -0,0 +1,76 @@
+package workout
+
+import (
+	"fmt"
+	"sort"
+)
+
+// minGroupSize is the fewest exercises each group type can hold
+var minGroupSize = map[GroupType]int{
+	SupersetGroup: 2,
+	GiantSetGroup: 3,
+	CircuitGroup:  2,
+}
+
+// ValidateGroups checks that every group is used and that its exercises sit next to each other.
+// groupIds holds the GroupID of each exercise, in exercise order.
+func ValidateGroups(groups []ExerciseGroup, groupIds []string) error {
+	known := make(map[string]ExerciseGroup, len(groups))
+	for _, group := range groups {
+		if _, ok := known[group.ID]; ok {
+			return fmt.Errorf("group %s is defined more than once", group.ID)
+		}
+		known[group.ID] = group
+	}
+
+	sizes := make(map[string]int, len(groups))
+	for i, groupId := range groupIds {
+		if groupId == "" {
+			continue
+		}
+		if _, ok := known[groupId]; !ok {
+			return fmt.Errorf("group %s does not exist", groupId)
+		}
+		if sizes[groupId] > 0 && groupIds[i-1] != groupId {
+			return fmt.Errorf("exercises of group %s must be next to each other", groupId)
+		}
+		sizes[groupId]++
+	}
+
+	for _, group := range groups {
+		if sizes[group.ID] < minGroupSize[group.Type] {
+			return fmt.Errorf("a %s needs at least %d exercises", group.Type, minGroupSize[group.Type])
+		}
+		if group.Type == SupersetGroup && sizes[group.ID] > 2 {
+			return fmt.Errorf("a superset holds exactly 2 exercises, use a giant set for group %s", group.ID)
+		}
+	}
+
+	return nil
+}
+
+// DefaultGroupRounds sets the rounds of groups that left them out to 1
+func DefaultGroupRounds(groups []ExerciseGroup) []ExerciseGroup {
+	for i := range groups {
+		if groups[i].Rounds == 0 {
+			groups[i].Rounds = 1
+		}
+	}
+	return groups
+}
+
+// ValidateWorkoutGroups runs ValidateGroups over exercises sorted by their Order
+func ValidateWorkoutGroups(exercises []WorkoutExercise, groups []ExerciseGroup) error {
+	sorted := make([]WorkoutExercise, len(exercises))
+	copy(sorted, exercises)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	groupIds := make([]string, len(sorted))
+	for i, exercise := range sorted {
+		groupIds[i] = exercise.GroupID
+	}
+
+	return ValidateGroups(groups, groupIds)
+}
@@ -12,11 +12,32 @@ type Workout struct {
 	Name        string             `json:"name" bson:"name" validate:"required"`
 	Description string             `json:"description" bson:"description"`
 	Exercises   []WorkoutExercise  `json:"exercises" bson:"exercises" validate:"required"`
+	Groups      []ExerciseGroup    `json:"groups" bson:"groups" validate:"dive"`
 	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
 type WorkoutExercise struct {
-	ExerciseID string `json:"exerciseid" bson:"exerciseid" validate:"required"`
-	Order      int    `json:"order" bson:"order" validate:"min=0"`
+	ExerciseID   string        `json:"exerciseid" bson:"exerciseid" validate:"required"`
+	Order        int           `json:"order" bson:"order" validate:"min=0"`
+	GroupID      string        `json:"groupid,omitempty" bson:"groupid,omitempty"`
+	Prescription *Prescription `json:"prescription,omitempty" bson:"prescription,omitempty" validate:"required_with=Progression"`
+	Progression  *Progression  `json:"progression,omitempty" bson:"progression,omitempty"`
+}
+
+type GroupType string
+
+const (
+	SupersetGroup GroupType = "superset"  // Two exercises back to back
+	GiantSetGroup GroupType = "giant_set" // Three or more exercises back to back
+	CircuitGroup  GroupType = "circuit"   // Any number of exercises repeated for rounds
+)
+
+// ExerciseGroup links exercises that are performed back to back. Exercises join a group
+// through their GroupID and keep their own order inside it.
+type ExerciseGroup struct {
+	ID                string    `json:"id" bson:"id" validate:"required"`
+	Type              GroupType `json:"type" bson:"type" validate:"required,oneof=superset giant_set circuit"`
+	Rounds            int       `json:"rounds" bson:"rounds" validate:"omitempty,min=1"`                 // 1 when left out
+	RestBetweenRounds int       `json:"rest_between_rounds" bson:"rest_between_rounds" validate:"min=0"` // Seconds
 }
@@ -0,0 +1,102 @@
+package workout
+
+import (
+	"math"
+
+	dashboardFunctions "github.com/Npwskp/GymsbroBackend/api/v1/dashboard/functions"
+)
+
+// TargetWeightIncrement is the step weights derived from a 1RM are rounded to, in kg
+const TargetWeightIncrement = 2.5
+
+// Prescription is what a workout asks of an exercise, e.g. 4 x 8-10 @ 70% 1RM with 90s rest.
+// Weights are in kg. When several targets are given TargetWeight wins over PercentOneRM,
+// which wins over TargetRPE; TargetRPE is still passed on to each set as an effort target.
+type Prescription struct {
+	Sets         int     `json:"sets" bson:"sets" validate:"min=1,max=20"`
+	MinReps      int     `json:"min_reps" bson:"min_reps" validate:"min=1"`
+	MaxReps      int     `json:"max_reps" bson:"max_reps" validate:"gtefield=MinReps"`
+	TargetWeight float64 `json:"target_weight,omitempty" bson:"target_weight,omitempty" validate:"min=0"`
+	PercentOneRM float64 `json:"percent_one_rm,omitempty" bson:"percent_one_rm,omitempty" validate:"min=0,max=100"`
+	TargetRPE    float64 `json:"target_rpe,omitempty" bson:"target_rpe,omitempty" validate:"omitempty,min=1,max=10"`
+	RestSeconds  int     `json:"rest_seconds" bson:"rest_seconds" validate:"min=0"`
+}
+
+// TargetSet is a single planned set expanded from a Prescription
+type TargetSet struct {
+	SetNumber   int     `json:"set_number" bson:"set_number"`
+	MinReps     int     `json:"min_reps" bson:"min_reps"`
+	MaxReps     int     `json:"max_reps" bson:"max_reps"`
+	Weight      float64 `json:"weight" bson:"weight"` // Load in kg, 0 when it could not be worked out
+	RPE         float64 `json:"rpe,omitempty" bson:"rpe,omitempty"`
+	RestSeconds int     `json:"rest_seconds" bson:"rest_seconds"`
+}
+
+// ExpandPrescription turns a prescription into target sets. oneRepMax is the lifter's current
+// estimated 1RM in kg and is used for percentage and RPE based targets, 0 when unknown.
+func ExpandPrescription(p Prescription, oneRepMax float64) []TargetSet {
+	weight := PrescribedWeight(p, oneRepMax)
+
+	sets := make([]TargetSet, 0, p.Sets)
+	for i := 1; i <= p.Sets; i++ {
+		sets = append(sets, TargetSet{
+			SetNumber:   i,
+			MinReps:     p.MinReps,
+			MaxReps:     p.MaxReps,
+			Weight:      weight,
+			RPE:         p.TargetRPE,
+			RestSeconds: p.RestSeconds,
+		})
+	}
+
+	return sets
+}
+
+// PrescribedWeight returns the load a prescription asks for
+func PrescribedWeight(p Prescription, oneRepMax float64) float64 {
+	if p.TargetWeight > 0 {
+		return p.TargetWeight
+	}
+	if oneRepMax <= 0 {
+		return 0
+	}
+
+	if p.PercentOneRM > 0 {
+		return RoundToIncrement(oneRepMax * p.PercentOneRM / 100)
+	}
+
+	if p.TargetRPE > 0 {
+		// Top of the rep range with the reps in reserve the RPE leaves
+		weight, err := dashboardFunctions.EstimateRepMax(oneRepMax, float64(p.MaxReps)+10-p.TargetRPE)
+		if err != nil {
+			return 0
+		}
+		return RoundToIncrement(weight)
+	}
+
+	return 0
+}
+
+// RoundToIncrement rounds a weight to the nearest TargetWeightIncrement
+func RoundToIncrement(weight float64) float64 {
+	return math.Round(weight/TargetWeightIncrement) * TargetWeightIncrement
+}
+
+// ScaleTargetSets applies volume and intensity modifiers to target sets. The number of sets is
+// multiplied by volume, keeping at least one, and every weight by intensity.
+func ScaleTargetSets(sets []TargetSet, volume, intensity float64) []TargetSet {
+	if len(sets) == 0 || (volume == 1 && intensity == 1) {
+		return sets
+	}
+
+	count := max(int(math.Round(float64(len(sets))*volume)), 1)
+	scaled := make([]TargetSet, 0, count)
+	for i := 0; i < count; i++ {
+		set := sets[min(i, len(sets)-1)]
+		set.SetNumber = i + 1
+		set.Weight = RoundToIncrement(set.Weight * intensity)
+		scaled = append(scaled, set)
+	}
+
+	return scaled
+}
@@ -0,0 +1,28 @@
+package workout
+
+type ProgressionScheme string
+
+const (
+	LinearProgression ProgressionScheme = "linear" // Add Increment after every successful session
+	DoubleProgression ProgressionScheme = "double" // Add a rep until MaxReps, then add Increment and go back to MinReps
+	PercentageWave    ProgressionScheme = "wave"   // Move through Wave, each step a percentage of the latest estimated 1RM
+)
+
+// Progression describes how the prescription of an exercise moves on after each session.
+// A session fails when fewer than the prescribed sets hit the target reps and weight; after
+// DeloadAfterFailures failed sessions in a row the load drops by DeloadPercent.
+type Progression struct {
+	Scheme              ProgressionScheme `json:"scheme" bson:"scheme" validate:"required,oneof=linear double wave"`
+	Increment           float64           `json:"increment" bson:"increment" validate:"min=0"`                                                     // kg, defaults to TargetWeightIncrement
+	Wave                []float64         `json:"wave,omitempty" bson:"wave,omitempty" validate:"omitempty,dive,min=1,max=100"`                    // Percentages, a wave without steps keeps its load
+	DeloadAfterFailures int               `json:"deload_after_failures" bson:"deload_after_failures" validate:"min=0"`                             // 0 never deloads
+	DeloadPercent       float64           `json:"deload_percent" bson:"deload_percent" validate:"required_with=DeloadAfterFailures,min=0,max=100"` // Above 0 when deloading
+}
+
+// LoadIncrement returns the weight added on progression
+func (p Progression) LoadIncrement() float64 {
+	if p.Increment > 0 {
+		return p.Increment
+	}
+	return TargetWeightIncrement
+}
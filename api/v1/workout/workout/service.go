@@ -30,6 +30,7 @@ func (ws *WorkoutService) CreateWorkout(dto *CreateWorkoutDto, userId string) (*
 		Name:        dto.Name,
 		Description: dto.Description,
 		Exercises:   dto.Exercises,
+		Groups:      DefaultGroupRounds(dto.Groups),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -114,6 +115,7 @@ func (ws *WorkoutService) UpdateWorkout(id string, dto *UpdateWorkoutDto, userId
 		{Key: "name", Value: dto.Name},
 		{Key: "description", Value: dto.Description},
 		{Key: "exercises", Value: dto.Exercises},
+		{Key: "groups", Value: DefaultGroupRounds(dto.Groups)},
 		{Key: "updatedAt", Value: time.Now()},
 	}}}
 
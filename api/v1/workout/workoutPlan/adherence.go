@@ -0,0 +1,187 @@
+package workoutPlan
+
+import (
+	"sort"
+	"time"
+)
+
+func dayOf(t time.Time) time.Time {
+	return t.Truncate(24 * time.Hour)
+}
+
+// dayKey identifies the day of a time whatever its location
+func dayKey(t time.Time) int64 {
+	return dayOf(t).Unix()
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(dayOf(to).Sub(dayOf(from)).Hours() / 24)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// MatchDate finds the planned date a session performed at the given time completes: the closest
+// date within MatchToleranceDays that is not completed yet, the earlier one on a tie
+func MatchDate(dates []time.Time, completions []PlanCompletion, performedAt time.Time) (time.Time, bool) {
+	completed := make(map[int64]bool)
+	for _, completion := range completions {
+		completed[dayKey(completion.Date)] = true
+	}
+
+	var match time.Time
+	found := false
+	bestDistance := MatchToleranceDays + 1
+	for _, date := range dates {
+		if completed[dayKey(date)] {
+			continue
+		}
+
+		distance := abs(daysBetween(date, performedAt))
+		if distance < bestDistance || (distance == bestDistance && found && date.Before(match)) {
+			match, found, bestDistance = date, true, distance
+		}
+	}
+
+	return match, found
+}
+
+// PlannedDays lists the planned dates of the plans between start and end, both days included,
+// with what became of them as of now, in date order
+func PlannedDays(plans []WorkoutPlan, start time.Time, end time.Time, now time.Time) []PlannedDay {
+	days := make([]PlannedDay, 0)
+	for _, plan := range plans {
+		completions := make(map[int64]PlanCompletion)
+		for _, completion := range plan.Completions {
+			completions[dayKey(completion.Date)] = completion
+		}
+
+		for _, date := range plan.Dates {
+			if dayOf(date).Before(dayOf(start)) || dayOf(date).After(dayOf(end)) {
+				continue
+			}
+
+			day := PlannedDay{
+				Date:      date,
+				PlanID:    plan.ID.Hex(),
+				WorkoutID: plan.WorkoutID,
+			}
+
+			if plan.Rest {
+				day.Status = PlannedRest
+			} else if completion, ok := completions[dayKey(date)]; ok {
+				performedAt := completion.PerformedAt
+				day.SessionID = completion.SessionID
+				day.PerformedAt = &performedAt
+				day.Status = PlannedCompleted
+				if dayOf(performedAt).After(dayOf(date)) {
+					day.Status = PlannedLate
+				}
+			} else if daysBetween(date, now) > MatchToleranceDays {
+				day.Status = PlannedMissed
+			} else {
+				day.Status = PlannedUpcoming
+			}
+
+			days = append(days, day)
+		}
+
+		for _, date := range plan.Skipped {
+			if dayOf(date).Before(dayOf(start)) || dayOf(date).After(dayOf(end)) {
+				continue
+			}
+			days = append(days, PlannedDay{Date: date, PlanID: plan.ID.Hex(), WorkoutID: plan.WorkoutID, Status: PlannedSkipped})
+		}
+	}
+
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date.Before(days[j].Date)
+	})
+
+	return days
+}
+
+// CalculateAdherence sums up planned days in date order. Upcoming, skipped and rest days are not
+// counted as planned and do not break a streak.
+func CalculateAdherence(days []PlannedDay, start time.Time, end time.Time) *Adherence {
+	adherence := &Adherence{
+		StartDate:  start,
+		EndDate:    end,
+		MissedDays: make([]PlannedDay, 0),
+	}
+
+	streak := 0
+	for _, day := range days {
+		switch day.Status {
+		case PlannedUpcoming:
+			adherence.Upcoming++
+			continue
+		case PlannedSkipped:
+			adherence.Skipped++
+			continue
+		case PlannedRest:
+			adherence.RestDays++
+			continue
+		case PlannedCompleted:
+			adherence.Completed++
+			streak++
+		case PlannedLate:
+			adherence.Late++
+			streak++
+		case PlannedMissed:
+			adherence.Missed++
+			adherence.MissedDays = append(adherence.MissedDays, day)
+			streak = 0
+		}
+
+		adherence.Planned++
+		adherence.LongestStreak = max(adherence.LongestStreak, streak)
+	}
+	adherence.CurrentStreak = streak
+
+	if adherence.Planned > 0 {
+		adherence.CompletionRate = float64(adherence.Completed+adherence.Late) / float64(adherence.Planned)
+	}
+
+	return adherence
+}
+
+// BuildCalendar merges planned days and performed workouts into the days that have either,
+// in date order
+func BuildCalendar(planned []PlannedDay, performed []PerformedWorkout) []CalendarDay {
+	byDay := make(map[int64]*CalendarDay)
+	calendar := make([]*CalendarDay, 0)
+	dayFor := func(date time.Time) *CalendarDay {
+		key := dayKey(date)
+		if day, ok := byDay[key]; ok {
+			return day
+		}
+		day := &CalendarDay{Date: dayOf(date), Planned: make([]PlannedDay, 0), Performed: make([]PerformedWorkout, 0)}
+		byDay[key] = day
+		calendar = append(calendar, day)
+		return day
+	}
+
+	for _, day := range planned {
+		calendarDay := dayFor(day.Date)
+		calendarDay.Planned = append(calendarDay.Planned, day)
+	}
+	for _, workout := range performed {
+		calendarDay := dayFor(workout.StartTime)
+		calendarDay.Performed = append(calendarDay.Performed, workout)
+	}
+
+	sort.Slice(calendar, func(i, j int) bool {
+		return calendar[i].Date.Before(calendar[j].Date)
+	})
+
+	days := make([]CalendarDay, 0, len(calendar))
+	for _, day := range calendar {
+		days = append(days, *day)
+	}
+	return days
+}
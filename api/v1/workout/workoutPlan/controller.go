@@ -1,9 +1,13 @@
 package workoutPlan
 
 import (
+	"errors"
+	"time"
+
 	"github.com/Npwskp/GymsbroBackend/api/v1/function"
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Error error
@@ -14,7 +18,7 @@ type WorkoutPlanController struct {
 }
 
 // @Summary		Create workout plan by days of week
-// @Description	Create a workout plan with specific workouts for each day of the week
+// @Description	Create a workout plan with workouts for days of the week. A day may hold several workouts (am/pm) or repeat every few weeks; days without a workout are rest days
 // @Tags		workoutPlan
 // @Accept		json
 // @Produce		json
@@ -40,6 +44,12 @@ func (wp *WorkoutPlanController) CreatePlanByDaysOfWeek(c *fiber.Ctx) error {
 		})
 	}
 
+	if !dto.HasWorkouts() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "at least one day must hold a workout",
+		})
+	}
+
 	workoutPlans, err := wp.Service.CreatePlanByDaysOfWeek(dto, userId)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -51,7 +61,7 @@ func (wp *WorkoutPlanController) CreatePlanByDaysOfWeek(c *fiber.Ctx) error {
 }
 
 // @Summary		Create workout plan by cyclic workouts
-// @Description	Create a workout plan by cycling through a list of workouts
+// @Description	Create a workout plan by cycling through a list of workouts, an empty workout ID being a rest day
 // @Tags		workoutPlan
 // @Accept		json
 // @Produce		json
@@ -87,6 +97,116 @@ func (wp *WorkoutPlanController) CreatePlanByCyclicWorkout(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(workoutPlans)
 }
 
+// @Summary		Preview plan by days of week
+// @Description	Return the dates a plan by days of week would generate and how they change the current plans, without writing anything
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		plan body CreatePlanByDaysOfWeekDto true "Create Workout Plan by Days"
+// @Success		200	{object} PlanPreview
+// @Failure		400	{object} Error
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/byDaysOfWeek/preview [post]
+func (wp *WorkoutPlanController) PreviewPlanByDaysOfWeek(c *fiber.Ctx) error {
+	validate := validator.New()
+	dto := new(CreatePlanByDaysOfWeekDto)
+	userId := function.GetUserIDFromContext(c)
+
+	if err := c.BodyParser(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if !dto.HasWorkouts() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "at least one day must hold a workout",
+		})
+	}
+
+	preview, err := wp.Service.PreviewPlanByDaysOfWeek(dto, userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(preview)
+}
+
+// @Summary		Preview plan by cyclic workouts
+// @Description	Return the dates a plan by cyclic workouts would generate and how they change the current plans, without writing anything
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		plan body CreatePlanByCyclicWorkoutDto true "Create Workout Plan by Cycle"
+// @Success		200	{object} PlanPreview
+// @Failure		400	{object} Error
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/byCyclicWorkout/preview [post]
+func (wp *WorkoutPlanController) PreviewPlanByCyclicWorkout(c *fiber.Ctx) error {
+	validate := validator.New()
+	dto := new(CreatePlanByCyclicWorkoutDto)
+	userId := function.GetUserIDFromContext(c)
+
+	if err := c.BodyParser(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	preview, err := wp.Service.PreviewPlanByCyclicWorkout(dto, userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(preview)
+}
+
+// @Summary		Commit plan preview
+// @Description	Apply exactly the changes of a preview, failing if the plans changed since it was made
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Preview ID"
+// @Success		200	{array} WorkoutPlan
+// @Failure		404	{object} Error
+// @Failure		409	{object} Error
+// @Router		/workoutPlan/preview/{id}/commit [post]
+func (wp *WorkoutPlanController) CommitPlanPreview(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	id := c.Params("id")
+
+	workoutPlans, err := wp.Service.CommitPlanPreview(id, userId)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			status = fiber.StatusNotFound
+		} else if errors.Is(err, ErrPlansChanged) {
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(workoutPlans)
+}
+
 // @Summary		Get all workout plans
 // @Description	Get all workout plans for the authenticated user
 // @Tags		workoutPlan
@@ -106,10 +226,384 @@ func (wp *WorkoutPlanController) GetWorkoutPlans(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(workoutPlans)
 }
 
+// @Summary		Get plan adherence
+// @Description	Compare planned and completed workouts between two dates, with streaks and missed days
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		startDate query string true "Start date"
+// @Param		endDate query string true "End date"
+// @Success		200	{object} Adherence
+// @Failure		400	{object} Error
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/adherence [get]
+func (wp *WorkoutPlanController) GetAdherence(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	adherence, err := wp.Service.GetAdherence(userId, startDate, endDate)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(adherence)
+}
+
+// @Summary		Get workout calendar
+// @Description	Get planned and performed workouts day by day between two dates
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		startDate query string true "Start date"
+// @Param		endDate query string true "End date"
+// @Success		200	{array} CalendarDay
+// @Failure		400	{object} Error
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/calendar [get]
+func (wp *WorkoutPlanController) GetCalendar(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	calendar, err := wp.Service.GetCalendar(userId, startDate, endDate)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(calendar)
+}
+
+// @Summary		Move a planned date
+// @Description	Move a single planned date of a workout plan to another day
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Workout Plan ID"
+// @Param		move body MovePlanDateDto true "Move Planned Date"
+// @Success		200	{object} WorkoutPlan
+// @Failure		400	{object} Error
+// @Failure		404	{object} Error
+// @Failure		409	{object} Error
+// @Router		/workoutPlan/{id}/move [put]
+func (wp *WorkoutPlanController) MovePlanDate(c *fiber.Ctx) error {
+	validate := validator.New()
+	dto := new(MovePlanDateDto)
+	userId := function.GetUserIDFromContext(c)
+	id := c.Params("id")
+
+	if err := c.BodyParser(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	plan, err := wp.Service.MovePlanDate(id, dto, userId)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(plan)
+}
+
+// @Summary		Skip a planned date
+// @Description	Take a single planned date off a workout plan
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Workout Plan ID"
+// @Param		skip body SkipPlanDateDto true "Skip Planned Date"
+// @Success		200	{object} WorkoutPlan
+// @Failure		400	{object} Error
+// @Failure		404	{object} Error
+// @Router		/workoutPlan/{id}/skip [put]
+func (wp *WorkoutPlanController) SkipPlanDate(c *fiber.Ctx) error {
+	validate := validator.New()
+	dto := new(SkipPlanDateDto)
+	userId := function.GetUserIDFromContext(c)
+	id := c.Params("id")
+
+	if err := c.BodyParser(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	plan, err := wp.Service.SkipPlanDate(id, dto, userId)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(plan)
+}
+
+// @Summary		Shift the schedule
+// @Description	Push every remaining planned date back or forward by a number of days
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		shift body ShiftPlansDto true "Shift Schedule"
+// @Success		200	{array} WorkoutPlan
+// @Failure		400	{object} Error
+// @Failure		404	{object} Error
+// @Failure		409	{object} Error
+// @Router		/workoutPlan/shift [put]
+func (wp *WorkoutPlanController) ShiftPlans(c *fiber.Ctx) error {
+	validate := validator.New()
+	dto := new(ShiftPlansDto)
+	userId := function.GetUserIDFromContext(c)
+
+	if err := c.BodyParser(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	workoutPlans, err := wp.Service.ShiftPlans(dto, userId)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(workoutPlans)
+}
+
+// @Summary		Swap two days
+// @Description	Swap the workouts planned on two days
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		swap body SwapPlanDaysDto true "Swap Days"
+// @Success		200	{array} WorkoutPlan
+// @Failure		400	{object} Error
+// @Failure		404	{object} Error
+// @Failure		409	{object} Error
+// @Router		/workoutPlan/swap [put]
+func (wp *WorkoutPlanController) SwapPlanDays(c *fiber.Ctx) error {
+	validate := validator.New()
+	dto := new(SwapPlanDaysDto)
+	userId := function.GetUserIDFromContext(c)
+
+	if err := c.BodyParser(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	workoutPlans, err := wp.Service.SwapPlanDays(dto, userId)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(workoutPlans)
+}
+
+// @Summary		Delete workout plan
+// @Description	Delete a workout plan and its planned dates
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Workout Plan ID"
+// @Success		204
+// @Failure		400	{object} Error
+// @Failure		404	{object} Error
+// @Router		/workoutPlan/{id} [delete]
+func (wp *WorkoutPlanController) DeletePlan(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	id := c.Params("id")
+
+	if err := wp.Service.DeletePlan(id, userId); err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary		Get calendar feed
+// @Description	Get the secret iCalendar feed URL of the user's planned workouts, for Google or Apple Calendar
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Success		200	{object} CalendarFeed
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/feed [get]
+func (wp *WorkoutPlanController) GetCalendarFeed(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	feed, err := wp.Service.GetCalendarFeed(userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	feed.URL = feedURL(c, feed.Token)
+	return c.Status(fiber.StatusOK).JSON(feed)
+}
+
+// @Summary		Rotate calendar feed
+// @Description	Give the calendar feed a new secret URL, the previous one stops working
+// @Tags		workoutPlan
+// @Accept		json
+// @Produce		json
+// @Success		200	{object} CalendarFeed
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/feed/rotate [post]
+func (wp *WorkoutPlanController) RotateCalendarFeed(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	feed, err := wp.Service.RotateCalendarFeed(userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	feed.URL = feedURL(c, feed.Token)
+	return c.Status(fiber.StatusOK).JSON(feed)
+}
+
+// @Summary		Export session history
+// @Description	Export the completed workout sessions of the user as an iCalendar file
+// @Tags		workoutPlan
+// @Produce		text/calendar
+// @Success		200	{string} string
+// @Failure		401	{object} Error
+// @Router		/workoutPlan/history.ics [get]
+func (wp *WorkoutPlanController) GetSessionHistoryCalendar(c *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(c)
+	calendar, err := wp.Service.GetSessionHistoryCalendar(userId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="workout-history.ics"`)
+	return c.SendString(calendar)
+}
+
+func feedURL(c *fiber.Ctx, token string) string {
+	return c.BaseURL() + "/api/v1/workoutPlan/feed/" + token + "/plan.ics"
+}
+
+// scheduleError maps errors of schedule edits to a response
+func scheduleError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		status = fiber.StatusNotFound
+	} else if errors.Is(err, ErrScheduleConflict) {
+		status = fiber.StatusConflict
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
+func parseDateRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	startDate, err := time.Parse("2006-01-02 15:04:05", c.Query("startDate"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	endDate, err := time.Parse("2006-01-02 15:04:05", c.Query("endDate"))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if startDate.After(endDate) {
+		return time.Time{}, time.Time{}, errors.New("start date cannot be after end date")
+	}
+
+	return startDate, endDate, nil
+}
+
 func (wp *WorkoutPlanController) Handle() {
 	g := wp.Instance.Group("/workoutPlan")
 
 	g.Post("/byDaysOfWeek", wp.CreatePlanByDaysOfWeek)
 	g.Post("/byCyclicWorkout", wp.CreatePlanByCyclicWorkout)
+	g.Post("/byDaysOfWeek/preview", wp.PreviewPlanByDaysOfWeek)
+	g.Post("/byCyclicWorkout/preview", wp.PreviewPlanByCyclicWorkout)
+	g.Post("/preview/:id/commit", wp.CommitPlanPreview)
 	g.Get("/byUser", wp.GetWorkoutPlans)
+	g.Get("/adherence", wp.GetAdherence)
+	g.Get("/calendar", wp.GetCalendar)
+	g.Get("/feed", wp.GetCalendarFeed)
+	g.Post("/feed/rotate", wp.RotateCalendarFeed)
+	g.Get("/history.ics", wp.GetSessionHistoryCalendar)
+	g.Put("/shift", wp.ShiftPlans)
+	g.Put("/swap", wp.SwapPlanDays)
+	g.Put("/:id/move", wp.MovePlanDate)
+	g.Put("/:id/skip", wp.SkipPlanDate)
+	g.Delete("/:id", wp.DeletePlan)
+}
+
+// CalendarFeedController serves the calendar feed to calendar apps, which cannot log in, so it is
+// registered on the public routes and protected by the feed token instead
+type CalendarFeedController struct {
+	Instance fiber.Router
+	Service  IWorkoutPlanService
+}
+
+// @Summary		Planned workouts calendar feed
+// @Description	iCalendar feed of a user's planned workouts, identified by the secret feed token
+// @Tags		workoutPlan
+// @Produce		text/calendar
+// @Param		token path string true "Feed token"
+// @Success		200	{string} string
+// @Failure		404	{object} Error
+// @Router		/workoutPlan/feed/{token}/plan.ics [get]
+func (fc *CalendarFeedController) GetPlanCalendar(c *fiber.Ctx) error {
+	calendar, err := fc.Service.GetPlanCalendar(c.Params("token"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "calendar feed not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.SendString(calendar)
+}
+
+func (fc *CalendarFeedController) Handle() {
+	g := fc.Instance.Group("/workoutPlan")
+
+	g.Get("/feed/:token/plan.ics", fc.GetPlanCalendar)
 }
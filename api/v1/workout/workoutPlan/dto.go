@@ -1,17 +1,89 @@
 package workoutPlan
 
+import "time"
+
+// CreatePlanByDaysOfWeekDto plans workouts on days of the week. Days that hold no workout in a
+// week are rest days. The per-weekday IDs are kept for older clients and are added to Days.
 type CreatePlanByDaysOfWeekDto struct {
-	MondayWorkoutID    string `json:"mondayWorkoutId" validate:"required"`
-	TuesdayWorkoutID   string `json:"tuesdayWorkoutId" validate:"required"`
-	WednesdayWorkoutID string `json:"wednesdayWorkoutId" validate:"required"`
-	ThursdayWorkoutID  string `json:"thursdayWorkoutId" validate:"required"`
-	FridayWorkoutID    string `json:"fridayWorkoutId" validate:"required"`
-	SaturdayWorkoutID  string `json:"saturdayWorkoutId" validate:"required"`
-	SundayWorkoutID    string `json:"sundayWorkoutId" validate:"required"`
-	WeeksDuration      int    `json:"weeksDuration" validate:"required"`
+	MondayWorkoutID    string       `json:"mondayWorkoutId"`
+	TuesdayWorkoutID   string       `json:"tuesdayWorkoutId"`
+	WednesdayWorkoutID string       `json:"wednesdayWorkoutId"`
+	ThursdayWorkoutID  string       `json:"thursdayWorkoutId"`
+	FridayWorkoutID    string       `json:"fridayWorkoutId"`
+	SaturdayWorkoutID  string       `json:"saturdayWorkoutId"`
+	SundayWorkoutID    string       `json:"sundayWorkoutId"`
+	Days               []PlanDayDto `json:"days" validate:"dive"`
+	WeeksDuration      int          `json:"weeksDuration" validate:"required"`
+}
+
+// PlanDayDto plans one or more workouts on a day of the week
+type PlanDayDto struct {
+	Day        time.Weekday        `json:"day" validate:"min=0,max=6"` // 0 is Sunday
+	Workouts   []PlanDayWorkoutDto `json:"workouts" validate:"dive"`
+	EveryWeeks int                 `json:"everyWeeks" validate:"min=0"` // 2 plans the day every other week, 0 or 1 every week
+}
+
+type PlanDayWorkoutDto struct {
+	WorkoutID string   `json:"workoutId" validate:"required"`
+	Slot      PlanSlot `json:"slot" validate:"omitempty,oneof=am pm"`
+}
+
+// PlanDays returns the planned days, the per-weekday IDs included
+func (dto *CreatePlanByDaysOfWeekDto) PlanDays() []PlanDayDto {
+	days := append([]PlanDayDto(nil), dto.Days...)
+	legacy := map[time.Weekday]string{
+		time.Monday:    dto.MondayWorkoutID,
+		time.Tuesday:   dto.TuesdayWorkoutID,
+		time.Wednesday: dto.WednesdayWorkoutID,
+		time.Thursday:  dto.ThursdayWorkoutID,
+		time.Friday:    dto.FridayWorkoutID,
+		time.Saturday:  dto.SaturdayWorkoutID,
+		time.Sunday:    dto.SundayWorkoutID,
+	}
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		if legacy[day] != "" {
+			days = append(days, PlanDayDto{Day: day, Workouts: []PlanDayWorkoutDto{{WorkoutID: legacy[day]}}})
+		}
+	}
+	return days
+}
+
+// HasWorkouts tells whether any day holds a workout
+func (dto *CreatePlanByDaysOfWeekDto) HasWorkouts() bool {
+	for _, day := range dto.PlanDays() {
+		if len(day.Workouts) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
+// CreatePlanByCyclicWorkoutDto cycles through workouts one day after another. An empty ID is a
+// rest day.
 type CreatePlanByCyclicWorkoutDto struct {
-	WorkoutIDs    []string `json:"workoutIds" validate:"required"`
+	WorkoutIDs    []string `json:"workoutIds" validate:"required,min=1"`
 	WeeksDuration int      `json:"weeksDuration" validate:"required"`
 }
+
+type MovePlanDateDto struct {
+	From           time.Time `json:"from" validate:"required"`
+	To             time.Time `json:"to" validate:"required"`
+	AllowConflicts bool      `json:"allowConflicts"` // Allow another workout on the new day
+}
+
+type SkipPlanDateDto struct {
+	Date time.Time `json:"date" validate:"required"`
+}
+
+type ShiftPlansDto struct {
+	Days           int       `json:"days" validate:"required"`
+	From           time.Time `json:"from"` // Defaults to today
+	AllowConflicts bool      `json:"allowConflicts"`
+}
+
+type SwapPlanDaysDto struct {
+	First          time.Time `json:"first" validate:"required"`
+	Second         time.Time `json:"second" validate:"required"`
+	AllowConflicts bool      `json:"allowConflicts"`
+}
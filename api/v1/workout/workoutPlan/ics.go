@@ -0,0 +1,196 @@
+package workoutPlan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
+)
+
+// CalendarEvent is a single event of an iCalendar feed
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// PlanEventUID identifies the event of a workout on a day. It only depends on the workout and the
+// day, so the event stays the same when the plan holding the date is rewritten.
+func PlanEventUID(workoutId string, date time.Time) string {
+	return fmt.Sprintf("plan-%s-%s@gymsbro", workoutId, dayOf(date).Format("20060102"))
+}
+
+// PlanEvents turns the planned dates of the plans into all-day events. workouts are keyed by ID and
+// exerciseNames by exercise ID; workouts that cannot be found are left out.
+func PlanEvents(plans []WorkoutPlan, workouts map[string]*workout.Workout, exerciseNames map[string]string) []CalendarEvent {
+	seen := make(map[string]bool)
+	events := make([]CalendarEvent, 0)
+	for _, plan := range plans {
+		w, ok := workouts[plan.WorkoutID]
+		if !ok {
+			continue
+		}
+
+		description := describeWorkout(w, exerciseNames)
+		for _, date := range plan.Dates {
+			uid := PlanEventUID(plan.WorkoutID, date)
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+
+			events = append(events, CalendarEvent{
+				UID:         uid,
+				Summary:     w.Name,
+				Description: description,
+				Start:       dayOf(date),
+				End:         dayOf(date).AddDate(0, 0, 1),
+				AllDay:      true,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events
+}
+
+// SessionEvents turns completed sessions into timed events
+func SessionEvents(sessions []workoutSession.WorkoutSession, workouts map[string]*workout.Workout, exerciseNames map[string]string) []CalendarEvent {
+	events := make([]CalendarEvent, 0, len(sessions))
+	for _, session := range sessions {
+		summary := "Workout"
+		if w, ok := workouts[session.WorkoutID]; ok {
+			summary = w.Name
+		}
+
+		end := session.EndTime
+		if end.Before(session.StartTime) {
+			end = session.StartTime
+		}
+
+		lines := []string{
+			fmt.Sprintf("Duration: %d min", int(end.Sub(session.StartTime).Minutes())),
+			fmt.Sprintf("Total volume: %.1f kg", session.TotalVolume),
+		}
+		for _, ex := range session.Exercises {
+			lines = append(lines, "- "+exerciseName(ex.ExerciseID, exerciseNames))
+		}
+		if session.Notes != "" {
+			lines = append(lines, session.Notes)
+		}
+
+		events = append(events, CalendarEvent{
+			UID:         fmt.Sprintf("session-%s@gymsbro", session.ID.Hex()),
+			Summary:     summary,
+			Description: strings.Join(lines, "\n"),
+			Start:       session.StartTime,
+			End:         end,
+		})
+	}
+	return events
+}
+
+func exerciseName(exerciseId string, exerciseNames map[string]string) string {
+	if name, ok := exerciseNames[exerciseId]; ok {
+		return name
+	}
+	return "Exercise"
+}
+
+func describeWorkout(w *workout.Workout, exerciseNames map[string]string) string {
+	exercises := append([]workout.WorkoutExercise(nil), w.Exercises...)
+	sort.SliceStable(exercises, func(i, j int) bool {
+		return exercises[i].Order < exercises[j].Order
+	})
+
+	lines := make([]string, 0, len(exercises)+1)
+	if w.Description != "" {
+		lines = append(lines, w.Description)
+	}
+	for _, ex := range exercises {
+		line := "- " + exerciseName(ex.ExerciseID, exerciseNames)
+		if p := ex.Prescription; p != nil {
+			reps := fmt.Sprintf("%d", p.MinReps)
+			if p.MaxReps > p.MinReps {
+				reps = fmt.Sprintf("%d-%d", p.MinReps, p.MaxReps)
+			}
+			line += fmt.Sprintf(": %d x %s", p.Sets, reps)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderCalendar writes events as an iCalendar (RFC 5545) document
+func RenderCalendar(name string, events []CalendarEvent, stamp time.Time) string {
+	var b strings.Builder
+	write := func(line string) {
+		b.WriteString(foldLine(line))
+		b.WriteString("\r\n")
+	}
+
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:-//Gymsbro//Workout Calendar//EN")
+	write("CALSCALE:GREGORIAN")
+	write("X-WR-CALNAME:" + escapeText(name))
+	for _, event := range events {
+		write("BEGIN:VEVENT")
+		write("UID:" + event.UID)
+		write("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		if event.AllDay {
+			write("DTSTART;VALUE=DATE:" + event.Start.Format("20060102"))
+			write("DTEND;VALUE=DATE:" + event.End.Format("20060102"))
+		} else {
+			write("DTSTART:" + event.Start.UTC().Format("20060102T150405Z"))
+			write("DTEND:" + event.End.UTC().Format("20060102T150405Z"))
+		}
+		write("SUMMARY:" + escapeText(event.Summary))
+		if event.Description != "" {
+			write("DESCRIPTION:" + escapeText(event.Description))
+		}
+		write("END:VEVENT")
+	}
+	write("END:VCALENDAR")
+
+	return b.String()
+}
+
+func escapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// foldLine splits lines longer than 75 octets, continuing them with a space
+func foldLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
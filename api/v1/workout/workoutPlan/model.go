@@ -7,10 +7,131 @@ import (
 )
 
 type WorkoutPlan struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      string             `json:"userid" bson:"userid" validate:"required"`
+	WorkoutID   string             `json:"workoutid" bson:"workoutid"` // Empty for the rest days plan
+	Dates       []time.Time        `json:"dates" bson:"dates" validate:"required,min=1"`
+	ProgramID   string             `json:"programid,omitempty" bson:"programid,omitempty"` // Set when the dates come from a program
+	Rest        bool               `json:"rest,omitempty" bson:"rest,omitempty"`           // The dates are rest days
+	Completions []PlanCompletion   `json:"completions,omitempty" bson:"completions,omitempty"`
+	Skipped     []time.Time        `json:"skipped,omitempty" bson:"skipped,omitempty"` // Planned dates the user chose to skip
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// RestDayID stands for rest days where workouts are keyed by workout ID
+const RestDayID = ""
+
+type PlanSlot string
+
+const (
+	MorningSlot PlanSlot = "am"
+	EveningSlot PlanSlot = "pm"
+)
+
+// Hours of the day, in UTC, that workouts of each slot are planned at
+const (
+	MorningSlotHour = 8
+	EveningSlotHour = 17
+)
+
+// MatchToleranceDays is how many days before or after a planned date a session still counts for it
+const MatchToleranceDays = 2
+
+// PlanCompletion links a planned date to the session that completed it
+type PlanCompletion struct {
+	Date        time.Time `json:"date" bson:"date"` // The planned date
+	SessionID   string    `json:"sessionid" bson:"sessionid"`
+	PerformedAt time.Time `json:"performed_at" bson:"performed_at"`
+}
+
+type PlannedStatus string
+
+const (
+	PlannedCompleted PlannedStatus = "completed" // Done on or before the planned day
+	PlannedLate      PlannedStatus = "late"      // Done after the planned day, within the tolerance
+	PlannedMissed    PlannedStatus = "missed"    // Not done and the tolerance has passed
+	PlannedUpcoming  PlannedStatus = "upcoming"  // Not done yet, still within the tolerance
+	PlannedSkipped   PlannedStatus = "skipped"   // Skipped on purpose
+	PlannedRest      PlannedStatus = "rest"      // A rest day
+)
+
+// PlannedDay is a planned date of a workout and what became of it
+type PlannedDay struct {
+	Date        time.Time     `json:"date"`
+	PlanID      string        `json:"planid"`
+	WorkoutID   string        `json:"workoutid,omitempty"`
+	Status      PlannedStatus `json:"status"`
+	SessionID   string        `json:"sessionid,omitempty"`
+	PerformedAt *time.Time    `json:"performed_at,omitempty"`
+}
+
+// Adherence compares planned and completed workouts over a date range
+type Adherence struct {
+	StartDate      time.Time    `json:"start_date"`
+	EndDate        time.Time    `json:"end_date"`
+	Planned        int          `json:"planned"` // Planned days that are due, upcoming ones are left out
+	Completed      int          `json:"completed"`
+	Late           int          `json:"late"`
+	Missed         int          `json:"missed"`
+	Upcoming       int          `json:"upcoming"`
+	Skipped        int          `json:"skipped"`
+	RestDays       int          `json:"rest_days"`
+	CompletionRate float64      `json:"completion_rate"` // Completed and late over planned, 0 to 1
+	CurrentStreak  int          `json:"current_streak"`  // Planned days done in a row up to the latest due one
+	LongestStreak  int          `json:"longest_streak"`
+	MissedDays     []PlannedDay `json:"missed_days"`
+}
+
+// PerformedWorkout is a completed session shown on the calendar
+type PerformedWorkout struct {
+	SessionID   string     `json:"sessionid"`
+	WorkoutID   string     `json:"workoutid"`
+	Type        string     `json:"type"`
+	StartTime   time.Time  `json:"start_time"`
+	Duration    int        `json:"duration"`
+	TotalVolume float64    `json:"total_volume"`
+	PlannedDate *time.Time `json:"planned_date,omitempty"` // The planned date the session completed, if any
+}
+
+// CalendarDay merges what was planned and what was performed on a day
+type CalendarDay struct {
+	Date      time.Time          `json:"date"`
+	Planned   []PlannedDay       `json:"planned"`
+	Performed []PerformedWorkout `json:"performed"`
+}
+
+// CalendarFeed gives calendar apps access to a user's planned workouts through a secret token
+type CalendarFeed struct {
 	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	UserID    string             `json:"userid" bson:"userid" validate:"required"`
-	WorkoutID string             `json:"workoutid" bson:"workoutid" validate:"required"`
-	Dates     []time.Time        `json:"dates" bson:"dates" validate:"required,min=1"`
+	UserID    string             `json:"userid" bson:"userid"`
+	Token     string             `json:"token" bson:"token"`
+	URL       string             `json:"url" bson:"-"` // Subscription URL, set on responses
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// PlanChange is a date of a workout added to, removed from or kept in a plan. PlanID is empty
+// when the date goes to a new plan.
+type PlanChange struct {
+	PlanID    string    `json:"planid,omitempty" bson:"planid,omitempty"`
+	WorkoutID string    `json:"workoutid" bson:"workoutid"`
+	Date      time.Time `json:"date" bson:"date"`
+}
+
+type PlanDiff struct {
+	Added     []PlanChange `json:"added" bson:"added"`
+	Removed   []PlanChange `json:"removed" bson:"removed"`
+	Unchanged []PlanChange `json:"unchanged" bson:"unchanged"`
+}
+
+// PlanPreview holds the outcome of creating a plan without writing it. Committing it applies
+// Diff as long as the plans are still at Revision.
+type PlanPreview struct {
+	ID        primitive.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    string                 `json:"userid" bson:"userid"`
+	Revision  string                 `json:"revision" bson:"revision"`
+	Generated map[string][]time.Time `json:"generated" bson:"generated"` // Generated dates by workout ID
+	Diff      PlanDiff               `json:"diff" bson:"diff"`
+	CreatedAt time.Time              `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time              `json:"expires_at" bson:"expires_at"`
 }
@@ -0,0 +1,268 @@
+package workoutPlan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PreviewTTL is how long a preview can be committed for
+const PreviewTTL = 30 * time.Minute
+
+var ErrPlansChanged = errors.New("workout plans changed since the preview, preview them again")
+
+// DaysOfWeekDates lays the workouts of each weekday out over the weeks from start on. Days without
+// a workout that week are rest days, planned under RestDayID.
+func DaysOfWeekDates(dto *CreatePlanByDaysOfWeekDto, start time.Time) ([]string, map[string][]time.Time) {
+	days := dto.PlanDays()
+
+	workoutIds := make([]string, 0)
+	workoutDates := make(map[string][]time.Time)
+	for i := 0; i < dto.WeeksDuration*7; i++ {
+		date := start.AddDate(0, 0, i)
+		rest := true
+		for _, day := range days {
+			if day.Day != date.Weekday() || (day.EveryWeeks > 1 && (i/7)%day.EveryWeeks != 0) {
+				continue
+			}
+			for _, w := range day.Workouts {
+				if _, ok := workoutDates[w.WorkoutID]; !ok {
+					workoutIds = append(workoutIds, w.WorkoutID)
+				}
+				workoutDates[w.WorkoutID] = append(workoutDates[w.WorkoutID], slotTime(date, w.Slot))
+				rest = false
+			}
+		}
+		if rest {
+			workoutDates[RestDayID] = append(workoutDates[RestDayID], dayOf(date))
+		}
+	}
+
+	if _, ok := workoutDates[RestDayID]; ok {
+		workoutIds = append(workoutIds, RestDayID)
+	}
+	return workoutIds, workoutDates
+}
+
+// CyclicDates cycles through the workouts one day after another from start on. Empty IDs are rest
+// days.
+func CyclicDates(dto *CreatePlanByCyclicWorkoutDto, start time.Time) ([]string, map[string][]time.Time) {
+	workoutDates := make(map[string][]time.Time)
+	for i := 0; i < dto.WeeksDuration*7; i++ {
+		workoutId := dto.WorkoutIDs[i%len(dto.WorkoutIDs)]
+		date := start.AddDate(0, 0, i)
+		if workoutId == RestDayID {
+			date = dayOf(date)
+		}
+		workoutDates[workoutId] = append(workoutDates[workoutId], date)
+	}
+
+	return dto.WorkoutIDs, workoutDates
+}
+
+// slotTime moves a date to the hour of its slot, dates without a slot keep their time of day
+func slotTime(date time.Time, slot PlanSlot) time.Time {
+	switch slot {
+	case MorningSlot:
+		return dayOf(date).Add(MorningSlotHour * time.Hour)
+	case EveningSlot:
+		return dayOf(date).Add(EveningSlotHour * time.Hour)
+	}
+	return date
+}
+
+// dateSlot returns the slot a date was planned in by slotTime, or no slot
+func dateSlot(date time.Time) PlanSlot {
+	switch {
+	case date.Equal(slotTime(date, MorningSlot)):
+		return MorningSlot
+	case date.Equal(slotTime(date, EveningSlot)):
+		return EveningSlot
+	}
+	return ""
+}
+
+// ApplySchedule returns the plans as they are once a generated schedule is applied at now.
+// Plans of workouts left out of the schedule lose their dates from today on, plans of scheduled
+// workouts keep their upcoming dates and get the generated ones, and scheduled workouts without a
+// plan get a new one. Existing plans keep their position, new plans follow in workoutIds order.
+// Rest days are replaced from today on rather than added to.
+func ApplySchedule(plans []WorkoutPlan, userId string, workoutIds []string, workoutDates map[string][]time.Time, now time.Time) []WorkoutPlan {
+	scheduled := make(map[string]bool)
+	for _, id := range workoutIds {
+		scheduled[id] = true
+	}
+	startOfDay := dayOf(now)
+
+	after := make([]WorkoutPlan, 0, len(plans))
+	hasPlan := make(map[string]bool)
+	for _, plan := range plans {
+		var dates []time.Time
+		if scheduled[plan.WorkoutID] {
+			hasPlan[plan.WorkoutID] = true
+			for _, date := range plan.Dates {
+				if (plan.Rest && date.Before(startOfDay)) || (!plan.Rest && !date.Before(now)) {
+					dates = append(dates, date)
+				}
+			}
+			dates = append(dates, workoutDates[plan.WorkoutID]...)
+		} else {
+			for _, date := range plan.Dates {
+				if date.Before(startOfDay) {
+					dates = append(dates, date)
+				}
+			}
+		}
+
+		plan.Dates = dates
+		after = append(after, plan)
+	}
+
+	for _, workoutId := range workoutIds {
+		if hasPlan[workoutId] || len(workoutDates[workoutId]) == 0 {
+			continue
+		}
+		hasPlan[workoutId] = true
+		after = append(after, WorkoutPlan{
+			UserID:    userId,
+			WorkoutID: workoutId,
+			Rest:      workoutId == RestDayID,
+			Dates:     workoutDates[workoutId],
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	return after
+}
+
+// DiffPlans compares plans before and after a change, plans after being in the order
+// ApplySchedule returns them. Unchanged dates are only listed from today on.
+func DiffPlans(before []WorkoutPlan, after []WorkoutPlan, now time.Time) PlanDiff {
+	diff := PlanDiff{
+		Added:     make([]PlanChange, 0),
+		Removed:   make([]PlanChange, 0),
+		Unchanged: make([]PlanChange, 0),
+	}
+
+	for i, plan := range after {
+		planId := ""
+		var previous []time.Time
+		if i < len(before) {
+			planId = before[i].ID.Hex()
+			previous = before[i].Dates
+		}
+
+		remaining := make(map[int64]int)
+		for _, date := range previous {
+			remaining[date.UnixMilli()]++
+		}
+
+		for _, date := range plan.Dates {
+			change := PlanChange{PlanID: planId, WorkoutID: plan.WorkoutID, Date: date}
+			if remaining[date.UnixMilli()] > 0 {
+				remaining[date.UnixMilli()]--
+				if !date.Before(dayOf(now)) {
+					diff.Unchanged = append(diff.Unchanged, change)
+				}
+			} else {
+				diff.Added = append(diff.Added, change)
+			}
+		}
+
+		for _, date := range previous {
+			if remaining[date.UnixMilli()] > 0 {
+				remaining[date.UnixMilli()]--
+				diff.Removed = append(diff.Removed, PlanChange{PlanID: planId, WorkoutID: plan.WorkoutID, Date: date})
+			}
+		}
+	}
+
+	for _, changes := range [][]PlanChange{diff.Added, diff.Removed, diff.Unchanged} {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Date.Before(changes[j].Date)
+		})
+	}
+
+	return diff
+}
+
+// ApplyDiff applies a diff to the plans it was made from. Existing plans keep their position and
+// added dates without a plan make new plans, in the order they first appear.
+func ApplyDiff(plans []WorkoutPlan, userId string, diff PlanDiff, now time.Time) ([]WorkoutPlan, error) {
+	after := make([]WorkoutPlan, len(plans))
+	index := make(map[string]int)
+	for i, plan := range plans {
+		plan.Dates = append([]time.Time(nil), plan.Dates...)
+		after[i] = plan
+		index[plan.ID.Hex()] = i
+	}
+
+	for _, change := range diff.Removed {
+		i, ok := index[change.PlanID]
+		if !ok {
+			return nil, fmt.Errorf("%w: plan %s not found", ErrPlansChanged, change.PlanID)
+		}
+
+		removed := false
+		for j, date := range after[i].Dates {
+			if date.UnixMilli() == change.Date.UnixMilli() {
+				after[i].Dates = append(after[i].Dates[:j], after[i].Dates[j+1:]...)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			return nil, fmt.Errorf("%w: date %s not found", ErrPlansChanged, change.Date.Format("2006-01-02"))
+		}
+	}
+
+	newPlans := make(map[string]int)
+	for _, change := range diff.Added {
+		if change.PlanID != "" {
+			i, ok := index[change.PlanID]
+			if !ok {
+				return nil, fmt.Errorf("%w: plan %s not found", ErrPlansChanged, change.PlanID)
+			}
+			after[i].Dates = append(after[i].Dates, change.Date)
+			continue
+		}
+
+		i, ok := newPlans[change.WorkoutID]
+		if !ok {
+			i = len(after)
+			newPlans[change.WorkoutID] = i
+			after = append(after, WorkoutPlan{
+				UserID:    userId,
+				WorkoutID: change.WorkoutID,
+				Rest:      change.WorkoutID == RestDayID,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		after[i].Dates = append(after[i].Dates, change.Date)
+	}
+
+	return after, nil
+}
+
+// PlansRevision fingerprints the schedule of the plans, to tell whether they changed
+func PlansRevision(plans []WorkoutPlan) string {
+	sorted := append([]WorkoutPlan(nil), plans...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID.Hex() < sorted[j].ID.Hex()
+	})
+
+	hash := sha256.New()
+	for _, plan := range sorted {
+		fmt.Fprintf(hash, "%s:%s:%d:%d;", plan.ID.Hex(), plan.WorkoutID, len(plan.Skipped), len(plan.Completions))
+		for _, date := range plan.Dates {
+			fmt.Fprintf(hash, "%d,", date.UnixMilli())
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
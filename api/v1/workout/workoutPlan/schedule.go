@@ -0,0 +1,195 @@
+package workoutPlan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrScheduleConflict = errors.New("another workout is already planned")
+	ErrDateNotPlanned   = errors.New("no workout is planned on this date")
+	ErrDateCompleted    = errors.New("the workout planned on this date is already done")
+)
+
+// findDate returns the index of the planned date on the same day as date, or -1
+func (p *WorkoutPlan) findDate(date time.Time) int {
+	for i, planned := range p.Dates {
+		if dayKey(planned) == dayKey(date) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *WorkoutPlan) isCompleted(date time.Time) bool {
+	for _, completion := range p.Completions {
+		if dayKey(completion.Date) == dayKey(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// editableDate returns the index of a planned date that has not been done yet
+func (p *WorkoutPlan) editableDate(date time.Time) (int, error) {
+	i := p.findDate(date)
+	if i < 0 {
+		return -1, ErrDateNotPlanned
+	}
+	if p.isCompleted(date) {
+		return -1, ErrDateCompleted
+	}
+	return i, nil
+}
+
+// MoveDate moves a planned date to another day, keeping its time of day
+func MoveDate(plan *WorkoutPlan, from time.Time, to time.Time) error {
+	i, err := plan.editableDate(from)
+	if err != nil {
+		return err
+	}
+	if dayKey(from) != dayKey(to) && plan.findDate(to) >= 0 {
+		return fmt.Errorf("%w on %s", ErrScheduleConflict, to.Format("2006-01-02"))
+	}
+
+	plan.Dates[i] = plan.Dates[i].AddDate(0, 0, daysBetween(from, to))
+	return nil
+}
+
+// SkipDate takes a planned date off the schedule and keeps it as skipped
+func SkipDate(plan *WorkoutPlan, date time.Time) error {
+	i, err := plan.editableDate(date)
+	if err != nil {
+		return err
+	}
+
+	plan.Skipped = append(plan.Skipped, plan.Dates[i])
+	plan.Dates = append(plan.Dates[:i], plan.Dates[i+1:]...)
+	return nil
+}
+
+// ShiftDates pushes every planned date from the given day on by days, leaving completed dates in
+// place. It returns the indexes of the plans that changed, the days workouts now land on and the
+// days of the workouts that stayed in place. Rest days move along but are not returned.
+func ShiftDates(plans []WorkoutPlan, from time.Time, days int) ([]int, []time.Time, []time.Time) {
+	changed := make([]int, 0)
+	landed := make([]time.Time, 0)
+	stayed := make([]time.Time, 0)
+	for i := range plans {
+		moved := false
+		for j, date := range plans[i].Dates {
+			if dayOf(date).Before(dayOf(from)) || plans[i].isCompleted(date) {
+				if !plans[i].Rest {
+					stayed = append(stayed, date)
+				}
+				continue
+			}
+			plans[i].Dates[j] = date.AddDate(0, 0, days)
+			if !plans[i].Rest {
+				landed = append(landed, plans[i].Dates[j])
+			}
+			moved = true
+		}
+		if moved {
+			changed = append(changed, i)
+		}
+	}
+	return changed, landed, stayed
+}
+
+// FindOverlap returns the first of the landed days that clashes with one of the stayed days
+func FindOverlap(landed []time.Time, stayed []time.Time) (time.Time, bool) {
+	for _, date := range landed {
+		for _, other := range stayed {
+			if clashes(date, other) {
+				return dayOf(date), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// SwapDays swaps the workouts planned on two days. It returns the indexes of the plans that changed.
+func SwapDays(plans []WorkoutPlan, first time.Time, second time.Time) ([]int, error) {
+	type plannedDate struct {
+		plan int
+		date int
+	}
+
+	var onFirst, onSecond []plannedDate
+	for i := range plans {
+		for j, date := range plans[i].Dates {
+			if dayKey(date) != dayKey(first) && dayKey(date) != dayKey(second) {
+				continue
+			}
+			if plans[i].isCompleted(date) {
+				return nil, fmt.Errorf("%w: %s", ErrDateCompleted, date.Format("2006-01-02"))
+			}
+			if dayKey(date) == dayKey(first) {
+				onFirst = append(onFirst, plannedDate{i, j})
+			} else {
+				onSecond = append(onSecond, plannedDate{i, j})
+			}
+		}
+	}
+
+	if len(onFirst) == 0 && len(onSecond) == 0 {
+		return nil, ErrDateNotPlanned
+	}
+
+	offset := daysBetween(first, second)
+	changed := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, planned := range onFirst {
+		plans[planned.plan].Dates[planned.date] = plans[planned.plan].Dates[planned.date].AddDate(0, 0, offset)
+		if !seen[planned.plan] {
+			seen[planned.plan] = true
+			changed = append(changed, planned.plan)
+		}
+	}
+	for _, planned := range onSecond {
+		plans[planned.plan].Dates[planned.date] = plans[planned.plan].Dates[planned.date].AddDate(0, 0, -offset)
+		if !seen[planned.plan] {
+			seen[planned.plan] = true
+			changed = append(changed, planned.plan)
+		}
+	}
+
+	return changed, nil
+}
+
+// FindConflict returns the first of the days on which two workouts clash, rest days aside. Workouts
+// in the AM and PM slots of a day do not clash.
+func FindConflict(plans []WorkoutPlan, days []time.Time) (time.Time, bool) {
+	for _, day := range days {
+		planned := make([]time.Time, 0)
+		for _, plan := range plans {
+			if plan.Rest {
+				continue
+			}
+			for _, date := range plan.Dates {
+				if dayKey(date) != dayKey(day) {
+					continue
+				}
+				for _, other := range planned {
+					if clashes(date, other) {
+						return dayOf(day), true
+					}
+				}
+				planned = append(planned, date)
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// clashes tells whether two planned dates take the same slot of the same day. A date without a
+// slot takes the whole day.
+func clashes(a time.Time, b time.Time) bool {
+	if dayKey(a) != dayKey(b) {
+		return false
+	}
+	slotA, slotB := dateSlot(a), dateSlot(b)
+	return slotA == "" || slotB == "" || slotA == slotB
+}
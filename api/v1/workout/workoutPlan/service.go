@@ -2,10 +2,18 @@ package workoutPlan
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workoutSession"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type WorkoutPlanService struct {
@@ -15,336 +23,724 @@ type WorkoutPlanService struct {
 type IWorkoutPlanService interface {
 	CreatePlanByDaysOfWeek(dto *CreatePlanByDaysOfWeekDto, userId string) ([]WorkoutPlan, error)
 	CreatePlanByCyclicWorkout(dto *CreatePlanByCyclicWorkoutDto, userId string) ([]WorkoutPlan, error)
+	PreviewPlanByDaysOfWeek(dto *CreatePlanByDaysOfWeekDto, userId string) (*PlanPreview, error)
+	PreviewPlanByCyclicWorkout(dto *CreatePlanByCyclicWorkoutDto, userId string) (*PlanPreview, error)
+	CommitPlanPreview(id string, userId string) ([]WorkoutPlan, error)
 	GetWorkoutPlansByUser(userId string) ([]WorkoutPlan, error)
+	MatchSession(userId string, workoutId string, sessionId string, performedAt time.Time) error
+	UnmatchSession(userId string, sessionId string) error
+	GetAdherence(userId string, startDate time.Time, endDate time.Time) (*Adherence, error)
+	GetCalendar(userId string, startDate time.Time, endDate time.Time) ([]CalendarDay, error)
+	MovePlanDate(id string, dto *MovePlanDateDto, userId string) (*WorkoutPlan, error)
+	SkipPlanDate(id string, dto *SkipPlanDateDto, userId string) (*WorkoutPlan, error)
+	ShiftPlans(dto *ShiftPlansDto, userId string) ([]WorkoutPlan, error)
+	SwapPlanDays(dto *SwapPlanDaysDto, userId string) ([]WorkoutPlan, error)
+	DeletePlan(id string, userId string) error
+	GetCalendarFeed(userId string) (*CalendarFeed, error)
+	RotateCalendarFeed(userId string) (*CalendarFeed, error)
+	GetPlanCalendar(token string) (string, error)
+	GetSessionHistoryCalendar(userId string) (string, error)
 }
 
 func (s *WorkoutPlanService) CreatePlanByDaysOfWeek(dto *CreatePlanByDaysOfWeekDto, userId string) ([]WorkoutPlan, error) {
-	currentDate := time.Now()
-
-	// Clean up old workout plans first
-	newWorkoutIds := []string{
-		dto.MondayWorkoutID,
-		dto.TuesdayWorkoutID,
-		dto.WednesdayWorkoutID,
-		dto.ThursdayWorkoutID,
-		dto.FridayWorkoutID,
-		dto.SaturdayWorkoutID,
-		dto.SundayWorkoutID,
-	}
-	if err := s.cleanupOldWorkoutPlans(userId, currentDate, newWorkoutIds); err != nil {
+	workoutIds, workoutDates := DaysOfWeekDates(dto, time.Now())
+	return s.applySchedule(userId, workoutIds, workoutDates)
+}
+
+func (s *WorkoutPlanService) CreatePlanByCyclicWorkout(dto *CreatePlanByCyclicWorkoutDto, userId string) ([]WorkoutPlan, error) {
+	workoutIds, workoutDates := CyclicDates(dto, time.Now())
+	return s.applySchedule(userId, workoutIds, workoutDates)
+}
+
+// PreviewPlanByDaysOfWeek shows what CreatePlanByDaysOfWeek would do without writing it
+func (s *WorkoutPlanService) PreviewPlanByDaysOfWeek(dto *CreatePlanByDaysOfWeekDto, userId string) (*PlanPreview, error) {
+	workoutIds, workoutDates := DaysOfWeekDates(dto, time.Now())
+	return s.previewSchedule(userId, workoutIds, workoutDates)
+}
+
+// PreviewPlanByCyclicWorkout shows what CreatePlanByCyclicWorkout would do without writing it
+func (s *WorkoutPlanService) PreviewPlanByCyclicWorkout(dto *CreatePlanByCyclicWorkoutDto, userId string) (*PlanPreview, error) {
+	workoutIds, workoutDates := CyclicDates(dto, time.Now())
+	return s.previewSchedule(userId, workoutIds, workoutDates)
+}
+
+// CommitPlanPreview applies the diff of a preview, as long as the plans did not change since.
+// It returns the plans that changed.
+func (s *WorkoutPlanService) CommitPlanPreview(id string, userId string) ([]WorkoutPlan, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
 		return nil, err
 	}
 
-	// Map to group dates by workoutID
-	workoutDates := make(map[string][]time.Time)
-
-	// Calculate total days based on weeks duration
-	totalDays := dto.WeeksDuration * 7
-
-	// Iterate through each day and group dates by workout
-	for i := 0; i < totalDays; i++ {
-		date := currentDate.AddDate(0, 0, i)
-		var workoutId string
-
-		switch date.Weekday() {
-		case time.Monday:
-			workoutId = dto.MondayWorkoutID
-		case time.Tuesday:
-			workoutId = dto.TuesdayWorkoutID
-		case time.Wednesday:
-			workoutId = dto.WednesdayWorkoutID
-		case time.Thursday:
-			workoutId = dto.ThursdayWorkoutID
-		case time.Friday:
-			workoutId = dto.FridayWorkoutID
-		case time.Saturday:
-			workoutId = dto.SaturdayWorkoutID
-		case time.Sunday:
-			workoutId = dto.SundayWorkoutID
+	filter := bson.M{
+		"_id":        oid,
+		"userid":     userId,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	// The revision check and the writes share a transaction, so plans changed by another
+	// request in between fail the commit instead of being overwritten
+	var saved []WorkoutPlan
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		preview := &PlanPreview{}
+		if err := s.DB.Collection("planPreviews").FindOne(ctx, filter).Decode(preview); err != nil {
+			return err
+		}
+
+		plans, err := s.findPlans(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		if PlansRevision(plans) != preview.Revision {
+			return ErrPlansChanged
+		}
+
+		after, err := ApplyDiff(plans, userId, preview.Diff, time.Now())
+		if err != nil {
+			return err
+		}
+
+		saved, err = s.savePlans(ctx, plans, after)
+		if err != nil {
+			return err
 		}
 
-		workoutDates[workoutId] = append(workoutDates[workoutId], date)
+		_, err = s.DB.Collection("planPreviews").DeleteOne(ctx, bson.M{"_id": oid})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Get existing workout plans
-	var existingPlans []WorkoutPlan
-	filter := bson.M{
-		"userid": userId,
-		"workoutid": bson.M{
-			"$in": newWorkoutIds,
-		},
+	return saved, nil
+}
+
+// applySchedule writes a generated schedule and returns the plans of the scheduled workouts
+func (s *WorkoutPlanService) applySchedule(userId string, workoutIds []string, workoutDates map[string][]time.Time) ([]WorkoutPlan, error) {
+	plans, err := s.GetWorkoutPlansByUser(userId)
+	if err != nil {
+		return nil, err
 	}
 
-	cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), filter)
+	saved, err := s.savePlans(context.Background(), plans, ApplySchedule(plans, userId, workoutIds, workoutDates, time.Now()))
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
 
-	if err := cursor.All(context.Background(), &existingPlans); err != nil {
+	scheduledPlans := make([]WorkoutPlan, 0, len(saved))
+	for _, plan := range saved {
+		if len(workoutDates[plan.WorkoutID]) > 0 {
+			scheduledPlans = append(scheduledPlans, plan)
+		}
+	}
+
+	return scheduledPlans, nil
+}
+
+func (s *WorkoutPlanService) previewSchedule(userId string, workoutIds []string, workoutDates map[string][]time.Time) (*PlanPreview, error) {
+	plans, err := s.GetWorkoutPlansByUser(userId)
+	if err != nil {
 		return nil, err
 	}
 
-	// Separate workouts into existing and new
-	existingWorkoutIds := make(map[string]bool)
-	for _, plan := range existingPlans {
-		existingWorkoutIds[plan.WorkoutID] = true
+	now := time.Now()
+	preview := &PlanPreview{
+		UserID:    userId,
+		Revision:  PlansRevision(plans),
+		Generated: workoutDates,
+		Diff:      DiffPlans(plans, ApplySchedule(plans, userId, workoutIds, workoutDates, now), now),
+		CreatedAt: now,
+		ExpiresAt: now.Add(PreviewTTL),
 	}
 
-	var updatedPlans []WorkoutPlan
-	var newWorkouts []interface{}
-
-	// Handle existing plans: update with new dates
-	for _, plan := range existingPlans {
-		newDates := workoutDates[plan.WorkoutID]
-		// Filter out past dates from existing plan
-		var futureDates []time.Time
-		for _, date := range plan.Dates {
-			if !date.Before(currentDate) {
-				futureDates = append(futureDates, date)
-			}
-		}
-		// Combine with new dates
-		plan.Dates = append(futureDates, newDates...)
-		plan.UpdatedAt = time.Now()
-
-		// Update in database
-		_, err := s.DB.Collection("workoutPlan").UpdateOne(
-			context.Background(),
-			bson.M{"_id": plan.ID},
-			bson.M{"$set": bson.M{
-				"dates":      plan.Dates,
-				"updated_at": plan.UpdatedAt,
-			}},
-		)
-		if err != nil {
-			return nil, err
-		}
-		updatedPlans = append(updatedPlans, plan)
-	}
-
-	// Handle new plans: create new entries
-	for workoutId, dates := range workoutDates {
-		if !existingWorkoutIds[workoutId] {
-			newWorkouts = append(newWorkouts, WorkoutPlan{
-				UserID:    userId,
-				WorkoutID: workoutId,
-				Dates:     dates,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-			})
-		}
+	result, err := s.DB.Collection("planPreviews").InsertOne(context.Background(), preview)
+	if err != nil {
+		return nil, err
 	}
+	preview.ID = result.InsertedID.(primitive.ObjectID)
 
-	// Insert new workout plans if any
-	if len(newWorkouts) > 0 {
-		result, err := s.DB.Collection("workoutPlan").InsertMany(context.Background(), newWorkouts)
-		if err != nil {
-			return nil, err
-		}
+	return preview, nil
+}
 
-		// Fetch newly inserted documents
-		newFilter := bson.M{"_id": bson.M{"$in": result.InsertedIDs}}
-		cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), newFilter)
-		if err != nil {
-			return nil, err
+// savePlans writes the plans of after that differ from before, plans past the end of before
+// being new, and returns them
+func (s *WorkoutPlanService) savePlans(ctx context.Context, before []WorkoutPlan, after []WorkoutPlan) ([]WorkoutPlan, error) {
+	saved := make([]WorkoutPlan, 0)
+	for i, plan := range after {
+		if i < len(before) {
+			if sameDates(before[i].Dates, plan.Dates) {
+				continue
+			}
+
+			plan.UpdatedAt = time.Now()
+			_, err := s.DB.Collection("workoutPlan").UpdateOne(
+				ctx,
+				bson.M{"_id": plan.ID},
+				bson.M{"$set": bson.M{
+					"dates":      plan.Dates,
+					"updated_at": plan.UpdatedAt,
+				}},
+			)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			result, err := s.DB.Collection("workoutPlan").InsertOne(ctx, plan)
+			if err != nil {
+				return nil, err
+			}
+			plan.ID = result.InsertedID.(primitive.ObjectID)
 		}
-		defer cursor.Close(context.Background())
 
-		var newPlans []WorkoutPlan
-		if err := cursor.All(context.Background(), &newPlans); err != nil {
-			return nil, err
+		saved = append(saved, plan)
+	}
+
+	return saved, nil
+}
+
+func sameDates(a []time.Time, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
 		}
-		updatedPlans = append(updatedPlans, newPlans...)
 	}
+	return true
+}
 
-	return updatedPlans, nil
+func (s *WorkoutPlanService) GetWorkoutPlansByUser(userId string) ([]WorkoutPlan, error) {
+	return s.findPlans(context.Background(), userId)
 }
 
-func (s *WorkoutPlanService) CreatePlanByCyclicWorkout(dto *CreatePlanByCyclicWorkoutDto, userId string) ([]WorkoutPlan, error) {
-	currentDate := time.Now()
+func (s *WorkoutPlanService) findPlans(ctx context.Context, userId string) ([]WorkoutPlan, error) {
+	var workoutPlans []WorkoutPlan
 
-	// Clean up old workout plans first
-	if err := s.cleanupOldWorkoutPlans(userId, currentDate, dto.WorkoutIDs); err != nil {
+	filter := bson.M{"userid": userId}
+	cursor, err := s.DB.Collection("workoutPlan").Find(ctx, filter)
+	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	// Map to group dates by workoutID
-	workoutDates := make(map[string][]time.Time)
+	if err := cursor.All(ctx, &workoutPlans); err != nil {
+		return nil, err
+	}
 
-	// Calculate total days based on weeks duration
-	totalDays := dto.WeeksDuration * 7
+	return workoutPlans, nil
+}
 
-	// Iterate through each day and assign workouts cyclically
-	for i := 0; i < totalDays; i++ {
-		date := currentDate.AddDate(0, 0, i)
-		// Get workout ID from the cycle using modulo operation
-		workoutId := dto.WorkoutIDs[i%len(dto.WorkoutIDs)]
-		workoutDates[workoutId] = append(workoutDates[workoutId], date)
+// FindScheduleConflict finds the first day on which dates about to be planned for workouts clash
+// with the user's plans, reading the plans with ctx
+func (s *WorkoutPlanService) FindScheduleConflict(ctx context.Context, userId string, dates map[string][]time.Time) (time.Time, bool, error) {
+	plans, err := s.findPlans(ctx, userId)
+	if err != nil {
+		return time.Time{}, false, err
 	}
 
-	// Get existing workout plans
-	var existingPlans []WorkoutPlan
-	filter := bson.M{
-		"userid": userId,
-		"workoutid": bson.M{
-			"$in": dto.WorkoutIDs,
-		},
+	days := make([]time.Time, 0)
+	for workoutId, workoutDates := range dates {
+		plans = append(plans, WorkoutPlan{UserID: userId, WorkoutID: workoutId, Dates: workoutDates})
+		days = append(days, workoutDates...)
 	}
 
-	cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), filter)
+	day, ok := FindConflict(plans, days)
+	return day, ok, nil
+}
+
+// MovePlanDate moves a single planned date of a plan to another day
+func (s *WorkoutPlanService) MovePlanDate(id string, dto *MovePlanDateDto, userId string) (*WorkoutPlan, error) {
+	updatedPlans, err := s.editSchedules(userId, func(plans []WorkoutPlan) ([]int, error) {
+		i, err := planIndex(plans, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := MoveDate(&plans[i], dto.From, dto.To); err != nil {
+			return nil, err
+		}
+
+		if !dto.AllowConflicts {
+			if day, ok := FindConflict(plans, []time.Time{dto.To}); ok {
+				return nil, fmt.Errorf("%w on %s", ErrScheduleConflict, day.Format("2006-01-02"))
+			}
+		}
+		return []int{i}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
 
-	if err := cursor.All(context.Background(), &existingPlans); err != nil {
+	return &updatedPlans[0], nil
+}
+
+// SkipPlanDate takes a single planned date off a plan
+func (s *WorkoutPlanService) SkipPlanDate(id string, dto *SkipPlanDateDto, userId string) (*WorkoutPlan, error) {
+	updatedPlans, err := s.editSchedules(userId, func(plans []WorkoutPlan) ([]int, error) {
+		i, err := planIndex(plans, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := SkipDate(&plans[i], dto.Date); err != nil {
+			return nil, err
+		}
+		return []int{i}, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Separate workouts into existing and new
-	existingWorkoutIds := make(map[string]bool)
-	for _, plan := range existingPlans {
-		existingWorkoutIds[plan.WorkoutID] = true
+	return &updatedPlans[0], nil
+}
+
+// ShiftPlans pushes the remaining schedule of every plan back (or forward) by a number of days
+func (s *WorkoutPlanService) ShiftPlans(dto *ShiftPlansDto, userId string) ([]WorkoutPlan, error) {
+	from := dto.From
+	if from.IsZero() {
+		from = time.Now()
 	}
 
-	var updatedPlans []WorkoutPlan
-	var newWorkouts []interface{}
-
-	// Handle existing plans: update with new dates
-	for _, plan := range existingPlans {
-		newDates := workoutDates[plan.WorkoutID]
-		// Filter out past dates from existing plan
-		var futureDates []time.Time
-		for _, date := range plan.Dates {
-			if !date.Before(currentDate) {
-				futureDates = append(futureDates, date)
+	return s.editSchedules(userId, func(plans []WorkoutPlan) ([]int, error) {
+		changed, landed, stayed := ShiftDates(plans, from, dto.Days)
+		if !dto.AllowConflicts {
+			if day, ok := FindOverlap(landed, stayed); ok {
+				return nil, fmt.Errorf("%w on %s", ErrScheduleConflict, day.Format("2006-01-02"))
 			}
 		}
-		// Combine with new dates
-		plan.Dates = append(futureDates, newDates...)
-		plan.UpdatedAt = time.Now()
-
-		// Update in database
-		_, err := s.DB.Collection("workoutPlan").UpdateOne(
-			context.Background(),
-			bson.M{"_id": plan.ID},
-			bson.M{"$set": bson.M{
-				"dates":      plan.Dates,
-				"updated_at": plan.UpdatedAt,
-			}},
-		)
+		return changed, nil
+	})
+}
+
+// SwapPlanDays swaps the workouts planned on two days
+func (s *WorkoutPlanService) SwapPlanDays(dto *SwapPlanDaysDto, userId string) ([]WorkoutPlan, error) {
+	return s.editSchedules(userId, func(plans []WorkoutPlan) ([]int, error) {
+		changed, err := SwapDays(plans, dto.First, dto.Second)
 		if err != nil {
 			return nil, err
 		}
-		updatedPlans = append(updatedPlans, plan)
-	}
-
-	// Handle new plans: create new entries
-	for workoutId, dates := range workoutDates {
-		if !existingWorkoutIds[workoutId] {
-			newWorkouts = append(newWorkouts, WorkoutPlan{
-				UserID:    userId,
-				WorkoutID: workoutId,
-				Dates:     dates,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-			})
+
+		if !dto.AllowConflicts {
+			if day, ok := FindConflict(plans, []time.Time{dto.First, dto.Second}); ok {
+				return nil, fmt.Errorf("%w on %s", ErrScheduleConflict, day.Format("2006-01-02"))
+			}
+		}
+		return changed, nil
+	})
+}
+
+func (s *WorkoutPlanService) DeletePlan(id string, userId string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.DB.Collection("workoutPlan").DeleteOne(context.Background(), bson.M{"_id": oid, "userid": userId})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// planIndex returns the index of the plan with the given ID
+func planIndex(plans []WorkoutPlan, id string) (int, error) {
+	for i, plan := range plans {
+		if plan.ID.Hex() == id {
+			return i, nil
 		}
 	}
 
-	// Insert new workout plans if any
-	if len(newWorkouts) > 0 {
-		result, err := s.DB.Collection("workoutPlan").InsertMany(context.Background(), newWorkouts)
+	return -1, mongo.ErrNoDocuments
+}
+
+func (s *WorkoutPlanService) saveSchedule(ctx context.Context, plan *WorkoutPlan) error {
+	plan.UpdatedAt = time.Now()
+	_, err := s.DB.Collection("workoutPlan").UpdateOne(
+		ctx,
+		bson.M{"_id": plan.ID},
+		bson.M{"$set": bson.M{
+			"dates":      plan.Dates,
+			"skipped":    plan.Skipped,
+			"updated_at": plan.UpdatedAt,
+		}},
+	)
+	return err
+}
+
+// editSchedules reads every plan of the user, for conflict checks, edits them and writes the plans
+// the edit changed in one transaction. An edit spanning several plans is never left half applied,
+// and plans another request changes in between are read again instead of being overwritten.
+func (s *WorkoutPlanService) editSchedules(userId string, edit func(plans []WorkoutPlan) ([]int, error)) ([]WorkoutPlan, error) {
+	var updatedPlans []WorkoutPlan
+	err := function.WithTransaction(s.DB, func(ctx context.Context) error {
+		plans, err := s.findPlans(ctx, userId)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		// Fetch newly inserted documents
-		newFilter := bson.M{"_id": bson.M{"$in": result.InsertedIDs}}
-		cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), newFilter)
+		changed, err := edit(plans)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		defer cursor.Close(context.Background())
 
-		var newPlans []WorkoutPlan
-		if err := cursor.All(context.Background(), &newPlans); err != nil {
-			return nil, err
+		updatedPlans = make([]WorkoutPlan, 0, len(changed))
+		for _, i := range changed {
+			if err := s.saveSchedule(ctx, &plans[i]); err != nil {
+				return err
+			}
+			updatedPlans = append(updatedPlans, plans[i])
 		}
-		updatedPlans = append(updatedPlans, newPlans...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return updatedPlans, nil
 }
 
-func (s *WorkoutPlanService) GetWorkoutPlansByUser(userId string) ([]WorkoutPlan, error) {
-	var workoutPlans []WorkoutPlan
+// MatchSession marks the planned date closest to a completed session of the workout as done.
+// A session with no planned date within MatchToleranceDays is left unmatched.
+func (s *WorkoutPlanService) MatchSession(userId string, workoutId string, sessionId string, performedAt time.Time) error {
+	filter := bson.M{
+		"userid":    userId,
+		"workoutid": workoutId,
+		"rest":      bson.M{"$ne": true},
+		"dates": bson.M{
+			"$elemMatch": bson.M{
+				"$gte": dayOf(performedAt).AddDate(0, 0, -MatchToleranceDays),
+				"$lt":  dayOf(performedAt).AddDate(0, 0, MatchToleranceDays+1),
+			},
+		},
+	}
 
-	filter := bson.M{"userid": userId}
+	var plans []WorkoutPlan
 	cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), filter)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer cursor.Close(context.Background())
 
-	if err := cursor.All(context.Background(), &workoutPlans); err != nil {
+	if err := cursor.All(context.Background(), &plans); err != nil {
+		return err
+	}
+
+	var matchedPlan *WorkoutPlan
+	var matchedDate time.Time
+	for i, plan := range plans {
+		date, ok := MatchDate(plan.Dates, plan.Completions, performedAt)
+		if !ok {
+			continue
+		}
+		if matchedPlan == nil || abs(daysBetween(date, performedAt)) < abs(daysBetween(matchedDate, performedAt)) {
+			matchedPlan, matchedDate = &plans[i], date
+		}
+	}
+
+	if matchedPlan == nil {
+		return nil
+	}
+
+	completion := PlanCompletion{
+		Date:        matchedDate,
+		SessionID:   sessionId,
+		PerformedAt: performedAt,
+	}
+	_, err = s.DB.Collection("workoutPlan").UpdateOne(
+		context.Background(),
+		bson.M{"_id": matchedPlan.ID},
+		bson.M{
+			"$push": bson.M{"completions": completion},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+// UnmatchSession frees the planned date a session completed, e.g. when the session is deleted
+func (s *WorkoutPlanService) UnmatchSession(userId string, sessionId string) error {
+	_, err := s.DB.Collection("workoutPlan").UpdateMany(
+		context.Background(),
+		bson.M{"userid": userId, "completions.sessionid": sessionId},
+		bson.M{
+			"$pull": bson.M{"completions": bson.M{"sessionid": sessionId}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+// GetAdherence compares the planned and completed workouts of a user between two dates
+func (s *WorkoutPlanService) GetAdherence(userId string, startDate time.Time, endDate time.Time) (*Adherence, error) {
+	plans, err := s.getPlansBetween(userId, startDate, endDate)
+	if err != nil {
 		return nil, err
 	}
 
-	return workoutPlans, nil
+	days := PlannedDays(plans, startDate, endDate, time.Now())
+	return CalculateAdherence(days, startDate, endDate), nil
 }
 
-// Helper function to clean up old workout plans
-func (s *WorkoutPlanService) cleanupOldWorkoutPlans(userId string, currentDate time.Time, newWorkoutIds []string) error {
-	startOfDay := currentDate.Truncate(24 * time.Hour)
+// GetCalendar returns the planned and performed workouts of a user day by day between two dates
+func (s *WorkoutPlanService) GetCalendar(userId string, startDate time.Time, endDate time.Time) ([]CalendarDay, error) {
+	plans, err := s.getPlansBetween(userId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
 
-	// Find all existing plans for the user that are not in the new workout IDs
-	// and have at least one date greater than or equal to today
 	filter := bson.M{
 		"userid": userId,
-		"workoutid": bson.M{
-			"$nin": newWorkoutIds,
+		"status": workoutSession.StatusCompleted,
+		"start_time": bson.M{
+			"$gte": dayOf(startDate),
+			"$lt":  dayOf(endDate).AddDate(0, 0, 1),
 		},
+	}
+
+	var sessions []workoutSession.WorkoutSession
+	cursor, err := s.DB.Collection("workoutSessions").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	if err := cursor.All(context.Background(), &sessions); err != nil {
+		return nil, err
+	}
+
+	plannedDates := make(map[string]time.Time)
+	for _, plan := range plans {
+		for _, completion := range plan.Completions {
+			plannedDates[completion.SessionID] = completion.Date
+		}
+	}
+
+	performed := make([]PerformedWorkout, 0, len(sessions))
+	for _, session := range sessions {
+		workout := PerformedWorkout{
+			SessionID:   session.ID.Hex(),
+			WorkoutID:   session.WorkoutID,
+			Type:        string(session.Type),
+			StartTime:   session.StartTime,
+			Duration:    session.Duration,
+			TotalVolume: session.TotalVolume,
+		}
+		if date, ok := plannedDates[workout.SessionID]; ok {
+			workout.PlannedDate = &date
+		}
+		performed = append(performed, workout)
+	}
+
+	return BuildCalendar(PlannedDays(plans, startDate, endDate, time.Now()), performed), nil
+}
+
+func (s *WorkoutPlanService) getPlansBetween(userId string, startDate time.Time, endDate time.Time) ([]WorkoutPlan, error) {
+	filter := bson.M{
+		"userid": userId,
 		"dates": bson.M{
 			"$elemMatch": bson.M{
-				"$gte": startOfDay,
+				"$gte": dayOf(startDate),
+				"$lt":  dayOf(endDate).AddDate(0, 0, 1),
 			},
 		},
 	}
 
-	var plansToUpdate []WorkoutPlan
+	plans := make([]WorkoutPlan, 0)
 	cursor, err := s.DB.Collection("workoutPlan").Find(context.Background(), filter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer cursor.Close(context.Background())
 
-	if err := cursor.All(context.Background(), &plansToUpdate); err != nil {
-		return err
+	if err := cursor.All(context.Background(), &plans); err != nil {
+		return nil, err
 	}
 
-	// Update each plan to remove future dates
-	for _, plan := range plansToUpdate {
-		var pastDates []time.Time
-		for _, date := range plan.Dates {
-			if date.Before(startOfDay) {
-				pastDates = append(pastDates, date)
-			}
+	return plans, nil
+}
+
+// GetCalendarFeed returns the calendar feed of the user, creating it on first use
+func (s *WorkoutPlanService) GetCalendarFeed(userId string) (*CalendarFeed, error) {
+	feed := &CalendarFeed{}
+	err := s.DB.Collection("calendarFeeds").FindOne(context.Background(), bson.M{"userid": userId}).Decode(feed)
+	if err == nil {
+		return feed, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	return s.RotateCalendarFeed(userId)
+}
+
+// RotateCalendarFeed gives the feed of the user a new token, the old feed URL stops working
+func (s *WorkoutPlanService) RotateCalendarFeed(userId string) (*CalendarFeed, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{"token": token},
+		"$setOnInsert": bson.M{
+			"userid":     userId,
+			"created_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	feed := &CalendarFeed{}
+	if err := s.DB.Collection("calendarFeeds").FindOneAndUpdate(context.Background(), bson.M{"userid": userId}, update, opts).Decode(feed); err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// GetPlanCalendar renders the planned workouts of the user owning the feed token as iCalendar
+func (s *WorkoutPlanService) GetPlanCalendar(token string) (string, error) {
+	feed := &CalendarFeed{}
+	if err := s.DB.Collection("calendarFeeds").FindOne(context.Background(), bson.M{"token": token}).Decode(feed); err != nil {
+		return "", err
+	}
+
+	plans, err := s.GetWorkoutPlansByUser(feed.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	workoutIds := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		workoutIds = append(workoutIds, plan.WorkoutID)
+	}
+
+	workouts, exerciseNames, err := s.getWorkoutDetails(workoutIds)
+	if err != nil {
+		return "", err
+	}
+
+	return RenderCalendar("Planned workouts", PlanEvents(plans, workouts, exerciseNames), time.Now()), nil
+}
+
+// GetSessionHistoryCalendar renders the completed sessions of the user as iCalendar
+func (s *WorkoutPlanService) GetSessionHistoryCalendar(userId string) (string, error) {
+	filter := bson.M{
+		"userid": userId,
+		"status": workoutSession.StatusCompleted,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	var sessions []workoutSession.WorkoutSession
+	cursor, err := s.DB.Collection("workoutSessions").Find(context.Background(), filter, opts)
+	if err != nil {
+		return "", err
+	}
+	defer cursor.Close(context.Background())
+
+	if err := cursor.All(context.Background(), &sessions); err != nil {
+		return "", err
+	}
+
+	workoutIds := make([]string, 0, len(sessions))
+	exerciseIds := make([]string, 0)
+	for _, session := range sessions {
+		workoutIds = append(workoutIds, session.WorkoutID)
+		for _, ex := range session.Exercises {
+			exerciseIds = append(exerciseIds, ex.ExerciseID)
 		}
+	}
 
-		_, err := s.DB.Collection("workoutPlan").UpdateOne(
-			context.Background(),
-			bson.M{"_id": plan.ID},
-			bson.M{"$set": bson.M{
-				"dates":      pastDates,
-				"updated_at": time.Now(),
-			}},
-		)
-		if err != nil {
-			return err
+	workouts, _, err := s.getWorkoutDetails(workoutIds)
+	if err != nil {
+		return "", err
+	}
+
+	exerciseNames, err := s.getExerciseNames(exerciseIds)
+	if err != nil {
+		return "", err
+	}
+
+	return RenderCalendar("Workout history", SessionEvents(sessions, workouts, exerciseNames), time.Now()), nil
+}
+
+// getWorkoutDetails loads workouts by ID along with the names of their exercises
+func (s *WorkoutPlanService) getWorkoutDetails(workoutIds []string) (map[string]*workout.Workout, map[string]string, error) {
+	oids := make([]primitive.ObjectID, 0, len(workoutIds))
+	for _, id := range workoutIds {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
 		}
 	}
 
-	return nil
+	var found []*workout.Workout
+	cursor, err := s.DB.Collection("workout").Find(context.Background(), bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	if err := cursor.All(context.Background(), &found); err != nil {
+		return nil, nil, err
+	}
+
+	workouts := make(map[string]*workout.Workout)
+	exerciseIds := make([]string, 0)
+	for _, w := range found {
+		workouts[w.ID.Hex()] = w
+		for _, ex := range w.Exercises {
+			exerciseIds = append(exerciseIds, ex.ExerciseID)
+		}
+	}
+
+	exerciseNames, err := s.getExerciseNames(exerciseIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return workouts, exerciseNames, nil
+}
+
+func (s *WorkoutPlanService) getExerciseNames(exerciseIds []string) (map[string]string, error) {
+	oids := make([]primitive.ObjectID, 0, len(exerciseIds))
+	for _, id := range exerciseIds {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+
+	var exercises []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	opts := options.Find().SetProjection(bson.M{"name": 1})
+	cursor, err := s.DB.Collection("exercises").Find(context.Background(), bson.M{"_id": bson.M{"$in": oids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	if err := cursor.All(context.Background(), &exercises); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, ex := range exercises {
+		names[ex.ID.Hex()] = ex.Name
+	}
+
+	return names, nil
+}
+
+func newFeedToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,169 @@
+package workoutSession
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+)
+
+type SummaryFormat string
+
+const (
+	JSONSummary     SummaryFormat = "json"
+	MarkdownSummary SummaryFormat = "markdown"
+	SVGSummary      SummaryFormat = "svg" // A shareable image
+)
+
+// maxCardLines caps the records and misses listed on the image card
+const maxCardLines = 4
+
+// MarkdownCard renders a summary as a Markdown card to paste into chats and notes
+func MarkdownCard(summary *SessionSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s · %s\n\n", summary.Title, summary.StartTime.Format("2 Jan 2006"))
+	fmt.Fprintf(&b, "**%s** · **%s kg** · **%d sets**\n", formatDuration(summary.Duration), formatNumber(summary.TotalVolume), summary.TotalSets)
+
+	if len(summary.Records) > 0 {
+		b.WriteString("\n### Personal records\n\n")
+		for _, record := range summary.Records {
+			fmt.Fprintf(&b, "- %s\n", describeRecord(record))
+		}
+	}
+
+	if len(summary.Muscles) > 0 {
+		b.WriteString("\n### Muscles\n\n| Muscle | Sets | Volume |\n| --- | ---: | ---: |\n")
+		for _, muscle := range summary.Muscles {
+			fmt.Fprintf(&b, "| %s | %d | %s kg |\n", muscle.Muscle, muscle.Sets, formatNumber(muscle.Volume))
+		}
+	}
+
+	if summary.Comparison != nil {
+		fmt.Fprintf(&b, "\n### Compared to %s\n\n", summary.Comparison.PreviousStartTime.Format("2 Jan"))
+		fmt.Fprintf(&b, "- Volume: %s kg\n", formatDelta(summary.Comparison.VolumeDelta))
+		for _, exercise := range summary.Comparison.Exercises {
+			fmt.Fprintf(&b, "- %s: estimated 1RM %s kg\n", exercise.Name, formatDelta(exercise.EstimatedOneRMDelta))
+		}
+	}
+
+	if len(summary.Misses) > 0 {
+		b.WriteString("\n### Missed targets\n\n")
+		for _, miss := range summary.Misses {
+			fmt.Fprintf(&b, "- %s\n", describeMiss(miss))
+		}
+	}
+
+	return b.String()
+}
+
+// SVGCard renders a summary as an image to share. Long lists are cut to keep the card readable.
+func SVGCard(summary *SessionSummary) string {
+	lines := []struct {
+		text  string
+		class string
+	}{
+		{summary.Title, "title"},
+		{summary.StartTime.Format("Monday 2 January 2006"), "muted"},
+		{fmt.Sprintf("%s · %s kg · %d sets", formatDuration(summary.Duration), formatNumber(summary.TotalVolume), summary.TotalSets), "stat"},
+	}
+	add := func(text string, class string) {
+		lines = append(lines, struct {
+			text  string
+			class string
+		}{text, class})
+	}
+
+	if summary.Comparison != nil {
+		add(fmt.Sprintf("%s kg volume vs %s", formatDelta(summary.Comparison.VolumeDelta), summary.Comparison.PreviousStartTime.Format("2 Jan")), "muted")
+	}
+	if len(summary.Records) > 0 {
+		add(fmt.Sprintf("Personal records: %d", len(summary.Records)), "heading")
+		for i, record := range summary.Records {
+			if i == maxCardLines {
+				add(fmt.Sprintf("and %d more", len(summary.Records)-i), "muted")
+				break
+			}
+			add(describeRecord(record), "line")
+		}
+	}
+	if len(summary.Muscles) > 0 {
+		add("Muscles", "heading")
+		for i, muscle := range summary.Muscles {
+			if i == maxCardLines {
+				break
+			}
+			add(fmt.Sprintf("%s: %d sets", muscle.Muscle, muscle.Sets), "line")
+		}
+	}
+	if len(summary.Misses) > 0 {
+		add(fmt.Sprintf("Missed targets: %d", len(summary.Misses)), "heading")
+	}
+
+	const width, lineHeight, padding = 600, 34, 40
+	height := padding*2 + lineHeight*len(lines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	b.WriteString(`<style>text{font-family:Helvetica,Arial,sans-serif;fill:#f5f5f5}.title{font-size:28px;font-weight:bold}.stat{font-size:22px;font-weight:bold;fill:#7dd3fc}.heading{font-size:18px;font-weight:bold}.line{font-size:16px}.muted{font-size:16px;fill:#a3a3a3}</style>`)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" rx="24" fill="#171717"/>`, width, height)
+	for i, line := range lines {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" class="%s">%s</text>`, padding, padding+lineHeight*(i+1)-10, line.class, html.EscapeString(line.text))
+	}
+	b.WriteString(`</svg>`)
+
+	return b.String()
+}
+
+func describeRecord(record SessionRecord) string {
+	var name string
+	switch record.Type {
+	case personalRecordEnums.RepMaxRecord:
+		name = fmt.Sprintf("%dRM", record.Reps)
+	case personalRecordEnums.EstimatedOneRMRecord:
+		name = "estimated 1RM"
+	case personalRecordEnums.SetVolumeRecord:
+		name = "best set volume"
+	case personalRecordEnums.SessionVolumeRecord:
+		name = "volume"
+	default:
+		name = string(record.Type)
+	}
+
+	text := fmt.Sprintf("%s: %s %s kg", record.Name, name, formatNumber(record.Value))
+	if record.Previous > 0 {
+		text += fmt.Sprintf(" (was %s kg)", formatNumber(record.Previous))
+	}
+	return text
+}
+
+func describeMiss(miss TargetMiss) string {
+	switch miss.Reason {
+	case MissedSet:
+		return fmt.Sprintf("%s set %d: not done", miss.Name, miss.SetNumber)
+	case MissedReps:
+		return fmt.Sprintf("%s set %d: %d of %d reps", miss.Name, miss.SetNumber, miss.Reps, miss.TargetReps)
+	default:
+		return fmt.Sprintf("%s set %d: %s of %s kg", miss.Name, miss.SetNumber, formatNumber(miss.Weight), formatNumber(miss.TargetWeight))
+	}
+}
+
+func formatDuration(seconds int) string {
+	minutes := seconds / 60
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(round(value), 'f', -1, 64)
+}
+
+func formatDelta(value float64) string {
+	if value > 0 {
+		return "+" + formatNumber(value)
+	}
+	return formatNumber(value)
+}
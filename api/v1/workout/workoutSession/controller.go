@@ -1,9 +1,16 @@
 package workoutSession
 
 import (
+	"bufio"
+	"errors"
+	"strconv"
+	"time"
+
 	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Error error
@@ -14,13 +21,15 @@ type WorkoutSessionController struct {
 }
 
 // @Summary     Start workout session
-// @Description Start a new workout session
+// @Description Start a new workout session, from a workout or by repeating a past session given as fromSessionId. A repeated session gets the past session's exercises in order with the sets done as targets.
 // @Tags        workoutSessions
 // @Accept      json
 // @Produce     json
 // @Param       session body CreateWorkoutSessionDto true "Create Session"
 // @Success     201 {object} WorkoutSession
 // @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
 // @Router      /workout-session [post]
 func (c *WorkoutSessionController) StartSessionHandler(ctx *fiber.Ctx) error {
 	userId := function.GetUserIDFromContext(ctx)
@@ -41,7 +50,16 @@ func (c *WorkoutSessionController) StartSessionHandler(ctx *fiber.Ctx) error {
 
 	session, err := c.Service.StartSession(dto, userId)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrRepeatSource):
+			status = fiber.StatusBadRequest
+		case err == mongo.ErrNoDocuments:
+			status = fiber.StatusNotFound
+		case errors.Is(err, ErrRepeatOngoing):
+			status = fiber.StatusConflict
+		}
+		return ctx.Status(status).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
@@ -75,6 +93,12 @@ func (c *WorkoutSessionController) LogSessionHandler(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if err := validateGroups(dto.Exercises, dto.Groups); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
 	session, err := c.Service.LogSession(dto, userId)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -86,30 +110,355 @@ func (c *WorkoutSessionController) LogSessionHandler(ctx *fiber.Ctx) error {
 }
 
 // @Summary     End workout session
-// @Description End an active workout session
+// @Description End an active or paused workout session. Its duration is the time it was active.
 // @Tags        workoutSessions
 // @Accept      json
 // @Produce     json
 // @Param       id path string true "Session ID"
 // @Success     200 {object} WorkoutSession
 // @Failure     400 {object} Error
+// @Failure     409 {object} Error
 // @Router      /workout-session/{id}/end [put]
 func (c *WorkoutSessionController) EndSessionHandler(ctx *fiber.Ctx) error {
+	return c.changeSession(ctx, c.Service.EndSession)
+}
+
+// @Summary     Pause workout session
+// @Description Stop the clock of a session in progress until it is resumed
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Success     200 {object} WorkoutSession
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/pause [put]
+func (c *WorkoutSessionController) PauseSessionHandler(ctx *fiber.Ctx) error {
+	return c.changeSession(ctx, c.Service.PauseSession)
+}
+
+// @Summary     Resume workout session
+// @Description Start the clock of a paused session again
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Success     200 {object} WorkoutSession
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/resume [put]
+func (c *WorkoutSessionController) ResumeSessionHandler(ctx *fiber.Ctx) error {
+	return c.changeSession(ctx, c.Service.ResumeSession)
+}
+
+// @Summary     Abandon workout session
+// @Description End an active or paused session without counting it as done. It matches no planned workout and does not move progression on.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Success     200 {object} WorkoutSession
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/abandon [put]
+func (c *WorkoutSessionController) AbandonSessionHandler(ctx *fiber.Ctx) error {
+	return c.changeSession(ctx, c.Service.AbandonSession)
+}
+
+// @Summary     Start rest timer
+// @Description Start the rest after a set. Without seconds it lasts the rest of the target set, else the user's default for the exercise, else 90 seconds. The device is notified when the rest is over.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       rest body StartRestDto true "Rest"
+// @Success     200 {object} WorkoutSession
+// @Failure     400 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/rest/start [put]
+func (c *WorkoutSessionController) StartRestHandler(ctx *fiber.Ctx) error {
+	dto := new(StartRestDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.changeSession(ctx, func(id string, userId string) (*WorkoutSession, error) {
+		return c.Service.StartRest(id, dto, userId)
+	})
+}
+
+// @Summary     Extend rest timer
+// @Description Add time to the running rest, counted from now when the rest is already over
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       rest body ExtendRestDto true "Extra Rest"
+// @Success     200 {object} WorkoutSession
+// @Failure     400 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/rest/extend [put]
+func (c *WorkoutSessionController) ExtendRestHandler(ctx *fiber.Ctx) error {
+	dto := new(ExtendRestDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.changeSession(ctx, func(id string, userId string) (*WorkoutSession, error) {
+		return c.Service.ExtendRest(id, dto, userId)
+	})
+}
+
+// @Summary     Skip rest timer
+// @Description Stop the running rest. The rest taken is recorded on the next set logged.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Success     200 {object} WorkoutSession
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/rest/skip [put]
+func (c *WorkoutSessionController) SkipRestHandler(ctx *fiber.Ctx) error {
+	return c.changeSession(ctx, c.Service.SkipRest)
+}
+
+// @Summary     Log session set
+// @Description Log a set after the last one of a session exercise. The first set starts the exercise's log and links it to the session; the session's total volume follows every set.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       exerciseId path string true "Exercise ID"
+// @Param       set body SessionSetDto true "Set"
+// @Success     201 {object} SetChange
+// @Failure     400 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/exercises/{exerciseId}/sets [post]
+func (c *WorkoutSessionController) AddSetHandler(ctx *fiber.Ctx) error {
 	userId := function.GetUserIDFromContext(ctx)
 	sessionId := ctx.Params("id")
+	dto, err := parseSessionSet(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
 
-	session, err := c.Service.EndSession(sessionId, userId)
+	change, err := c.Service.AddSet(sessionId, ctx.Params("exerciseId"), dto, userId)
+	if err != nil {
+		return c.setError(ctx, err, sessionId, userId)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(change)
+}
+
+// @Summary     Update session set
+// @Description Replace a logged set of a session exercise
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       exerciseId path string true "Exercise ID"
+// @Param       setNumber path int true "Set Number"
+// @Param       set body SessionSetDto true "Set"
+// @Success     200 {object} SetChange
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/exercises/{exerciseId}/sets/{setNumber} [put]
+func (c *WorkoutSessionController) UpdateSetHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+	setNumber, err := ctx.ParamsInt("setNumber")
+	if err != nil || setNumber < 1 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid set number",
+		})
+	}
+	dto, err := parseSessionSet(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	change, err := c.Service.UpdateSet(sessionId, ctx.Params("exerciseId"), setNumber, dto, userId)
+	if err != nil {
+		return c.setError(ctx, err, sessionId, userId)
+	}
+
+	return ctx.JSON(change)
+}
+
+// @Summary     Delete session set
+// @Description Delete a logged set of a session exercise, moving the sets after it up one number. Deleting the last set deletes the exercise's log.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       exerciseId path string true "Exercise ID"
+// @Param       setNumber path int true "Set Number"
+// @Param       version query int false "Session version the change was made on"
+// @Success     200 {object} SetChange
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/exercises/{exerciseId}/sets/{setNumber} [delete]
+func (c *WorkoutSessionController) DeleteSetHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+	setNumber, err := ctx.ParamsInt("setNumber")
+	if err != nil || setNumber < 1 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid set number",
+		})
+	}
+
+	var version *int64
+	if ctx.Query("version") != "" {
+		v, err := strconv.ParseInt(ctx.Query("version"), 10, 64)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "invalid version",
+			})
+		}
+		version = &v
+	}
+
+	change, err := c.Service.DeleteSet(sessionId, ctx.Params("exerciseId"), setNumber, version, userId)
+	if err != nil {
+		return c.setError(ctx, err, sessionId, userId)
+	}
+
+	return ctx.JSON(change)
+}
+
+// parseSessionSet reads and validates a set from the body
+func parseSessionSet(ctx *fiber.Ctx) (*SessionSetDto, error) {
+	dto := new(SessionSetDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return nil, err
+	}
+	if err := exerciseLog.NewValidator().Struct(dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (c *WorkoutSessionController) setError(ctx *fiber.Ctx, err error, sessionId string, userId string) error {
+	if errors.Is(err, ErrVersionConflict) {
+		return c.versionConflict(ctx, sessionId, userId)
+	}
+	return sessionStateError(ctx, err)
+}
+
+// @Summary     Get rest defaults
+// @Description Get the user's default rests per exercise
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Success     200 {array} RestDefault
+// @Failure     500 {object} Error
+// @Router      /workout-session/rest-defaults [get]
+func (c *WorkoutSessionController) GetRestDefaultsHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	defaults, err := c.Service.GetRestDefaults(userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(defaults)
+}
+
+// @Summary     Set rest default
+// @Description Set how long the user rests between sets of an exercise without a prescribed rest
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId path string true "Exercise ID"
+// @Param       rest body RestDefaultDto true "Rest Default"
+// @Success     200 {object} RestDefault
+// @Failure     400 {object} Error
+// @Router      /workout-session/rest-defaults/{exerciseId} [put]
+func (c *WorkoutSessionController) SetRestDefaultHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	dto := new(RestDefaultDto)
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validator.New().Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	restDefault, err := c.Service.SetRestDefault(ctx.Params("exerciseId"), dto, userId)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
 
+	return ctx.JSON(restDefault)
+}
+
+// @Summary     Delete rest default
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       exerciseId path string true "Exercise ID"
+// @Success     204
+// @Failure     404 {object} Error
+// @Router      /workout-session/rest-defaults/{exerciseId} [delete]
+func (c *WorkoutSessionController) DeleteRestDefaultHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+
+	if err := c.Service.DeleteRestDefault(ctx.Params("exerciseId"), userId); err != nil {
+		return sessionStateError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (c *WorkoutSessionController) changeSession(ctx *fiber.Ctx, change func(id string, userId string) (*WorkoutSession, error)) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+
+	session, err := change(sessionId, userId)
+	if err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return c.versionConflict(ctx, sessionId, userId)
+		}
+		return sessionStateError(ctx, err)
+	}
+
 	return ctx.JSON(session)
 }
 
 // @Summary     Update workout session
-// @Description Update a workout session
+// @Description Update a workout session. A new status is applied the way the pause, resume, end and abandon endpoints apply it.
 // @Tags        workoutSessions
 // @Accept      json
 // @Produce     json
@@ -117,6 +466,7 @@ func (c *WorkoutSessionController) EndSessionHandler(ctx *fiber.Ctx) error {
 // @Param       session body UpdateWorkoutSessionDto true "Update Session"
 // @Success     200 {object} WorkoutSession
 // @Failure     400 {object} Error
+// @Failure     409 {object} Error
 // @Router      /workout-session/{id} [put]
 func (c *WorkoutSessionController) UpdateSessionHandler(ctx *fiber.Ctx) error {
 	userId := function.GetUserIDFromContext(ctx)
@@ -136,8 +486,20 @@ func (c *WorkoutSessionController) UpdateSessionHandler(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if err := validateGroups(dto.Exercises, dto.Groups); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
 	session, err := c.Service.UpdateSession(sessionId, dto, userId)
 	if err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return c.versionConflict(ctx, sessionId, userId)
+		}
+		if errors.Is(err, ErrSessionEnded) || errors.Is(err, ErrSessionNotInProgress) || errors.Is(err, ErrSessionNotPaused) || errors.Is(err, ErrInvalidLinkedLog) {
+			return sessionStateError(ctx, err)
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": err.Error(),
 		})
@@ -146,6 +508,48 @@ func (c *WorkoutSessionController) UpdateSessionHandler(ctx *fiber.Ctx) error {
 	return ctx.JSON(session)
 }
 
+// @Summary     Reorder session exercises
+// @Description Move exercises and groups of a session, grouped exercises move with their group
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       order body ReorderExercisesDto true "Exercise Order"
+// @Success     200 {object} WorkoutSession
+// @Failure     400 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/reorder [put]
+func (c *WorkoutSessionController) ReorderExercisesHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+	validate := validator.New()
+	dto := new(ReorderExercisesDto)
+
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	session, err := c.Service.ReorderExercises(sessionId, dto, userId)
+	if err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return c.versionConflict(ctx, sessionId, userId)
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(session)
+}
+
 // @Summary     Get session
 // @Description Get a workout session by ID
 // @Tags        workoutSessions
@@ -169,6 +573,86 @@ func (c *WorkoutSessionController) GetSessionHandler(ctx *fiber.Ctx) error {
 	return ctx.JSON(session)
 }
 
+// @Summary     Get session summary
+// @Description Get the recap of a session: duration, volume and sets per muscle, the personal records it set, a comparison with the previous session of the same workout and the targets it missed. format=markdown returns a Markdown card, format=svg a shareable image.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json,text/markdown,image/svg+xml
+// @Param       id path string true "Session ID"
+// @Param       format query string false "json, markdown or svg" default(json)
+// @Success     200 {object} SessionSummary
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Router      /workout-session/{id}/summary [get]
+func (c *WorkoutSessionController) GetSessionSummaryHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	format := SummaryFormat(ctx.Query("format", string(JSONSummary)))
+	if format != JSONSummary && format != MarkdownSummary && format != SVGSummary {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "format must be json, markdown or svg",
+		})
+	}
+
+	summary, err := c.Service.GetSessionSummary(ctx.Params("id"), userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "session not found",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	switch format {
+	case MarkdownSummary:
+		ctx.Set("Content-Type", "text/markdown; charset=utf-8")
+		return ctx.SendString(MarkdownCard(summary))
+	case SVGSummary:
+		ctx.Set("Content-Type", "image/svg+xml")
+		return ctx.SendString(SVGCard(summary))
+	}
+	return ctx.JSON(summary)
+}
+
+// @Summary     Save session as workout
+// @Description Save a completed custom or logged session as a new workout. Each exercise is prescribed the working sets done, their reps range and the load of the heaviest one.
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       workout body SaveAsWorkoutDto true "Workout"
+// @Success     201 {object} workout.Workout
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/workout [post]
+func (c *WorkoutSessionController) SaveAsWorkoutHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	validate := validator.New()
+	dto := new(SaveAsWorkoutDto)
+
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	created, err := c.Service.SaveAsWorkout(ctx.Params("id"), dto, userId)
+	if err != nil {
+		return sessionStateError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(created)
+}
+
 // @Summary     Get user sessions
 // @Description Get all workout sessions for a user
 // @Tags        workoutSessions
@@ -212,7 +696,7 @@ func (c *WorkoutSessionController) GetOnGoingSessionHandler(ctx *fiber.Ctx) erro
 }
 
 // @Summary     Delete session
-// @Description Delete a workout session
+// @Description Delete a workout session with the exercise logs linked to it. A log also linked to another session is kept.
 // @Tags        workoutSessions
 // @Accept      json
 // @Produce     json
@@ -233,6 +717,179 @@ func (c *WorkoutSessionController) DeleteSessionHandler(ctx *fiber.Ctx) error {
 	return ctx.SendStatus(fiber.StatusNoContent)
 }
 
+// @Summary     Complete session exercise
+// @Description Link the exercise log holding the sets done for an exercise of an ongoing session
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       exerciseId path string true "Exercise ID"
+// @Param       exercise body CompleteExerciseDto true "Exercise Log"
+// @Success     200 {object} WorkoutSession
+// @Failure     400 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/exercises/{exerciseId}/complete [put]
+func (c *WorkoutSessionController) CompleteExerciseHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+	validate := validator.New()
+	dto := new(CompleteExerciseDto)
+
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	session, err := c.Service.CompleteExercise(sessionId, ctx.Params("exerciseId"), dto, userId)
+	if err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return c.versionConflict(ctx, sessionId, userId)
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.JSON(session)
+}
+
+// versionConflict answers a change made on an outdated version with the current session
+func (c *WorkoutSessionController) versionConflict(ctx *fiber.Ctx, sessionId string, userId string) error {
+	current, err := c.Service.GetSession(sessionId, userId)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"message": ErrVersionConflict.Error(),
+		"session": current,
+	})
+}
+
+// @Summary     Follow session live
+// @Description Stream the changes of an ongoing session as Server-Sent Events. The first event is a snapshot of the session; every event carries the session version it produced, so devices ignore events they are already past. The stream ends with session_ended or session_deleted.
+// @Tags        workoutSessions
+// @Produce     text/event-stream
+// @Param       id path string true "Session ID"
+// @Success     200 {object} LiveEvent
+// @Failure     404 {object} Error
+// @Failure     409 {object} Error
+// @Router      /workout-session/{id}/live [get]
+func (c *WorkoutSessionController) LiveSessionHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+
+	session, events, unsubscribe, err := c.Service.SubscribeLive(sessionId, userId)
+	if err != nil {
+		return sessionStateError(ctx, err)
+	}
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	snapshot := LiveEvent{Type: LiveSnapshot, SessionID: sessionId, Version: session.Version, Data: session, At: time.Now()}
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(LiveHeartbeat)
+		defer heartbeat.Stop()
+
+		write := func(message []byte) bool {
+			if _, err := w.Write(message); err != nil {
+				return false
+			}
+			// A failed flush means the device went away
+			return w.Flush() == nil
+		}
+		send := func(event LiveEvent) bool {
+			message, err := FormatLiveEvent(event)
+			return err == nil && write(message)
+		}
+
+		if !send(snapshot) {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok || !send(event) || event.Ends() {
+					return
+				}
+			case <-heartbeat.C:
+				if !write([]byte(": ping\n\n")) {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// @Summary     Relay live event
+// @Description Pass an event that does not change the session, such as a rest timer, on to the other devices following it
+// @Tags        workoutSessions
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "Session ID"
+// @Param       event body LiveEventDto true "Live Event"
+// @Success     202 {object} LiveEvent
+// @Failure     400 {object} Error
+// @Failure     404 {object} Error
+// @Router      /workout-session/{id}/live/events [post]
+func (c *WorkoutSessionController) RelayLiveEventHandler(ctx *fiber.Ctx) error {
+	userId := function.GetUserIDFromContext(ctx)
+	sessionId := ctx.Params("id")
+	validate := validator.New()
+	dto := new(LiveEventDto)
+
+	if err := ctx.BodyParser(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := validate.Struct(dto); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	event, err := c.Service.RelayLiveEvent(sessionId, dto, userId)
+	if err != nil {
+		return sessionStateError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(event)
+}
+
+func sessionStateError(ctx *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case err == mongo.ErrNoDocuments, errors.Is(err, ErrSetNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, ErrSessionNotInProgress), errors.Is(err, ErrSessionNotPaused), errors.Is(err, ErrSessionEnded), errors.Is(err, ErrNoRestTimer), errors.Is(err, ErrNotSavable):
+		status = fiber.StatusConflict
+	case errors.Is(err, ErrLiveUnavailable), errors.Is(err, ErrSetLoggingUnavailable), errors.Is(err, ErrWorkoutUnavailable):
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return ctx.Status(status).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
 func (c *WorkoutSessionController) Handle() {
 	g := c.Instance.Group("/workout-session")
 
@@ -240,8 +897,26 @@ func (c *WorkoutSessionController) Handle() {
 	g.Post("/log", c.LogSessionHandler)
 	g.Get("/", c.GetUserSessionsHandler)
 	g.Get("/ongoing", c.GetOnGoingSessionHandler)
+	g.Get("/rest-defaults", c.GetRestDefaultsHandler)
+	g.Put("/rest-defaults/:exerciseId", c.SetRestDefaultHandler)
+	g.Delete("/rest-defaults/:exerciseId", c.DeleteRestDefaultHandler)
 	g.Get("/:id", c.GetSessionHandler)
+	g.Get("/:id/summary", c.GetSessionSummaryHandler)
+	g.Post("/:id/workout", c.SaveAsWorkoutHandler)
 	g.Put("/:id", c.UpdateSessionHandler)
 	g.Put("/:id/end", c.EndSessionHandler)
+	g.Put("/:id/pause", c.PauseSessionHandler)
+	g.Put("/:id/resume", c.ResumeSessionHandler)
+	g.Put("/:id/abandon", c.AbandonSessionHandler)
+	g.Put("/:id/rest/start", c.StartRestHandler)
+	g.Put("/:id/rest/extend", c.ExtendRestHandler)
+	g.Put("/:id/rest/skip", c.SkipRestHandler)
+	g.Put("/:id/reorder", c.ReorderExercisesHandler)
+	g.Put("/:id/exercises/:exerciseId/complete", c.CompleteExerciseHandler)
+	g.Post("/:id/exercises/:exerciseId/sets", c.AddSetHandler)
+	g.Put("/:id/exercises/:exerciseId/sets/:setNumber", c.UpdateSetHandler)
+	g.Delete("/:id/exercises/:exerciseId/sets/:setNumber", c.DeleteSetHandler)
+	g.Get("/:id/live", c.LiveSessionHandler)
+	g.Post("/:id/live/events", c.RelayLiveEventHandler)
 	g.Delete("/:id", c.DeleteSessionHandler)
 }
@@ -1,37 +1,112 @@
 package workoutSession
 
-import "time"
+import (
+	"time"
+
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+)
 
 type CreateWorkoutSessionDto struct {
-	WorkoutID string      `json:"workoutId"`
-	Type      SessionType `json:"type" validate:"required,oneof=planned custom"`
-	Notes     string      `json:"notes"`
+	WorkoutID     string      `json:"workoutId"`
+	FromSessionID string      `json:"fromSessionId"` // A past session to repeat, its sets become the targets
+	Type          SessionType `json:"type" validate:"required,oneof=planned custom"`
+	Notes         string      `json:"notes"`
+}
+
+// SaveAsWorkoutDto names the workout a session is saved as
+type SaveAsWorkoutDto struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
 }
 
 type UpdateWorkoutSessionDto struct {
-	Status    SessionStatus     `json:"status"`
-	Exercises []SessionExercise `json:"exercises"`
-	Notes     string            `json:"notes"`
+	Status    SessionStatus           `json:"status" validate:"omitempty,oneof=in_progress paused completed abandoned"`
+	Exercises []SessionExercise       `json:"exercises"`
+	Groups    []workout.ExerciseGroup `json:"groups" validate:"dive"`
+	Notes     string                  `json:"notes"`
+	Version   *int64                  `json:"version"` // The version the change was made on, no conflict check without it
 }
 
+// ReorderExercisesDto moves exercises and groups of a session. Grouped exercises move with
+// their group, so they are referenced by GroupID; entries left out keep their relative order.
 type ReorderExercisesDto struct {
 	Exercises []ExerciseOrder `json:"exercises" validate:"required,dive"`
+	Version   *int64          `json:"version"`
 }
 
 type ExerciseOrder struct {
-	ExerciseID string `json:"exerciseId" validate:"required"`
-	Order      int    `json:"order" validate:"required,min=0"`
+	ExerciseID string `json:"exerciseId" validate:"required_without=GroupID"`
+	GroupID    string `json:"groupId" validate:"required_without=ExerciseID"`
+	Order      int    `json:"order" validate:"min=0"`
 }
 
 type CompleteExerciseDto struct {
 	ExerciseLogID string `json:"exerciseLogId" validate:"required"`
+	Version       *int64 `json:"version"`
+}
+
+// LiveEventDto is an event a device relays to the other devices following the session without
+// changing the session
+type LiveEventDto struct {
+	Type LiveEventType          `json:"type" validate:"required,oneof=rest_timer_started rest_timer_stopped"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// StartRestDto starts the rest after a set. Seconds overrides the prescribed or default rest.
+type StartRestDto struct {
+	ExerciseID string `json:"exerciseId" validate:"required"`
+	SetNumber  int    `json:"setNumber" validate:"required,min=1"`
+	Seconds    int    `json:"seconds" validate:"min=0,max=3600"`
+}
+
+type ExtendRestDto struct {
+	Seconds int `json:"seconds" validate:"required,min=1,max=3600"`
+}
+
+type RestDefaultDto struct {
+	Seconds int `json:"seconds" validate:"required,min=1,max=3600"`
+}
+
+// SessionSetDto is a set of a session exercise. Weight is in the unit of the exercise's log; Unit
+// only applies to the set that starts the log and defaults to the user's weight unit.
+type SessionSetDto struct {
+	Weight      float64                      `json:"weight" validate:"min=0"`
+	Reps        int                          `json:"reps" validate:"required,min=1"`
+	Type        exerciseLog.SetType          `json:"type" validate:"required,oneof=warm_up working drop failure"`
+	RPE         *float64                     `json:"rpe,omitempty" validate:"omitempty,min=1,max=10"`
+	RIR         *int                         `json:"rir,omitempty" validate:"omitempty,min=0,max=10"`
+	Tempo       string                       `json:"tempo,omitempty" validate:"omitempty,tempo"`
+	RestSeconds int                          `json:"restSeconds,omitempty" validate:"min=0"` // Taken from the rest timer when left out
+	CompletedAt time.Time                    `json:"completedAt,omitempty"`
+	Round       int                          `json:"round,omitempty" validate:"min=0"`
+	Unit        unitEnums.ExerciseWeightUnit `json:"unit" validate:"omitempty,oneof=kg lbs"`
+	Version     *int64                       `json:"version"`
+}
+
+// SetLog turns the set into the set numbered setNumber of a log
+func (d SessionSetDto) SetLog(setNumber int) exerciseLog.SetLog {
+	return exerciseLog.SetLog{
+		Weight:      d.Weight,
+		Reps:        d.Reps,
+		SetNumber:   setNumber,
+		Type:        d.Type,
+		RPE:         d.RPE,
+		RIR:         d.RIR,
+		Tempo:       d.Tempo,
+		RestSeconds: d.RestSeconds,
+		CompletedAt: d.CompletedAt,
+		Round:       d.Round,
+	}
 }
 
 type LoggedSessionDto struct {
-	WorkoutID string            `json:"workoutId"`
-	StartTime time.Time         `json:"startTime" validate:"required"`
-	EndTime   time.Time         `json:"endTime" validate:"required"`
-	Status    SessionStatus     `json:"status" validate:"required"`
-	Exercises []SessionExercise `json:"exercises"`
-	Notes     string            `json:"notes"`
+	WorkoutID string                  `json:"workoutId"`
+	StartTime time.Time               `json:"startTime" validate:"required"`
+	EndTime   time.Time               `json:"endTime" validate:"required"`
+	Status    SessionStatus           `json:"status" validate:"required"`
+	Exercises []SessionExercise       `json:"exercises"`
+	Groups    []workout.ExerciseGroup `json:"groups" validate:"dive"`
+	Notes     string                  `json:"notes"`
 }
@@ -0,0 +1,23 @@
+package workoutSession
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// IdleTimeoutFromEnv reads how long sessions may stay idle from SESSION_IDLE_TIMEOUT, a duration
+// such as "4h" or "90m". It falls back to DefaultIdleTimeout.
+func IdleTimeoutFromEnv() time.Duration {
+	value := os.Getenv("SESSION_IDLE_TIMEOUT")
+	if value == "" {
+		return DefaultIdleTimeout
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		log.Printf("Invalid SESSION_IDLE_TIMEOUT %q, using %s", value, DefaultIdleTimeout)
+		return DefaultIdleTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,52 @@
+package workoutSession
+
+import "time"
+
+const (
+	// DefaultIdleTimeout is how long an ongoing session may go without changes before the sweeper ends it
+	DefaultIdleTimeout = 4 * time.Hour
+	// SweepInterval is how often idle sessions are looked for
+	SweepInterval = 5 * time.Minute
+)
+
+// ActiveSeconds is how long a session has been active at a time, pauses left out. Sessions
+// started before pausing existed have no ResumedAt and count from their start.
+func ActiveSeconds(session *WorkoutSession, at time.Time) int {
+	total := session.ActiveTime
+	if session.Status != StatusInProgress {
+		return total
+	}
+
+	since := session.StartTime
+	if session.ResumedAt != nil {
+		since = *session.ResumedAt
+	}
+	if at.After(since) {
+		total += int(at.Sub(since).Seconds())
+	}
+	return total
+}
+
+// LastActivity is when a session was last changed
+func LastActivity(session *WorkoutSession) time.Time {
+	if session.UpdatedAt.After(session.StartTime) {
+		return session.UpdatedAt
+	}
+	return session.StartTime
+}
+
+// IsIdle tells whether an ongoing session went without changes for longer than timeout
+func IsIdle(session *WorkoutSession, now time.Time, timeout time.Duration) bool {
+	return session.Status.Ongoing() && now.Sub(LastActivity(session)) > timeout
+}
+
+// TimeoutStatus is how the sweeper ends an idle session: completed when exercises were logged,
+// abandoned otherwise
+func TimeoutStatus(session *WorkoutSession) SessionStatus {
+	for _, ex := range session.Exercises {
+		if ex.ExerciseLogID != "" {
+			return StatusCompleted
+		}
+	}
+	return StatusAbandoned
+}
@@ -0,0 +1,170 @@
+package workoutSession
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LiveEventType string
+
+const (
+	LiveSnapshot           LiveEventType = "snapshot"            // The whole session, sent first on every stream
+	LiveSessionUpdated     LiveEventType = "session_updated"     // The whole session after an update
+	LiveExerciseCompleted  LiveEventType = "exercise_completed"  // An exercise got its logged sets
+	LiveExercisesReordered LiveEventType = "exercises_reordered" // Exercises moved
+	LiveNotesUpdated       LiveEventType = "notes_updated"
+	LiveSetLogged          LiveEventType = "set_logged"
+	LiveSetUpdated         LiveEventType = "set_updated"
+	LiveSetDeleted         LiveEventType = "set_deleted"
+	LiveRestTimerStarted   LiveEventType = "rest_timer_started"
+	LiveRestTimerExtended  LiveEventType = "rest_timer_extended"
+	LiveRestTimerStopped   LiveEventType = "rest_timer_stopped"
+	LiveSessionPaused      LiveEventType = "session_paused"
+	LiveSessionResumed     LiveEventType = "session_resumed"
+	LiveSessionEnded       LiveEventType = "session_ended" // Last event of a stream
+	LiveSessionAbandoned   LiveEventType = "session_abandoned"
+	LiveSessionDeleted     LiveEventType = "session_deleted"
+)
+
+const (
+	// LiveSubscriberBuffer is how many events a slow subscriber may fall behind before it is dropped
+	LiveSubscriberBuffer = 32
+	// LiveHeartbeat is how often an idle stream sends a comment, so proxies keep it open
+	LiveHeartbeat = 15 * time.Second
+)
+
+// LiveEvent is a change of an ongoing session sent to every device following it. Version is the
+// session version the change produced; a device already at that version or later ignores it.
+// Relayed events that change nothing carry the current version.
+type LiveEvent struct {
+	Type      LiveEventType `json:"type"`
+	SessionID string        `json:"sessionid"`
+	Version   int64         `json:"version"`
+	Data      interface{}   `json:"data,omitempty"`
+	At        time.Time     `json:"at"`
+}
+
+// Ends tells whether no event follows this one
+func (e LiveEvent) Ends() bool {
+	return e.Type == LiveSessionEnded || e.Type == LiveSessionAbandoned || e.Type == LiveSessionDeleted
+}
+
+// FormatLiveEvent writes an event as a Server-Sent Events message, its id being the version
+func FormatLiveEvent(event LiveEvent) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.Version, event.Type, data)), nil
+}
+
+// LiveHub fans session events out to the devices subscribed to the session. Subscriptions live in
+// memory, so devices must reach the instance the changes are made on.
+type LiveHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan LiveEvent]struct{}
+}
+
+func NewLiveHub() *LiveHub {
+	return &LiveHub{subscribers: make(map[string]map[chan LiveEvent]struct{})}
+}
+
+// Subscribe follows the events of a session until the returned func is called. The channel is
+// closed when the subscription ends, including when the subscriber falls too far behind.
+func (h *LiveHub) Subscribe(sessionId string) (<-chan LiveEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := make(chan LiveEvent, LiveSubscriberBuffer)
+	if h.subscribers[sessionId] == nil {
+		h.subscribers[sessionId] = make(map[chan LiveEvent]struct{})
+	}
+	h.subscribers[sessionId][events] = struct{}{}
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sessionId, events)
+	}
+}
+
+// Publish sends an event to every subscriber of its session without waiting on any of them
+func (h *LiveHub) Publish(event LiveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	for events := range h.subscribers[event.SessionID] {
+		select {
+		case events <- event:
+		default:
+			// It missed an event, dropping it makes the device reconnect and start from a snapshot
+			h.remove(event.SessionID, events)
+		}
+	}
+}
+
+// Subscribers returns how many devices follow a session
+func (h *LiveHub) Subscribers(sessionId string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[sessionId])
+}
+
+func (h *LiveHub) remove(sessionId string, events chan LiveEvent) {
+	if _, ok := h.subscribers[sessionId][events]; !ok {
+		return
+	}
+	delete(h.subscribers[sessionId], events)
+	close(events)
+	if len(h.subscribers[sessionId]) == 0 {
+		delete(h.subscribers, sessionId)
+	}
+}
+
+// ChangeEvents lists the finer events an update from before to after makes, besides the
+// session_updated event carrying the whole session
+func ChangeEvents(before *WorkoutSession, after *WorkoutSession) []LiveEvent {
+	events := make([]LiveEvent, 0)
+	event := func(eventType LiveEventType, data interface{}) {
+		events = append(events, LiveEvent{Type: eventType, SessionID: after.ID.Hex(), Version: after.Version, Data: data})
+	}
+
+	completed := make(map[string]string)
+	for _, ex := range before.Exercises {
+		completed[ex.ExerciseID] = ex.ExerciseLogID
+	}
+	for _, ex := range after.Exercises {
+		if ex.ExerciseLogID != "" && completed[ex.ExerciseID] != ex.ExerciseLogID {
+			event(LiveExerciseCompleted, ex)
+		}
+	}
+
+	if exerciseOrder(before.Exercises) != exerciseOrder(after.Exercises) {
+		event(LiveExercisesReordered, after.Exercises)
+	}
+	if before.Notes != after.Notes {
+		event(LiveNotesUpdated, after.Notes)
+	}
+	return events
+}
+
+func exerciseOrder(exercises []SessionExercise) string {
+	sorted := append([]SessionExercise(nil), exercises...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	ids := make([]string, 0, len(sorted))
+	for _, ex := range sorted {
+		ids = append(ids, ex.ExerciseID)
+	}
+	return strings.Join(ids, ",")
+}
@@ -3,23 +3,34 @@ package workoutSession
 import (
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type WorkoutSession struct {
-	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	UserID      string             `json:"userid" bson:"userid" validate:"required"`
-	WorkoutID   string             `json:"workoutid" bson:"workoutid"`
-	Type        SessionType        `json:"type" bson:"type" validate:"required"`
-	StartTime   time.Time          `json:"start_time" bson:"start_time"`
-	EndTime     time.Time          `json:"end_time" bson:"end_time"`
-	Status      SessionStatus      `json:"status" bson:"status"`
-	TotalVolume float64            `json:"total_volume" bson:"total_volume"`
-	Duration    int                `json:"duration" bson:"duration"`
-	Exercises   []SessionExercise  `json:"exercises" validate:"dive"`
-	Notes       string             `json:"notes" bson:"notes"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID             primitive.ObjectID      `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID         string                  `json:"userid" bson:"userid" validate:"required"`
+	WorkoutID      string                  `json:"workoutid" bson:"workoutid"`
+	Type           SessionType             `json:"type" bson:"type" validate:"required"`
+	RepeatedFrom   string                  `json:"repeated_from,omitempty" bson:"repeated_from,omitempty"` // The past session it was started from
+	StartTime      time.Time               `json:"start_time" bson:"start_time"`
+	EndTime        time.Time               `json:"end_time" bson:"end_time"`
+	Status         SessionStatus           `json:"status" bson:"status"`
+	TotalVolume    float64                 `json:"total_volume" bson:"total_volume"`
+	Duration       int                     `json:"duration" bson:"duration"`                         // Seconds once ended, pauses left out; start to end for logged sessions
+	ActiveTime     int                     `json:"active_time" bson:"active_time"`                   // Active seconds before ResumedAt
+	ResumedAt      *time.Time              `json:"resumed_at,omitempty" bson:"resumed_at,omitempty"` // Start of the running stretch, unset while paused
+	PausedAt       *time.Time              `json:"paused_at,omitempty" bson:"paused_at,omitempty"`
+	AutoClosed     bool                    `json:"auto_closed,omitempty" bson:"auto_closed,omitempty"` // Ended by the idle sweeper
+	RestTimer      *RestTimer              `json:"rest_timer,omitempty" bson:"rest_timer,omitempty"`   // Rest before the next set, unset once that set is logged
+	Exercises      []SessionExercise       `json:"exercises" validate:"dive"`
+	Groups         []workout.ExerciseGroup `json:"groups" bson:"groups" validate:"dive"`
+	GroupSummaries []GroupSummary          `json:"group_summaries" bson:"group_summaries"`
+	Notes          string                  `json:"notes" bson:"notes"`
+	ImportKey      string                  `json:"import_key,omitempty" bson:"import_key,omitempty"` // Workout of another app the session was imported from, imported once
+	Version        int64                   `json:"version" bson:"version"`                           // Goes up with every change, devices send it back to detect conflicts
+	CreatedAt      time.Time               `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at" bson:"updated_at"`
 }
 
 type SessionType string
@@ -31,13 +42,61 @@ const (
 )
 
 type SessionExercise struct {
-	ExerciseID    string `json:"exerciseid" bson:"exerciseid" validate:"required"`
-	ExerciseLogID string `json:"exerciselogid" bson:"exerciselogid"`
-	Order         int    `json:"order" bson:"order" validate:"required,min=0"`
+	ExerciseID    string              `json:"exerciseid" bson:"exerciseid" validate:"required"`
+	ExerciseLogID string              `json:"exerciselogid" bson:"exerciselogid"`
+	Order         int                 `json:"order" bson:"order" validate:"required,min=0"`
+	GroupID       string              `json:"groupid,omitempty" bson:"groupid,omitempty"`
+	TargetSets    []workout.TargetSet `json:"target_sets,omitempty" bson:"target_sets,omitempty"`   // Expanded from the workout's prescription, weights are entered weights in kg
+	WarmUpSets    []workout.TargetSet `json:"warm_up_sets,omitempty" bson:"warm_up_sets,omitempty"` // Ramp to the heaviest target, to log as warm_up sets
+}
+
+// GroupSummary describes how a group of the session was performed once the session ends
+type GroupSummary struct {
+	GroupID         string  `json:"groupid" bson:"groupid"`
+	CompletedRounds int     `json:"completed_rounds" bson:"completed_rounds"`
+	TotalVolume     float64 `json:"total_volume" bson:"total_volume"`
+	Duration        int     `json:"duration" bson:"duration"` // Seconds from the first to the last set of the group
 }
 type SessionStatus string
 
 const (
 	StatusInProgress SessionStatus = "in_progress"
+	StatusPaused     SessionStatus = "paused"
 	StatusCompleted  SessionStatus = "completed"
+	StatusAbandoned  SessionStatus = "abandoned" // Ended without counting as done, it matches no plan nor moves progression on
+)
+
+// Ongoing tells whether a session with the status has not ended yet
+func (s SessionStatus) Ongoing() bool {
+	return s == StatusInProgress || s == StatusPaused
+}
+
+type RestSource string
+
+const (
+	PrescriptionRest    RestSource = "prescription"     // Rest of the target set
+	ExerciseDefaultRest RestSource = "exercise_default" // The user's default for the exercise
+	DefaultRest         RestSource = "default"          // DefaultRestSeconds
+	CustomRest          RestSource = "custom"           // Given when starting the timer
 )
+
+// RestTimer is a rest after a set of the session. It keeps running past EndsAt until it is skipped
+// or the next set is logged, which gets the rest actually taken.
+type RestTimer struct {
+	ExerciseID    string     `json:"exerciseid" bson:"exerciseid"`
+	SetNumber     int        `json:"set_number" bson:"set_number"` // The set the rest follows
+	Source        RestSource `json:"source" bson:"source"`
+	TargetSeconds int        `json:"target_seconds" bson:"target_seconds"`
+	StartedAt     time.Time  `json:"started_at" bson:"started_at"`
+	EndsAt        time.Time  `json:"ends_at" bson:"ends_at"`
+	StoppedAt     *time.Time `json:"stopped_at,omitempty" bson:"stopped_at,omitempty"` // Set when skipped
+}
+
+// RestDefault is how long a user rests between sets of an exercise without a prescribed rest
+type RestDefault struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID     string             `json:"userid" bson:"userid"`
+	ExerciseID string             `json:"exerciseid" bson:"exerciseid"`
+	Seconds    int                `json:"seconds" bson:"seconds"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,135 @@
+package workoutSession
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
+)
+
+var (
+	ErrRepeatOngoing      = errors.New("only sessions that ended can be repeated")
+	ErrRepeatSource       = errors.New("start a session from either a workout or a past session")
+	ErrNotSavable         = errors.New("only completed custom or logged sessions can be saved as a workout")
+	ErrWorkoutUnavailable = errors.New("saving sessions as workouts is not available")
+)
+
+// maxPrescribedSets is the most sets a workout prescribes for an exercise
+const maxPrescribedSets = 20
+
+// RepeatExercises copies the exercises of a past session in their order. The sets done for an
+// exercise become its targets; exercises without a log keep the targets they had.
+func RepeatExercises(source *WorkoutSession, logs map[string]*exerciseLog.ExerciseLog) []SessionExercise {
+	exercises := make([]SessionExercise, 0, len(source.Exercises))
+	for i, ex := range sortedExercises(source.Exercises) {
+		targetSets := ex.TargetSets
+		if log := logs[ex.ExerciseLogID]; log != nil {
+			if done := TargetsFromSets(log.Sets); len(done) > 0 {
+				targetSets = done
+			}
+		}
+
+		exercises = append(exercises, SessionExercise{
+			ExerciseID: ex.ExerciseID,
+			Order:      i,
+			GroupID:    ex.GroupID,
+			TargetSets: targetSets,
+		})
+	}
+	return exercises
+}
+
+// TargetsFromSets turns the working sets of a log into target sets with the same loads, reps and
+// rests. Warm-up sets are left out.
+func TargetsFromSets(sets []exerciseLog.SetLog) []workout.TargetSet {
+	targets := make([]workout.TargetSet, 0, len(sets))
+	for _, set := range workingSetsInOrder(sets) {
+		target := workout.TargetSet{
+			SetNumber:   len(targets) + 1,
+			MinReps:     set.Reps,
+			MaxReps:     set.Reps,
+			Weight:      set.Load(),
+			RestSeconds: set.RestSeconds,
+		}
+		if set.RPE != nil {
+			target.RPE = *set.RPE
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// WorkoutFromSession turns a session into a workout. Each exercise is prescribed its working
+// sets, the reps range they were done in and the load of the heaviest one; exercises that were
+// not done get no prescription.
+func WorkoutFromSession(session *WorkoutSession, logs map[string]*exerciseLog.ExerciseLog, dto *SaveAsWorkoutDto) *workout.CreateWorkoutDto {
+	exercises := make([]workout.WorkoutExercise, 0, len(session.Exercises))
+	for i, ex := range sortedExercises(session.Exercises) {
+		exercise := workout.WorkoutExercise{
+			ExerciseID: ex.ExerciseID,
+			Order:      i,
+			GroupID:    ex.GroupID,
+		}
+		if log := logs[ex.ExerciseLogID]; log != nil {
+			exercise.Prescription = PrescriptionFromSets(log.Sets)
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	return &workout.CreateWorkoutDto{
+		Name:        dto.Name,
+		Description: dto.Description,
+		Exercises:   exercises,
+		Groups:      session.Groups,
+	}
+}
+
+// PrescriptionFromSets sums up the working sets of a log as a prescription, nil without any
+func PrescriptionFromSets(sets []exerciseLog.SetLog) *workout.Prescription {
+	working := workingSetsInOrder(sets)
+	if len(working) == 0 {
+		return nil
+	}
+
+	prescription := &workout.Prescription{
+		Sets:    len(working),
+		MinReps: working[0].Reps,
+		MaxReps: working[0].Reps,
+	}
+	if prescription.Sets > maxPrescribedSets {
+		prescription.Sets = maxPrescribedSets
+	}
+	var rest, rests int
+	for _, set := range working {
+		if set.Reps < prescription.MinReps {
+			prescription.MinReps = set.Reps
+		}
+		if set.Reps > prescription.MaxReps {
+			prescription.MaxReps = set.Reps
+		}
+		prescription.TargetWeight = math.Max(prescription.TargetWeight, set.Load())
+		if set.RestSeconds > 0 {
+			rest += set.RestSeconds
+			rests++
+		}
+	}
+	if rests > 0 {
+		prescription.RestSeconds = rest / rests
+	}
+	return prescription
+}
+
+func workingSetsInOrder(sets []exerciseLog.SetLog) []exerciseLog.SetLog {
+	working := make([]exerciseLog.SetLog, 0, len(sets))
+	for _, set := range sets {
+		if set.Type != exerciseLog.WarmUpSet {
+			working = append(working, set)
+		}
+	}
+	sort.SliceStable(working, func(i, j int) bool {
+		return working[i].SetNumber < working[j].SetNumber
+	})
+	return working
+}
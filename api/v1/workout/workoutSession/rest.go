@@ -0,0 +1,74 @@
+package workoutSession
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRestSeconds is the rest after a set with neither a prescribed rest nor a default of the user
+const DefaultRestSeconds = 90
+
+// RestTarget returns how long to rest after a set of an exercise of the session: the rest of its
+// target set, else the user's default for the exercise, else DefaultRestSeconds. exerciseDefault
+// is 0 when the user has none.
+func RestTarget(session *WorkoutSession, exerciseId string, setNumber int, exerciseDefault int) (int, RestSource) {
+	for _, ex := range session.Exercises {
+		if ex.ExerciseID != exerciseId {
+			continue
+		}
+		for _, target := range ex.TargetSets {
+			if target.SetNumber == setNumber && target.RestSeconds > 0 {
+				return target.RestSeconds, PrescriptionRest
+			}
+		}
+	}
+
+	if exerciseDefault > 0 {
+		return exerciseDefault, ExerciseDefaultRest
+	}
+	return DefaultRestSeconds, DefaultRest
+}
+
+// NewRestTimer starts a rest of seconds at now
+func NewRestTimer(exerciseId string, setNumber int, seconds int, source RestSource, now time.Time) *RestTimer {
+	return &RestTimer{
+		ExerciseID:    exerciseId,
+		SetNumber:     setNumber,
+		Source:        source,
+		TargetSeconds: seconds,
+		StartedAt:     now,
+		EndsAt:        now.Add(time.Duration(seconds) * time.Second),
+	}
+}
+
+// Running tells whether the timer has not been skipped
+func (t RestTimer) Running() bool {
+	return t.StoppedAt == nil
+}
+
+// Extend adds seconds to the rest. A rest already over is extended from now.
+func (t *RestTimer) Extend(seconds int, now time.Time) {
+	from := t.EndsAt
+	if now.After(from) {
+		from = now
+	}
+	t.EndsAt = from.Add(time.Duration(seconds) * time.Second)
+	t.TargetSeconds = int(t.EndsAt.Sub(t.StartedAt).Seconds())
+}
+
+// Taken is how long the rest actually lasted: until it was skipped, or else until at
+func (t RestTimer) Taken(at time.Time) int {
+	end := at
+	if t.StoppedAt != nil {
+		end = *t.StoppedAt
+	}
+	if !end.After(t.StartedAt) {
+		return 0
+	}
+	return int(end.Sub(t.StartedAt).Seconds())
+}
+
+// Key identifies the timer as started or last extended, so a signal of an outdated timer can be told apart
+func (t RestTimer) Key(sessionId string) string {
+	return fmt.Sprintf("%s:%d:%d", sessionId, t.StartedAt.Unix(), t.EndsAt.Unix())
+}
@@ -3,9 +3,20 @@ package workoutSession
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/Npwskp/GymsbroBackend/api/v1/function"
+	unitEnums "github.com/Npwskp/GymsbroBackend/api/v1/unit/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/program"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/progression"
 	"github.com/Npwskp/GymsbroBackend/api/v1/workout/workout"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,8 +24,47 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// PlanTracker links completed sessions to the planned dates of the user's workout plans
+type PlanTracker interface {
+	MatchSession(userId string, workoutId string, sessionId string, performedAt time.Time) error
+	UnmatchSession(userId string, sessionId string) error
+}
+
+var (
+	ErrVersionConflict       = errors.New("session was changed on another device")
+	ErrSessionNotInProgress  = errors.New("session is not in progress")
+	ErrSessionNotPaused      = errors.New("session is not paused")
+	ErrSessionEnded          = errors.New("session has already ended")
+	ErrNoRestTimer           = errors.New("no rest timer is running")
+	ErrSetLoggingUnavailable = errors.New("set logging is not available")
+	ErrLiveUnavailable       = errors.New("live session updates are not available")
+	ErrInvalidLinkedLog      = errors.New("linked exercise log is not the user's log of the exercise")
+)
+
+// RestNotifier signals the device that a rest is over, also when the app is closed. A timer is
+// cancelled before it is replaced or extended.
+type RestNotifier interface {
+	ScheduleRestOver(userId string, sessionId string, timer RestTimer) error
+	CancelRestOver(userId string, sessionId string, timer RestTimer) error
+}
+
+// WarmUpPlanner gives the warm-up sets leading to the working weights of a session's exercises,
+// keyed by exercise ID with weights in kg. Users who do not warm up automatically get none.
+type WarmUpPlanner interface {
+	WarmUpTargets(userId string, weights map[string]float64) (map[string][]workout.TargetSet, error)
+}
+
 type WorkoutSessionService struct {
-	DB *mongo.Database
+	DB                 *mongo.Database
+	ProgressionService progression.IProgressionService
+	ProgramService     program.IProgramService
+	PlanTracker        PlanTracker
+	Live               *LiveHub
+	IdleTimeout        time.Duration // DefaultIdleTimeout when zero
+	RestNotifier       RestNotifier
+	ExerciseLogService exerciseLog.IExerciseLogService
+	WorkoutService     workout.IWorkoutService
+	WarmUpPlanner      WarmUpPlanner
 }
 
 type IWorkoutSessionService interface {
@@ -26,23 +76,53 @@ type IWorkoutSessionService interface {
 	GetOnGoingSession(userId string) (*WorkoutSession, error)
 	DeleteSession(id string, userId string) error
 	LogSession(dto *LoggedSessionDto, userId string) (*WorkoutSession, error)
+	ReorderExercises(id string, dto *ReorderExercisesDto, userId string) (*WorkoutSession, error)
+	CompleteExercise(id string, exerciseId string, dto *CompleteExerciseDto, userId string) (*WorkoutSession, error)
+	SubscribeLive(id string, userId string) (*WorkoutSession, <-chan LiveEvent, func(), error)
+	RelayLiveEvent(id string, dto *LiveEventDto, userId string) (*LiveEvent, error)
+	PauseSession(id string, userId string) (*WorkoutSession, error)
+	ResumeSession(id string, userId string) (*WorkoutSession, error)
+	AbandonSession(id string, userId string) (*WorkoutSession, error)
+	StartRest(id string, dto *StartRestDto, userId string) (*WorkoutSession, error)
+	ExtendRest(id string, dto *ExtendRestDto, userId string) (*WorkoutSession, error)
+	SkipRest(id string, userId string) (*WorkoutSession, error)
+	GetRestDefaults(userId string) ([]*RestDefault, error)
+	SetRestDefault(exerciseId string, dto *RestDefaultDto, userId string) (*RestDefault, error)
+	DeleteRestDefault(exerciseId string, userId string) error
+	AddSet(id string, exerciseId string, dto *SessionSetDto, userId string) (*SetChange, error)
+	UpdateSet(id string, exerciseId string, setNumber int, dto *SessionSetDto, userId string) (*SetChange, error)
+	DeleteSet(id string, exerciseId string, setNumber int, version *int64, userId string) (*SetChange, error)
+	GetSessionSummary(id string, userId string) (*SessionSummary, error)
+	SaveAsWorkout(id string, dto *SaveAsWorkoutDto, userId string) (*workout.Workout, error)
 }
 
 func (s *WorkoutSessionService) StartSession(dto *CreateWorkoutSessionDto, userId string) (*WorkoutSession, error) {
 	var exercises []SessionExercise
+	var groups []workout.ExerciseGroup
+	workoutId := dto.WorkoutID
 
-	ongoingSession, err := s.GetOnGoingSession(userId)
-	if err != nil {
-		if err != mongo.ErrNoDocuments {
-			return nil, err
+	if dto.FromSessionID != "" {
+		if dto.WorkoutID != "" {
+			return nil, ErrRepeatSource
 		}
-	}
 
-	if ongoingSession != nil {
-		return nil, errors.New("user already has an ongoing session")
-	}
+		// Repeating a past session copies its exercises with the sets done as targets
+		source, err := s.GetSession(dto.FromSessionID, userId)
+		if err != nil {
+			return nil, err
+		}
+		if source.Status.Ongoing() {
+			return nil, ErrRepeatOngoing
+		}
+		logs, err := s.sessionLogs(context.Background(), source.Exercises, userId)
+		if err != nil {
+			return nil, err
+		}
 
-	if dto.Type == PlannedSession && dto.WorkoutID != "" {
+		exercises = RepeatExercises(source, logs)
+		groups = source.Groups
+		workoutId = source.WorkoutID
+	} else if dto.Type == PlannedSession && dto.WorkoutID != "" {
 		// If starting from a plan, fetch and copy the workout exercises
 		workout := &workout.Workout{}
 		workoutOid, err := primitive.ObjectIDFromHex(dto.WorkoutID)
@@ -58,131 +138,575 @@ func (s *WorkoutSessionService) StartSession(dto *CreateWorkoutSessionDto, userI
 			return nil, err
 		}
 
-		// Convert workout exercises to session exercises
+		// Convert workout exercises to session exercises, keeping their groups
+		sort.SliceStable(workout.Exercises, func(i, j int) bool {
+			return workout.Exercises[i].Order < workout.Exercises[j].Order
+		})
 		for i, ex := range workout.Exercises {
+			targetSets, err := s.expandPrescription(ex, dto.WorkoutID, userId)
+			if err != nil {
+				return nil, err
+			}
 			exercises = append(exercises, SessionExercise{
 				ExerciseID: ex.ExerciseID,
 				Order:      i,
+				GroupID:    ex.GroupID,
+				TargetSets: targetSets,
 			})
 		}
+		groups = workout.Groups
+		s.addWarmUps(exercises, userId)
 	}
 
+	startTime := time.Now()
 	session := &WorkoutSession{
-		UserID:      userId,
-		WorkoutID:   dto.WorkoutID,
-		Type:        dto.Type,
-		StartTime:   time.Now(),
-		Status:      StatusInProgress,
-		TotalVolume: 0,
-		Duration:    0,
-		Exercises:   exercises,
-		Notes:       dto.Notes,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		UserID:       userId,
+		WorkoutID:    workoutId,
+		Type:         dto.Type,
+		RepeatedFrom: dto.FromSessionID,
+		StartTime:    startTime,
+		ResumedAt:    &startTime,
+		Status:       StatusInProgress,
+		TotalVolume:  0,
+		Duration:     0,
+		Exercises:    exercises,
+		Groups:       groups,
+		Notes:        dto.Notes,
+		Version:      1,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// The check for an ongoing session and the insert commit together. The unique index on the
+	// ongoing sessions of a user refuses the insert of a start racing this one.
+	errOngoing := errors.New("user already has an ongoing session")
+	createdSession := &WorkoutSession{}
+	err := function.WithTransaction(s.DB, func(ctx context.Context) error {
+		_, err := s.ongoingSession(ctx, userId)
+		if err == nil {
+			return errOngoing
+		}
+		if err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		result, err := s.DB.Collection("workoutSessions").InsertOne(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		filter := bson.D{{Key: "_id", Value: result.InsertedID}}
+		return s.DB.Collection("workoutSessions").FindOne(ctx, filter).Decode(createdSession)
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, errOngoing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return createdSession, nil
+}
+
+// addWarmUps ramps up to the heaviest target of each exercise. A session starts without them
+// when they cannot be worked out.
+func (s *WorkoutSessionService) addWarmUps(exercises []SessionExercise, userId string) {
+	if s.WarmUpPlanner == nil {
+		return
+	}
+
+	weights := make(map[string]float64)
+	for _, ex := range exercises {
+		for _, target := range ex.TargetSets {
+			weights[ex.ExerciseID] = math.Max(weights[ex.ExerciseID], target.Weight)
+		}
 	}
+	warmUps, err := s.WarmUpPlanner.WarmUpTargets(userId, weights)
+	if err != nil {
+		fmt.Printf("Error planning warm-up sets: %v\n", err)
+		return
+	}
+	for i := range exercises {
+		exercises[i].WarmUpSets = warmUps[exercises[i].ExerciseID]
+	}
+}
 
-	result, err := s.DB.Collection("workoutSessions").InsertOne(context.Background(), session)
+func (s *WorkoutSessionService) EndSession(id string, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
 	if err != nil {
 		return nil, err
 	}
+	if !session.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
 
-	filter := bson.D{{Key: "_id", Value: result.InsertedID}}
-	createdSession := &WorkoutSession{}
-	err = s.DB.Collection("workoutSessions").FindOne(context.Background(), filter).Decode(createdSession)
+	return s.finish(session, StatusCompleted, time.Now(), false)
+}
+
+// AbandonSession ends an ongoing session without counting it as done. The sets already logged
+// keep counting towards its volume.
+func (s *WorkoutSessionService) AbandonSession(id string, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
 	if err != nil {
 		return nil, err
 	}
+	if !session.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
 
-	return createdSession, nil
+	return s.finish(session, StatusAbandoned, time.Now(), false)
 }
 
-func (s *WorkoutSessionService) EndSession(id string, userId string) (*WorkoutSession, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+// finish ends an ongoing session as of endTime with the given status. Its duration is the time
+// it was active and its volume that of the exercise logs linked to it, read in the same
+// transaction as the session is ended.
+func (s *WorkoutSessionService) finish(session *WorkoutSession, status SessionStatus, endTime time.Time, autoClosed bool) (*WorkoutSession, error) {
+	return s.applyStatus(session, status, endTime, autoClosed)
+}
+
+// applyStatus moves a session to another status as of now in one transaction, then lets the
+// devices, the plans and the progression know
+func (s *WorkoutSessionService) applyStatus(session *WorkoutSession, status SessionStatus, now time.Time, autoClosed bool) (*WorkoutSession, error) {
+	var logs map[string]*exerciseLog.ExerciseLog
+	var result *WorkoutSession
+	err := function.WithTransaction(s.DB, func(ctx context.Context) error {
+		set, unset, statusLogs, err := s.statusUpdate(ctx, session, status, now, autoClosed)
+		if err != nil {
+			return err
+		}
+		logs = statusLogs
+
+		set = append(set, bson.E{Key: "updated_at", Value: time.Now()})
+		result, err = s.transition(ctx, session, versionedUpdate(set, unset))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	s.statusApplied(session, result, logs)
+	return result, nil
+}
+
+// statusUpdate returns the fields to set and unset to move a session to another status as of
+// now. Ending a session reads the exercise logs linked to it with ctx and returns them.
+func (s *WorkoutSessionService) statusUpdate(ctx context.Context, session *WorkoutSession, status SessionStatus, now time.Time, autoClosed bool) (bson.D, bson.D, map[string]*exerciseLog.ExerciseLog, error) {
+	switch status {
+	case StatusPaused:
+		if session.Status != StatusInProgress {
+			return nil, nil, nil, ErrSessionNotInProgress
+		}
+		return bson.D{
+			{Key: "status", Value: StatusPaused},
+			{Key: "active_time", Value: ActiveSeconds(session, now)},
+			{Key: "paused_at", Value: now},
+		}, bson.D{{Key: "resumed_at", Value: ""}}, nil, nil
+
+	case StatusInProgress:
+		if session.Status != StatusPaused {
+			return nil, nil, nil, ErrSessionNotPaused
+		}
+		return bson.D{
+			{Key: "status", Value: StatusInProgress},
+			{Key: "resumed_at", Value: now},
+		}, bson.D{{Key: "paused_at", Value: ""}}, nil, nil
+
+	case StatusCompleted, StatusAbandoned:
+		if !session.Status.Ongoing() {
+			return nil, nil, nil, ErrSessionEnded
+		}
+		logs, err := s.sessionLogs(ctx, session.Exercises, session.UserID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		totalVolume, err := s.sessionVolume(ctx, session.Exercises, session.UserID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		duration := ActiveSeconds(session, now)
+		return bson.D{
+			{Key: "end_time", Value: now},
+			{Key: "status", Value: status},
+			{Key: "duration", Value: duration},
+			{Key: "active_time", Value: duration},
+			{Key: "total_volume", Value: totalVolume},
+			{Key: "group_summaries", Value: SummariseGroups(session.Exercises, session.Groups, logs)},
+			{Key: "auto_closed", Value: autoClosed},
+		}, bson.D{
+			{Key: "resumed_at", Value: ""},
+			{Key: "paused_at", Value: ""},
+			{Key: "rest_timer", Value: ""},
+		}, logs, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unknown session status %s", status)
+}
+
+// statusApplied follows up on a status change once it is written. An ended session has its rest
+// timer cancelled and, when completed, moves its progression and plan on.
+func (s *WorkoutSessionService) statusApplied(before *WorkoutSession, result *WorkoutSession, logs map[string]*exerciseLog.ExerciseLog) {
+	eventType := LiveSessionUpdated
+	switch result.Status {
+	case StatusPaused:
+		eventType = LiveSessionPaused
+	case StatusInProgress:
+		eventType = LiveSessionResumed
+	case StatusCompleted:
+		eventType = LiveSessionEnded
+		s.evaluateProgression(result, logs)
+		s.trackPlan(result)
+	case StatusAbandoned:
+		eventType = LiveSessionAbandoned
+	}
+	if !result.Status.Ongoing() && before.RestTimer != nil && before.RestTimer.Running() {
+		s.cancelRestOver(before, *before.RestTimer)
+	}
+	s.publish(LiveEvent{Type: eventType, SessionID: result.ID.Hex(), Version: result.Version, Data: result})
+}
+
+// versionedUpdate sets and unsets fields of a session and moves its version on
+func versionedUpdate(set bson.D, unset bson.D) bson.D {
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+	return append(update, bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}})
+}
+
+// sessionLogs returns the exercise logs linked to the exercises by ID, read in one query
+func (s *WorkoutSessionService) sessionLogs(ctx context.Context, exercises []SessionExercise, userId string) (map[string]*exerciseLog.ExerciseLog, error) {
+	logs := make(map[string]*exerciseLog.ExerciseLog)
+	ids, err := linkedLogIds(exercises)
+	if err != nil || len(ids) == 0 {
+		return logs, err
+	}
+
 	filter := bson.D{
-		{Key: "_id", Value: oid},
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
 		{Key: "userid", Value: userId},
 	}
+	cursor, err := s.DB.Collection("exerciseLogs").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
-	session := &WorkoutSession{}
-	if err := s.DB.Collection("workoutSessions").FindOne(context.Background(), filter).Decode(session); err != nil {
+	found := make([]*exerciseLog.ExerciseLog, 0, len(ids))
+	if err := cursor.All(ctx, &found); err != nil {
 		return nil, err
 	}
+	for _, log := range found {
+		logs[log.ID.Hex()] = log
+	}
+	return logs, nil
+}
 
-	if session.Status != StatusInProgress {
-		return nil, errors.New("session is not in progress")
+// sessionVolume sums the volume of the exercise logs linked to the exercises in one aggregation.
+// A log linked twice counts once.
+func (s *WorkoutSessionService) sessionVolume(ctx context.Context, exercises []SessionExercise, userId string) (float64, error) {
+	ids, err := linkedLogIds(exercises)
+	if err != nil || len(ids) == 0 {
+		return 0, err
 	}
 
-	endTime := time.Now()
-	duration := int(endTime.Sub(session.StartTime).Seconds())
+	pipeline := []bson.D{
+		{{Key: "$match", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+			{Key: "userid", Value: userId},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "total_volume", Value: bson.D{{Key: "$sum", Value: "$total_volume"}}},
+		}}},
+	}
 
-	// Calculate total volume from exercise logs
-	var totalVolume float64
-	for _, exercise := range session.Exercises {
-		if exercise.ExerciseLogID != "" {
-			logOid, err := primitive.ObjectIDFromHex(exercise.ExerciseLogID)
-			if err != nil {
-				continue
-			}
+	cursor, err := s.DB.Collection("exerciseLogs").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
 
-			log := &exerciseLog.ExerciseLog{}
-			err = s.DB.Collection("exerciseLogs").FindOne(context.Background(), bson.D{
-				{Key: "_id", Value: logOid},
-			}).Decode(log)
-			if err != nil {
-				continue
-			}
+	var results []struct {
+		TotalVolume float64 `bson:"total_volume"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].TotalVolume, nil
+}
 
-			totalVolume += log.TotalVolume
+// linkedLogIds returns the IDs of the exercise logs linked to the exercises
+func linkedLogIds(exercises []SessionExercise) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(exercises))
+	for _, ex := range exercises {
+		if ex.ExerciseLogID == "" {
+			continue
+		}
+		oid, err := primitive.ObjectIDFromHex(ex.ExerciseLogID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid id %s of exercise %s", ErrInvalidLinkedLog, ex.ExerciseLogID, ex.ExerciseID)
 		}
+		ids = append(ids, oid)
 	}
+	return ids, nil
+}
 
-	update := bson.D{{Key: "$set", Value: bson.D{
-		{Key: "end_time", Value: endTime},
-		{Key: "status", Value: StatusCompleted},
-		{Key: "duration", Value: duration},
-		{Key: "total_volume", Value: totalVolume},
-		{Key: "updated_at", Value: time.Now()},
-	}}}
+// checkLinkedLogs makes sure every exercise log linked to the exercises exists and is the user's
+// log of that exercise, as deleting the session deletes the logs linked to it
+func (s *WorkoutSessionService) checkLinkedLogs(ctx context.Context, exercises []SessionExercise, userId string) error {
+	logs, err := s.sessionLogs(ctx, exercises, userId)
+	if err != nil {
+		return err
+	}
 
-	after := options.After
-	opts := options.FindOneAndUpdate().SetReturnDocument(after)
+	for _, ex := range exercises {
+		if ex.ExerciseLogID == "" {
+			continue
+		}
+		if log, ok := logs[ex.ExerciseLogID]; !ok || log.ExerciseID != ex.ExerciseID {
+			return fmt.Errorf("%w: log %s of exercise %s", ErrInvalidLinkedLog, ex.ExerciseLogID, ex.ExerciseID)
+		}
+	}
+	return nil
+}
 
-	result := &WorkoutSession{}
-	err = s.DB.Collection("workoutSessions").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(result)
+// PauseSession stops the clock of a session in progress
+func (s *WorkoutSessionService) PauseSession(id string, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	return result, nil
+	return s.pause(session)
 }
 
-func (s *WorkoutSessionService) UpdateSession(id string, dto *UpdateWorkoutSessionDto, userId string) (*WorkoutSession, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+func (s *WorkoutSessionService) pause(session *WorkoutSession) (*WorkoutSession, error) {
+	return s.applyStatus(session, StatusPaused, time.Now(), false)
+}
+
+// ResumeSession starts the clock of a paused session again
+func (s *WorkoutSessionService) ResumeSession(id string, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.resume(session)
+}
+
+func (s *WorkoutSessionService) resume(session *WorkoutSession) (*WorkoutSession, error) {
+	return s.applyStatus(session, StatusInProgress, time.Now(), false)
+}
+
+// transition applies a status change to a session as it was read. Any change made in between,
+// another device pausing or the sweeper ending it, makes it a version conflict.
+func (s *WorkoutSessionService) transition(ctx context.Context, session *WorkoutSession, update bson.D) (*WorkoutSession, error) {
+	filter := withVersion(bson.D{
+		{Key: "_id", Value: session.ID},
+		{Key: "userid", Value: session.UserID},
+		{Key: "status", Value: session.Status},
+	}, &session.Version)
+
+	return s.updateVersioned(ctx, filter, update, &session.Version)
+}
+
+// SweepIdleSessions ends the ongoing sessions left without changes for longer than the idle
+// timeout, as of their last change. Sessions with logged exercises complete, the others are
+// abandoned. It returns how many sessions it ended.
+func (s *WorkoutSessionService) SweepIdleSessions(now time.Time) (int, error) {
+	timeout := s.IdleTimeout
+	if timeout <= 0 {
+		timeout = DefaultIdleTimeout
+	}
+
+	filter := bson.D{
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{StatusInProgress, StatusPaused}}}},
+		{Key: "updated_at", Value: bson.D{{Key: "$lt", Value: now.Add(-timeout)}}},
+	}
+	cursor, err := s.DB.Collection("workoutSessions").Find(context.Background(), filter)
+	if err != nil {
+		return 0, err
+	}
+
+	sessions := make([]*WorkoutSession, 0)
+	if err := cursor.All(context.Background(), &sessions); err != nil {
+		return 0, err
+	}
+
+	ended := 0
+	for _, session := range sessions {
+		if !IsIdle(session, now, timeout) {
+			continue
+		}
+		if _, err := s.finish(session, TimeoutStatus(session), LastActivity(session), true); err != nil {
+			// A session changed since it was read is no longer idle
+			if !errors.Is(err, ErrVersionConflict) {
+				fmt.Printf("Error closing idle session %s: %v\n", session.ID.Hex(), err)
+			}
+			continue
+		}
+		ended++
+	}
+
+	return ended, nil
+}
+
+// TouchSession marks the user's ongoing session holding the exercise as changed at at, when a
+// log of it is written outside of the session. The idle sweeper goes by that last change.
+func (s *WorkoutSessionService) TouchSession(userId string, exerciseId string, at time.Time) error {
 	filter := bson.D{
-		{Key: "_id", Value: oid},
 		{Key: "userid", Value: userId},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{StatusInProgress, StatusPaused}}}},
+		{Key: "exercises.exerciseid", Value: exerciseId},
 	}
+	update := bson.D{{Key: "$max", Value: bson.D{{Key: "updated_at", Value: at}}}}
 
-	update := bson.D{{Key: "$set", Value: bson.D{
-		{Key: "status", Value: dto.Status},
-		{Key: "exercises", Value: dto.Exercises},
-		{Key: "notes", Value: dto.Notes},
-		{Key: "updatedAt", Value: time.Now()},
-	}}}
+	_, err := s.DB.Collection("workoutSessions").UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+// trackPlan marks the planned date a completed session of a workout was done for
+func (s *WorkoutSessionService) trackPlan(session *WorkoutSession) {
+	if s.PlanTracker == nil || session.WorkoutID == "" || session.Status != StatusCompleted {
+		return
+	}
+
+	if err := s.PlanTracker.MatchSession(session.UserID, session.WorkoutID, session.ID.Hex(), session.StartTime); err != nil {
+		fmt.Printf("Error tracking plan: %v\n", err)
+	}
+}
+
+// evaluateProgression moves the targets of a finished planned session on. The session is already
+// completed at this point, so failures are only reported.
+func (s *WorkoutSessionService) evaluateProgression(session *WorkoutSession, logs map[string]*exerciseLog.ExerciseLog) {
+	if s.ProgressionService == nil || session.WorkoutID == "" {
+		return
+	}
+
+	// A program week scaling the workout, like a deload, is not held to the full target and does
+	// not move it
+	if s.ProgramService != nil {
+		volume, intensity, err := s.ProgramService.GetWeekModifiers(session.UserID, session.WorkoutID, session.StartTime)
+		if err != nil {
+			fmt.Printf("Error evaluating progression: %v\n", err)
+			return
+		}
+		if volume != 1 || intensity != 1 {
+			return
+		}
+	}
+
+	completedLogs := make([]*exerciseLog.ExerciseLog, 0, len(logs))
+	for _, log := range logs {
+		completedLogs = append(completedLogs, log)
+	}
+
+	if _, err := s.ProgressionService.EvaluateSession(session.UserID, session.WorkoutID, session.ID.Hex(), completedLogs); err != nil {
+		fmt.Printf("Error evaluating progression: %v\n", err)
+	}
+}
+
+func (s *WorkoutSessionService) UpdateSession(id string, dto *UpdateWorkoutSessionDto, userId string) (*WorkoutSession, error) {
+	before, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	// A new status is written with the rest of the change, through the same update as the status
+	// endpoints make. Ended sessions stay ended.
+	statusChange := dto.Status != "" && dto.Status != before.Status
+	if statusChange && !before.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
+	if _, err := linkedLogIds(dto.Exercises); err != nil {
+		return nil, err
+	}
+
+	filter := withVersion(bson.D{
+		{Key: "_id", Value: before.ID},
+		{Key: "userid", Value: userId},
+	}, dto.Version)
+	if statusChange {
+		filter = append(filter, bson.E{Key: "status", Value: before.Status})
+	}
+
+	changed := *before
+	changed.Exercises = dto.Exercises
+	changed.Groups = dto.Groups
+
+	var logs map[string]*exerciseLog.ExerciseLog
+	var result *WorkoutSession
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		if err := s.checkLinkedLogs(ctx, dto.Exercises, userId); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		set := bson.D{
+			{Key: "exercises", Value: dto.Exercises},
+			{Key: "groups", Value: dto.Groups},
+			{Key: "notes", Value: dto.Notes},
+			{Key: "updated_at", Value: now},
+		}
+		// Ending the session sums the volume itself
+		if !statusChange || dto.Status.Ongoing() {
+			totalVolume, err := s.sessionVolume(ctx, dto.Exercises, userId)
+			if err != nil {
+				return err
+			}
+			set = append(set, bson.E{Key: "total_volume", Value: totalVolume})
+		}
+
+		var unset bson.D
+		if statusChange {
+			statusSet, statusUnset, statusLogs, err := s.statusUpdate(ctx, &changed, dto.Status, now, false)
+			if err != nil {
+				return err
+			}
+			set = append(set, statusSet...)
+			unset = statusUnset
+			logs = statusLogs
+		}
+
+		var err error
+		result, err = s.updateVersioned(ctx, filter, versionedUpdate(set, unset), dto.Version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ChangeEvents(before, result)...)
+	if statusChange {
+		s.statusApplied(before, result, logs)
+	} else {
+		s.publish(LiveEvent{Type: LiveSessionUpdated, SessionID: id, Version: result.Version, Data: result})
+	}
+	return result, nil
+}
+
+// withVersion adds a version check to a filter. Sessions created before versions count as 0.
+func withVersion(filter bson.D, version *int64) bson.D {
+	if version == nil {
+		return filter
+	}
+	if *version == 0 {
+		return append(filter, bson.E{Key: "version", Value: bson.M{"$in": bson.A{0, nil}}})
+	}
+	return append(filter, bson.E{Key: "version", Value: *version})
+}
 
-	after := options.After
-	opts := options.FindOneAndUpdate().SetReturnDocument(after)
+// updateVersioned applies an update to the session the filter matches. When the filter checks a
+// version and nothing matches, the session moved on and ErrVersionConflict is returned.
+func (s *WorkoutSessionService) updateVersioned(ctx context.Context, filter bson.D, update bson.D, version *int64) (*WorkoutSession, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
 	result := &WorkoutSession{}
-	err = s.DB.Collection("workoutSessions").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(result)
+	err := s.DB.Collection("workoutSessions").FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	if err == mongo.ErrNoDocuments && version != nil {
+		return nil, ErrVersionConflict
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +714,16 @@ func (s *WorkoutSessionService) UpdateSession(id string, dto *UpdateWorkoutSessi
 	return result, nil
 }
 
+// publish sends events to the devices following the session, when live updates are on
+func (s *WorkoutSessionService) publish(events ...LiveEvent) {
+	if s.Live == nil {
+		return
+	}
+	for _, event := range events {
+		s.Live.Publish(event)
+	}
+}
+
 func (s *WorkoutSessionService) GetSession(id string, userId string) (*WorkoutSession, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -211,7 +745,7 @@ func (s *WorkoutSessionService) GetSession(id string, userId string) (*WorkoutSe
 
 func (s *WorkoutSessionService) GetUserSessions(userId string) ([]*WorkoutSession, error) {
 	filter := bson.D{{Key: "userid", Value: userId}}
-	opts := options.Find().SetSort(bson.D{{Key: "startTime", Value: -1}})
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: -1}})
 
 	cursor, err := s.DB.Collection("workoutSessions").Find(context.Background(), filter, opts)
 	if err != nil {
@@ -227,11 +761,18 @@ func (s *WorkoutSessionService) GetUserSessions(userId string) ([]*WorkoutSessio
 }
 
 func (s *WorkoutSessionService) GetOnGoingSession(userId string) (*WorkoutSession, error) {
-	filter := bson.D{{Key: "userid", Value: userId}, {Key: "status", Value: StatusInProgress}}
-	opts := options.FindOne().SetSort(bson.D{{Key: "startTime", Value: -1}})
+	return s.ongoingSession(context.Background(), userId)
+}
+
+func (s *WorkoutSessionService) ongoingSession(ctx context.Context, userId string) (*WorkoutSession, error) {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{StatusInProgress, StatusPaused}}}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "start_time", Value: -1}})
 
 	session := &WorkoutSession{}
-	err := s.DB.Collection("workoutSessions").FindOne(context.Background(), filter, opts).Decode(session)
+	err := s.DB.Collection("workoutSessions").FindOne(ctx, filter, opts).Decode(session)
 	if err != nil {
 		return nil, err
 	}
@@ -239,6 +780,9 @@ func (s *WorkoutSessionService) GetOnGoingSession(userId string) (*WorkoutSessio
 	return session, nil
 }
 
+// DeleteSession deletes a session with the exercise logs linked to it. The logs hold the sets done
+// in the session, so left behind they would count in history, stats and records without it. A log
+// another session links too is kept for that session.
 func (s *WorkoutSessionService) DeleteSession(id string, userId string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -250,73 +794,1155 @@ func (s *WorkoutSessionService) DeleteSession(id string, userId string) error {
 		{Key: "userid", Value: userId},
 	}
 
-	result, err := s.DB.Collection("workoutSessions").DeleteOne(context.Background(), filter)
+	deleted := &WorkoutSession{}
+	var deletedLogs []*exerciseLog.ExerciseLog
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		if err := s.DB.Collection("workoutSessions").FindOneAndDelete(ctx, filter).Decode(deleted); err != nil {
+			return err
+		}
+
+		var err error
+		deletedLogs, err = s.deleteSessionLogs(ctx, deleted)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	s.publish(LiveEvent{Type: LiveSessionDeleted, SessionID: id, Version: deleted.Version + 1})
 
-	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+	if s.PlanTracker != nil {
+		if err := s.PlanTracker.UnmatchSession(userId, id); err != nil {
+			fmt.Printf("Error tracking plan: %v\n", err)
+		}
 	}
+	s.refreshRecords(userId, deletedLogs)
 
 	return nil
 }
 
-func (s *WorkoutSessionService) LogSession(dto *LoggedSessionDto, userId string) (*WorkoutSession, error) {
-	session := &WorkoutSession{
-		UserID:    userId,
-		WorkoutID: dto.WorkoutID,
-		Type:      LoggedSession,
-		StartTime: dto.StartTime,
-		EndTime:   dto.EndTime,
-		Status:    dto.Status,
-		Exercises: dto.Exercises,
-		Notes:     dto.Notes,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+// deleteSessionLogs deletes the logs linked to a deleted session that no other session links
+func (s *WorkoutSessionService) deleteSessionLogs(ctx context.Context, session *WorkoutSession) ([]*exerciseLog.ExerciseLog, error) {
+	logs, err := s.sessionLogs(ctx, session.Exercises, session.UserID)
+	if err != nil || len(logs) == 0 {
+		return nil, err
 	}
 
-	// Calculate duration in minutes
-	duration := int(dto.EndTime.Sub(dto.StartTime).Minutes())
-	if duration < 0 {
-		return nil, errors.New("end time must be after start time")
+	linked := make([]string, 0, len(logs))
+	for logId := range logs {
+		linked = append(linked, logId)
+	}
+	cursor, err := s.DB.Collection("workoutSessions").Find(ctx, bson.D{
+		{Key: "userid", Value: session.UserID},
+		{Key: "exercises.exerciselogid", Value: bson.D{{Key: "$in", Value: linked}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	others := make([]*WorkoutSession, 0)
+	if err := cursor.All(ctx, &others); err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		for _, ex := range other.Exercises {
+			delete(logs, ex.ExerciseLogID)
+		}
+	}
+	if len(logs) == 0 {
+		return nil, nil
 	}
-	session.Duration = duration
 
-	// Calculate total volume if exercises are provided
-	var totalVolume float64
-	for _, exercise := range dto.Exercises {
-		// Fetch exercise log to get volume
-		if exercise.ExerciseLogID != "" {
-			logOid, err := primitive.ObjectIDFromHex(exercise.ExerciseLogID)
-			if err != nil {
-				return nil, err
-			}
+	ids := make([]primitive.ObjectID, 0, len(logs))
+	deleted := make([]*exerciseLog.ExerciseLog, 0, len(logs))
+	for _, log := range logs {
+		ids = append(ids, log.ID)
+		deleted = append(deleted, log)
+	}
+	_, err = s.DB.Collection("exerciseLogs").DeleteMany(ctx, bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: "userid", Value: session.UserID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
 
-			exerciseLog := &exerciseLog.ExerciseLog{}
-			err = s.DB.Collection("exerciseLog").FindOne(context.Background(), bson.D{
-				{Key: "_id", Value: logOid},
-				{Key: "userid", Value: userId},
-			}).Decode(exerciseLog)
-			if err != nil {
+// refreshRecords brings the personal records of the exercises of deleted logs up to date
+func (s *WorkoutSessionService) refreshRecords(userId string, logs []*exerciseLog.ExerciseLog) {
+	if s.ExerciseLogService == nil {
+		return
+	}
+
+	refreshed := make(map[string]bool)
+	for _, log := range logs {
+		if refreshed[log.ExerciseID] {
+			continue
+		}
+		refreshed[log.ExerciseID] = true
+		s.ExerciseLogService.RefreshRecords(userId, log.ExerciseID, "")
+	}
+}
+
+// MigrateLoggedDurations moves the durations of logged and imported sessions, which were kept in
+// minutes, to seconds like those of tracked sessions. A logged session lasts from its start to its
+// end, so its duration is worked out again from them and sessions already in seconds match.
+func (s *WorkoutSessionService) MigrateLoggedDurations() (int, error) {
+	seconds := bson.D{{Key: "$toInt", Value: bson.D{{Key: "$floor", Value: bson.D{{Key: "$divide", Value: bson.A{
+		bson.D{{Key: "$subtract", Value: bson.A{"$end_time", "$start_time"}}}, 1000,
+	}}}}}}}
+	filter := bson.D{
+		{Key: "type", Value: LoggedSession},
+		{Key: "$expr", Value: bson.D{{Key: "$ne", Value: bson.A{"$duration", seconds}}}},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "duration", Value: seconds}}}}}
+
+	result, err := s.DB.Collection("workoutSessions").UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// LogSession records a session done without the app. The exercise logs it links must exist, they
+// are read in the same transaction as the session is inserted.
+func (s *WorkoutSessionService) LogSession(dto *LoggedSessionDto, userId string) (*WorkoutSession, error) {
+	session := &WorkoutSession{
+		UserID:    userId,
+		WorkoutID: dto.WorkoutID,
+		Type:      LoggedSession,
+		StartTime: dto.StartTime,
+		EndTime:   dto.EndTime,
+		Status:    dto.Status,
+		Exercises: dto.Exercises,
+		Groups:    dto.Groups,
+		Notes:     dto.Notes,
+		Version:   1,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	// Calculate duration in seconds
+	duration := int(dto.EndTime.Sub(dto.StartTime).Seconds())
+	if duration < 0 {
+		return nil, errors.New("end time must be after start time")
+	}
+	session.Duration = duration
+
+	createdSession := &WorkoutSession{}
+	err := function.WithTransaction(s.DB, func(ctx context.Context) error {
+		logs, err := s.sessionLogs(ctx, dto.Exercises, userId)
+		if err != nil {
+			return err
+		}
+		for _, ex := range dto.Exercises {
+			if ex.ExerciseLogID != "" && logs[ex.ExerciseLogID] == nil {
+				return fmt.Errorf("exercise log %s not found", ex.ExerciseLogID)
+			}
+		}
+
+		session.TotalVolume, err = s.sessionVolume(ctx, dto.Exercises, userId)
+		if err != nil {
+			return err
+		}
+		session.GroupSummaries = SummariseGroups(dto.Exercises, dto.Groups, logs)
+
+		result, err := s.DB.Collection("workoutSessions").InsertOne(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		return s.DB.Collection("workoutSessions").FindOne(ctx, bson.D{{Key: "_id", Value: result.InsertedID}}).Decode(createdSession)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.trackPlan(createdSession)
+
+	return createdSession, nil
+}
+
+func (s *WorkoutSessionService) ReorderExercises(id string, dto *ReorderExercisesDto, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	exercises, err := ReorderSessionExercises(session.Exercises, dto.Exercises)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := withVersion(bson.D{
+		{Key: "_id", Value: session.ID},
+		{Key: "userid", Value: userId},
+	}, dto.Version)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "exercises", Value: exercises},
+		{Key: "updated_at", Value: time.Now()},
+	}}, {Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}}}
+
+	result, err := s.updateVersioned(context.Background(), filter, update, dto.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(
+		LiveEvent{Type: LiveExercisesReordered, SessionID: id, Version: result.Version, Data: result.Exercises},
+		LiveEvent{Type: LiveSessionUpdated, SessionID: id, Version: result.Version, Data: result},
+	)
+
+	return result, nil
+}
+
+// CompleteExercise links the exercise log holding the sets done for an exercise of an ongoing session
+func (s *WorkoutSessionService) CompleteExercise(id string, exerciseId string, dto *CompleteExerciseDto, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
+	if !hasExercise(session.Exercises, exerciseId) {
+		return nil, fmt.Errorf("exercise %s is not part of the session", exerciseId)
+	}
+
+	// The log is checked and linked, with the volume it adds, in one transaction
+	var result *WorkoutSession
+	err = function.WithTransaction(s.DB, func(ctx context.Context) error {
+		if err := s.checkLog(ctx, dto.ExerciseLogID, exerciseId, userId); err != nil {
+			return err
+		}
+		totalVolume, err := s.sessionVolume(ctx, linkLog(session.Exercises, exerciseId, dto.ExerciseLogID), userId)
+		if err != nil {
+			return err
+		}
+
+		filter := withVersion(bson.D{
+			{Key: "_id", Value: session.ID},
+			{Key: "userid", Value: userId},
+			{Key: "status", Value: session.Status},
+			{Key: "exercises.exerciseid", Value: exerciseId},
+		}, dto.Version)
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "exercises.$.exerciselogid", Value: dto.ExerciseLogID},
+			{Key: "total_volume", Value: totalVolume},
+			{Key: "updated_at", Value: time.Now()},
+		}}, {Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}}}
+
+		result, err = s.updateVersioned(ctx, filter, update, dto.Version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ChangeEvents(session, result)...)
+	s.publish(LiveEvent{Type: LiveSessionUpdated, SessionID: id, Version: result.Version, Data: result})
+
+	return result, nil
+}
+
+// checkLog makes sure a log about to be linked to an exercise is a log of the user for it
+func (s *WorkoutSessionService) checkLog(ctx context.Context, logId string, exerciseId string, userId string) error {
+	logOid, err := primitive.ObjectIDFromHex(logId)
+	if err != nil {
+		return err
+	}
+
+	log := &exerciseLog.ExerciseLog{}
+	err = s.DB.Collection("exerciseLogs").FindOne(ctx, bson.D{
+		{Key: "_id", Value: logOid},
+		{Key: "userid", Value: userId},
+	}).Decode(log)
+	if err != nil {
+		return err
+	}
+	if log.ExerciseID != exerciseId {
+		return fmt.Errorf("exercise log %s is not a log of exercise %s", logId, exerciseId)
+	}
+	return nil
+}
+
+func hasExercise(exercises []SessionExercise, exerciseId string) bool {
+	for _, ex := range exercises {
+		if ex.ExerciseID == exerciseId {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscribeLive follows the events of an ongoing session. It returns the session as of
+// subscribing; events already reflected in it may still follow and carry no newer version.
+func (s *WorkoutSessionService) SubscribeLive(id string, userId string) (*WorkoutSession, <-chan LiveEvent, func(), error) {
+	if s.Live == nil {
+		return nil, nil, nil, ErrLiveUnavailable
+	}
+
+	// Subscribing first leaves no gap between the snapshot and the events
+	events, unsubscribe := s.Live.Subscribe(id)
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		unsubscribe()
+		return nil, nil, nil, err
+	}
+	if !session.Status.Ongoing() {
+		unsubscribe()
+		return nil, nil, nil, ErrSessionEnded
+	}
+
+	return session, events, unsubscribe, nil
+}
+
+// RelayLiveEvent passes an event of one device, such as a rest timer, on to the others
+func (s *WorkoutSessionService) RelayLiveEvent(id string, dto *LiveEventDto, userId string) (*LiveEvent, error) {
+	if s.Live == nil {
+		return nil, ErrLiveUnavailable
+	}
+
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
+
+	event := LiveEvent{Type: dto.Type, SessionID: id, Version: session.Version, Data: dto.Data, At: time.Now()}
+	s.Live.Publish(event)
+	return &event, nil
+}
+
+// StartRest starts the rest after a set of the session, replacing any rest before it. The device
+// is signalled when the rest is over.
+func (s *WorkoutSessionService) StartRest(id string, dto *StartRestDto, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
+	if !hasExercise(session.Exercises, dto.ExerciseID) {
+		return nil, fmt.Errorf("exercise %s is not part of the session", dto.ExerciseID)
+	}
+
+	seconds, source := dto.Seconds, CustomRest
+	if seconds == 0 {
+		exerciseDefault, err := s.restDefaultSeconds(dto.ExerciseID, userId)
+		if err != nil {
+			return nil, err
+		}
+		seconds, source = RestTarget(session, dto.ExerciseID, dto.SetNumber, exerciseDefault)
+	}
+
+	timer := NewRestTimer(dto.ExerciseID, dto.SetNumber, seconds, source, time.Now())
+	result, err := s.saveRestTimer(session, timer)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RestTimer != nil && session.RestTimer.Running() {
+		s.cancelRestOver(session, *session.RestTimer)
+	}
+	s.scheduleRestOver(result, *timer)
+	s.publish(LiveEvent{Type: LiveRestTimerStarted, SessionID: id, Version: result.Version, Data: timer})
+
+	return result, nil
+}
+
+// ExtendRest adds time to the running rest
+func (s *WorkoutSessionService) ExtendRest(id string, dto *ExtendRestDto, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if session.RestTimer == nil || !session.RestTimer.Running() {
+		return nil, ErrNoRestTimer
+	}
+
+	timer := *session.RestTimer
+	timer.Extend(dto.Seconds, time.Now())
+	result, err := s.saveRestTimer(session, &timer)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cancelRestOver(session, *session.RestTimer)
+	s.scheduleRestOver(result, timer)
+	s.publish(LiveEvent{Type: LiveRestTimerExtended, SessionID: id, Version: result.Version, Data: timer})
+
+	return result, nil
+}
+
+// SkipRest stops the running rest. The rest taken until now goes to the next set logged.
+func (s *WorkoutSessionService) SkipRest(id string, userId string) (*WorkoutSession, error) {
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if session.RestTimer == nil || !session.RestTimer.Running() {
+		return nil, ErrNoRestTimer
+	}
+
+	timer := *session.RestTimer
+	now := time.Now()
+	timer.StoppedAt = &now
+	result, err := s.saveRestTimer(session, &timer)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cancelRestOver(session, *session.RestTimer)
+	s.publish(LiveEvent{Type: LiveRestTimerStopped, SessionID: id, Version: result.Version, Data: timer})
+
+	return result, nil
+}
+
+// PendingRest returns the rest of the user's ongoing session as a set of the exercise logged at
+// would take it. It is nil when the session has no rest after a set of that exercise.
+func (s *WorkoutSessionService) PendingRest(userId string, exerciseId string, at time.Time) (*exerciseLog.PendingRest, error) {
+	session, err := s.GetOnGoingSession(userId)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RestTimer == nil || session.RestTimer.ExerciseID != exerciseId {
+		return nil, nil
+	}
+
+	return &exerciseLog.PendingRest{
+		ExerciseID: exerciseId,
+		StartedAt:  session.RestTimer.StartedAt,
+		Seconds:    session.RestTimer.Taken(at),
+	}, nil
+}
+
+// TakeRest clears the rest of the user's ongoing session once the set it went to is written. A
+// rest started since is left alone.
+func (s *WorkoutSessionService) TakeRest(userId string, rest *exerciseLog.PendingRest) error {
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{StatusInProgress, StatusPaused}}}},
+		{Key: "rest_timer.exerciseid", Value: rest.ExerciseID},
+		{Key: "rest_timer.started_at", Value: rest.StartedAt},
+	}
+	update := bson.D{
+		{Key: "$unset", Value: bson.D{{Key: "rest_timer", Value: ""}}},
+		{Key: "$currentDate", Value: bson.D{{Key: "updated_at", Value: true}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	// The session as it was before still holds the timer
+	session := &WorkoutSession{}
+	err := s.DB.Collection("workoutSessions").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(session)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if timer := *session.RestTimer; timer.Running() {
+		s.cancelRestOver(session, timer)
+		s.publish(LiveEvent{Type: LiveRestTimerStopped, SessionID: session.ID.Hex(), Version: session.Version + 1, Data: timer})
+	}
+	return nil
+}
+
+// saveRestTimer sets the rest timer of the session as it was read, or clears it when timer is nil
+func (s *WorkoutSessionService) saveRestTimer(session *WorkoutSession, timer *RestTimer) (*WorkoutSession, error) {
+	change := bson.E{Key: "$set", Value: bson.D{{Key: "rest_timer", Value: timer}}}
+	if timer == nil {
+		change = bson.E{Key: "$unset", Value: bson.D{{Key: "rest_timer", Value: ""}}}
+	}
+	update := bson.D{
+		change,
+		{Key: "$currentDate", Value: bson.D{{Key: "updated_at", Value: true}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+
+	return s.transition(context.Background(), session, update)
+}
+
+func (s *WorkoutSessionService) scheduleRestOver(session *WorkoutSession, timer RestTimer) {
+	if s.RestNotifier == nil {
+		return
+	}
+	if err := s.RestNotifier.ScheduleRestOver(session.UserID, session.ID.Hex(), timer); err != nil {
+		fmt.Printf("Error scheduling rest over: %v\n", err)
+	}
+}
+
+func (s *WorkoutSessionService) cancelRestOver(session *WorkoutSession, timer RestTimer) {
+	if s.RestNotifier == nil {
+		return
+	}
+	if err := s.RestNotifier.CancelRestOver(session.UserID, session.ID.Hex(), timer); err != nil {
+		fmt.Printf("Error cancelling rest over: %v\n", err)
+	}
+}
+
+// restDefaultSeconds returns the user's default rest for the exercise, 0 when there is none
+func (s *WorkoutSessionService) restDefaultSeconds(exerciseId string, userId string) (int, error) {
+	restDefault := &RestDefault{}
+	filter := bson.D{{Key: "userid", Value: userId}, {Key: "exerciseid", Value: exerciseId}}
+	err := s.DB.Collection("restDefaults").FindOne(context.Background(), filter).Decode(restDefault)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return restDefault.Seconds, nil
+}
+
+func (s *WorkoutSessionService) GetRestDefaults(userId string) ([]*RestDefault, error) {
+	filter := bson.D{{Key: "userid", Value: userId}}
+	cursor, err := s.DB.Collection("restDefaults").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := make([]*RestDefault, 0)
+	if err := cursor.All(context.Background(), &defaults); err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
+
+func (s *WorkoutSessionService) SetRestDefault(exerciseId string, dto *RestDefaultDto, userId string) (*RestDefault, error) {
+	filter := bson.D{{Key: "userid", Value: userId}, {Key: "exerciseid", Value: exerciseId}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "seconds", Value: dto.Seconds},
+		{Key: "updated_at", Value: time.Now()},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	result := &RestDefault{}
+	if err := s.DB.Collection("restDefaults").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *WorkoutSessionService) DeleteRestDefault(exerciseId string, userId string) error {
+	filter := bson.D{{Key: "userid", Value: userId}, {Key: "exerciseid", Value: exerciseId}}
+	result, err := s.DB.Collection("restDefaults").DeleteOne(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AddSet logs a set after the last one of a session exercise, starting the exercise's log with
+// its first set. Without a rest of its own the set gets the rest the timer measured after a set
+// of the same exercise.
+func (s *WorkoutSessionService) AddSet(id string, exerciseId string, dto *SessionSetDto, userId string) (*SetChange, error) {
+	session, err := s.setSession(id, exerciseId, dto.Version, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rest timed after a set of the exercise goes to this set, and the write of the set clears it
+	timer := session.RestTimer
+	takeRest := dto.RestSeconds == 0 && timer != nil && timer.ExerciseID == exerciseId
+	if takeRest {
+		at := dto.CompletedAt
+		if at.IsZero() {
+			at = time.Now()
+		}
+		dto.RestSeconds = timer.Taken(at)
+	}
+
+	log, err := s.exerciseLog(session, exerciseId)
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []exerciseLog.SetLog
+	if log != nil {
+		sets = EnteredSets(log.Sets)
+	}
+	sets = AppendSet(sets, *dto)
+
+	change, err := s.writeSets(session, exerciseId, log, sets, dto.Unit, takeRest, userId)
+	if err != nil {
+		return nil, err
+	}
+	if takeRest && timer.Running() {
+		s.cancelRestOver(session, *timer)
+		s.publish(LiveEvent{Type: LiveRestTimerStopped, SessionID: id, Version: change.Session.Version, Data: *timer})
+	}
+	s.publishSetChange(LiveSetLogged, session, change, exerciseId, sets[len(sets)-1].SetNumber)
+	return change, nil
+}
+
+// UpdateSet replaces a logged set of a session exercise
+func (s *WorkoutSessionService) UpdateSet(id string, exerciseId string, setNumber int, dto *SessionSetDto, userId string) (*SetChange, error) {
+	session, err := s.setSession(id, exerciseId, dto.Version, userId)
+	if err != nil {
+		return nil, err
+	}
+	log, err := s.exerciseLog(session, exerciseId)
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		return nil, ErrSetNotFound
+	}
+
+	sets, err := ReplaceSet(EnteredSets(log.Sets), setNumber, *dto)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.writeSets(session, exerciseId, log, sets, log.Unit, false, userId)
+	if err != nil {
+		return nil, err
+	}
+	s.publishSetChange(LiveSetUpdated, session, change, exerciseId, setNumber)
+	return change, nil
+}
+
+// DeleteSet removes a logged set of a session exercise, and the exercise's log with its last set
+func (s *WorkoutSessionService) DeleteSet(id string, exerciseId string, setNumber int, version *int64, userId string) (*SetChange, error) {
+	session, err := s.setSession(id, exerciseId, version, userId)
+	if err != nil {
+		return nil, err
+	}
+	log, err := s.exerciseLog(session, exerciseId)
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		return nil, ErrSetNotFound
+	}
+
+	sets, err := RemoveSet(EnteredSets(log.Sets), setNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.writeSets(session, exerciseId, log, sets, log.Unit, false, userId)
+	if err != nil {
+		return nil, err
+	}
+	s.publishSetChange(LiveSetDeleted, session, change, exerciseId, setNumber)
+	return change, nil
+}
+
+// setSession reads an ongoing session whose sets of an exercise are about to change
+func (s *WorkoutSessionService) setSession(id string, exerciseId string, version *int64, userId string) (*WorkoutSession, error) {
+	if s.ExerciseLogService == nil {
+		return nil, ErrSetLoggingUnavailable
+	}
+
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Status.Ongoing() {
+		return nil, ErrSessionEnded
+	}
+	if !hasExercise(session.Exercises, exerciseId) {
+		return nil, fmt.Errorf("exercise %s is not part of the session", exerciseId)
+	}
+	if version != nil && *version != session.Version {
+		return nil, ErrVersionConflict
+	}
+	return session, nil
+}
+
+// exerciseLog returns the log linked to an exercise of the session, nil when there is none yet
+func (s *WorkoutSessionService) exerciseLog(session *WorkoutSession, exerciseId string) (*exerciseLog.ExerciseLog, error) {
+	for _, ex := range session.Exercises {
+		if ex.ExerciseID != exerciseId || ex.ExerciseLogID == "" {
+			continue
+		}
+
+		logOid, err := primitive.ObjectIDFromHex(ex.ExerciseLogID)
+		if err != nil {
+			return nil, err
+		}
+		log := &exerciseLog.ExerciseLog{}
+		err = s.DB.Collection("exerciseLogs").FindOne(context.Background(), bson.D{
+			{Key: "_id", Value: logOid},
+			{Key: "userid", Value: session.UserID},
+		}).Decode(log)
+		if err == mongo.ErrNoDocuments {
+			// The log was deleted on its own, the exercise starts over
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return log, nil
+	}
+	return nil, nil
+}
+
+// writeSets saves the sets of a session exercise to its log, creating the log with the first set
+// and deleting it with the last, then links the log and the new total volume to the session as it
+// was read, clearing its rest timer with clearRest. Both writes share a transaction: should the
+// session have changed meanwhile, ErrVersionConflict is returned and the log is left as it was.
+func (s *WorkoutSessionService) writeSets(session *WorkoutSession, exerciseId string, previous *exerciseLog.ExerciseLog, sets []exerciseLog.SetLog, unit unitEnums.ExerciseWeightUnit, clearRest bool, userId string) (*SetChange, error) {
+	var log *exerciseLog.ExerciseLog
+	var result *WorkoutSession
+	err := function.WithTransaction(s.DB, func(ctx context.Context) error {
+		// Copied on every attempt, as writing a log converts the weights of its sets
+		sets := append([]exerciseLog.SetLog(nil), sets...)
+		var err error
+		switch {
+		case previous == nil:
+			log, err = s.ExerciseLogService.CreateLogContext(ctx, &exerciseLog.CreateExerciseLogDto{
+				ExerciseID: exerciseId,
+				DateTime:   time.Now(),
+				Unit:       unit,
+				Sets:       sets,
+				GroupID:    exerciseGroup(session.Exercises, exerciseId),
+			}, userId)
+		case len(sets) == 0:
+			_, err = s.ExerciseLogService.DeleteLogContext(ctx, previous.ID.Hex(), userId)
+		default:
+			log, err = s.ExerciseLogService.UpdateLogContext(ctx, previous.ID.Hex(), &exerciseLog.UpdateExerciseLogDto{
+				Sets:     sets,
+				DateTime: previous.DateTime,
+				Unit:     previous.Unit,
+				Notes:    previous.Notes,
+				GroupID:  previous.GroupID,
+			}, userId)
+		}
+		if err != nil {
+			return err
+		}
+
+		logId := ""
+		if log != nil {
+			logId = log.ID.Hex()
+		}
+		totalVolume, err := s.sessionVolume(ctx, linkLog(session.Exercises, exerciseId, logId), userId)
+		if err != nil {
+			return err
+		}
+
+		filter := withVersion(bson.D{
+			{Key: "_id", Value: session.ID},
+			{Key: "userid", Value: userId},
+			{Key: "status", Value: session.Status},
+			{Key: "exercises.exerciseid", Value: exerciseId},
+		}, &session.Version)
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "exercises.$.exerciselogid", Value: logId},
+			{Key: "total_volume", Value: totalVolume},
+			{Key: "updated_at", Value: time.Now()},
+		}}, {Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}}}
+		if clearRest {
+			update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: "rest_timer", Value: ""}}})
+		}
+
+		result, err = s.updateVersioned(ctx, filter, update, &session.Version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Personal records are read from committed logs
+	if log != nil {
+		log.PersonalRecords = s.ExerciseLogService.RefreshRecords(userId, exerciseId, log.ID.Hex())
+	} else {
+		s.ExerciseLogService.RefreshRecords(userId, exerciseId, "")
+	}
+
+	return &SetChange{Session: result, Log: log}, nil
+}
+
+func (s *WorkoutSessionService) publishSetChange(eventType LiveEventType, before *WorkoutSession, change *SetChange, exerciseId string, setNumber int) {
+	after := change.Session
+	s.publish(ChangeEvents(before, after)...)
+	s.publish(
+		LiveEvent{Type: eventType, SessionID: after.ID.Hex(), Version: after.Version, Data: map[string]interface{}{
+			"exerciseid": exerciseId,
+			"set_number": setNumber,
+			"log":        change.Log,
+		}},
+		LiveEvent{Type: LiveSessionUpdated, SessionID: after.ID.Hex(), Version: after.Version, Data: after},
+	)
+}
+
+func exerciseGroup(exercises []SessionExercise, exerciseId string) string {
+	for _, ex := range exercises {
+		if ex.ExerciseID == exerciseId {
+			return ex.GroupID
+		}
+	}
+	return ""
+}
+
+// SaveAsWorkout saves a completed custom or logged session as a new workout, prescribing each
+// exercise the sets done in the session
+func (s *WorkoutSessionService) SaveAsWorkout(id string, dto *SaveAsWorkoutDto, userId string) (*workout.Workout, error) {
+	if s.WorkoutService == nil {
+		return nil, ErrWorkoutUnavailable
+	}
+
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != StatusCompleted || session.Type == PlannedSession {
+		return nil, ErrNotSavable
+	}
+
+	logs, err := s.sessionLogs(context.Background(), session.Exercises, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.WorkoutService.CreateWorkout(WorkoutFromSession(session, logs, dto), userId)
+}
+
+// GetSessionSummary recaps a session with the records its logs set, how it compares to the
+// previous completed session of the same workout and the targets it missed
+func (s *WorkoutSessionService) GetSessionSummary(id string, userId string) (*SessionSummary, error) {
+	session, err := s.GetSession(id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	in := SummaryInput{Session: session, At: time.Now()}
+	if in.Logs, err = s.sessionLogs(context.Background(), session.Exercises, userId); err != nil {
+		return nil, err
+	}
+	if in.Exercises, err = s.summaryExercises(session.Exercises); err != nil {
+		return nil, err
+	}
+	if in.Records, err = s.sessionRecords(in.Logs, userId); err != nil {
+		return nil, err
+	}
+
+	if session.WorkoutID != "" {
+		if in.Title, err = s.workoutName(session.WorkoutID, userId); err != nil {
+			return nil, err
+		}
+		if in.Previous, err = s.previousSession(session); err != nil {
+			return nil, err
+		}
+		if in.Previous != nil {
+			if in.PreviousLogs, err = s.sessionLogs(context.Background(), in.Previous.Exercises, userId); err != nil {
 				return nil, err
 			}
+		}
+	}
 
-			totalVolume += exerciseLog.TotalVolume
+	return Summarise(in), nil
+}
+
+// summaryExercises reads the names and muscles of the exercises of a session
+func (s *WorkoutSessionService) summaryExercises(exercises []SessionExercise) (map[string]SummaryExercise, error) {
+	summaries := make(map[string]SummaryExercise, len(exercises))
+	ids := make([]primitive.ObjectID, 0, len(exercises))
+	for _, ex := range exercises {
+		summaries[ex.ExerciseID] = SummaryExercise{Name: "Exercise"}
+		if oid, err := primitive.ObjectIDFromHex(ex.ExerciseID); err == nil {
+			ids = append(ids, oid)
 		}
 	}
-	session.TotalVolume = totalVolume
+	if len(ids) == 0 {
+		return summaries, nil
+	}
 
-	result, err := s.DB.Collection("workoutSessions").InsertOne(context.Background(), session)
+	cursor, err := s.DB.Collection("exercises").Find(context.Background(), bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}})
 	if err != nil {
 		return nil, err
 	}
+	found := make([]*exercise.Exercise, 0, len(ids))
+	if err := cursor.All(context.Background(), &found); err != nil {
+		return nil, err
+	}
+	for _, ex := range found {
+		summaries[ex.ID.Hex()] = SummaryExercise{Name: ex.Name, Muscles: ex.TargetMuscle}
+	}
+	return summaries, nil
+}
 
-	createdSession := &WorkoutSession{}
-	err = s.DB.Collection("workoutSessions").FindOne(context.Background(), bson.D{{Key: "_id", Value: result.InsertedID}}).Decode(createdSession)
+// sessionRecords returns the personal records set by the logs, by log ID
+func (s *WorkoutSessionService) sessionRecords(logs map[string]*exerciseLog.ExerciseLog, userId string) (map[string][]personalRecordEnums.AchievedRecord, error) {
+	records := make(map[string][]personalRecordEnums.AchievedRecord)
+	if len(logs) == 0 {
+		return records, nil
+	}
+
+	logIds := make([]string, 0, len(logs))
+	for logId := range logs {
+		logIds = append(logIds, logId)
+	}
+	filter := bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "exerciselogid", Value: bson.D{{Key: "$in", Value: logIds}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "type", Value: 1}, {Key: "reps", Value: 1}})
+	cursor, err := s.DB.Collection("personalRecordHistory").Find(context.Background(), filter, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return createdSession, nil
+	history := make([]*personalRecord.RecordHistory, 0)
+	if err := cursor.All(context.Background(), &history); err != nil {
+		return nil, err
+	}
+	for _, entry := range history {
+		records[entry.ExerciseLogID] = append(records[entry.ExerciseLogID], personalRecordEnums.AchievedRecord{
+			Type:     entry.Type,
+			Reps:     entry.Reps,
+			Value:    entry.Value,
+			Previous: entry.Previous,
+		})
+	}
+	return records, nil
+}
+
+// previousSession returns the last completed session of the same workout started before the
+// session, nil when there is none
+func (s *WorkoutSessionService) previousSession(session *WorkoutSession) (*WorkoutSession, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$ne", Value: session.ID}}},
+		{Key: "userid", Value: session.UserID},
+		{Key: "workoutid", Value: session.WorkoutID},
+		{Key: "status", Value: StatusCompleted},
+		{Key: "start_time", Value: bson.D{{Key: "$lt", Value: session.StartTime}}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "start_time", Value: -1}})
+
+	previous := &WorkoutSession{}
+	err := s.DB.Collection("workoutSessions").FindOne(context.Background(), filter, opts).Decode(previous)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// workoutName returns the name of a workout, empty when it was deleted
+func (s *WorkoutSessionService) workoutName(workoutId string, userId string) (string, error) {
+	oid, err := primitive.ObjectIDFromHex(workoutId)
+	if err != nil {
+		return "", nil
+	}
+
+	w := &workout.Workout{}
+	err = s.DB.Collection("workout").FindOne(context.Background(), bson.D{
+		{Key: "_id", Value: oid},
+		{Key: "userid", Value: userId},
+	}).Decode(w)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return w.Name, nil
+}
+
+// ReorderSessionExercises applies a reorder request to the exercises of a session. A group moves as one
+// unit and keeps the order of its exercises. Units that are not mentioned follow the mentioned ones in
+// their current order. Orders are renumbered from 0.
+func ReorderSessionExercises(exercises []SessionExercise, order []ExerciseOrder) ([]SessionExercise, error) {
+	sorted := make([]SessionExercise, len(exercises))
+	copy(sorted, exercises)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	// Split the session into units, either a whole group or a single ungrouped exercise
+	type unit struct {
+		key       string
+		exercises []SessionExercise
+		position  int
+		moved     bool
+	}
+	units := make([]*unit, 0, len(sorted))
+	groupOf := make(map[string]string)
+	for _, exercise := range sorted {
+		if exercise.GroupID != "" {
+			groupOf[exercise.ExerciseID] = exercise.GroupID
+			last := len(units) - 1
+			if last >= 0 && units[last].key == "group:"+exercise.GroupID {
+				units[last].exercises = append(units[last].exercises, exercise)
+				continue
+			}
+			units = append(units, &unit{key: "group:" + exercise.GroupID, exercises: []SessionExercise{exercise}})
+			continue
+		}
+		units = append(units, &unit{key: "exercise:" + exercise.ExerciseID, exercises: []SessionExercise{exercise}})
+	}
+
+	for _, entry := range order {
+		key := "group:" + entry.GroupID
+		if entry.GroupID == "" {
+			if groupId, ok := groupOf[entry.ExerciseID]; ok {
+				return nil, fmt.Errorf("exercise %s belongs to group %s, move the group instead", entry.ExerciseID, groupId)
+			}
+			key = "exercise:" + entry.ExerciseID
+		}
+
+		found := false
+		for _, u := range units {
+			if u.key == key && !u.moved {
+				u.position = entry.Order
+				u.moved = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not part of this session", strings.Replace(key, ":", " ", 1))
+		}
+	}
+
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].moved != units[j].moved {
+			return units[i].moved
+		}
+		return units[i].moved && units[i].position < units[j].position
+	})
+
+	reordered := make([]SessionExercise, 0, len(sorted))
+	for _, u := range units {
+		for _, exercise := range u.exercises {
+			exercise.Order = len(reordered)
+			reordered = append(reordered, exercise)
+		}
+	}
+
+	return reordered, nil
+}
+
+// SummariseGroups works out the rounds, volume and duration of each group from the logs of its exercises.
+// logs is keyed by exercise log ID. Sets without a round count as one round each, in set order.
+func SummariseGroups(exercises []SessionExercise, groups []workout.ExerciseGroup, logs map[string]*exerciseLog.ExerciseLog) []GroupSummary {
+	summaries := make([]GroupSummary, 0, len(groups))
+	for _, group := range groups {
+		summary := GroupSummary{GroupID: group.ID}
+		var first, last time.Time
+		logged, skipped := false, false
+
+		for _, exercise := range exercises {
+			if exercise.GroupID != group.ID {
+				continue
+			}
+			log, ok := logs[exercise.ExerciseLogID]
+			if !ok {
+				// An exercise of the group that was never logged got through no round
+				skipped = true
+				continue
+			}
+			summary.TotalVolume += log.TotalVolume
+
+			rounds := 0
+			for _, set := range log.Sets {
+				if set.Type == exerciseLog.WarmUpSet {
+					continue
+				}
+				if set.Round > 0 {
+					rounds = max(rounds, set.Round)
+				} else {
+					rounds++
+				}
+				if set.CompletedAt.IsZero() {
+					continue
+				}
+				if first.IsZero() || set.CompletedAt.Before(first) {
+					first = set.CompletedAt
+				}
+				if set.CompletedAt.After(last) {
+					last = set.CompletedAt
+				}
+			}
+
+			// A round is complete once every exercise of the group got through it
+			if !logged || rounds < summary.CompletedRounds {
+				summary.CompletedRounds = rounds
+			}
+			logged = true
+		}
+		if skipped {
+			summary.CompletedRounds = 0
+		}
+
+		if !first.IsZero() {
+			summary.Duration = int(last.Sub(first).Seconds())
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// expandPrescription turns the prescription of a workout exercise into target sets, with weights
+// worked out from the user's latest estimated 1RM of the exercise. A progression target set by
+// earlier sessions of the workout takes the place of the prescribed weight and reps.
+func (s *WorkoutSessionService) expandPrescription(ex workout.WorkoutExercise, workoutId string, userId string) ([]workout.TargetSet, error) {
+	if ex.Prescription == nil {
+		return nil, nil
+	}
+
+	prescription := *ex.Prescription
+	if s.ProgressionService != nil && ex.Progression != nil {
+		target, err := s.ProgressionService.GetTarget(userId, workoutId, ex.ExerciseID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		prescription = progression.ApplyTarget(prescription, target)
+	}
+
+	latestLog := &exerciseLog.ExerciseLog{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "datetime", Value: -1}})
+	err := s.DB.Collection("exerciseLogs").FindOne(context.Background(), bson.D{
+		{Key: "userid", Value: userId},
+		{Key: "exerciseid", Value: ex.ExerciseID},
+	}, opts).Decode(latestLog)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	targetSets := workout.ExpandPrescription(prescription, latestLog.EstimatedOneRepMax())
+
+	// Weeks of a running program scale the volume and intensity of its workouts
+	if s.ProgramService != nil {
+		volume, intensity, err := s.ProgramService.GetWeekModifiers(userId, workoutId, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		targetSets = workout.ScaleTargetSets(targetSets, volume, intensity)
+	}
+
+	return targetSets, nil
+}
+
+// validateGroups checks the groups of a session against its exercises
+func validateGroups(exercises []SessionExercise, groups []workout.ExerciseGroup) error {
+	sorted := make([]SessionExercise, len(exercises))
+	copy(sorted, exercises)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	groupIds := make([]string, len(sorted))
+	for i, exercise := range sorted {
+		groupIds[i] = exercise.GroupID
+	}
+
+	return workout.ValidateGroups(groups, groupIds)
 }
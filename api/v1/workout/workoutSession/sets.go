@@ -0,0 +1,82 @@
+package workoutSession
+
+import (
+	"errors"
+
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+)
+
+var ErrSetNotFound = errors.New("set not found")
+
+// SetChange is a session after one of its sets changed, with the log holding the sets of the
+// exercise. Log is nil once the last set of the exercise is deleted.
+type SetChange struct {
+	Session *WorkoutSession          `json:"session"`
+	Log     *exerciseLog.ExerciseLog `json:"log,omitempty"`
+}
+
+// EnteredSets copies logged sets with their weight back in the log's unit, as logs take them
+func EnteredSets(sets []exerciseLog.SetLog) []exerciseLog.SetLog {
+	entered := make([]exerciseLog.SetLog, len(sets))
+	for i, set := range sets {
+		entered[i] = set
+		if set.EnteredWeight != 0 {
+			entered[i].Weight = set.EnteredWeight
+		}
+	}
+	return entered
+}
+
+// AppendSet adds a set after the last one
+func AppendSet(sets []exerciseLog.SetLog, dto SessionSetDto) []exerciseLog.SetLog {
+	last := 0
+	for _, set := range sets {
+		if set.SetNumber > last {
+			last = set.SetNumber
+		}
+	}
+	return append(sets, dto.SetLog(last+1))
+}
+
+// ReplaceSet swaps the set numbered setNumber for dto
+func ReplaceSet(sets []exerciseLog.SetLog, setNumber int, dto SessionSetDto) ([]exerciseLog.SetLog, error) {
+	for i, set := range sets {
+		if set.SetNumber == setNumber {
+			sets[i] = dto.SetLog(setNumber)
+			return sets, nil
+		}
+	}
+	return nil, ErrSetNotFound
+}
+
+// RemoveSet drops the set numbered setNumber and moves the sets after it up one number
+func RemoveSet(sets []exerciseLog.SetLog, setNumber int) ([]exerciseLog.SetLog, error) {
+	remaining := make([]exerciseLog.SetLog, 0, len(sets))
+	found := false
+	for _, set := range sets {
+		switch {
+		case set.SetNumber == setNumber:
+			found = true
+			continue
+		case set.SetNumber > setNumber:
+			set.SetNumber--
+		}
+		remaining = append(remaining, set)
+	}
+	if !found {
+		return nil, ErrSetNotFound
+	}
+	return remaining, nil
+}
+
+// linkLog returns the exercises with the log of an exercise set to logId
+func linkLog(exercises []SessionExercise, exerciseId string, logId string) []SessionExercise {
+	linked := append([]SessionExercise(nil), exercises...)
+	for i := range linked {
+		if linked[i].ExerciseID == exerciseId {
+			linked[i].ExerciseLogID = logId
+			break
+		}
+	}
+	return linked
+}
@@ -0,0 +1,280 @@
+package workoutSession
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	exerciseEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/exercise/enums"
+	"github.com/Npwskp/GymsbroBackend/api/v1/workout/exerciseLog"
+	personalRecordEnums "github.com/Npwskp/GymsbroBackend/api/v1/workout/personalRecord/enums"
+)
+
+// SessionSummary recaps a session for the screen shown once it ends and for sharing. Weights and
+// volumes are in kg, warm-up sets are left out of set counts.
+type SessionSummary struct {
+	SessionID   string             `json:"sessionid"`
+	WorkoutID   string             `json:"workoutid,omitempty"`
+	Title       string             `json:"title"` // The workout's name, "Workout" for custom sessions
+	Status      SessionStatus      `json:"status"`
+	StartTime   time.Time          `json:"start_time"`
+	EndTime     time.Time          `json:"end_time"`
+	Duration    int                `json:"duration"` // Active seconds
+	TotalVolume float64            `json:"total_volume"`
+	TotalSets   int                `json:"total_sets"`
+	Muscles     []MuscleSummary    `json:"muscles"`
+	Exercises   []ExerciseSummary  `json:"exercises"`
+	Records     []SessionRecord    `json:"records"`
+	Comparison  *SessionComparison `json:"comparison,omitempty"` // Unset without an earlier session of the same workout
+	Misses      []TargetMiss       `json:"misses"`
+}
+
+// MuscleSummary is the work a target muscle got. An exercise counts fully for each of its muscles.
+type MuscleSummary struct {
+	Muscle exerciseEnums.TargetMuscle `json:"muscle"`
+	Sets   int                        `json:"sets"`
+	Volume float64                    `json:"volume"`
+}
+
+type ExerciseSummary struct {
+	ExerciseID     string  `json:"exerciseid"`
+	Name           string  `json:"name"`
+	Sets           int     `json:"sets"`
+	Volume         float64 `json:"volume"`
+	EstimatedOneRM float64 `json:"estimated_one_rm"`
+}
+
+// SessionRecord is a personal record set by one of the session's logs
+type SessionRecord struct {
+	ExerciseID string `json:"exerciseid"`
+	Name       string `json:"name"`
+	personalRecordEnums.AchievedRecord
+}
+
+// SessionComparison compares a session to the previous completed session of the same workout
+type SessionComparison struct {
+	PreviousSessionID string               `json:"previous_sessionid"`
+	PreviousStartTime time.Time            `json:"previous_start_time"`
+	PreviousVolume    float64              `json:"previous_volume"`
+	VolumeDelta       float64              `json:"volume_delta"`
+	Exercises         []ExerciseComparison `json:"exercises"` // Exercises done in both sessions
+}
+
+type ExerciseComparison struct {
+	ExerciseID             string  `json:"exerciseid"`
+	Name                   string  `json:"name"`
+	EstimatedOneRM         float64 `json:"estimated_one_rm"`
+	PreviousEstimatedOneRM float64 `json:"previous_estimated_one_rm"`
+	EstimatedOneRMDelta    float64 `json:"estimated_one_rm_delta"`
+	VolumeDelta            float64 `json:"volume_delta"`
+}
+
+type MissReason string
+
+const (
+	MissedSet    MissReason = "missed_set"    // The target set was not done
+	MissedReps   MissReason = "missed_reps"   // Fewer reps than the target's minimum
+	MissedWeight MissReason = "missed_weight" // Less weight than the target
+)
+
+// TargetMiss is a target set of the session that was not met. Working sets are matched to the
+// targets in order.
+type TargetMiss struct {
+	ExerciseID   string     `json:"exerciseid"`
+	Name         string     `json:"name"`
+	SetNumber    int        `json:"set_number"`
+	Reason       MissReason `json:"reason"`
+	TargetReps   int        `json:"target_reps"`
+	Reps         int        `json:"reps"`
+	TargetWeight float64    `json:"target_weight"`
+	Weight       float64    `json:"weight"`
+}
+
+// SummaryExercise is what a summary needs to know about an exercise
+type SummaryExercise struct {
+	Name    string
+	Muscles []exerciseEnums.TargetMuscle
+}
+
+// SummaryInput holds what Summarise works from. Logs are keyed by ID, exercises by exercise ID and
+// Previous is nil when there is nothing to compare with.
+type SummaryInput struct {
+	Session      *WorkoutSession
+	Title        string
+	Logs         map[string]*exerciseLog.ExerciseLog
+	Exercises    map[string]SummaryExercise
+	Records      map[string][]personalRecordEnums.AchievedRecord // By log ID
+	Previous     *WorkoutSession
+	PreviousLogs map[string]*exerciseLog.ExerciseLog
+	At           time.Time // Counts the duration of a session that did not end yet
+}
+
+// Summarise works out the summary of a session
+func Summarise(in SummaryInput) *SessionSummary {
+	session := in.Session
+	summary := &SessionSummary{
+		SessionID:   session.ID.Hex(),
+		WorkoutID:   session.WorkoutID,
+		Title:       in.Title,
+		Status:      session.Status,
+		StartTime:   session.StartTime,
+		EndTime:     session.EndTime,
+		TotalVolume: session.TotalVolume,
+		Muscles:     make([]MuscleSummary, 0),
+		Exercises:   make([]ExerciseSummary, 0),
+		Records:     make([]SessionRecord, 0),
+		Misses:      make([]TargetMiss, 0),
+	}
+	summary.Duration = session.Duration
+	if session.Status.Ongoing() {
+		summary.Duration = ActiveSeconds(session, in.At)
+	}
+	if summary.Title == "" {
+		summary.Title = "Workout"
+	}
+
+	exercises := sortedExercises(session.Exercises)
+	muscles := make(map[exerciseEnums.TargetMuscle]*MuscleSummary)
+	var muscleOrder []exerciseEnums.TargetMuscle
+	for _, ex := range exercises {
+		info := in.Exercises[ex.ExerciseID]
+		log := in.Logs[ex.ExerciseLogID]
+		summary.Misses = append(summary.Misses, targetMisses(ex, info.Name, log)...)
+		if log == nil {
+			continue
+		}
+
+		exercise := ExerciseSummary{
+			ExerciseID:     ex.ExerciseID,
+			Name:           info.Name,
+			Sets:           workingSets(log.Sets),
+			Volume:         log.TotalVolume,
+			EstimatedOneRM: round(log.EstimatedOneRepMax()),
+		}
+		summary.Exercises = append(summary.Exercises, exercise)
+		summary.TotalSets += exercise.Sets
+
+		for _, muscle := range info.Muscles {
+			if muscles[muscle] == nil {
+				muscles[muscle] = &MuscleSummary{Muscle: muscle}
+				muscleOrder = append(muscleOrder, muscle)
+			}
+			muscles[muscle].Sets += exercise.Sets
+			muscles[muscle].Volume += exercise.Volume
+		}
+
+		for _, record := range in.Records[ex.ExerciseLogID] {
+			summary.Records = append(summary.Records, SessionRecord{ExerciseID: ex.ExerciseID, Name: info.Name, AchievedRecord: record})
+		}
+	}
+
+	for _, muscle := range muscleOrder {
+		summary.Muscles = append(summary.Muscles, *muscles[muscle])
+	}
+	sort.SliceStable(summary.Muscles, func(i, j int) bool {
+		return summary.Muscles[i].Sets > summary.Muscles[j].Sets
+	})
+
+	if in.Previous != nil {
+		summary.Comparison = compareSessions(summary, in.Previous, in.PreviousLogs)
+	}
+
+	return summary
+}
+
+// compareSessions compares a summary with the previous session, exercise by exercise
+func compareSessions(summary *SessionSummary, previous *WorkoutSession, previousLogs map[string]*exerciseLog.ExerciseLog) *SessionComparison {
+	comparison := &SessionComparison{
+		PreviousSessionID: previous.ID.Hex(),
+		PreviousStartTime: previous.StartTime,
+		PreviousVolume:    previous.TotalVolume,
+		VolumeDelta:       round(summary.TotalVolume - previous.TotalVolume),
+		Exercises:         make([]ExerciseComparison, 0),
+	}
+
+	for _, exercise := range summary.Exercises {
+		var log *exerciseLog.ExerciseLog
+		for _, ex := range previous.Exercises {
+			if ex.ExerciseID == exercise.ExerciseID && previousLogs[ex.ExerciseLogID] != nil {
+				log = previousLogs[ex.ExerciseLogID]
+				break
+			}
+		}
+		if log == nil {
+			continue
+		}
+
+		previousOneRM := round(log.EstimatedOneRepMax())
+		comparison.Exercises = append(comparison.Exercises, ExerciseComparison{
+			ExerciseID:             exercise.ExerciseID,
+			Name:                   exercise.Name,
+			EstimatedOneRM:         exercise.EstimatedOneRM,
+			PreviousEstimatedOneRM: previousOneRM,
+			EstimatedOneRMDelta:    round(exercise.EstimatedOneRM - previousOneRM),
+			VolumeDelta:            round(exercise.Volume - log.TotalVolume),
+		})
+	}
+
+	return comparison
+}
+
+// targetMisses matches the working sets of an exercise to its target sets in order and returns
+// the targets that were not met
+func targetMisses(ex SessionExercise, name string, log *exerciseLog.ExerciseLog) []TargetMiss {
+	var done []exerciseLog.SetLog
+	if log != nil {
+		done = workingSetsInOrder(log.Sets)
+	}
+
+	misses := make([]TargetMiss, 0)
+	for i, target := range ex.TargetSets {
+		miss := TargetMiss{
+			ExerciseID:   ex.ExerciseID,
+			Name:         name,
+			SetNumber:    target.SetNumber,
+			TargetReps:   target.MinReps,
+			TargetWeight: target.Weight,
+		}
+		if i >= len(done) {
+			miss.Reason = MissedSet
+			misses = append(misses, miss)
+			continue
+		}
+
+		set := done[i]
+		miss.Reps = set.Reps
+		miss.Weight = set.Load()
+		switch {
+		case target.MinReps > 0 && set.Reps < target.MinReps:
+			miss.Reason = MissedReps
+		case target.Weight > 0 && set.Load() < target.Weight-0.01:
+			miss.Reason = MissedWeight
+		default:
+			continue
+		}
+		misses = append(misses, miss)
+	}
+	return misses
+}
+
+func workingSets(sets []exerciseLog.SetLog) int {
+	count := 0
+	for _, set := range sets {
+		if set.Type != exerciseLog.WarmUpSet {
+			count++
+		}
+	}
+	return count
+}
+
+func sortedExercises(exercises []SessionExercise) []SessionExercise {
+	sorted := append([]SessionExercise(nil), exercises...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+	return sorted
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -108,6 +108,8 @@ func main() {
 		log.Fatalf("Error creating indexes: %v", err)
 	}
 
+	dbmongo.RunMigrations(mg.Db)
+
 	utils.InjectApp(app, mg.Db)
 
 	app.Get("/swagger/*", swagger.New(swagger.Config{